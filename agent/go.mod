@@ -15,10 +15,12 @@ require (
 	github.com/google/uuid v1.3.0
 	github.com/gorilla/websocket v1.4.2
 	github.com/jinzhu/copier v0.3.5
+	github.com/klauspost/compress v1.14.2
 	github.com/nav-inc/datetime v0.1.3
 	github.com/op/go-logging v0.0.0-20160315200505-970db520ece7
 	github.com/orcaman/concurrent-map v1.0.0
 	github.com/stretchr/testify v1.7.0
+	github.com/ugorji/go/codec v1.2.6
 	github.com/up9inc/basenine/client/go v0.0.0-20220612112747-3b28eeac9c51
 	github.com/up9inc/mizu/logger v0.0.0
 	github.com/up9inc/mizu/shared v0.0.0
@@ -26,9 +28,15 @@ require (
 	github.com/up9inc/mizu/tap/api v0.0.0
 	github.com/up9inc/mizu/tap/dbgctl v0.0.0
 	github.com/up9inc/mizu/tap/extensions/amqp v0.0.0
+	github.com/up9inc/mizu/tap/extensions/dns v0.0.0
 	github.com/up9inc/mizu/tap/extensions/http v0.0.0
 	github.com/up9inc/mizu/tap/extensions/kafka v0.0.0
+	github.com/up9inc/mizu/tap/extensions/mongodb v0.0.0
+	github.com/up9inc/mizu/tap/extensions/mqtt v0.0.0
+	github.com/up9inc/mizu/tap/extensions/mysql v0.0.0
+	github.com/up9inc/mizu/tap/extensions/postgres v0.0.0
 	github.com/up9inc/mizu/tap/extensions/redis v0.0.0
+	github.com/up9inc/mizu/tap/extensions/tcpraw v0.0.0
 	github.com/wI2L/jsondiff v0.1.1
 	k8s.io/api v0.23.3
 	k8s.io/apimachinery v0.23.3
@@ -80,7 +88,6 @@ require (
 	github.com/inconshreveable/mousetrap v1.0.0 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
-	github.com/klauspost/compress v1.14.2 // indirect
 	github.com/knightsc/gapstone v0.0.0-20191231144527-6fa5afaf11a9 // indirect
 	github.com/leodido/go-urn v1.2.1 // indirect
 	github.com/liggitt/tabwriter v0.0.0-20181228230101-89fcab3d43de // indirect
@@ -114,7 +121,6 @@ require (
 	github.com/tidwall/sjson v1.2.4 // indirect
 	github.com/tklauser/go-sysconf v0.3.10 // indirect
 	github.com/tklauser/numcpus v0.4.0 // indirect
-	github.com/ugorji/go/codec v1.2.6 // indirect
 	github.com/vishvananda/netns v0.0.0-20211101163701-50045581ed74 // indirect
 	github.com/xlab/treeprint v1.1.0 // indirect
 	github.com/yusufpapurcu/wmi v1.2.2 // indirect
@@ -154,10 +160,22 @@ replace github.com/up9inc/mizu/tap/api v0.0.0 => ../tap/api
 
 replace github.com/up9inc/mizu/tap/extensions/amqp v0.0.0 => ../tap/extensions/amqp
 
+replace github.com/up9inc/mizu/tap/extensions/dns v0.0.0 => ../tap/extensions/dns
+
 replace github.com/up9inc/mizu/tap/extensions/http v0.0.0 => ../tap/extensions/http
 
 replace github.com/up9inc/mizu/tap/extensions/kafka v0.0.0 => ../tap/extensions/kafka
 
+replace github.com/up9inc/mizu/tap/extensions/mongodb v0.0.0 => ../tap/extensions/mongodb
+
+replace github.com/up9inc/mizu/tap/extensions/mqtt v0.0.0 => ../tap/extensions/mqtt
+
+replace github.com/up9inc/mizu/tap/extensions/mysql v0.0.0 => ../tap/extensions/mysql
+
+replace github.com/up9inc/mizu/tap/extensions/postgres v0.0.0 => ../tap/extensions/postgres
+
 replace github.com/up9inc/mizu/tap/extensions/redis v0.0.0 => ../tap/extensions/redis
 
+replace github.com/up9inc/mizu/tap/extensions/tcpraw v0.0.0 => ../tap/extensions/tcpraw
+
 replace github.com/up9inc/mizu/tap/dbgctl v0.0.0 => ../tap/dbgctl
@@ -2,12 +2,12 @@ package main
 
 import (
 	"encoding/json"
-	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"os/exec"
 	"os/signal"
 	"strconv"
 	"strings"
@@ -17,6 +17,7 @@ import (
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-contrib/static"
 	"github.com/gin-gonic/gin"
+	"github.com/up9inc/mizu/agent/pkg/analyze"
 	"github.com/up9inc/mizu/agent/pkg/dependency"
 	"github.com/up9inc/mizu/agent/pkg/entries"
 	"github.com/up9inc/mizu/agent/pkg/middlewares"
@@ -24,6 +25,7 @@ import (
 	"github.com/up9inc/mizu/agent/pkg/oas"
 	"github.com/up9inc/mizu/agent/pkg/routes"
 	"github.com/up9inc/mizu/agent/pkg/servicemap"
+	"github.com/up9inc/mizu/agent/pkg/spool"
 	"github.com/up9inc/mizu/agent/pkg/utils"
 
 	"github.com/up9inc/mizu/agent/pkg/api"
@@ -37,6 +39,7 @@ import (
 	"github.com/up9inc/mizu/tap"
 	tapApi "github.com/up9inc/mizu/tap/api"
 	"github.com/up9inc/mizu/tap/dbgctl"
+	"github.com/up9inc/mizu/tap/diagnose"
 )
 
 var tapperMode = flag.Bool("tap", false, "Run in tapper mode without API")
@@ -45,13 +48,23 @@ var standaloneMode = flag.Bool("standalone", false, "Run in standalone tapper an
 var apiServerAddress = flag.String("api-server-address", "", "Address of mizu API server")
 var namespace = flag.String("namespace", "", "Resolve IPs if they belong to resources in this namespace (default is all)")
 var harsReaderMode = flag.Bool("hars-read", false, "Run in hars-read mode")
+var analyzeMode = flag.Bool("analyze", false, "Run in analyze mode, the pcap file given with -r is analyzed in a single process")
+var analyzeOutput = flag.String("analyze-output", "", "Directory to write the entries, the OAS specs and the service map of the analyzed pcap file to (empty serves the UI instead)")
 var harsDir = flag.String("hars-dir", "", "Directory to read hars from")
 var profiler = flag.Bool("profiler", false, "Run pprof server")
+var extensionsDir = flag.String("extensions-dir", "./extensions", "Directory to load the dissector plugins from")
+var spoolDir = flag.String("spool-dir", "./spool", "Directory to spool the tapped entries in while the API server is unreachable")
+var metricsAddress = flag.String("metrics-address", ":8897", "Address to serve the Prometheus metrics of the tapper on (empty disables it)")
+var debugControlAddress = flag.String("debug-control-address", fmt.Sprintf(":%d", shared.TapperDebugControlPort), "Address to serve the debug control endpoint of the tapper on, it's served only when the token is set in "+shared.DebugControlTokenEnvVar)
+var spoolMaxSize = flag.Int64("spool-max-size", 100*1024*1024, "Max size of the spool in bytes, the oldest entries are dropped when it's full (0 disables the spool)")
 
 const (
 	socketConnectionRetries    = 30
 	socketConnectionRetryDelay = time.Second * 2
 	socketHandshakeTimeout     = time.Second * 2
+
+	tappedEntriesBatchSize          = 100
+	tappedEntriesBatchFlushInterval = time.Millisecond * 100
 )
 
 func main() {
@@ -60,10 +73,10 @@ func main() {
 	logger.InitLoggerStd(logLevel)
 	flag.Parse()
 
-	app.LoadExtensions()
+	app.LoadExtensions(*extensionsDir)
 
-	if !*tapperMode && !*apiServerMode && !*standaloneMode && !*harsReaderMode {
-		panic("One of the flags --tap, --api-server, --standalone or --hars-read must be provided")
+	if !*tapperMode && !*apiServerMode && !*standaloneMode && !*harsReaderMode && !*analyzeMode {
+		panic("One of the flags --tap, --api-server, --standalone, --hars-read or --analyze must be provided")
 	}
 
 	if *standaloneMode {
@@ -81,12 +94,24 @@ func main() {
 
 	} else if *harsReaderMode {
 		runInHarReaderMode()
+	} else if *analyzeMode {
+		if *analyzeOutput != "" {
+			runInAnalyzeOutputMode()
+			return
+		}
+
+		runInAnalyzeMode()
 	}
 
 	signalChan := make(chan os.Signal, 1)
-	signal.Notify(signalChan, os.Interrupt)
+	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 	<-signalChan
 
+	if *tapperMode {
+		// Kubernetes stops the tapper with SIGTERM, the buffered packets would be lost from the pcap file
+		tap.ClosePcapWriter()
+	}
+
 	logger.Log.Info("Exiting")
 }
 
@@ -132,6 +157,7 @@ func hostApi(socketHarOutputChannel chan<- *tapApi.OutputChannelItem) *gin.Engin
 	routes.StatusRoutes(ginApp)
 	routes.DbRoutes(ginApp)
 	routes.ReplayRoutes(ginApp)
+	routes.MetricsRoutes(ginApp)
 
 	return ginApp
 }
@@ -167,9 +193,51 @@ func runInTapperMode() {
 	if err != nil {
 		panic(fmt.Sprintf("Error connecting to socket server at %s %v", *apiServerAddress, err))
 	}
-	logger.Log.Infof("Connected successfully to websocket %s", *apiServerAddress)
+	logger.Log.Infof("Connected successfully to websocket %s, subprotocol: %q", *apiServerAddress, socketConnection.Subprotocol())
+
+	tapperSpool, err := spool.New(*spoolDir, *spoolMaxSize)
+	if err != nil {
+		logger.Log.Errorf("error opening the spool in %s, the entries tapped while disconnected will be dropped, err: %v", *spoolDir, err)
+		tapperSpool, _ = spool.New("", 0)
+	}
+
+	startTapperMetricsServer(tapperSpool)
+	startTapperDebugControlServer()
 
-	go pipeTapChannelToSocket(socketConnection, filteredOutputItemsChannel)
+	go pipeTapChannelToSocket(socketConnection, filteredOutputItemsChannel, tapperSpool)
+}
+
+func startTapperMetricsServer(tapperSpool *spool.Spool) {
+	if *metricsAddress == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", tapApi.MetricsContentType)
+		if err := tapApi.WriteMetrics(w, append(tap.GetMetrics(), tapperSpool.Metrics()...)); err != nil {
+			logger.Log.Errorf("Error writing the metrics: %v", err)
+		}
+	})
+
+	go func() {
+		if err := http.ListenAndServe(*metricsAddress, mux); err != nil {
+			logger.Log.Errorf("Error serving the metrics on %s: %v", *metricsAddress, err)
+		}
+	}()
+}
+
+func startTapperDebugControlServer() {
+	token := os.Getenv(shared.DebugControlTokenEnvVar)
+	if *debugControlAddress == "" || token == "" {
+		return
+	}
+
+	go func() {
+		if err := http.ListenAndServe(*debugControlAddress, diagnose.NewDebugControlHandler(token, tap.GetPcapDir())); err != nil {
+			logger.Log.Errorf("Error serving the debug control on %s: %v", *debugControlAddress, err)
+		}
+	}()
 }
 
 func runInStandaloneMode() {
@@ -200,6 +268,55 @@ func runInHarReaderMode() {
 	utils.StartServer(ginApp)
 }
 
+func runInAnalyzeMode() {
+	if tap.GetInputFilename() == "" {
+		panic("A pcap file must be provided with -r when using --analyze")
+	}
+
+	if err := config.LoadConfig(); err != nil {
+		logger.Log.Fatalf("Error loading config file %v", err)
+	}
+	config.Config.OAS.Enable = true
+	config.Config.ServiceMap = true
+
+	startBasenineServer()
+	app.ConfigureBasenineServer(shared.BasenineHost, shared.BaseninePort, config.Config.MaxDBSizeBytes, config.Config.LogLevel, config.Config.InsertionFilter)
+	enableExpFeatureIfNeeded()
+
+	go func() {
+		analyze.Run(tap.GetInputFilename(), getTrafficFilteringOptions())
+		logger.Log.Infof("Finished analyzing %s", tap.GetInputFilename())
+	}()
+
+	ginApp := hostApi(nil)
+	utils.StartServer(ginApp)
+}
+
+// runInAnalyzeOutputMode analyzes the pcap file without Basenine and writes the results to the output directory
+func runInAnalyzeOutputMode() {
+	if tap.GetInputFilename() == "" {
+		panic("A pcap file must be provided with -r when using --analyze")
+	}
+
+	count, err := analyze.RunToDir(tap.GetInputFilename(), *analyzeOutput, getTrafficFilteringOptions())
+	if err != nil {
+		logger.Log.Fatalf("Error analyzing %s: %v", tap.GetInputFilename(), err)
+	}
+
+	logger.Log.Infof("Analyzed %d entries of %s into %s", count, tap.GetInputFilename(), *analyzeOutput)
+}
+
+// startBasenineServer runs Basenine next to the agent, the way it runs in the API server pod
+func startBasenineServer() {
+	cmd := exec.Command("basenine", "-addr", shared.BasenineHost, "-port", shared.BaseninePort)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Pdeathsig: syscall.SIGKILL}
+	if err := cmd.Start(); err != nil {
+		logger.Log.Fatalf("Error starting Basenine, make sure it's in the PATH: %v", err)
+	}
+}
+
 func enableExpFeatureIfNeeded() {
 	if config.Config.OAS.Enable {
 		oasGenerator := dependency.GetInstance(dependency.OasGeneratorDependency).(oas.OasGenerator)
@@ -255,7 +372,7 @@ func getTrafficFilteringOptions() *tapApi.TrafficFilteringOptions {
 	return &filteringOptions
 }
 
-func pipeTapChannelToSocket(connection *websocket.Conn, messageDataChannel <-chan *tapApi.OutputChannelItem) {
+func pipeTapChannelToSocket(connection *websocket.Conn, messageDataChannel <-chan *tapApi.OutputChannelItem, tapperSpool *spool.Spool) {
 	if connection == nil {
 		panic("Websocket connection is nil")
 	}
@@ -264,34 +381,140 @@ func pipeTapChannelToSocket(connection *websocket.Conn, messageDataChannel <-cha
 		panic("Channel of captured messages is nil")
 	}
 
-	for messageData := range messageDataChannel {
-		marshaledData, err := models.CreateWebsocketTappedEntryMessage(messageData)
-		if err != nil {
-			logger.Log.Errorf("error converting message to json %v, err: %s, (%v,%+v)", messageData, err, err, err)
-			continue
+	defer tapperSpool.Close()
+
+	sender := &tappedEntriesSender{
+		connection:  connection,
+		spool:       tapperSpool,
+		reconnected: make(chan *websocket.Conn),
+	}
+
+	// The entries a previous run couldn't send go first
+	sender.drain()
+
+	for {
+		select {
+		case messageData, ok := <-messageDataChannel:
+			if !ok {
+				return
+			}
+			sender.send(collectTappedEntriesBatch(messageData, messageDataChannel))
+		case connection := <-sender.reconnected:
+			logger.Log.Infof("recovered connection successfully, draining %d spooled entries", tapperSpool.Len())
+			sender.connection = connection
+			sender.drain()
 		}
+	}
+}
 
-		if dbgctl.MizuTapperDisableSending {
-			continue
+// collectTappedEntriesBatch waits for more messages until the batch is full or the flush interval passes
+func collectTappedEntriesBatch(first *tapApi.OutputChannelItem, messageDataChannel <-chan *tapApi.OutputChannelItem) []*tapApi.OutputChannelItem {
+	batch := []*tapApi.OutputChannelItem{first}
+	flushTimer := time.NewTimer(tappedEntriesBatchFlushInterval)
+	defer flushTimer.Stop()
+
+	for len(batch) < tappedEntriesBatchSize {
+		select {
+		case messageData, ok := <-messageDataChannel:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, messageData)
+		case <-flushTimer.C:
+			return batch
 		}
+	}
 
-		// NOTE: This is where the `*tapApi.OutputChannelItem` leaves the code
-		// and goes into the intermediate WebSocket.
-		err = connection.WriteMessage(websocket.TextMessage, marshaledData)
-		if err != nil {
-			logger.Log.Errorf("error sending message through socket server %v, err: %s, (%v,%+v)", messageData, err, err, err)
-			if errors.Is(err, syscall.EPIPE) {
-				logger.Log.Warning("detected socket disconnection, reestablishing socket connection")
-				connection, err = dialSocketWithRetry(*apiServerAddress, socketConnectionRetries, socketConnectionRetryDelay)
-				if err != nil {
-					logger.Log.Fatalf("error reestablishing socket connection: %v", err)
-				} else {
-					logger.Log.Info("recovered connection successfully")
-				}
+	return batch
+}
+
+/* tappedEntriesSender sends the tapped entries to the API server.
+ * While the API server is unreachable, the entries are spooled and the connection is reestablished in the background.
+ * After the reconnection the spooled entries are drained before the new ones are sent, so the order is kept.
+ */
+type tappedEntriesSender struct {
+	connection  *websocket.Conn
+	spool       *spool.Spool
+	reconnected chan *websocket.Conn
+}
+
+func (s *tappedEntriesSender) send(batch []*tapApi.OutputChannelItem) {
+	if s.connection != nil && s.spool.Len() == 0 {
+		err := s.sendToSocket(batch)
+		if err == nil {
+			return
+		}
+		s.disconnect(err)
+	}
+
+	if err := s.spool.Write(batch); err != nil {
+		logger.Log.Errorf("error spooling %d messages, err: %s, (%v,%+v)", len(batch), err, err, err)
+	}
+}
+
+func (s *tappedEntriesSender) drain() {
+	if s.connection == nil || s.spool.Len() == 0 {
+		return
+	}
+
+	if err := s.spool.Drain(s.sendToSocket); err != nil {
+		s.disconnect(err)
+		return
+	}
+
+	stats := s.spool.Stats()
+	logger.Log.Infof("drained the spool, spooled: %d, drained: %d, dropped: %d", stats.SpooledItems, stats.DrainedItems, stats.DroppedItems)
+}
+
+func (s *tappedEntriesSender) disconnect(err error) {
+	logger.Log.Warningf("detected socket disconnection, spooling the tapped entries and reestablishing socket connection, err: %v", err)
+	s.connection.Close()
+	s.connection = nil
+
+	go func() {
+		for {
+			connection, err := dialSocketWithRetry(*apiServerAddress, socketConnectionRetries, socketConnectionRetryDelay)
+			if err == nil {
+				s.reconnected <- connection
+				return
 			}
-			continue
+			stats := s.spool.Stats()
+			logger.Log.Errorf("error reestablishing socket connection, pending: %d, dropped: %d, err: %v", stats.PendingItems, stats.DroppedItems, err)
 		}
+	}()
+}
+
+// sendToSocket returns an error only when the connection is broken, the messages that can't be encoded are skipped
+func (s *tappedEntriesSender) sendToSocket(batch []*tapApi.OutputChannelItem) error {
+	if dbgctl.MizuTapperDisableSending.Enabled() {
+		return nil
 	}
+
+	// The API servers of the older agents don't select the subprotocol, they get the JSON text frames
+	if s.connection.Subprotocol() != models.TappedEntriesBatchSubprotocol {
+		for _, messageData := range batch {
+			marshaledData, err := models.CreateWebsocketTappedEntryMessage(messageData)
+			if err != nil {
+				logger.Log.Errorf("error converting message to json %v, err: %s, (%v,%+v)", messageData, err, err, err)
+				continue
+			}
+
+			// NOTE: This is where the `*tapApi.OutputChannelItem` leaves the code
+			// and goes into the intermediate WebSocket.
+			if err := s.connection.WriteMessage(websocket.TextMessage, marshaledData); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	encodedBatch, err := models.EncodeTappedEntriesBatch(batch)
+	if err != nil {
+		logger.Log.Errorf("error encoding a batch of %d messages, err: %s, (%v,%+v)", len(batch), err, err, err)
+		return nil
+	}
+
+	return s.connection.WriteMessage(websocket.BinaryMessage, encodedBatch)
 }
 
 func determineLogLevel() (logLevel logging.Level) {
@@ -308,6 +531,7 @@ func dialSocketWithRetry(socketAddress string, retryAmount int, retryDelay time.
 	dialer := &websocket.Dialer{ // we use our own dialer instead of the default due to the default's 45 sec handshake timeout, we occasionally encounter hanging socket handshakes when tapper tries to connect to api too soon
 		Proxy:            http.ProxyFromEnvironment,
 		HandshakeTimeout: socketHandshakeTimeout,
+		Subprotocols:     []string{models.TappedEntriesBatchSubprotocol},
 	}
 	for i := 1; i < retryAmount; i++ {
 		socketConnection, _, err := dialer.Dial(socketAddress, nil)
@@ -329,10 +553,8 @@ func handleIncomingMessageAsTapper(socketConnection *websocket.Conn) {
 	for {
 		if _, message, err := socketConnection.ReadMessage(); err != nil {
 			logger.Log.Errorf("error reading message from socket connection, err: %s, (%v,%+v)", err, err, err)
-			if errors.Is(err, syscall.EPIPE) {
-				// socket has disconnected, we can safely stop this goroutine
-				return
-			}
+			// a websocket connection is broken after a read error, the sender reestablishes it
+			return
 		} else {
 			var socketMessageBase shared.WebSocketMessageMetadata
 			if err := json.Unmarshal(message, &socketMessageBase); err != nil {
@@ -0,0 +1,128 @@
+package analyze
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/up9inc/mizu/agent/pkg/api"
+	"github.com/up9inc/mizu/agent/pkg/app"
+	"github.com/up9inc/mizu/agent/pkg/config"
+	"github.com/up9inc/mizu/agent/pkg/dependency"
+	"github.com/up9inc/mizu/agent/pkg/oas"
+	"github.com/up9inc/mizu/agent/pkg/servicemap"
+	"github.com/up9inc/mizu/logger"
+	"github.com/up9inc/mizu/tap"
+	tapApi "github.com/up9inc/mizu/tap/api"
+)
+
+// The files the results of an analysis are written to in the output directory
+const (
+	EntriesFileName    = "entries.jsonl"
+	ServiceMapFileName = "service_map.json"
+	OasDirName         = "oas"
+)
+
+// Run feeds the packets of the pcap file through the tapper and the pipeline of the API server, in the current process.
+// The extensions must be loaded with app.LoadExtensions first.
+// It returns once all the entries of the file went to the entries inserter, the OAS generator and the service map.
+func Run(pcapFile string, filteringOptions *tapApi.TrafficFilteringOptions) {
+	outputItemsChannel := make(chan *tapApi.OutputChannelItem)
+	filteredOutputItemsChannel := make(chan *tapApi.OutputChannelItem)
+
+	tap.SetInputFilename(pcapFile)
+	tapOpts := &tap.TapOpts{}
+	tap.StartPassiveTapper(tapOpts, outputItemsChannel, app.Extensions, filteringOptions)
+
+	// The tapper closes the output channel once the file is processed
+	go func() {
+		app.FilterItems(outputItemsChannel, filteredOutputItemsChannel)
+		close(filteredOutputItemsChannel)
+	}()
+
+	api.StartReadingEntries(filteredOutputItemsChannel, nil, app.ExtensionsMap)
+}
+
+// RunToDir analyzes the pcap file without Basenine, the entries, the OAS specs and the service map are written to outputDir.
+// It returns the number of the entries.
+func RunToDir(pcapFile string, outputDir string, filteringOptions *tapApi.TrafficFilteringOptions) (int, error) {
+	if err := config.LoadConfig(); err != nil {
+		return 0, fmt.Errorf("error loading config file %v", err)
+	}
+	config.Config.OAS.Enable = true
+	config.Config.ServiceMap = true
+
+	if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+		return 0, fmt.Errorf("error creating the output directory %s: %v", outputDir, err)
+	}
+
+	entryInserter, err := api.NewFileEntryInserter(filepath.Join(outputDir, EntriesFileName))
+	if err != nil {
+		return 0, err
+	}
+
+	dependency.RegisterGenerator(dependency.ServiceMapGeneratorDependency, func() interface{} { return servicemap.GetDefaultServiceMapInstance() })
+	dependency.RegisterGenerator(dependency.OasGeneratorDependency, func() interface{} { return oas.GetDefaultOasGeneratorInstance(config.Config.OAS.MaxExampleLen) })
+	dependency.RegisterGenerator(dependency.EntriesInserter, func() interface{} { return entryInserter })
+
+	dependency.GetInstance(dependency.OasGeneratorDependency).(oas.OasGenerator).Start()
+	dependency.GetInstance(dependency.ServiceMapGeneratorDependency).(servicemap.ServiceMap).Enable()
+
+	Run(pcapFile, filteringOptions)
+
+	if err := entryInserter.Close(); err != nil {
+		return 0, fmt.Errorf("error writing the entries: %v", err)
+	}
+	if err := writeServiceMap(filepath.Join(outputDir, ServiceMapFileName)); err != nil {
+		return 0, fmt.Errorf("error writing the service map: %v", err)
+	}
+	if err := writeOasSpecs(filepath.Join(outputDir, OasDirName)); err != nil {
+		return 0, fmt.Errorf("error writing the OAS specs: %v", err)
+	}
+
+	return entryInserter.Count(), nil
+}
+
+func writeServiceMap(path string) error {
+	serviceMap := dependency.GetInstance(dependency.ServiceMapGeneratorDependency).(servicemap.ServiceMap)
+	return writeJson(path, &servicemap.ServiceMapResponse{
+		Status: serviceMap.GetStatus(),
+		Nodes:  serviceMap.GetNodes(),
+		Edges:  serviceMap.GetEdges(),
+	})
+}
+
+// writeOasSpecs writes the spec of every service to its own file
+func writeOasSpecs(dir string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	var err error
+	oasGenerator := dependency.GetInstance(dependency.OasGeneratorDependency).(oas.OasGenerator)
+	oasGenerator.GetServiceSpecs().Range(func(key, value interface{}) bool {
+		service := key.(string)
+		spec, specErr := value.(*oas.SpecGen).GetSpec()
+		if specErr != nil {
+			logger.Log.Warningf("Failed to obtain spec for service %s: %s", service, specErr)
+			return true
+		}
+
+		err = writeJson(filepath.Join(dir, url.PathEscape(service)+".json"), spec)
+		return err == nil
+	})
+
+	return err
+}
+
+func writeJson(path string, value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// FileEntryInserter writes the entries to a file as JSON lines, instead of inserting them to Basenine
+type FileEntryInserter struct {
+	sync.Mutex
+	file   *os.File
+	writer *bufio.Writer
+	nextId int
+}
+
+func NewFileEntryInserter(path string) (*FileEntryInserter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("error creating the entries file %s, err: %v", path, err)
+	}
+
+	return &FileEntryInserter{
+		file:   file,
+		writer: bufio.NewWriter(file),
+	}, nil
+}
+
+func (e *FileEntryInserter) Insert(entry *api.Entry) error {
+	e.Lock()
+	defer e.Unlock()
+
+	// The ids are assigned in the order of the insertion
+	entry.Id = strconv.Itoa(e.nextId)
+	e.nextId++
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("error marshling entry, err: %v", err)
+	}
+
+	if _, err := e.writer.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing entry, err: %v", err)
+	}
+
+	return nil
+}
+
+// Count is the number of the entries that were inserted
+func (e *FileEntryInserter) Count() int {
+	e.Lock()
+	defer e.Unlock()
+
+	return e.nextId
+}
+
+func (e *FileEntryInserter) Close() error {
+	e.Lock()
+	defer e.Unlock()
+
+	if err := e.writer.Flush(); err != nil {
+		e.file.Close()
+		return err
+	}
+	return e.file.Close()
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	basenine "github.com/up9inc/basenine/client/go"
+	"github.com/up9inc/mizu/agent/pkg/metrics"
 	"github.com/up9inc/mizu/logger"
 	"github.com/up9inc/mizu/shared"
 	"github.com/up9inc/mizu/tap/api"
@@ -40,12 +41,14 @@ func (e *BasenineEntryInserter) Insert(entry *api.Entry) error {
 		return fmt.Errorf("error marshling entry, err: %v", err)
 	}
 
+	start := time.Now()
 	if err := e.connection.SendText(string(data)); err != nil {
 		e.connection.Close()
 		e.connection = nil
 
 		return fmt.Errorf("error sending text to database, err: %v", err)
 	}
+	metrics.ObserveBasenineLatency("insert", time.Since(start))
 
 	return nil
 }
@@ -28,6 +28,7 @@ type EventHandlers interface {
 	WebSocketConnect(c *gin.Context, socketId int, isTapper bool)
 	WebSocketDisconnect(socketId int, isTapper bool)
 	WebSocketMessage(socketId int, isTapper bool, message []byte)
+	WebSocketBinaryMessage(socketId int, isTapper bool, message []byte)
 }
 
 type SocketConnection struct {
@@ -49,6 +50,7 @@ var (
 	websocketUpgrader = websocket.Upgrader{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
+		Subprotocols:    []string{models.TappedEntriesBatchSubprotocol},
 	}
 
 	websocketIdsLock            = sync.Mutex{}
@@ -111,7 +113,7 @@ func websocketHandler(c *gin.Context, eventHandlers EventHandlers, isTapper bool
 	}
 
 	for {
-		_, msg, err := ws.ReadMessage()
+		messageType, msg, err := ws.ReadMessage()
 		if err != nil {
 			if _, ok := err.(*websocket.CloseError); ok {
 				logger.Log.Debugf("received websocket close message, socket id: %d", socketId)
@@ -122,10 +124,33 @@ func websocketHandler(c *gin.Context, eventHandlers EventHandlers, isTapper bool
 			break
 		}
 
-		eventHandlers.WebSocketMessage(socketId, isTapper, msg)
+		if messageType == websocket.BinaryMessage {
+			eventHandlers.WebSocketBinaryMessage(socketId, isTapper, msg)
+		} else {
+			eventHandlers.WebSocketMessage(socketId, isTapper, msg)
+		}
 	}
 }
 
+// GetConnectedWebsocketsCount returns the number of the connected browser and tapper websockets
+func GetConnectedWebsocketsCount() (browsers int, tappers int) {
+	websocketIdsLock.Lock()
+	defer websocketIdsLock.Unlock()
+
+	for _, socketConnection := range connectedWebsockets {
+		if socketConnection == nil {
+			continue
+		}
+		if socketConnection.isTapper {
+			tappers++
+		} else {
+			browsers++
+		}
+	}
+
+	return
+}
+
 func SendToSocket(socketId int, message []byte) error {
 	socketObj := connectedWebsockets[socketId]
 	if socketObj == nil {
@@ -117,6 +117,27 @@ func (h *RoutesEventHandlers) WebSocketMessage(socketId int, isTapper bool, mess
 	}
 }
 
+func (h *RoutesEventHandlers) WebSocketBinaryMessage(socketId int, isTapper bool, message []byte) {
+	if isTapper {
+		HandleTapperIncomingBatch(message, h.SocketOutChannel)
+	} else {
+		logger.Log.Warningf("Unexpected binary message from browser socket %d", socketId)
+	}
+}
+
+// HandleTapperIncomingBatch handles the batches of tapped entries the tappers send on the negotiated subprotocol
+func HandleTapperIncomingBatch(message []byte, socketOutChannel chan<- *tapApi.OutputChannelItem) {
+	items, err := models.DecodeTappedEntriesBatch(message)
+	if err != nil {
+		logger.Log.Infof("Could not decode a batch of tapped entries %v", err)
+		return
+	}
+
+	for _, item := range items {
+		socketOutChannel <- item
+	}
+}
+
 func HandleTapperIncomingMessage(message []byte, socketOutChannel chan<- *tapApi.OutputChannelItem, broadcastMessageFunc func([]byte)) {
 	var socketMessageBase shared.WebSocketMessageMetadata
 	err := json.Unmarshal(message, &socketMessageBase)
@@ -15,9 +15,15 @@ import (
 	tapApi "github.com/up9inc/mizu/tap/api"
 	"github.com/up9inc/mizu/tap/dbgctl"
 	amqpExt "github.com/up9inc/mizu/tap/extensions/amqp"
+	dnsExt "github.com/up9inc/mizu/tap/extensions/dns"
 	httpExt "github.com/up9inc/mizu/tap/extensions/http"
 	kafkaExt "github.com/up9inc/mizu/tap/extensions/kafka"
+	mongodbExt "github.com/up9inc/mizu/tap/extensions/mongodb"
+	mqttExt "github.com/up9inc/mizu/tap/extensions/mqtt"
+	mysqlExt "github.com/up9inc/mizu/tap/extensions/mysql"
+	postgresExt "github.com/up9inc/mizu/tap/extensions/postgres"
 	redisExt "github.com/up9inc/mizu/tap/extensions/redis"
+	tcprawExt "github.com/up9inc/mizu/tap/extensions/tcpraw"
 )
 
 var (
@@ -26,7 +32,7 @@ var (
 	ProtocolsMap  map[string]*tapApi.Protocol  //global
 )
 
-func LoadExtensions() {
+func LoadExtensions(extensionsDir string) {
 	Extensions = make([]*tapApi.Extension, 0)
 	ExtensionsMap = make(map[string]*tapApi.Extension)
 	ProtocolsMap = make(map[string]*tapApi.Protocol)
@@ -42,7 +48,7 @@ func LoadExtensions() {
 		ProtocolsMap[k] = v
 	}
 
-	if !dbgctl.MizuTapperDisableNonHttpExtensions {
+	if !dbgctl.MizuTapperDisableNonHttpExtensions.Enabled() {
 		extensionAmqp := &tapApi.Extension{}
 		dissectorAmqp := amqpExt.NewDissector()
 		dissectorAmqp.Register(extensionAmqp)
@@ -75,6 +81,76 @@ func LoadExtensions() {
 		for k, v := range protocolsRedis {
 			ProtocolsMap[k] = v
 		}
+
+		extensionDns := &tapApi.Extension{}
+		dissectorDns := dnsExt.NewDissector()
+		dissectorDns.Register(extensionDns)
+		extensionDns.Dissector = dissectorDns
+		Extensions = append(Extensions, extensionDns)
+		ExtensionsMap[extensionDns.Protocol.Name] = extensionDns
+		protocolsDns := dissectorDns.GetProtocols()
+		for k, v := range protocolsDns {
+			ProtocolsMap[k] = v
+		}
+
+		extensionPostgres := &tapApi.Extension{}
+		dissectorPostgres := postgresExt.NewDissector()
+		dissectorPostgres.Register(extensionPostgres)
+		extensionPostgres.Dissector = dissectorPostgres
+		Extensions = append(Extensions, extensionPostgres)
+		ExtensionsMap[extensionPostgres.Protocol.Name] = extensionPostgres
+		protocolsPostgres := dissectorPostgres.GetProtocols()
+		for k, v := range protocolsPostgres {
+			ProtocolsMap[k] = v
+		}
+
+		extensionMySQL := &tapApi.Extension{}
+		dissectorMySQL := mysqlExt.NewDissector()
+		dissectorMySQL.Register(extensionMySQL)
+		extensionMySQL.Dissector = dissectorMySQL
+		Extensions = append(Extensions, extensionMySQL)
+		ExtensionsMap[extensionMySQL.Protocol.Name] = extensionMySQL
+		protocolsMySQL := dissectorMySQL.GetProtocols()
+		for k, v := range protocolsMySQL {
+			ProtocolsMap[k] = v
+		}
+
+		extensionMongoDB := &tapApi.Extension{}
+		dissectorMongoDB := mongodbExt.NewDissector()
+		dissectorMongoDB.Register(extensionMongoDB)
+		extensionMongoDB.Dissector = dissectorMongoDB
+		Extensions = append(Extensions, extensionMongoDB)
+		ExtensionsMap[extensionMongoDB.Protocol.Name] = extensionMongoDB
+		protocolsMongoDB := dissectorMongoDB.GetProtocols()
+		for k, v := range protocolsMongoDB {
+			ProtocolsMap[k] = v
+		}
+
+		extensionMQTT := &tapApi.Extension{}
+		dissectorMQTT := mqttExt.NewDissector()
+		dissectorMQTT.Register(extensionMQTT)
+		extensionMQTT.Dissector = dissectorMQTT
+		Extensions = append(Extensions, extensionMQTT)
+		ExtensionsMap[extensionMQTT.Protocol.Name] = extensionMQTT
+		protocolsMQTT := dissectorMQTT.GetProtocols()
+		for k, v := range protocolsMQTT {
+			ProtocolsMap[k] = v
+		}
+
+		extensionTcpRaw := &tapApi.Extension{}
+		dissectorTcpRaw := tcprawExt.NewDissector()
+		dissectorTcpRaw.Register(extensionTcpRaw)
+		extensionTcpRaw.Dissector = dissectorTcpRaw
+		Extensions = append(Extensions, extensionTcpRaw)
+		ExtensionsMap[extensionTcpRaw.Protocol.Name] = extensionTcpRaw
+		protocolsTcpRaw := dissectorTcpRaw.GetProtocols()
+		for k, v := range protocolsTcpRaw {
+			ProtocolsMap[k] = v
+		}
+
+		if extensionsDir != "" {
+			loadPluginExtensions(extensionsDir)
+		}
 	}
 
 	sort.Slice(Extensions, func(i, j int) bool {
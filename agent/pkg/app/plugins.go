@@ -0,0 +1,109 @@
+package app
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"plugin"
+
+	"github.com/up9inc/mizu/logger"
+	tapApi "github.com/up9inc/mizu/tap/api"
+)
+
+// The dissectors that are not built into the agent are Go plugins (`go build -buildmode=plugin`) in the extensions
+// directory. A plugin exports either a `NewDissector func() api.Dissector` or a `Dissector` variable, the same as the
+// built-in extensions. The plugins must be built with the same Go version and the same versions of the shared packages
+// as the agent, in the agent's image (see the Dockerfile). The agent is linked dynamically for them, a static or a
+// pure-Go (CGO_ENABLED=0) agent can't load plugins. `mizu tap --extensions-host-path` mounts the directory in the pods.
+const (
+	pluginExtension          = ".so"
+	pluginNewDissectorSymbol = "NewDissector"
+	pluginDissectorSymbol    = "Dissector"
+)
+
+func loadPluginExtensions(extensionsDir string) {
+	files, err := ioutil.ReadDir(extensionsDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logger.Log.Errorf("Error while reading the extensions directory %s: %v", extensionsDir, err)
+		}
+		return
+	}
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != pluginExtension {
+			continue
+		}
+
+		extensionPath := filepath.Join(extensionsDir, file.Name())
+		extension, err := loadPluginExtension(extensionPath)
+		if err != nil {
+			logger.Log.Errorf("Error while loading the extension %s: %v", extensionPath, err)
+			continue
+		}
+
+		logger.Log.Infof("Loaded extension %s (%s)", extensionPath, extension.Protocol.Name)
+	}
+}
+
+func loadPluginExtension(extensionPath string) (*tapApi.Extension, error) {
+	plug, err := plugin.Open(extensionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	dissector, err := lookupPluginDissector(plug)
+	if err != nil {
+		return nil, err
+	}
+
+	extension := &tapApi.Extension{
+		Path: extensionPath,
+	}
+	dissector.Register(extension)
+	extension.Dissector = dissector
+
+	if extension.Protocol == nil {
+		return nil, fmt.Errorf("the dissector did not register a protocol")
+	}
+	if _, ok := ExtensionsMap[extension.Protocol.Name]; ok {
+		return nil, fmt.Errorf("the protocol %s is already registered", extension.Protocol.Name)
+	}
+
+	protocols := dissector.GetProtocols()
+	for key, protocol := range protocols {
+		if _, ok := ProtocolsMap[key]; ok {
+			return nil, fmt.Errorf("the protocol %s %s is already registered", protocol.Name, protocol.Version)
+		}
+	}
+
+	Extensions = append(Extensions, extension)
+	ExtensionsMap[extension.Protocol.Name] = extension
+	for k, v := range protocols {
+		ProtocolsMap[k] = v
+	}
+
+	return extension, nil
+}
+
+func lookupPluginDissector(plug *plugin.Plugin) (tapApi.Dissector, error) {
+	if symbol, err := plug.Lookup(pluginNewDissectorSymbol); err == nil {
+		newDissector, ok := symbol.(func() tapApi.Dissector)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a func() api.Dissector", pluginNewDissectorSymbol)
+		}
+		return newDissector(), nil
+	}
+
+	symbol, err := plug.Lookup(pluginDissectorSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("neither %s nor %s is exported", pluginNewDissectorSymbol, pluginDissectorSymbol)
+	}
+	// The variables are looked up as pointers, their methods are promoted
+	dissector, ok := symbol.(tapApi.Dissector)
+	if !ok {
+		return nil, fmt.Errorf("%s does not implement api.Dissector", pluginDissectorSymbol)
+	}
+	return dissector, nil
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	tapApi "github.com/up9inc/mizu/tap/api"
+)
+
+// buildFixturePlugin builds the plugin of testdata/plugin with the packages of the test, like the plugins have to be built
+func buildFixturePlugin(t *testing.T, dir string) string {
+	pluginPath := filepath.Join(dir, "fixture"+pluginExtension)
+	output, err := exec.Command("go", "build", "-buildmode=plugin", "-o", pluginPath, "./testdata/plugin").CombinedOutput()
+	if err != nil {
+		t.Skipf("Could not build the fixture plugin: %v\n%s", err, output)
+	}
+	return pluginPath
+}
+
+func resetExtensions() {
+	Extensions = make([]*tapApi.Extension, 0)
+	ExtensionsMap = make(map[string]*tapApi.Extension)
+	ProtocolsMap = make(map[string]*tapApi.Protocol)
+}
+
+// A plugin is opened once by a process, so it's built once and all the cases are in a single test
+func TestLoadPluginExtensions(t *testing.T) {
+	dir := t.TempDir()
+	pluginPath := buildFixturePlugin(t, dir)
+	// Only the plugins are loaded from the directory
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a plugin"), 0644))
+
+	resetExtensions()
+	loadPluginExtensions(dir)
+
+	assert.Len(t, Extensions, 1)
+	extension := ExtensionsMap["fixture"]
+	if assert.NotNil(t, extension) {
+		assert.Equal(t, pluginPath, extension.Path)
+		assert.Equal(t, "proto.name == \"fixture\"", extension.Dissector.Macros()["fixture"])
+		assert.Contains(t, ProtocolsMap, extension.Protocol.ToString())
+	}
+
+	// A protocol can't be registered twice
+	_, err := loadPluginExtension(pluginPath)
+	assert.NotNil(t, err)
+	assert.Len(t, Extensions, 1)
+
+	notPluginPath := filepath.Join(t.TempDir(), "broken"+pluginExtension)
+	assert.Nil(t, ioutil.WriteFile(notPluginPath, []byte("not a plugin"), 0644))
+	_, err = loadPluginExtension(notPluginPath)
+	assert.NotNil(t, err)
+	assert.Len(t, Extensions, 1)
+}
+
+func TestLoadMissingPluginExtensionsDir(t *testing.T) {
+	resetExtensions()
+	loadPluginExtensions(filepath.Join(t.TempDir(), "missing"))
+	assert.Len(t, Extensions, 0)
+}
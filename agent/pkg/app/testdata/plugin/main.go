@@ -0,0 +1,67 @@
+// The dissector plugin the loader is tested with, built with `go build -buildmode=plugin`
+package main
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+var protocol = api.Protocol{
+	ProtocolSummary: api.ProtocolSummary{
+		Name:         "fixture",
+		Version:      "1.0",
+		Abbreviation: "FIX",
+	},
+	LongName:        "Fixture Protocol",
+	Macro:           "fixture",
+	BackgroundColor: "#000000",
+	ForegroundColor: "#ffffff",
+	FontSize:        12,
+	ReferenceLink:   "https://example.com",
+	Ports:           []string{"7777"},
+	Priority:        3,
+}
+
+type dissecting string
+
+func (d dissecting) Register(extension *api.Extension) {
+	extension.Protocol = &protocol
+}
+
+func (d dissecting) GetProtocols() map[string]*api.Protocol {
+	return map[string]*api.Protocol{
+		protocol.ToString(): &protocol,
+	}
+}
+
+func (d dissecting) Ping() {}
+
+func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.TrafficFilteringOptions) error {
+	return fmt.Errorf("not a fixture stream")
+}
+
+func (d dissecting) Analyze(item *api.OutputChannelItem, resolvedSource string, resolvedDestination string, namespace string) *api.Entry {
+	return nil
+}
+
+func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
+	return nil
+}
+
+func (d dissecting) Represent(request map[string]interface{}, response map[string]interface{}) (object []byte, err error) {
+	return nil, nil
+}
+
+func (d dissecting) Macros() map[string]string {
+	return map[string]string{protocol.Macro: fmt.Sprintf("proto.name == \"%s\"", protocol.Name)}
+}
+
+func (d dissecting) NewResponseRequestMatcher() api.RequestResponseMatcher {
+	return nil
+}
+
+func NewDissector() api.Dissector {
+	return dissecting("fixture")
+}
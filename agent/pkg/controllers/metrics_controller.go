@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/up9inc/mizu/agent/pkg/api"
+	"github.com/up9inc/mizu/agent/pkg/dependency"
+	"github.com/up9inc/mizu/agent/pkg/metrics"
+	"github.com/up9inc/mizu/agent/pkg/oas"
+	"github.com/up9inc/mizu/agent/pkg/servicemap"
+	"github.com/up9inc/mizu/logger"
+	tapApi "github.com/up9inc/mizu/tap/api"
+)
+
+func GetMetrics(c *gin.Context) {
+	collected := metrics.GetMetrics()
+
+	browsers, tappers := api.GetConnectedWebsocketsCount()
+	collected = append(collected, tapApi.NewMetric("mizu_api_server_websocket_clients", tapApi.GaugeMetric, "Connected websocket clients").
+		AddSample(float64(browsers), map[string]string{"client": "browser"}).
+		AddSample(float64(tappers), map[string]string{"client": "tapper"}))
+
+	serviceMap := dependency.GetInstance(dependency.ServiceMapGeneratorDependency).(servicemap.ServiceMap)
+	collected = append(collected,
+		tapApi.NewMetric("mizu_api_server_service_map_nodes", tapApi.GaugeMetric, "Nodes of the service map").AddSample(float64(serviceMap.GetNodesCount()), nil),
+		tapApi.NewMetric("mizu_api_server_service_map_edges", tapApi.GaugeMetric, "Edges of the service map").AddSample(float64(serviceMap.GetEdgesCount()), nil),
+		tapApi.NewMetric("mizu_api_server_service_map_processed_entries_total", tapApi.CounterMetric, "Entries processed by the service map").AddSample(float64(serviceMap.GetEntriesProcessedCount()), nil),
+	)
+
+	oasServices := 0
+	oasGenerator := dependency.GetInstance(dependency.OasGeneratorDependency).(oas.OasGenerator)
+	if serviceSpecs := oasGenerator.GetServiceSpecs(); serviceSpecs != nil {
+		serviceSpecs.Range(func(_, _ interface{}) bool {
+			oasServices++
+			return true
+		})
+	}
+	collected = append(collected, tapApi.NewMetric("mizu_api_server_oas_services", tapApi.GaugeMetric, "Services the OAS generator has specs of").AddSample(float64(oasServices), nil))
+
+	var body bytes.Buffer
+	if err := tapApi.WriteMetrics(&body, collected); err != nil {
+		logger.Log.Errorf("Error writing the metrics: %v", err)
+		c.AbortWithStatus(http.StatusInternalServerError)
+		return
+	}
+
+	c.Data(http.StatusOK, tapApi.MetricsContentType, body.Bytes())
+}
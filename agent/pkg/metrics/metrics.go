@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	tapApi "github.com/up9inc/mizu/tap/api"
+)
+
+type latency struct {
+	sum   time.Duration
+	count uint64
+}
+
+var (
+	lock              sync.Mutex
+	insertedEntries   = make(map[string]uint64)
+	basenineLatencies = make(map[string]*latency)
+)
+
+func EntryInserted(protocol string) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	insertedEntries[protocol]++
+}
+
+// ObserveBasenineLatency records how long an operation on Basenine took, e.g. an insert or a fetch
+func ObserveBasenineLatency(operation string, duration time.Duration) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	observed, ok := basenineLatencies[operation]
+	if !ok {
+		observed = &latency{}
+		basenineLatencies[operation] = observed
+	}
+	observed.sum += duration
+	observed.count++
+}
+
+// Since observes the latency of an operation that started at start, for a deferred call
+func Since(operation string, start time.Time) {
+	ObserveBasenineLatency(operation, time.Since(start))
+}
+
+func GetMetrics() []*tapApi.Metric {
+	lock.Lock()
+	defer lock.Unlock()
+
+	entries := tapApi.NewMetric("mizu_api_server_inserted_entries_total", tapApi.CounterMetric, "Entries inserted into Basenine")
+	for _, protocol := range sortedKeys(insertedEntries) {
+		entries.AddSample(float64(insertedEntries[protocol]), map[string]string{"protocol": protocol})
+	}
+
+	latencies := tapApi.NewMetric("mizu_api_server_basenine_latency_seconds", tapApi.SummaryMetric, "Latency of the operations on Basenine")
+	operations := make([]string, 0, len(basenineLatencies))
+	for operation := range basenineLatencies {
+		operations = append(operations, operation)
+	}
+	sort.Strings(operations)
+	for _, operation := range operations {
+		observed := basenineLatencies[operation]
+		latencies.AddSummary(observed.sum.Seconds(), observed.count, map[string]string{"operation": operation})
+	}
+
+	return []*tapApi.Metric{entries, latencies}
+}
+
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,46 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	tapApi "github.com/up9inc/mizu/tap/api"
+)
+
+func TestGetMetrics(t *testing.T) {
+	EntryInserted("HTTP")
+	EntryInserted("HTTP")
+	EntryInserted("REDIS")
+	ObserveBasenineLatency("insert", 250*time.Millisecond)
+	ObserveBasenineLatency("insert", 250*time.Millisecond)
+
+	var body bytes.Buffer
+	if err := tapApi.WriteMetrics(&body, GetMetrics()); err != nil {
+		t.Fatalf("error writing the metrics: %v", err)
+	}
+
+	expectedLines := []string{
+		"# TYPE mizu_api_server_inserted_entries_total counter",
+		`mizu_api_server_inserted_entries_total{protocol="HTTP"} 2`,
+		`mizu_api_server_inserted_entries_total{protocol="REDIS"} 1`,
+		"# TYPE mizu_api_server_basenine_latency_seconds summary",
+		`mizu_api_server_basenine_latency_seconds_sum{operation="insert"} 0.5`,
+		`mizu_api_server_basenine_latency_seconds_count{operation="insert"} 2`,
+	}
+
+	lines := strings.Split(body.String(), "\n")
+	for _, expected := range expectedLines {
+		found := false
+		for _, line := range lines {
+			if line == expected {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("expected the line %q in\n%s", expected, body.String())
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ugorji/go/codec"
+	tapApi "github.com/up9inc/mizu/tap/api"
+)
+
+// TappedEntriesBatchSubprotocol is the websocket subprotocol a tapper offers when it dials the API server.
+// When the API server selects it, the tapped entries are sent as binary frames of batches,
+// otherwise they are sent one by one as JSON text frames the way the older agents do.
+const TappedEntriesBatchSubprotocol = "mizu.tapped-entries.msgpack-zstd.v3"
+
+// The decompressed batches are bounded, so a corrupted or a hostile frame can't exhaust the memory
+const maxTappedEntriesBatchSize = 256 * 1024 * 1024
+
+var (
+	msgpackHandle = newMsgpackHandle()
+	zstdEncoder   *zstd.Encoder
+	zstdDecoder   *zstd.Decoder
+)
+
+func init() {
+	var err error
+	if zstdEncoder, err = zstd.NewWriter(nil); err != nil {
+		panic(fmt.Sprintf("Error creating the zstd encoder %v", err))
+	}
+	if zstdDecoder, err = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(maxTappedEntriesBatchSize)); err != nil {
+		panic(fmt.Sprintf("Error creating the zstd decoder %v", err))
+	}
+}
+
+// The payloads are sent as msgpack binaries, so they aren't mistaken for strings
+func newMsgpackHandle() *codec.MsgpackHandle {
+	handle := &codec.MsgpackHandle{}
+	handle.WriteExt = true
+	return handle
+}
+
+type tappedEntriesBatch struct {
+	Items []*tappedEntry `codec:"items"`
+}
+
+type tappedEntry struct {
+	Protocol       tapApi.Protocol        `codec:"protocol"`
+	Capture        tapApi.Capture         `codec:"capture"`
+	Timestamp      int64                  `codec:"timestamp"`
+	ConnectionInfo *tapApi.ConnectionInfo `codec:"connectionInfo"`
+	Namespace      string                 `codec:"namespace"`
+	Pair           *tappedEntryPair       `codec:"pair"`
+}
+
+type tappedEntryPair struct {
+	Request  tappedEntryMessage `codec:"request"`
+	Response tappedEntryMessage `codec:"response"`
+}
+
+// The payloads are marshaled once to JSON on the tapper, the way the JSON text frames carry them,
+// and unmarshaled on the API server, where the dissectors analyze them in their generic form
+type tappedEntryMessage struct {
+	IsRequest   bool   `codec:"isRequest"`
+	CaptureTime int64  `codec:"captureTime"`
+	CaptureSize int    `codec:"captureSize"`
+	Payload     []byte `codec:"payload"`
+}
+
+func EncodeTappedEntriesBatch(items []*tapApi.OutputChannelItem) ([]byte, error) {
+	batch := &tappedEntriesBatch{Items: make([]*tappedEntry, 0, len(items))}
+	for _, item := range items {
+		entry, err := newTappedEntry(item)
+		if err != nil {
+			return nil, err
+		}
+		batch.Items = append(batch.Items, entry)
+	}
+
+	var encoded []byte
+	if err := codec.NewEncoderBytes(&encoded, msgpackHandle).Encode(batch); err != nil {
+		return nil, err
+	}
+
+	return zstdEncoder.EncodeAll(encoded, nil), nil
+}
+
+func DecodeTappedEntriesBatch(frame []byte) ([]*tapApi.OutputChannelItem, error) {
+	encoded, err := zstdDecoder.DecodeAll(frame, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var batch tappedEntriesBatch
+	if err := codec.NewDecoderBytes(encoded, msgpackHandle).Decode(&batch); err != nil {
+		return nil, err
+	}
+
+	items := make([]*tapApi.OutputChannelItem, 0, len(batch.Items))
+	for _, entry := range batch.Items {
+		item, err := entry.outputChannelItem()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+func newTappedEntry(item *tapApi.OutputChannelItem) (*tappedEntry, error) {
+	entry := &tappedEntry{
+		Protocol:       item.Protocol,
+		Capture:        item.Capture,
+		Timestamp:      item.Timestamp,
+		ConnectionInfo: item.ConnectionInfo,
+		Namespace:      item.Namespace,
+	}
+
+	if item.Pair != nil {
+		request, err := newTappedEntryMessage(&item.Pair.Request)
+		if err != nil {
+			return nil, err
+		}
+		response, err := newTappedEntryMessage(&item.Pair.Response)
+		if err != nil {
+			return nil, err
+		}
+		entry.Pair = &tappedEntryPair{Request: *request, Response: *response}
+	}
+
+	return entry, nil
+}
+
+func newTappedEntryMessage(message *tapApi.GenericMessage) (*tappedEntryMessage, error) {
+	payload, err := json.Marshal(message.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	// The unanswered requests have a zero response, which doesn't fit in nanoseconds since the epoch
+	var captureTime int64
+	if !message.CaptureTime.IsZero() {
+		captureTime = message.CaptureTime.UnixNano()
+	}
+
+	return &tappedEntryMessage{
+		IsRequest:   message.IsRequest,
+		CaptureTime: captureTime,
+		CaptureSize: message.CaptureSize,
+		Payload:     payload,
+	}, nil
+}
+
+func (entry *tappedEntry) outputChannelItem() (*tapApi.OutputChannelItem, error) {
+	item := &tapApi.OutputChannelItem{
+		Protocol:       entry.Protocol,
+		Capture:        entry.Capture,
+		Timestamp:      entry.Timestamp,
+		ConnectionInfo: entry.ConnectionInfo,
+		Namespace:      entry.Namespace,
+	}
+
+	if entry.Pair != nil {
+		request, err := entry.Pair.Request.genericMessage()
+		if err != nil {
+			return nil, err
+		}
+		response, err := entry.Pair.Response.genericMessage()
+		if err != nil {
+			return nil, err
+		}
+		item.Pair = &tapApi.RequestResponsePair{Request: *request, Response: *response}
+	}
+
+	return item, nil
+}
+
+func (message *tappedEntryMessage) genericMessage() (*tapApi.GenericMessage, error) {
+	var payload interface{}
+	if err := json.Unmarshal(message.Payload, &payload); err != nil {
+		return nil, err
+	}
+
+	var captureTime time.Time
+	if message.CaptureTime != 0 {
+		captureTime = time.Unix(0, message.CaptureTime).UTC()
+	}
+
+	return &tapApi.GenericMessage{
+		IsRequest:   message.IsRequest,
+		CaptureTime: captureTime,
+		CaptureSize: message.CaptureSize,
+		Payload:     payload,
+	}, nil
+}
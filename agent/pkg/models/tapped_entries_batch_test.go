@@ -0,0 +1,256 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tapApi "github.com/up9inc/mizu/tap/api"
+	httpExt "github.com/up9inc/mizu/tap/extensions/http"
+)
+
+// marshalingPayload marshals itself, like the payloads of the dissectors
+type marshalingPayload struct {
+	method string
+	status int
+}
+
+func (p marshalingPayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"details": map[string]interface{}{"method": p.method, "status": p.status, "headers": []string{"a", "b"}},
+	})
+}
+
+func TestTappedEntriesBatchRoundTrip(t *testing.T) {
+	items := testTappedEntries()
+
+	frame, err := EncodeTappedEntriesBatch(items)
+	if err != nil {
+		t.Fatalf("error encoding the batch: %v", err)
+	}
+
+	decoded, err := DecodeTappedEntriesBatch(frame)
+	if err != nil {
+		t.Fatalf("error decoding the batch: %v", err)
+	}
+
+	// The JSON text frames are the reference, the batches must decode to the same items
+	if len(decoded) != len(items) {
+		t.Fatalf("expected %d items, got %d", len(items), len(decoded))
+	}
+	for i := range items {
+		expected := jsonRoundTrip(t, items[i])
+		if !reflect.DeepEqual(expected, decoded[i]) {
+			t.Errorf("item %d differs\nexpected: %+v\ngot:      %+v", i, expected, decoded[i])
+		}
+	}
+}
+
+func TestDecodeTappedEntriesBatchRejectsGarbage(t *testing.T) {
+	if _, err := DecodeTappedEntriesBatch([]byte(`{"messageType":"tappedEntry"}`)); err == nil {
+		t.Error("expected an error decoding a JSON message as a batch")
+	}
+}
+
+// The benchmarks compare the batches with the JSON text frames, on the tapper, which encodes the dissectors' payloads,
+// and end to end, where the API server decodes them to their generic form
+func BenchmarkEncodeTappedEntriesBatch(b *testing.B) {
+	items := benchmarkTappedEntries(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := EncodeTappedEntriesBatch(items); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeTappedEntriesJSON(b *testing.B) {
+	items := benchmarkTappedEntries(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, item := range items {
+			if _, err := json.Marshal(item); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkTappedEntriesBatch(b *testing.B) {
+	items := benchmarkTappedEntries(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		frame, err := EncodeTappedEntriesBatch(items)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := DecodeTappedEntriesBatch(frame); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTappedEntriesJSON(b *testing.B) {
+	items := benchmarkTappedEntries(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, item := range items {
+			marshaled, err := json.Marshal(item)
+			if err != nil {
+				b.Fatal(err)
+			}
+			var unmarshaled tapApi.OutputChannelItem
+			if err := json.Unmarshal(marshaled, &unmarshaled); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// benchmarkTappedEntries are HTTP entries with the payloads of the HTTP dissector, the way the tappers emit them
+func benchmarkTappedEntries(b *testing.B) []*tapApi.OutputChannelItem {
+	captureTime := time.Date(2022, 3, 1, 12, 0, 0, 123456789, time.UTC)
+
+	var items []*tapApi.OutputChannelItem
+	for i := 0; i < 100; i++ {
+		request := httptest.NewRequest(http.MethodPost, fmt.Sprintf("http://example.com/api/v1/orders/%d?expand=items&limit=10", i), strings.NewReader(`{"item":"book","quantity":1}`))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("User-Agent", "benchmark/1.0")
+		request.Header.Set("Authorization", "Bearer token")
+
+		response := &http.Response{
+			Status:     "200 OK",
+			StatusCode: http.StatusOK,
+			Proto:      "HTTP/1.1",
+			ProtoMajor: 1,
+			ProtoMinor: 1,
+			Header:     http.Header{"Content-Type": {"application/json"}, "Date": {captureTime.Format(http.TimeFormat)}},
+			Body:       io.NopCloser(strings.NewReader(`{"id":42,"status":"created","items":[{"item":"book","quantity":1}]}`)),
+		}
+
+		items = append(items, &tapApi.OutputChannelItem{
+			Protocol:  tapApi.Protocol{ProtocolSummary: tapApi.ProtocolSummary{Name: "http", Version: "1.1", Abbreviation: "HTTP"}},
+			Capture:   tapApi.Pcap,
+			Timestamp: captureTime.UnixNano() / int64(time.Millisecond),
+			ConnectionInfo: &tapApi.ConnectionInfo{
+				ClientIP:   "10.0.0.1",
+				ClientPort: "51234",
+				ServerIP:   "10.0.0.2",
+				ServerPort: "80",
+			},
+			Pair: &tapApi.RequestResponsePair{
+				Request: tapApi.GenericMessage{
+					IsRequest:   true,
+					CaptureTime: captureTime,
+					Payload:     httpExt.HTTPPayload{Type: httpExt.TypeHttpRequest, Data: request},
+				},
+				Response: tapApi.GenericMessage{
+					CaptureTime: captureTime.Add(time.Millisecond),
+					Payload:     httpExt.HTTPPayload{Type: httpExt.TypeHttpResponse, Data: response},
+				},
+			},
+		})
+	}
+
+	// The payloads must marshal, the benchmarks would measure the errors otherwise
+	if _, err := json.Marshal(items[0]); err != nil {
+		b.Fatal(err)
+	}
+	return items
+}
+
+func testTappedEntries() []*tapApi.OutputChannelItem {
+	captureTime := time.Date(2022, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	return []*tapApi.OutputChannelItem{
+		{
+			Protocol: tapApi.Protocol{
+				ProtocolSummary: tapApi.ProtocolSummary{Name: "http", Version: "1.1", Abbreviation: "HTTP"},
+				LongName:        "Hypertext Transfer Protocol -- HTTP/1.1",
+				Ports:           []string{"80", "8080"},
+				Priority:        0,
+			},
+			Capture:   tapApi.Pcap,
+			Timestamp: captureTime.UnixNano() / int64(time.Millisecond),
+			ConnectionInfo: &tapApi.ConnectionInfo{
+				ClientIP:   "10.0.0.1",
+				ClientPort: "51234",
+				ServerIP:   "10.0.0.2",
+				ServerPort: "80",
+				IsOutgoing: true,
+			},
+			Namespace: "default",
+			Pair: &tapApi.RequestResponsePair{
+				Request: tapApi.GenericMessage{
+					IsRequest:   true,
+					CaptureTime: captureTime,
+					CaptureSize: 42,
+					Payload: map[string]interface{}{"details": map[string]interface{}{
+						"method":  "GET",
+						"url":     "/",
+						"headers": []interface{}{map[string]interface{}{"name": "Host", "value": "example.com"}},
+						"cookies": []interface{}{},
+					}},
+				},
+				Response: tapApi.GenericMessage{
+					CaptureTime: captureTime.Add(time.Millisecond),
+					CaptureSize: 84,
+					Payload:     map[string]interface{}{"details": map[string]interface{}{"status": float64(200), "bodySize": float64(-1), "ok": true}},
+				},
+			},
+		},
+		{
+			Protocol: tapApi.Protocol{ProtocolSummary: tapApi.ProtocolSummary{Name: "redis", Version: "3.x", Abbreviation: "REDIS"}},
+			Capture:  tapApi.Pcap,
+			Pair: &tapApi.RequestResponsePair{
+				Request: tapApi.GenericMessage{
+					IsRequest:   true,
+					CaptureTime: captureTime,
+					Payload:     marshalingPayload{method: "GET", status: 200},
+				},
+				Response: tapApi.GenericMessage{
+					Payload: nil,
+				},
+			},
+		},
+		{
+			Protocol: tapApi.Protocol{ProtocolSummary: tapApi.ProtocolSummary{Name: "kafka", Version: "12", Abbreviation: "KAFKA"}},
+			Capture:  tapApi.Pcap,
+			Pair: &tapApi.RequestResponsePair{
+				Request: tapApi.GenericMessage{
+					IsRequest:   true,
+					CaptureTime: captureTime,
+					// The integers are float64 after a JSON round trip
+					Payload: map[string]interface{}{"details": map[string]interface{}{"apiKey": 3, "topics": []string{"orders"}}},
+				},
+			},
+		},
+	}
+}
+
+func jsonRoundTrip(t *testing.T, item *tapApi.OutputChannelItem) *tapApi.OutputChannelItem {
+	marshaled, err := json.Marshal(item)
+	if err != nil {
+		t.Fatalf("error marshaling the item: %v", err)
+	}
+
+	var unmarshaled tapApi.OutputChannelItem
+	if err := json.Unmarshal(marshaled, &unmarshaled); err != nil {
+		t.Fatalf("error unmarshaling the item: %v", err)
+	}
+
+	return &unmarshaled
+}
@@ -63,7 +63,9 @@ func (g *defaultOasGenerator) HandleEntry(mizuEntry *api.Entry) {
 		return
 	}
 
-	if mizuEntry.Protocol.Name == "http" {
+	// The WebSocket messages are dissected by the HTTP extension too, but they are not HAR entries.
+	// Neither are the requests that never got a response.
+	if mizuEntry.Protocol.Name == "http" && mizuEntry.Protocol.Abbreviation != "WS" && !api.IsUnanswered(mizuEntry.Response) {
 		dest := mizuEntry.Destination.Name
 		if dest == "" {
 			logger.Log.Debugf("OAS: Unresolved entry %d", mizuEntry.Id)
@@ -0,0 +1,10 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/up9inc/mizu/agent/pkg/controllers"
+)
+
+func MetricsRoutes(ginApp *gin.Engine) {
+	ginApp.GET("/metrics", controllers.GetMetrics)
+}
@@ -0,0 +1,166 @@
+package spool
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	segmentFileSuffix = ".seg"
+
+	// A record is the length of the data, the number of the items in it and the checksum of it, then the data
+	recordHeaderSize = 12
+)
+
+var errCorruptRecord = errors.New("corrupt spool record")
+
+type segment struct {
+	id   uint64
+	path string
+	size int64
+	// items is the number of the items that are spooled in the segment and weren't drained yet
+	items int
+	// readOffset is where the records that weren't drained yet start
+	readOffset int64
+}
+
+func segmentPath(dir string, id uint64) string {
+	return filepath.Join(dir, fmt.Sprintf("%020d%s", id, segmentFileSuffix))
+}
+
+func newRecord(data []byte, items int) []byte {
+	record := make([]byte, recordHeaderSize+len(data))
+	binary.LittleEndian.PutUint32(record[0:4], uint32(len(data)))
+	binary.LittleEndian.PutUint32(record[4:8], uint32(items))
+	binary.LittleEndian.PutUint32(record[8:12], crc32.ChecksumIEEE(data))
+	copy(record[recordHeaderSize:], data)
+	return record
+}
+
+// readRecord returns io.EOF at the end of the segment and errCorruptRecord for a partial or a damaged record.
+// The length in the header is checked against maxLength before the data is allocated, as the checksum is checked after.
+func readRecord(reader io.Reader, maxLength int64) (data []byte, items int, err error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err = io.ReadFull(reader, header); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = errCorruptRecord
+		}
+		return
+	}
+
+	length := int64(binary.LittleEndian.Uint32(header[0:4]))
+	if length > maxLength {
+		err = errCorruptRecord
+		return
+	}
+
+	data = make([]byte, length)
+	if _, err = io.ReadFull(reader, data); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = errCorruptRecord
+		}
+		return
+	}
+
+	if crc32.ChecksumIEEE(data) != binary.LittleEndian.Uint32(header[8:12]) {
+		err = errCorruptRecord
+		return
+	}
+
+	items = int(binary.LittleEndian.Uint32(header[4:8]))
+	return
+}
+
+// maxRecordLength is the longest data of a record at offset, it's within the rest of the file and the size cap of the spool
+func maxRecordLength(fileSize int64, offset int64, maxSize int64) int64 {
+	length := fileSize - offset
+	if maxSize < length {
+		length = maxSize
+	}
+	return length - recordHeaderSize
+}
+
+// loadSegments finds the segments a previous run left behind, oldest first.
+// A crash may leave a partial record at the end of a segment, the segment is truncated to its valid records.
+func loadSegments(dir string, maxSize int64) ([]*segment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := make([]*segment, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, segmentFileSuffix) {
+			continue
+		}
+
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentFileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		loaded, err := loadSegment(segmentPath(dir, id), id, maxSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if loaded.items == 0 {
+			if err := os.Remove(loaded.path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		segments = append(segments, loaded)
+	}
+
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].id < segments[j].id
+	})
+
+	return segments, nil
+}
+
+func loadSegment(path string, id uint64, maxSize int64) (*segment, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := &segment{id: id, path: path}
+	reader := bufio.NewReader(file)
+	for {
+		data, items, err := readRecord(reader, maxRecordLength(info.Size(), loaded.size, maxSize))
+		if err == io.EOF || err == errCorruptRecord {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		loaded.size += int64(recordHeaderSize + len(data))
+		loaded.items += items
+	}
+
+	if err := os.Truncate(path, loaded.size); err != nil {
+		return nil, err
+	}
+
+	return loaded, nil
+}
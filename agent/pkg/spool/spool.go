@@ -0,0 +1,267 @@
+package spool
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/up9inc/mizu/agent/pkg/models"
+	tapApi "github.com/up9inc/mizu/tap/api"
+)
+
+// The spool is split in segments, so dropping the oldest entries frees a fraction of it at a time
+const segmentsPerSpool = 8
+
+/* Spool buffers the tapped entries on the disk while the tapper is disconnected from the API server.
+ * It's a ring of segment files with a size cap, once it's full the oldest segment is dropped to make room.
+ * The entries are drained in the order they were spooled, including the ones a previous run left behind.
+ * Write and Drain are called by the goroutine that sends the entries, only Stats is safe for concurrent use.
+ */
+type Spool struct {
+	dir         string
+	maxSize     int64
+	segmentSize int64
+	segments    []*segment
+	writer      *os.File
+	nextId      uint64
+
+	spooledItems uint64
+	droppedItems uint64
+	drainedItems uint64
+	pendingItems int64
+	size         int64
+}
+
+type Stats struct {
+	SpooledItems uint64
+	DroppedItems uint64
+	DrainedItems uint64
+	PendingItems int64
+	SizeBytes    int64
+}
+
+// New opens the spool in dir, a max size of 0 disables the spool and every written entry is dropped
+func New(dir string, maxSize int64) (*Spool, error) {
+	spool := &Spool{
+		dir:         dir,
+		maxSize:     maxSize,
+		segmentSize: maxSize / segmentsPerSpool,
+	}
+
+	if maxSize <= 0 {
+		return spool, nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	segments, err := loadSegments(dir, maxSize)
+	if err != nil {
+		return nil, err
+	}
+
+	spool.segments = segments
+	for _, loaded := range segments {
+		spool.size += loaded.size
+		spool.pendingItems += int64(loaded.items)
+		spool.nextId = loaded.id + 1
+	}
+
+	return spool, nil
+}
+
+func (s *Spool) Write(items []*tapApi.OutputChannelItem) error {
+	if s.maxSize <= 0 {
+		atomic.AddUint64(&s.droppedItems, uint64(len(items)))
+		return nil
+	}
+
+	data, err := models.EncodeTappedEntriesBatch(items)
+	if err != nil {
+		atomic.AddUint64(&s.droppedItems, uint64(len(items)))
+		return err
+	}
+
+	record := newRecord(data, len(items))
+	recordSize := int64(len(record))
+	if recordSize > s.maxSize {
+		atomic.AddUint64(&s.droppedItems, uint64(len(items)))
+		return fmt.Errorf("a batch of %d bytes doesn't fit in a spool of %d bytes", recordSize, s.maxSize)
+	}
+
+	for atomic.LoadInt64(&s.size)+recordSize > s.maxSize {
+		if err := s.dropOldestSegment(); err != nil {
+			return err
+		}
+	}
+
+	if s.writer == nil || s.lastSegment().size+recordSize > s.segmentSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.writer.Write(record); err != nil {
+		atomic.AddUint64(&s.droppedItems, uint64(len(items)))
+		return err
+	}
+
+	current := s.lastSegment()
+	current.size += recordSize
+	current.items += len(items)
+	atomic.AddInt64(&s.size, recordSize)
+	atomic.AddInt64(&s.pendingItems, int64(len(items)))
+	atomic.AddUint64(&s.spooledItems, uint64(len(items)))
+
+	return nil
+}
+
+// Drain sends the spooled entries oldest first. When send fails, Drain stops and the entries are sent again on the next call.
+func (s *Spool) Drain(send func(items []*tapApi.OutputChannelItem) error) error {
+	for len(s.segments) > 0 {
+		oldest := s.segments[0]
+		if len(s.segments) == 1 && s.writer != nil {
+			// The written segment is closed, so the entries written after the drain go to a new segment
+			if err := s.closeWriter(); err != nil {
+				return err
+			}
+		}
+
+		if err := s.drainSegment(oldest, send); err != nil {
+			return err
+		}
+
+		// The items that are left are in a damaged record
+		if err := s.dropOldestSegment(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Spool) drainSegment(drained *segment, send func(items []*tapApi.OutputChannelItem) error) error {
+	file, err := os.Open(drained.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Seek(drained.readOffset, io.SeekStart); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+	for drained.items > 0 {
+		data, items, err := readRecord(reader, maxRecordLength(info.Size(), drained.readOffset, s.maxSize))
+		if err == io.EOF || err == errCorruptRecord {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if decoded, err := models.DecodeTappedEntriesBatch(data); err != nil {
+			atomic.AddUint64(&s.droppedItems, uint64(items))
+		} else if err := send(decoded); err != nil {
+			return err
+		} else {
+			atomic.AddUint64(&s.drainedItems, uint64(items))
+		}
+
+		drained.readOffset += int64(recordHeaderSize + len(data))
+		drained.items -= items
+		atomic.AddInt64(&s.pendingItems, -int64(items))
+	}
+
+	return nil
+}
+
+func (s *Spool) Len() int64 {
+	return atomic.LoadInt64(&s.pendingItems)
+}
+
+func (s *Spool) Stats() Stats {
+	return Stats{
+		SpooledItems: atomic.LoadUint64(&s.spooledItems),
+		DroppedItems: atomic.LoadUint64(&s.droppedItems),
+		DrainedItems: atomic.LoadUint64(&s.drainedItems),
+		PendingItems: atomic.LoadInt64(&s.pendingItems),
+		SizeBytes:    atomic.LoadInt64(&s.size),
+	}
+}
+
+func (s *Spool) Close() error {
+	return s.closeWriter()
+}
+
+func (s *Spool) lastSegment() *segment {
+	return s.segments[len(s.segments)-1]
+}
+
+func (s *Spool) rotate() error {
+	if err := s.closeWriter(); err != nil {
+		return err
+	}
+
+	created := &segment{id: s.nextId, path: segmentPath(s.dir, s.nextId)}
+	writer, err := os.OpenFile(created.path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+
+	s.nextId++
+	s.writer = writer
+	s.segments = append(s.segments, created)
+	return nil
+}
+
+func (s *Spool) closeWriter() error {
+	if s.writer == nil {
+		return nil
+	}
+
+	err := s.writer.Close()
+	s.writer = nil
+	return err
+}
+
+// dropOldestSegment removes the oldest segment, the items in it that weren't drained are dropped
+func (s *Spool) dropOldestSegment() error {
+	oldest := s.segments[0]
+	atomic.AddUint64(&s.droppedItems, uint64(oldest.items))
+	if len(s.segments) == 1 {
+		if err := s.closeWriter(); err != nil {
+			return err
+		}
+	}
+
+	s.segments = s.segments[1:]
+	atomic.AddInt64(&s.size, -oldest.size)
+	atomic.AddInt64(&s.pendingItems, -int64(oldest.items))
+
+	if err := os.Remove(oldest.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+func (s *Spool) Metrics() []*tapApi.Metric {
+	stats := s.Stats()
+	return []*tapApi.Metric{
+		tapApi.NewMetric("mizu_tapper_spooled_items_total", tapApi.CounterMetric, "Items spooled while the API server was unreachable").AddSample(float64(stats.SpooledItems), nil),
+		tapApi.NewMetric("mizu_tapper_spool_drained_items_total", tapApi.CounterMetric, "Spooled items sent after the reconnection").AddSample(float64(stats.DrainedItems), nil),
+		tapApi.NewMetric("mizu_tapper_spool_dropped_items_total", tapApi.CounterMetric, "Items dropped since the spool was full or disabled").AddSample(float64(stats.DroppedItems), nil),
+		tapApi.NewMetric("mizu_tapper_spool_pending_items", tapApi.GaugeMetric, "Spooled items waiting to be sent").AddSample(float64(stats.PendingItems), nil),
+		tapApi.NewMetric("mizu_tapper_spool_bytes", tapApi.GaugeMetric, "Size of the spool on the disk").AddSample(float64(stats.SizeBytes), nil),
+	}
+}
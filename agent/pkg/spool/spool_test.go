@@ -0,0 +1,279 @@
+package spool
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	tapApi "github.com/up9inc/mizu/tap/api"
+)
+
+func newItems(from int, count int) []*tapApi.OutputChannelItem {
+	items := make([]*tapApi.OutputChannelItem, 0, count)
+	for i := from; i < from+count; i++ {
+		items = append(items, &tapApi.OutputChannelItem{
+			Protocol:  tapApi.Protocol{ProtocolSummary: tapApi.ProtocolSummary{Name: "http"}},
+			Timestamp: int64(i),
+			Namespace: strconv.Itoa(i),
+		})
+	}
+	return items
+}
+
+func drainTimestamps(t *testing.T, spool *Spool) []int64 {
+	timestamps := make([]int64, 0)
+	err := spool.Drain(func(items []*tapApi.OutputChannelItem) error {
+		for _, item := range items {
+			timestamps = append(timestamps, item.Timestamp)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error draining the spool: %v", err)
+	}
+	return timestamps
+}
+
+func TestSpoolDrainsInOrder(t *testing.T) {
+	spool, err := New(t.TempDir(), 1024*1024)
+	if err != nil {
+		t.Fatalf("error creating the spool: %v", err)
+	}
+	defer spool.Close()
+
+	for i := 0; i < 10; i++ {
+		if err := spool.Write(newItems(i*3, 3)); err != nil {
+			t.Fatalf("error writing to the spool: %v", err)
+		}
+	}
+
+	timestamps := drainTimestamps(t, spool)
+	if len(timestamps) != 30 {
+		t.Fatalf("expected 30 items, got %d", len(timestamps))
+	}
+	for i, timestamp := range timestamps {
+		if timestamp != int64(i) {
+			t.Fatalf("expected item %d at position %d, got %d", i, i, timestamp)
+		}
+	}
+
+	stats := spool.Stats()
+	if stats.SpooledItems != 30 || stats.DrainedItems != 30 || stats.DroppedItems != 0 || stats.PendingItems != 0 || stats.SizeBytes != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestSpoolDropsOldestSegmentsWhenFull(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := New(dir, 4096)
+	if err != nil {
+		t.Fatalf("error creating the spool: %v", err)
+	}
+	defer spool.Close()
+
+	for i := 0; i < 200; i++ {
+		if err := spool.Write(newItems(i, 1)); err != nil {
+			t.Fatalf("error writing to the spool: %v", err)
+		}
+		if spool.Stats().SizeBytes > 4096 {
+			t.Fatalf("the spool exceeded its size cap, %d bytes", spool.Stats().SizeBytes)
+		}
+	}
+
+	stats := spool.Stats()
+	if stats.DroppedItems == 0 {
+		t.Fatalf("expected dropped items, got %+v", stats)
+	}
+	if stats.SpooledItems != stats.DroppedItems+uint64(stats.PendingItems) {
+		t.Errorf("spooled items aren't either dropped or pending %+v", stats)
+	}
+
+	timestamps := drainTimestamps(t, spool)
+	if int64(len(timestamps)) != stats.PendingItems {
+		t.Fatalf("expected %d items, got %d", stats.PendingItems, len(timestamps))
+	}
+	if timestamps[len(timestamps)-1] != 199 {
+		t.Errorf("expected the newest item to be kept, got %d", timestamps[len(timestamps)-1])
+	}
+	for i := 1; i < len(timestamps); i++ {
+		if timestamps[i] != timestamps[i-1]+1 {
+			t.Fatalf("expected consecutive items, got %d after %d", timestamps[i], timestamps[i-1])
+		}
+	}
+}
+
+func TestSpoolResumesAfterFailedSend(t *testing.T) {
+	spool, err := New(t.TempDir(), 1024*1024)
+	if err != nil {
+		t.Fatalf("error creating the spool: %v", err)
+	}
+	defer spool.Close()
+
+	for i := 0; i < 5; i++ {
+		if err := spool.Write(newItems(i, 1)); err != nil {
+			t.Fatalf("error writing to the spool: %v", err)
+		}
+	}
+
+	sent := 0
+	err = spool.Drain(func(items []*tapApi.OutputChannelItem) error {
+		if sent == 2 {
+			return errors.New("disconnected")
+		}
+		sent++
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected the send error to be returned")
+	}
+
+	if err := spool.Write(newItems(5, 1)); err != nil {
+		t.Fatalf("error writing to the spool: %v", err)
+	}
+
+	timestamps := drainTimestamps(t, spool)
+	expected := []int64{2, 3, 4, 5}
+	if len(timestamps) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, timestamps)
+	}
+	for i := range expected {
+		if timestamps[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, timestamps)
+		}
+	}
+}
+
+func TestSpoolRecoversSegmentsOfPreviousRun(t *testing.T) {
+	dir := t.TempDir()
+	spool, err := New(dir, 1024*1024)
+	if err != nil {
+		t.Fatalf("error creating the spool: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := spool.Write(newItems(i, 1)); err != nil {
+			t.Fatalf("error writing to the spool: %v", err)
+		}
+	}
+	if err := spool.Close(); err != nil {
+		t.Fatalf("error closing the spool: %v", err)
+	}
+
+	// A crash in the middle of a write leaves a partial record behind
+	file, err := os.OpenFile(filepath.Join(dir, "00000000000000000000.seg"), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatalf("error opening the segment: %v", err)
+	}
+	if _, err := file.Write([]byte{0xff, 0x00, 0x00}); err != nil {
+		t.Fatalf("error writing to the segment: %v", err)
+	}
+	file.Close()
+
+	recovered, err := New(dir, 1024*1024)
+	if err != nil {
+		t.Fatalf("error reopening the spool: %v", err)
+	}
+	defer recovered.Close()
+
+	if recovered.Len() != 3 {
+		t.Fatalf("expected 3 pending items, got %d", recovered.Len())
+	}
+
+	if err := recovered.Write(newItems(3, 1)); err != nil {
+		t.Fatalf("error writing to the spool: %v", err)
+	}
+
+	timestamps := drainTimestamps(t, recovered)
+	if len(timestamps) != 4 || timestamps[0] != 0 || timestamps[3] != 3 {
+		t.Errorf("expected [0 1 2 3], got %v", timestamps)
+	}
+}
+
+func TestSpoolTruncatesCorruptedSegments(t *testing.T) {
+	record := newRecord([]byte("spooled entries"), 1)
+	hugeLength := append([]byte{}, record...)
+	binary.LittleEndian.PutUint32(hugeLength[0:4], 0xffffffff)
+	pastTheEnd := append([]byte{}, record...)
+	binary.LittleEndian.PutUint32(pastTheEnd[0:4], uint32(len(record)))
+	damaged := append([]byte{}, record...)
+	damaged[len(damaged)-1] ^= 0xff
+
+	tests := []struct {
+		name    string
+		trailer []byte
+	}{
+		{"partial header", record[:recordHeaderSize-1]},
+		{"partial data", record[:len(record)-1]},
+		{"length past the size cap", hugeLength},
+		{"length past the end of the file", pastTheEnd},
+		{"bad checksum", damaged},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			spool, err := New(dir, 1024*1024)
+			if err != nil {
+				t.Fatalf("error creating the spool: %v", err)
+			}
+			for i := 0; i < 2; i++ {
+				if err := spool.Write(newItems(i, 1)); err != nil {
+					t.Fatalf("error writing to the spool: %v", err)
+				}
+			}
+			if err := spool.Close(); err != nil {
+				t.Fatalf("error closing the spool: %v", err)
+			}
+
+			path := filepath.Join(dir, "00000000000000000000.seg")
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("error reading the segment: %v", err)
+			}
+			file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+			if err != nil {
+				t.Fatalf("error opening the segment: %v", err)
+			}
+			if _, err := file.Write(test.trailer); err != nil {
+				t.Fatalf("error writing to the segment: %v", err)
+			}
+			file.Close()
+
+			recovered, err := New(dir, 1024*1024)
+			if err != nil {
+				t.Fatalf("error reopening the spool: %v", err)
+			}
+			defer recovered.Close()
+
+			if recovered.Len() != 2 {
+				t.Errorf("expected 2 pending items, got %d", recovered.Len())
+			}
+			if truncated, err := os.Stat(path); err != nil || truncated.Size() != info.Size() {
+				t.Errorf("expected the segment to be truncated to %d bytes, got %v (%v)", info.Size(), truncated.Size(), err)
+			}
+
+			timestamps := drainTimestamps(t, recovered)
+			if len(timestamps) != 2 || timestamps[0] != 0 || timestamps[1] != 1 {
+				t.Errorf("expected [0 1], got %v", timestamps)
+			}
+		})
+	}
+}
+
+func TestDisabledSpoolDropsItems(t *testing.T) {
+	spool, err := New("", 0)
+	if err != nil {
+		t.Fatalf("error creating the spool: %v", err)
+	}
+
+	if err := spool.Write(newItems(0, 3)); err != nil {
+		t.Fatalf("error writing to the spool: %v", err)
+	}
+
+	if stats := spool.Stats(); stats.DroppedItems != 3 || stats.PendingItems != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
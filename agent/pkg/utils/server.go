@@ -0,0 +1,49 @@
+//go:build !windows
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/up9inc/mizu/logger"
+	"github.com/up9inc/mizu/shared"
+)
+
+// StartServer starts the server with a graceful shutdown
+func StartServer(app *gin.Engine) {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals,
+		os.Interrupt,    // this catch ctrl + c
+		syscall.SIGTSTP, // this catch ctrl + z
+	)
+
+	srv := &http.Server{
+		Addr:    ":8080",
+		Handler: app,
+	}
+
+	go func() {
+		<-signals
+		logger.Log.Infof("Shutting down...")
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		err := srv.Shutdown(ctx)
+		if err != nil {
+			logger.Log.Errorf("%v", err)
+		}
+		os.Exit(0)
+	}()
+
+	// Run server.
+	logger.Log.Infof("Starting the server...")
+	if err := app.Run(fmt.Sprintf(":%d", shared.DefaultApiServerPort)); err != nil {
+		logger.Log.Errorf("Server is not running! Reason: %v", err)
+	}
+}
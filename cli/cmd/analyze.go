@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/creasty/defaults"
+	"github.com/spf13/cobra"
+	"github.com/up9inc/mizu/cli/config"
+	"github.com/up9inc/mizu/cli/config/configStructs"
+	"github.com/up9inc/mizu/logger"
+)
+
+var analyzeCmd = &cobra.Command{
+	Use:   "analyze [PCAP FILE]",
+	Short: "Analyze a pcap file locally, without Kubernetes",
+	Long: `Analyze a pcap file locally, without Kubernetes.
+With --output-dir the pipeline of the agent runs on the file in the CLI process, and the entries,
+the OAS specs and the service map are written to the directory. Otherwise the UI is opened,
+it's served by the agent image with Docker, which ships the UI and the Basenine database.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		runMizuAnalyze()
+		return nil
+	},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return errors.New("the pcap file to analyze must be provided")
+		}
+
+		config.Config.Analyze.PcapFile = args[0]
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(analyzeCmd)
+
+	defaultAnalyzeConfig := configStructs.AnalyzeConfig{}
+	if err := defaults.Set(&defaultAnalyzeConfig); err != nil {
+		logger.Log.Debug(err)
+	}
+
+	analyzeCmd.Flags().String(configStructs.OutputDirAnalyzeName, defaultAnalyzeConfig.OutputDir, "Write the entries, the OAS specs and the service map to this directory instead of opening the UI")
+	analyzeCmd.Flags().Uint16P(configStructs.GuiPortAnalyzeName, "p", defaultAnalyzeConfig.GuiPort, "Provide a custom port for the web interface webserver")
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+
+	"github.com/up9inc/mizu/agent/pkg/analyze"
+	"github.com/up9inc/mizu/agent/pkg/app"
+	"github.com/up9inc/mizu/cli/apiserver"
+	"github.com/up9inc/mizu/cli/config"
+	"github.com/up9inc/mizu/cli/errormessage"
+	"github.com/up9inc/mizu/cli/mizu"
+	"github.com/up9inc/mizu/cli/uiUtils"
+	"github.com/up9inc/mizu/cli/utils"
+	"github.com/up9inc/mizu/logger"
+	"github.com/up9inc/mizu/shared"
+	"github.com/up9inc/mizu/shared/kubernetes"
+	tapApi "github.com/up9inc/mizu/tap/api"
+)
+
+// The path the pcap file is mounted at in the agent container
+const analyzePcapMountPath = "/app/analyze/capture.pcap"
+
+func runMizuAnalyze() {
+	pcapFile, err := filepath.Abs(config.Config.Analyze.PcapFile)
+	if err == nil {
+		_, err = os.Stat(pcapFile)
+	}
+	if err != nil {
+		logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Couldn't read the pcap file %s: %v", config.Config.Analyze.PcapFile, errormessage.FormatError(err)))
+		return
+	}
+
+	if config.Config.Analyze.OutputDir != "" {
+		analyzeToDir(pcapFile)
+		return
+	}
+
+	// The UI is served from the agent image, where its static files and Basenine, which its queries run on, are installed
+	if _, err := exec.LookPath("docker"); err != nil {
+		logger.Log.Errorf(uiUtils.Error, "Docker is required to open the UI of a pcap file, make sure it's installed and in the PATH, or use --output-dir")
+		return
+	}
+
+	logFilePath := path.Join(mizu.GetMizuFolderPath(), "mizu_analyze.log")
+	logFile, err := os.Create(logFilePath)
+	if err != nil {
+		logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Error creating the log file %s: %v", logFilePath, errormessage.FormatError(err)))
+		return
+	}
+	defer logFile.Close()
+
+	containerName := fmt.Sprintf("%sanalyze-%d", kubernetes.MizuResourcesPrefix, os.Getpid())
+	dockerArgs := []string{
+		"run", "--rm", "--name", containerName,
+		"-v", fmt.Sprintf("%s:%s:ro", pcapFile, analyzePcapMountPath),
+		"-p", fmt.Sprintf("%d:%d", config.Config.Analyze.GuiPort, shared.DefaultApiServerPort),
+	}
+	agentArgs := []string{"--analyze", "-r", analyzePcapMountPath}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		if err := runAnalyzeContainer(dockerArgs, agentArgs, logFile); err != nil {
+			logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Error analyzing the pcap file, for more info check logs at %s: %v", logFilePath, errormessage.FormatError(err)))
+		}
+		cancel()
+	}()
+	defer stopAnalyzeContainer(containerName)
+
+	url := fmt.Sprintf("http://localhost:%d", config.Config.Analyze.GuiPort)
+	apiServerProvider := apiserver.NewProvider(url, apiserver.DefaultRetries, apiserver.DefaultTimeout)
+	if err := apiServerProvider.TestConnection(); err != nil {
+		logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Couldn't connect to the agent, for more info check logs at %s", logFilePath))
+		return
+	}
+
+	logger.Log.Infof("Mizu is available at %s", url)
+	if !config.Config.HeadlessMode {
+		uiUtils.OpenBrowser(url)
+	}
+
+	utils.WaitForFinish(ctx, cancel)
+}
+
+// analyzeToDir runs the pipeline of the agent on the pcap file in the CLI process and writes its results to the output directory
+func analyzeToDir(pcapFile string) {
+	outputDir, err := filepath.Abs(config.Config.Analyze.OutputDir)
+	if err != nil {
+		logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Error resolving the output directory %s: %v", config.Config.Analyze.OutputDir, errormessage.FormatError(err)))
+		return
+	}
+
+	logger.Log.Infof("Analyzing %s, it may take a while...", config.Config.Analyze.PcapFile)
+	app.LoadExtensions("")
+	count, err := analyze.RunToDir(pcapFile, outputDir, &tapApi.TrafficFilteringOptions{IgnoredUserAgents: []string{}})
+	if err != nil {
+		logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Error analyzing the pcap file: %v", errormessage.FormatError(err)))
+		return
+	}
+
+	logger.Log.Infof("Analyzed %d entries, you can find them, the OAS specs and the service map in %s", count, outputDir)
+}
+
+func runAnalyzeContainer(dockerArgs []string, agentArgs []string, logFile *os.File) error {
+	args := append(append(dockerArgs, config.Config.AgentImage), agentArgs...)
+	logger.Log.Debugf("Running docker %v", args)
+
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	return cmd.Run()
+}
+
+func stopAnalyzeContainer(containerName string) {
+	if err := exec.Command("docker", "stop", containerName).Run(); err != nil {
+		logger.Log.Debugf("Error stopping the container %s: %v", containerName, err)
+	}
+}
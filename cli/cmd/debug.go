@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/creasty/defaults"
+	"github.com/spf13/cobra"
+	"github.com/up9inc/mizu/cli/config"
+	"github.com/up9inc/mizu/cli/config/configStructs"
+	"github.com/up9inc/mizu/cli/errormessage"
+	"github.com/up9inc/mizu/logger"
+)
+
+const (
+	statusDebugAction  = "status"
+	enableDebugAction  = "enable"
+	disableDebugAction = "disable"
+	profileDebugAction = "profile"
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug status | enable [STAGE] | disable [STAGE] | profile",
+	Short: "Inspect and toggle the stages of the running tappers",
+	Long: `Inspect and toggle the stages of the running tappers.
+status prints the switches of the stages with their counters,
+enable and disable turn a stage on and off at runtime,
+profile saves a CPU or heap profile of each tapper.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var stage string
+		if len(args) == 2 {
+			stage = args[1]
+		}
+
+		runMizuDebug(args[0], stage)
+		return nil
+	},
+	PreRunE: func(cmd *cobra.Command, args []string) error {
+		if len(args) == 0 {
+			return errors.New("missing the debug action: status, enable, disable or profile")
+		}
+
+		switch args[0] {
+		case statusDebugAction, profileDebugAction:
+			if len(args) != 1 {
+				return errors.New("unexpected number of arguments")
+			}
+		case enableDebugAction, disableDebugAction:
+			if len(args) != 2 {
+				return errors.New("the stage to enable or disable must be provided")
+			}
+		default:
+			return errors.New("unknown debug action, expected status, enable, disable or profile")
+		}
+
+		if err := config.Config.Debug.Validate(); err != nil {
+			return errormessage.FormatError(err)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(debugCmd)
+
+	defaultDebugConfig := configStructs.DebugConfig{}
+	if err := defaults.Set(&defaultDebugConfig); err != nil {
+		logger.Log.Debug(err)
+	}
+
+	debugCmd.Flags().String(configStructs.NodeDebugName, defaultDebugConfig.Node, "Only the tapper of this node (default all the tappers)")
+	debugCmd.Flags().String(configStructs.ProfileTypeDebugName, defaultDebugConfig.ProfileType, "The profile to capture: cpu or heap")
+	debugCmd.Flags().Int(configStructs.ProfileSecondsDebugName, defaultDebugConfig.ProfileSeconds, "How long to profile the CPU for")
+	debugCmd.Flags().String(configStructs.OutputDirDebugName, defaultDebugConfig.OutputDir, "Directory to save the profiles in")
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+	"time"
+
+	core "k8s.io/api/core/v1"
+
+	"github.com/up9inc/mizu/cli/config"
+	"github.com/up9inc/mizu/cli/errormessage"
+	"github.com/up9inc/mizu/cli/uiUtils"
+	"github.com/up9inc/mizu/logger"
+	"github.com/up9inc/mizu/shared"
+	"github.com/up9inc/mizu/shared/kubernetes"
+	"github.com/up9inc/mizu/tap/dbgctl"
+)
+
+// On top of the profiling time, for the proxy and the transfer of the profile
+const debugRequestTimeout = time.Minute
+
+func runMizuDebug(action string, stage string) {
+	kubernetesProvider, err := getKubernetesProviderForCli()
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	token, err := kubernetesProvider.GetSecretValue(ctx, config.Config.MizuResourcesNamespace, kubernetes.DebugControlSecretName, kubernetes.DebugControlTokenSecretKey)
+	if err != nil {
+		logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Couldn't find the debug control token of the tappers, you should run `mizu tap` command first: %v", errormessage.FormatError(err)))
+		return
+	}
+
+	tappers, err := listRunningTappers(ctx, kubernetesProvider, config.Config.Debug.Node)
+	if err != nil {
+		logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Error listing the tappers: %v", errormessage.FormatError(err)))
+		return
+	}
+	if len(tappers) == 0 {
+		logger.Log.Infof("No running tappers found, you should run `mizu tap` command first")
+		return
+	}
+
+	for _, tapper := range tappers {
+		switch action {
+		case statusDebugAction:
+			err = printTapperSwitches(ctx, kubernetesProvider, token, tapper, http.MethodGet, nil)
+		case enableDebugAction, disableDebugAction:
+			err = setTapperSwitch(ctx, kubernetesProvider, token, tapper, stage, action == disableDebugAction)
+		case profileDebugAction:
+			err = saveTapperProfile(ctx, kubernetesProvider, token, tapper)
+		}
+
+		if err != nil {
+			logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Error debugging the tapper %s on node %s: %v", tapper.Name, tapper.Spec.NodeName, errormessage.FormatError(err)))
+		}
+	}
+}
+
+// listRunningTappers lists the running tappers, only the one of the node when it's set
+func listRunningTappers(ctx context.Context, kubernetesProvider *kubernetes.Provider, node string) ([]core.Pod, error) {
+	pods, err := kubernetesProvider.ListPodsByAppLabel(ctx, config.Config.MizuResourcesNamespace, kubernetes.TapperPodName)
+	if err != nil {
+		return nil, err
+	}
+
+	var tappers []core.Pod
+	for _, pod := range pods {
+		if pod.Status.Phase != core.PodRunning {
+			continue
+		}
+		if node != "" && pod.Spec.NodeName != node {
+			continue
+		}
+		tappers = append(tappers, pod)
+	}
+
+	return tappers, nil
+}
+
+func requestTapperDebugControl(ctx context.Context, kubernetesProvider *kubernetes.Provider, token string, tapper core.Pod, method string, path string, params map[string]string, body []byte, timeout time.Duration) ([]byte, error) {
+	requestCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	headers := map[string]string{dbgctl.TokenHeader: token}
+	if body != nil {
+		headers["Content-Type"] = "application/json"
+	}
+
+	return kubernetesProvider.ProxyPodRequest(requestCtx, config.Config.MizuResourcesNamespace, tapper.Name, shared.TapperDebugControlPort, method, path, headers, params, body)
+}
+
+func printTapperSwitches(ctx context.Context, kubernetesProvider *kubernetes.Provider, token string, tapper core.Pod, method string, body []byte) error {
+	response, err := requestTapperDebugControl(ctx, kubernetesProvider, token, tapper, method, dbgctl.SwitchesPath, nil, body, debugRequestTimeout)
+	if err != nil {
+		return err
+	}
+
+	var statuses []*dbgctl.SwitchStatus
+	if err := json.Unmarshal(response, &statuses); err != nil {
+		return fmt.Errorf("invalid switches response, %w", err)
+	}
+
+	logger.Log.Infof("Tapper %s on node %s:", tapper.Name, tapper.Spec.NodeName)
+	for _, status := range statuses {
+		state := fmt.Sprintf(uiUtils.Green, "enabled")
+		if status.Disabled {
+			state = fmt.Sprintf(uiUtils.Red, "disabled")
+		}
+		logger.Log.Infof("  %-20s %s (passed: %d, skipped: %d)", status.Stage, state, status.Passed, status.Skipped)
+	}
+
+	return nil
+}
+
+func setTapperSwitch(ctx context.Context, kubernetesProvider *kubernetes.Provider, token string, tapper core.Pod, stage string, disabled bool) error {
+	body, err := json.Marshal(&dbgctl.SwitchRequest{Stage: stage, Disabled: disabled})
+	if err != nil {
+		return err
+	}
+
+	if s := dbgctl.GetSwitch(stage); s != nil && s.Status().StartupOnly {
+		logger.Log.Warningf(uiUtils.Warning, fmt.Sprintf("The %s stage is read at startup, the change takes effect once the tapper %s restarts", stage, tapper.Name))
+	}
+
+	return printTapperSwitches(ctx, kubernetesProvider, token, tapper, http.MethodPost, body)
+}
+
+func saveTapperProfile(ctx context.Context, kubernetesProvider *kubernetes.Provider, token string, tapper core.Pod) error {
+	params := map[string]string{
+		"type":    config.Config.Debug.ProfileType,
+		"seconds": strconv.Itoa(config.Config.Debug.ProfileSeconds),
+	}
+
+	timeout := debugRequestTimeout
+	if config.Config.Debug.ProfileType == "cpu" {
+		timeout += time.Duration(config.Config.Debug.ProfileSeconds) * time.Second
+		logger.Log.Infof("Profiling the CPU of the tapper %s on node %s for %d seconds...", tapper.Name, tapper.Spec.NodeName, config.Config.Debug.ProfileSeconds)
+	}
+
+	profile, err := requestTapperDebugControl(ctx, kubernetesProvider, token, tapper, http.MethodGet, dbgctl.ProfilePath, params, nil, timeout)
+	if err != nil {
+		return err
+	}
+
+	filePath := path.Join(config.Config.Debug.OutputDir, fmt.Sprintf("%s.%s.pprof", tapper.Name, config.Config.Debug.ProfileType))
+	if err := ioutil.WriteFile(filePath, profile, 0644); err != nil {
+		return err
+	}
+
+	logger.Log.Infof("Saved the %s profile of the tapper %s to %s", config.Config.Debug.ProfileType, tapper.Name, filePath)
+	return nil
+}
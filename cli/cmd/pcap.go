@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"github.com/creasty/defaults"
+	"github.com/spf13/cobra"
+	"github.com/up9inc/mizu/cli/config"
+	"github.com/up9inc/mizu/cli/config/configStructs"
+	"github.com/up9inc/mizu/cli/errormessage"
+	"github.com/up9inc/mizu/logger"
+)
+
+var pcapCmd = &cobra.Command{
+	Use:   "pcap",
+	Short: "Create a zip file with the pcap files of the tappers",
+	Long: `Create a zip file with the pcap files of the tappers.
+The tappers write the pcap files when mizu tap runs with --pcap.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if validationErr := config.Config.Pcap.Validate(); validationErr != nil {
+			return errormessage.FormatError(validationErr)
+		}
+
+		runMizuPcap()
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pcapCmd)
+
+	defaultPcapConfig := configStructs.PcapConfig{}
+	if err := defaults.Set(&defaultPcapConfig); err != nil {
+		logger.Log.Debug(err)
+	}
+
+	pcapCmd.Flags().StringP(configStructs.FilePcapName, "f", defaultPcapConfig.FileStr, "Path for zip file (default current <pwd>\\mizu_pcap.zip)")
+	pcapCmd.Flags().String(configStructs.NodePcapName, defaultPcapConfig.Node, "Only the tapper of this node (default all the tappers)")
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	core "k8s.io/api/core/v1"
+
+	"github.com/up9inc/mizu/cli/config"
+	"github.com/up9inc/mizu/cli/errormessage"
+	"github.com/up9inc/mizu/cli/uiUtils"
+	"github.com/up9inc/mizu/logger"
+	"github.com/up9inc/mizu/shared"
+	"github.com/up9inc/mizu/shared/kubernetes"
+	"github.com/up9inc/mizu/tap/dbgctl"
+)
+
+// pcapMinDownloadRate is the rate in bytes per second the download timeout of a pcap file is scaled by
+const pcapMinDownloadRate = 256 * 1024
+
+var errPcapFileNotFetched = errors.New("the pcap file wasn't fetched")
+
+func runMizuPcap() {
+	kubernetesProvider, err := getKubernetesProviderForCli()
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	token, err := kubernetesProvider.GetSecretValue(ctx, config.Config.MizuResourcesNamespace, kubernetes.DebugControlSecretName, kubernetes.DebugControlTokenSecretKey)
+	if err != nil {
+		logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Couldn't find the tappers, you should run `mizu tap --pcap` command first: %v", errormessage.FormatError(err)))
+		return
+	}
+
+	tappers, err := listRunningTappers(ctx, kubernetesProvider, config.Config.Pcap.Node)
+	if err != nil {
+		logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Error listing the tappers: %v", errormessage.FormatError(err)))
+		return
+	}
+	if len(tappers) == 0 {
+		logger.Log.Infof("No running tappers found, you should run `mizu tap --pcap` command first")
+		return
+	}
+
+	filePath := config.Config.Pcap.FilePath()
+	zipFile, err := os.Create(filePath)
+	if err != nil {
+		logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Error creating %s: %v", filePath, errormessage.FormatError(err)))
+		return
+	}
+	defer zipFile.Close()
+	zipWriter := zip.NewWriter(zipFile)
+	defer zipWriter.Close()
+
+	files := 0
+	for _, tapper := range tappers {
+		tapperFiles, err := addTapperPcapsToZip(ctx, kubernetesProvider, token, tapper, zipWriter)
+		if err != nil {
+			logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Error fetching the pcap files of the tapper %s on node %s: %v", tapper.Name, tapper.Spec.NodeName, errormessage.FormatError(err)))
+		}
+		files += tapperFiles
+	}
+
+	if files == 0 {
+		logger.Log.Infof("The tappers have no pcap files, make sure you ran `mizu tap --pcap`")
+		return
+	}
+
+	logger.Log.Infof("You can find the zip file with %d pcap files in %s", files, filePath)
+}
+
+// addTapperPcapsToZip adds the pcap files of a tapper under a directory of its node, returns the number of the files it added
+func addTapperPcapsToZip(ctx context.Context, kubernetesProvider *kubernetes.Provider, token string, tapper core.Pod, zipWriter *zip.Writer) (int, error) {
+	response, err := requestTapperDebugControl(ctx, kubernetesProvider, token, tapper, http.MethodGet, dbgctl.PcapsPath, nil, nil, debugRequestTimeout)
+	if err != nil {
+		return 0, err
+	}
+
+	var pcapFiles []*dbgctl.PcapFile
+	if err := json.Unmarshal(response, &pcapFiles); err != nil {
+		return 0, fmt.Errorf("invalid pcap files response, %w", err)
+	}
+
+	added := 0
+	for _, pcapFile := range pcapFiles {
+		logger.Log.Debugf("Fetching the pcap file %s of size %d from the tapper %s", pcapFile.Name, pcapFile.Size, tapper.Name)
+
+		if err := addTapperPcapToZip(ctx, kubernetesProvider, token, tapper, pcapFile, zipWriter); err != nil {
+			if errors.Is(err, errPcapFileNotFetched) {
+				// The oldest files are removed by the tapper while it rotates them
+				logger.Log.Warningf("Skipping the pcap file %s of the tapper %s, %v", pcapFile.Name, tapper.Name, err)
+				continue
+			}
+			return added, err
+		}
+		added++
+	}
+
+	return added, nil
+}
+
+// addTapperPcapToZip streams a pcap file of a tapper into the zip, so the file isn't loaded to memory
+func addTapperPcapToZip(ctx context.Context, kubernetesProvider *kubernetes.Provider, token string, tapper core.Pod, pcapFile *dbgctl.PcapFile, zipWriter *zip.Writer) error {
+	requestCtx, cancel := context.WithTimeout(ctx, pcapDownloadTimeout(pcapFile.Size))
+	defer cancel()
+
+	headers := map[string]string{dbgctl.TokenHeader: token}
+	stream, err := kubernetesProvider.StreamProxyPodRequest(requestCtx, config.Config.MizuResourcesNamespace, tapper.Name, shared.TapperDebugControlPort, http.MethodGet, path.Join(dbgctl.PcapsPath, pcapFile.Name), headers, nil)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errPcapFileNotFetched, err)
+	}
+	defer stream.Close()
+
+	writer, err := zipWriter.Create(path.Join(tapper.Spec.NodeName, pcapFile.Name))
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, stream); err != nil {
+		return fmt.Errorf("error fetching the pcap file %s, it's truncated in the zip, %w", pcapFile.Name, err)
+	}
+
+	return nil
+}
+
+// pcapDownloadTimeout gives the slow connections the time to download a file at pcapMinDownloadRate, the current file
+// may grow while it's downloaded, so debugRequestTimeout is added on top
+func pcapDownloadTimeout(size int64) time.Duration {
+	return debugRequestTimeout + time.Duration(size/pcapMinDownloadRate)*time.Second
+}
@@ -56,4 +56,14 @@ func init() {
 	tapCmd.Flags().Bool(configStructs.TlsName, defaultTapConfig.Tls, "Record tls traffic")
 	tapCmd.Flags().Bool(configStructs.ProfilerName, defaultTapConfig.Profiler, "Run pprof server")
 	tapCmd.Flags().Int(configStructs.MaxLiveStreamsName, defaultTapConfig.MaxLiveStreams, "Maximum live tcp streams to handle concurrently")
+	tapCmd.Flags().Int(configStructs.EmitQueueSizeName, defaultTapConfig.EmitQueueSize, "Maximum items waiting in a tapper to be sent to the API server")
+	tapCmd.Flags().String(configStructs.EmitPolicyName, defaultTapConfig.EmitPolicy, "What the tappers do with the items while the emit queue is full: block, drop-newest or drop-oldest")
+	tapCmd.Flags().Int(configStructs.EmitSampleRateName, defaultTapConfig.EmitSampleRate, "With the drop-oldest policy, queue only one in every N items while the emit queue is full")
+	tapCmd.Flags().String(configStructs.HumanMaxSpoolSizeName, defaultTapConfig.HumanMaxSpoolSize, "Max size of the tappers' on-disk spool for the traffic tapped while the API server is unreachable (0 disables it)")
+	tapCmd.Flags().Bool(configStructs.PcapName, defaultTapConfig.Pcap, "Write the traffic of the tapped pods to pcap files on the tappers, to fetch with `mizu pcap`")
+	tapCmd.Flags().String(configStructs.HumanMaxPcapSizeName, defaultTapConfig.HumanMaxPcapSize, "Max size of the pcap files of each tapper, the oldest traffic is removed once the limit is reached")
+	tapCmd.Flags().String(configStructs.ExtensionsHostPathName, defaultTapConfig.ExtensionsHostPath, "Directory of the dissector plugins (.so) on the nodes, mounted in the tappers and the API server (default no plugins)")
+	tapCmd.Flags().String(configStructs.CaptureBackendName, defaultTapConfig.CaptureBackend, "How the tappers capture the traffic: pcap (libpcap) or af_packet (AF_PACKET ring buffers with fanout, for busy nodes)")
+	tapCmd.Flags().Bool(configStructs.TcpRawName, defaultTapConfig.TcpRaw, "Record the tcp streams that no protocol was identified in, as raw payloads")
+	tapCmd.Flags().Bool(configStructs.TcpConnectionsName, defaultTapConfig.TcpConnections, "Record the tcp connections with their handshake, byte counts and termination")
 }
@@ -2,6 +2,8 @@ package cmd
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"regexp"
@@ -33,6 +35,7 @@ type tapState struct {
 	startTime                time.Time
 	targetNamespaces         []string
 	mizuServiceAccountExists bool
+	debugControlToken        string
 }
 
 var state tapState
@@ -53,6 +56,11 @@ func RunMizuTap() {
 
 	state.targetNamespaces = getNamespaces(kubernetesProvider)
 
+	if state.debugControlToken, err = generateDebugControlToken(); err != nil {
+		logger.Log.Errorf(uiUtils.Error, fmt.Sprintf("Error generating the debug control token: %v", errormessage.FormatError(err)))
+		return
+	}
+
 	mizuAgentConfig := getTapMizuAgentConfig()
 	serializedMizuConfig, err := getSerializedMizuAgentConfig(mizuAgentConfig)
 	if err != nil {
@@ -86,7 +94,7 @@ func RunMizuTap() {
 	}
 
 	logger.Log.Infof("Waiting for Mizu Agent to start...")
-	if state.mizuServiceAccountExists, err = resources.CreateTapMizuResources(ctx, kubernetesProvider, serializedMizuConfig, config.Config.IsNsRestrictedMode(), config.Config.MizuResourcesNamespace, config.Config.AgentImage, config.Config.Tap.MaxEntriesDBSizeBytes(), config.Config.Tap.ApiServerResources, config.Config.ImagePullPolicy(), config.Config.LogLevel(), config.Config.Tap.Profiler); err != nil {
+	if state.mizuServiceAccountExists, err = resources.CreateTapMizuResources(ctx, kubernetesProvider, serializedMizuConfig, config.Config.IsNsRestrictedMode(), config.Config.MizuResourcesNamespace, config.Config.AgentImage, config.Config.Tap.MaxEntriesDBSizeBytes(), config.Config.Tap.ApiServerResources, config.Config.ImagePullPolicy(), config.Config.LogLevel(), config.Config.Tap.Profiler, config.Config.Tap.ExtensionsHostPath, state.debugControlToken); err != nil {
 		var statusError *k8serrors.StatusError
 		if errors.As(err, &statusError) && (statusError.ErrStatus.Reason == metav1.StatusReasonAlreadyExists) {
 			logger.Log.Info("Mizu is already running in this namespace, change the `mizu-resources-namespace` configuration or run `mizu clean` to remove the currently running Mizu instance")
@@ -147,6 +155,16 @@ func printTappedPodsPreview(ctx context.Context, kubernetesProvider *kubernetes.
 	}
 }
 
+// generateDebugControlToken generates the token the tappers of the session accept on their debug control endpoint
+func generateDebugControlToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(token), nil
+}
+
 func startTapperSyncer(ctx context.Context, cancel context.CancelFunc, provider *kubernetes.Provider, targetNamespaces []string, startTime time.Time) error {
 	tapperSyncer, err := kubernetes.CreateAndStartMizuTapperSyncer(ctx, provider, kubernetes.TapperSyncerConfig{
 		TargetNamespaces:       targetNamespaces,
@@ -157,12 +175,22 @@ func startTapperSyncer(ctx context.Context, cancel context.CancelFunc, provider
 		ImagePullPolicy:        config.Config.ImagePullPolicy(),
 		LogLevel:               config.Config.LogLevel(),
 		MizuApiFilteringOptions: api.TrafficFilteringOptions{
-			IgnoredUserAgents: config.Config.Tap.IgnoredUserAgents,
+			IgnoredUserAgents:    config.Config.Tap.IgnoredUserAgents,
+			GrpcDescriptorSets:   config.Config.Tap.GetGrpcDescriptorSets(),
+			EnableTcpRaw:         config.Config.Tap.TcpRaw,
+			EnableTcpConnections: config.Config.Tap.TcpConnections,
 		},
 		MizuServiceAccountExists: state.mizuServiceAccountExists,
 		ServiceMesh:              config.Config.Tap.ServiceMesh,
 		Tls:                      config.Config.Tap.Tls,
 		MaxLiveStreams:           config.Config.Tap.MaxLiveStreams,
+		EmitQueueSize:            config.Config.Tap.EmitQueueSize,
+		EmitPolicy:               config.Config.Tap.EmitPolicy,
+		EmitSampleRate:           config.Config.Tap.EmitSampleRate,
+		MaxSpoolSizeBytes:        config.Config.Tap.MaxSpoolSizeBytes(),
+		MaxPcapSizeBytes:         config.Config.Tap.MaxPcapSizeBytes(),
+		CaptureBackend:           config.Config.Tap.CaptureBackend,
+		ExtensionsHostPath:       config.Config.Tap.ExtensionsHostPath,
 	}, startTime)
 
 	if err != nil {
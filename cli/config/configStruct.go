@@ -27,6 +27,9 @@ type ConfigStruct struct {
 	Version                configStructs.VersionConfig `yaml:"version"`
 	View                   configStructs.ViewConfig    `yaml:"view"`
 	Logs                   configStructs.LogsConfig    `yaml:"logs"`
+	Debug                  configStructs.DebugConfig   `yaml:"debug"`
+	Pcap                   configStructs.PcapConfig    `yaml:"pcap"`
+	Analyze                configStructs.AnalyzeConfig `yaml:"analyze"`
 	Config                 configStructs.ConfigConfig  `yaml:"config,omitempty"`
 	AgentImage             string                      `yaml:"agent-image,omitempty" readonly:""`
 	ImagePullPolicyStr     string                      `yaml:"image-pull-policy" default:"Always"`
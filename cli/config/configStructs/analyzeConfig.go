@@ -0,0 +1,12 @@
+package configStructs
+
+const (
+	OutputDirAnalyzeName = "output-dir"
+	GuiPortAnalyzeName   = "gui-port"
+)
+
+type AnalyzeConfig struct {
+	PcapFile  string `yaml:"pcap-file,omitempty" readonly:""`
+	OutputDir string `yaml:"output-dir"`
+	GuiPort   uint16 `yaml:"gui-port" default:"8899"`
+}
@@ -0,0 +1,33 @@
+package configStructs
+
+import (
+	"fmt"
+)
+
+const (
+	NodeDebugName           = "node"
+	ProfileTypeDebugName    = "profile-type"
+	ProfileSecondsDebugName = "profile-seconds"
+	OutputDirDebugName      = "output-dir"
+
+	maxProfileSeconds = 300
+)
+
+type DebugConfig struct {
+	Node           string `yaml:"node"`
+	ProfileType    string `yaml:"profile-type" default:"cpu"`
+	ProfileSeconds int    `yaml:"profile-seconds" default:"30"`
+	OutputDir      string `yaml:"output-dir" default:"."`
+}
+
+func (config *DebugConfig) Validate() error {
+	if config.ProfileType != "cpu" && config.ProfileType != "heap" {
+		return fmt.Errorf("Could not parse --%s value %s, expected cpu or heap", ProfileTypeDebugName, config.ProfileType)
+	}
+
+	if config.ProfileSeconds < 1 || config.ProfileSeconds > maxProfileSeconds {
+		return fmt.Errorf("Could not parse --%s value %d, expected between 1 and %d", ProfileSecondsDebugName, config.ProfileSeconds, maxProfileSeconds)
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package configStructs
+
+import (
+	"fmt"
+	"os"
+	"path"
+)
+
+const (
+	FilePcapName = "file"
+	NodePcapName = "node"
+)
+
+type PcapConfig struct {
+	FileStr string `yaml:"file"`
+	Node    string `yaml:"node"`
+}
+
+func (config *PcapConfig) Validate() error {
+	if config.FileStr == "" {
+		_, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get PWD, %v (try using `mizu pcap -f <full path dest zip file>)`", err)
+		}
+	}
+
+	return nil
+}
+
+func (config *PcapConfig) FilePath() string {
+	if config.FileStr == "" {
+		pwd, _ := os.Getwd()
+		return path.Join(pwd, "mizu_pcap.zip")
+	}
+
+	return config.FileStr
+}
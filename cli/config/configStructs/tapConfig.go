@@ -13,6 +13,7 @@ import (
 
 	"github.com/up9inc/mizu/logger"
 	"github.com/up9inc/mizu/shared/units"
+	"github.com/up9inc/mizu/tap/api"
 )
 
 const (
@@ -27,6 +28,16 @@ const (
 	TlsName                      = "tls"
 	ProfilerName                 = "profiler"
 	MaxLiveStreamsName           = "max-live-streams"
+	EmitQueueSizeName            = "emit-queue-size"
+	EmitPolicyName               = "emit-policy"
+	EmitSampleRateName           = "emit-sample-rate"
+	HumanMaxSpoolSizeName        = "max-spool-size"
+	PcapName                     = "pcap"
+	HumanMaxPcapSizeName         = "max-pcap-size"
+	CaptureBackendName           = "capture-backend"
+	ExtensionsHostPathName       = "extensions-host-path"
+	TcpRawName                   = "tcp-raw"
+	TcpConnectionsName           = "tcp-connections"
 )
 
 type TapConfig struct {
@@ -53,6 +64,17 @@ type TapConfig struct {
 	Tls                   bool             `yaml:"tls" default:"false"`
 	Profiler              bool             `yaml:"profiler" default:"false"`
 	MaxLiveStreams        int              `yaml:"max-live-streams" default:"500"`
+	EmitQueueSize         int              `yaml:"emit-queue-size" default:"1000"`
+	EmitPolicy            string           `yaml:"emit-policy" default:"block"`
+	EmitSampleRate        int              `yaml:"emit-sample-rate" default:"1"`
+	HumanMaxSpoolSize     string           `yaml:"max-spool-size" default:"100MB"`
+	Pcap                  bool             `yaml:"pcap" default:"false"`
+	HumanMaxPcapSize      string           `yaml:"max-pcap-size" default:"500MB"`
+	CaptureBackend        string           `yaml:"capture-backend" default:"pcap"`
+	ExtensionsHostPath    string           `yaml:"extensions-host-path"`
+	GrpcDescriptorSets    []string         `yaml:"grpc-descriptor-sets"`
+	TcpRaw                bool             `yaml:"tcp-raw" default:"false"`
+	TcpConnections        bool             `yaml:"tcp-connections" default:"false"`
 }
 
 func (config *TapConfig) PodRegex() *regexp.Regexp {
@@ -65,6 +87,21 @@ func (config *TapConfig) MaxEntriesDBSizeBytes() int64 {
 	return maxEntriesDBSizeBytes
 }
 
+func (config *TapConfig) MaxSpoolSizeBytes() int64 {
+	maxSpoolSizeBytes, _ := units.HumanReadableToBytes(config.HumanMaxSpoolSize)
+	return maxSpoolSizeBytes
+}
+
+// MaxPcapSizeBytes is 0 when the tappers don't write pcap files
+func (config *TapConfig) MaxPcapSizeBytes() int64 {
+	if !config.Pcap {
+		return 0
+	}
+
+	maxPcapSizeBytes, _ := units.HumanReadableToBytes(config.HumanMaxPcapSize)
+	return maxPcapSizeBytes
+}
+
 func (config *TapConfig) GetInsertionFilter() string {
 	insertionFilter := config.InsertionFilter
 	if fs.ValidPath(insertionFilter) {
@@ -88,6 +125,22 @@ func (config *TapConfig) GetInsertionFilter() string {
 	return insertionFilter
 }
 
+// The FileDescriptorSets are read on the CLI side and handed to the tappers,
+// e.g. the output of `protoc --include_imports --descriptor_set_out`.
+func (config *TapConfig) GetGrpcDescriptorSets() [][]byte {
+	descriptorSets := make([][]byte, 0)
+	for _, path := range config.GrpcDescriptorSets {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			logger.Log.Warningf(uiUtils.Warning, fmt.Sprintf("Couldn't read the gRPC descriptor set on path: %s, err: %v", path, err))
+			continue
+		}
+		descriptorSets = append(descriptorSets, b)
+	}
+
+	return descriptorSets
+}
+
 func getRedactFilter(config *TapConfig) string {
 	if !config.EnableRedaction {
 		return ""
@@ -130,5 +183,29 @@ func (config *TapConfig) Validate() error {
 		return fmt.Errorf("Could not parse --%s value %s", HumanMaxEntriesDBSizeTapName, config.HumanMaxEntriesDBSize)
 	}
 
+	if _, err := api.ParseEmitPolicy(config.EmitPolicy); err != nil {
+		return fmt.Errorf("Could not parse --%s value %s", EmitPolicyName, config.EmitPolicy)
+	}
+
+	if config.EmitQueueSize < 1 {
+		return fmt.Errorf("--%s must be at least 1", EmitQueueSizeName)
+	}
+
+	if config.EmitSampleRate < 1 {
+		return fmt.Errorf("--%s must be at least 1", EmitSampleRateName)
+	}
+
+	if _, err := units.HumanReadableToBytes(config.HumanMaxSpoolSize); err != nil {
+		return fmt.Errorf("Could not parse --%s value %s", HumanMaxSpoolSizeName, config.HumanMaxSpoolSize)
+	}
+
+	if maxPcapSizeBytes, err := units.HumanReadableToBytes(config.HumanMaxPcapSize); err != nil || maxPcapSizeBytes <= 0 {
+		return fmt.Errorf("Could not parse --%s value %s", HumanMaxPcapSizeName, config.HumanMaxPcapSize)
+	}
+
+	if config.CaptureBackend != "pcap" && config.CaptureBackend != "af_packet" {
+		return fmt.Errorf("--%s must be pcap or af_packet, got %s", CaptureBackendName, config.CaptureBackend)
+	}
+
 	return nil
 }
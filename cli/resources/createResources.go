@@ -14,7 +14,7 @@ import (
 	core "k8s.io/api/core/v1"
 )
 
-func CreateTapMizuResources(ctx context.Context, kubernetesProvider *kubernetes.Provider, serializedMizuConfig string, isNsRestrictedMode bool, mizuResourcesNamespace string, agentImage string, maxEntriesDBSizeBytes int64, apiServerResources shared.Resources, imagePullPolicy core.PullPolicy, logLevel logging.Level, profiler bool) (bool, error) {
+func CreateTapMizuResources(ctx context.Context, kubernetesProvider *kubernetes.Provider, serializedMizuConfig string, isNsRestrictedMode bool, mizuResourcesNamespace string, agentImage string, maxEntriesDBSizeBytes int64, apiServerResources shared.Resources, imagePullPolicy core.PullPolicy, logLevel logging.Level, profiler bool, extensionsHostPath string, debugControlToken string) (bool, error) {
 	if !isNsRestrictedMode {
 		if err := createMizuNamespace(ctx, kubernetesProvider, mizuResourcesNamespace); err != nil {
 			return false, err
@@ -25,6 +25,10 @@ func CreateTapMizuResources(ctx context.Context, kubernetesProvider *kubernetes.
 		return false, err
 	}
 
+	if err := createMizuDebugControlSecret(ctx, kubernetesProvider, debugControlToken, mizuResourcesNamespace); err != nil {
+		return false, err
+	}
+
 	mizuServiceAccountExists, err := createRBACIfNecessary(ctx, kubernetesProvider, isNsRestrictedMode, mizuResourcesNamespace, []string{"pods", "services", "endpoints"})
 	if err != nil {
 		logger.Log.Warningf(uiUtils.Warning, fmt.Sprintf("Failed to ensure the resources required for IP resolving. Mizu will not resolve target IPs to names. error: %v", errormessage.FormatError(err)))
@@ -50,6 +54,7 @@ func CreateTapMizuResources(ctx context.Context, kubernetesProvider *kubernetes.
 		ImagePullPolicy:       imagePullPolicy,
 		LogLevel:              logLevel,
 		Profiler:              profiler,
+		ExtensionsHostPath:    extensionsHostPath,
 	}
 
 	if err := createMizuApiServerPod(ctx, kubernetesProvider, opts); err != nil {
@@ -76,6 +81,11 @@ func createMizuConfigmap(ctx context.Context, kubernetesProvider *kubernetes.Pro
 	return err
 }
 
+func createMizuDebugControlSecret(ctx context.Context, kubernetesProvider *kubernetes.Provider, debugControlToken string, mizuResourcesNamespace string) error {
+	err := kubernetesProvider.CreateSecret(ctx, mizuResourcesNamespace, kubernetes.DebugControlSecretName, kubernetes.DebugControlTokenSecretKey, debugControlToken)
+	return err
+}
+
 func createRBACIfNecessary(ctx context.Context, kubernetesProvider *kubernetes.Provider, isNsRestrictedMode bool, mizuResourcesNamespace string, resources []string) (bool, error) {
 	if !isNsRestrictedMode {
 		if err := kubernetesProvider.CreateMizuRBAC(ctx, mizuResourcesNamespace, kubernetes.ServiceAccountName, kubernetes.ClusterRoleName, kubernetes.ClusterRoleBindingName, mizu.RBACVersion, resources); err != nil {
@@ -13,4 +13,6 @@ const (
 	BasenineHost               = "127.0.0.1"
 	BaseninePort               = "9099"
 	BasenineReconnectInterval  = 3
+	DebugControlTokenEnvVar    = "MIZU_DEBUG_CONTROL_TOKEN"
+	TapperDebugControlPort     = 8898
 )
@@ -12,6 +12,8 @@ const (
 	TapperDaemonSetName        = MizuResourcesPrefix + "tapper-daemon-set"
 	TapperPodName              = MizuResourcesPrefix + "tapper"
 	ConfigMapName              = MizuResourcesPrefix + "config"
+	DebugControlSecretName     = MizuResourcesPrefix + "debug-control"
+	DebugControlTokenSecretKey = "token"
 	MinKubernetesServerVersion = "1.16.0"
 )
 
@@ -48,6 +48,13 @@ type TapperSyncerConfig struct {
 	ServiceMesh              bool
 	Tls                      bool
 	MaxLiveStreams           int
+	EmitQueueSize            int
+	EmitPolicy               string
+	EmitSampleRate           int
+	MaxSpoolSizeBytes        int64
+	MaxPcapSizeBytes         int64
+	CaptureBackend           string
+	ExtensionsHostPath       string
 }
 
 func CreateAndStartMizuTapperSyncer(ctx context.Context, kubernetesProvider *Provider, config TapperSyncerConfig, startTime time.Time) (*MizuTapperSyncer, error) {
@@ -338,7 +345,14 @@ func (tapperSyncer *MizuTapperSyncer) updateMizuTappers() error {
 			tapperSyncer.config.LogLevel,
 			tapperSyncer.config.ServiceMesh,
 			tapperSyncer.config.Tls,
-			tapperSyncer.config.MaxLiveStreams); err != nil {
+			tapperSyncer.config.MaxLiveStreams,
+			tapperSyncer.config.EmitQueueSize,
+			tapperSyncer.config.EmitPolicy,
+			tapperSyncer.config.EmitSampleRate,
+			tapperSyncer.config.MaxSpoolSizeBytes,
+			tapperSyncer.config.MaxPcapSizeBytes,
+			tapperSyncer.config.CaptureBackend,
+			tapperSyncer.config.ExtensionsHostPath); err != nil {
 			return err
 		}
 
@@ -52,6 +52,18 @@ const (
 	procfsMountPath  = "/hostproc"
 	sysfsVolumeName  = "sys"
 	sysfsMountPath   = "/sys"
+	spoolVolumeName  = "spool"
+	spoolMountPath   = "/app/spool"
+	// Room for the filesystem overhead of the spool segments, the volume is evicted when it goes over its limit
+	spoolVolumeSizeMargin = 16 * 1024 * 1024
+	pcapVolumeName        = "pcap"
+	pcapMountPath         = "/app/pcap"
+	// The pcap files are rotated, so at most one file of the traffic is removed at once
+	pcapFiles = 10
+	// Room for the packet the current pcap file goes over its size with
+	pcapVolumeSizeMargin = 1024 * 1024
+	extensionsVolumeName = "extensions"
+	extensionsMountPath  = "/app/extensions"
 )
 
 func NewProvider(kubeConfigPath string, contextName string) (*Provider, error) {
@@ -182,6 +194,8 @@ type ApiServerOptions struct {
 	ImagePullPolicy       core.PullPolicy
 	LogLevel              logging.Level
 	Profiler              bool
+	// ExtensionsHostPath is the directory of the dissector plugins on the nodes, it's not mounted when empty
+	ExtensionsHostPath string
 }
 
 func (provider *Provider) GetMizuApiServerPodObject(opts *ApiServerOptions, mountVolumeClaim bool, volumeClaimName string, createAuthContainer bool) (*core.Pod, error) {
@@ -210,6 +224,10 @@ func (provider *Provider) GetMizuApiServerPodObject(opts *ApiServerOptions, moun
 		"--api-server",
 	}
 
+	if opts.ExtensionsHostPath != "" {
+		command = append(command, "--extensions-dir", extensionsMountPath)
+	}
+
 	if opts.Profiler {
 		command = append(command, "--profiler")
 	}
@@ -366,6 +384,22 @@ func (provider *Provider) GetMizuApiServerPodObject(opts *ApiServerOptions, moun
 		})
 	}
 
+	// The plugins are loaded by the API server container, the same ones as the tappers load
+	if opts.ExtensionsHostPath != "" {
+		hostPathType := core.HostPathDirectory
+		volumes = append(volumes, core.Volume{
+			Name: extensionsVolumeName,
+			VolumeSource: core.VolumeSource{
+				HostPath: &core.HostPathVolumeSource{Path: opts.ExtensionsHostPath, Type: &hostPathType},
+			},
+		})
+		containers[0].VolumeMounts = append(append([]core.VolumeMount{}, volumeMounts...), core.VolumeMount{
+			Name:      extensionsVolumeName,
+			MountPath: extensionsMountPath,
+			ReadOnly:  true,
+		})
+	}
+
 	pod := &core.Pod{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: opts.PodName,
@@ -665,6 +699,11 @@ func (provider *Provider) RemoveConfigMap(ctx context.Context, namespace string,
 	return provider.handleRemovalError(err)
 }
 
+func (provider *Provider) RemoveSecret(ctx context.Context, namespace string, secretName string) error {
+	err := provider.clientSet.CoreV1().Secrets(namespace).Delete(ctx, secretName, metav1.DeleteOptions{})
+	return provider.handleRemovalError(err)
+}
+
 func (provider *Provider) RemoveService(ctx context.Context, namespace string, serviceName string) error {
 	err := provider.clientSet.CoreV1().Services(namespace).Delete(ctx, serviceName, metav1.DeleteOptions{})
 	return provider.handleRemovalError(err)
@@ -709,7 +748,29 @@ func (provider *Provider) CreateConfigMap(ctx context.Context, namespace string,
 	return nil
 }
 
-func (provider *Provider) ApplyMizuTapperDaemonSet(ctx context.Context, namespace string, daemonSetName string, podImage string, tapperPodName string, apiServerPodIp string, nodeNames []string, serviceAccountName string, resources shared.Resources, imagePullPolicy core.PullPolicy, mizuApiFilteringOptions api.TrafficFilteringOptions, logLevel logging.Level, serviceMesh bool, tls bool, maxLiveStreams int) error {
+func (provider *Provider) CreateSecret(ctx context.Context, namespace string, secretName string, key string, value string) error {
+	secret := &core.Secret{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Secret",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: secretName,
+			Labels: map[string]string{
+				LabelManagedBy: provider.managedBy,
+				LabelCreatedBy: provider.createdBy,
+			},
+		},
+		Type:       core.SecretTypeOpaque,
+		StringData: map[string]string{key: value},
+	}
+	if _, err := provider.clientSet.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (provider *Provider) ApplyMizuTapperDaemonSet(ctx context.Context, namespace string, daemonSetName string, podImage string, tapperPodName string, apiServerPodIp string, nodeNames []string, serviceAccountName string, resources shared.Resources, imagePullPolicy core.PullPolicy, mizuApiFilteringOptions api.TrafficFilteringOptions, logLevel logging.Level, serviceMesh bool, tls bool, maxLiveStreams int, emitQueueSize int, emitPolicy string, emitSampleRate int, maxSpoolSizeBytes int64, maxPcapSizeBytes int64, captureBackend string, extensionsHostPath string) error {
 	logger.Log.Debugf("Applying %d tapper daemon sets, ns: %s, daemonSetName: %s, podImage: %s, tapperPodName: %s", len(nodeNames), namespace, daemonSetName, podImage, tapperPodName)
 
 	if len(nodeNames) == 0 {
@@ -728,16 +789,34 @@ func (provider *Provider) ApplyMizuTapperDaemonSet(ctx context.Context, namespac
 		"--api-server-address", fmt.Sprintf("ws://%s/wsTapper", apiServerPodIp),
 		"--nodefrag",
 		"--max-live-streams", strconv.Itoa(maxLiveStreams),
+		"--emit-queue-size", strconv.Itoa(emitQueueSize),
+		"--emit-policy", emitPolicy,
+		"--emit-sample-rate", strconv.Itoa(emitSampleRate),
+		"--spool-dir", spoolMountPath,
+		"--spool-max-size", strconv.FormatInt(maxSpoolSizeBytes, 10),
+		"--capture-backend", captureBackend,
 	}
 
 	if serviceMesh {
 		mizuCmd = append(mizuCmd, "--servicemesh")
 	}
 
+	if maxPcapSizeBytes > 0 {
+		mizuCmd = append(mizuCmd,
+			"--pcap-dir", pcapMountPath,
+			"--pcap-max-file-size", strconv.FormatInt(maxPcapSizeBytes/pcapFiles, 10),
+			"--pcap-max-files", strconv.Itoa(pcapFiles),
+		)
+	}
+
 	if tls {
 		mizuCmd = append(mizuCmd, "--tls")
 	}
 
+	if extensionsHostPath != "" {
+		mizuCmd = append(mizuCmd, "--extensions-dir", extensionsMountPath)
+	}
+
 	if serviceMesh || tls {
 		mizuCmd = append(mizuCmd, "--procfs", procfsMountPath)
 	}
@@ -772,6 +851,13 @@ func (provider *Provider) ApplyMizuTapperDaemonSet(ctx context.Context, namespac
 		applyconfcore.EnvVar().WithName(shared.HostModeEnvVar).WithValue("1"),
 		applyconfcore.EnvVar().WithName(shared.MizuFilteringOptionsEnvVar).WithValue(string(mizuApiFilteringOptionsJsonStr)),
 	)
+	agentContainer.WithEnv(
+		applyconfcore.EnvVar().WithName(shared.DebugControlTokenEnvVar).WithValueFrom(
+			applyconfcore.EnvVarSource().WithSecretKeyRef(
+				applyconfcore.SecretKeySelector().WithName(DebugControlSecretName).WithKey(DebugControlTokenSecretKey).WithOptional(true),
+			),
+		),
+	)
 	agentContainer.WithEnv(
 		applyconfcore.EnvVar().WithName(shared.NodeNameEnvVar).WithValueFrom(
 			applyconfcore.EnvVarSource().WithFieldRef(
@@ -847,6 +933,29 @@ func (provider *Provider) ApplyMizuTapperDaemonSet(ctx context.Context, namespac
 	sysfsVolumeMount := applyconfcore.VolumeMount().WithName(sysfsVolumeName).WithMountPath(sysfsMountPath).WithReadOnly(true)
 	agentContainer.WithVolumeMounts(sysfsVolumeMount)
 
+	// The spool outlives the restarts of the tapper container, so the entries it holds are sent after the restart
+	spoolVolume := applyconfcore.Volume()
+	spoolVolume.WithName(spoolVolumeName).WithEmptyDir(applyconfcore.EmptyDirVolumeSource().WithSizeLimit(*resource.NewQuantity(maxSpoolSizeBytes+spoolVolumeSizeMargin, resource.BinarySI)))
+	spoolVolumeMount := applyconfcore.VolumeMount().WithName(spoolVolumeName).WithMountPath(spoolMountPath)
+	agentContainer.WithVolumeMounts(spoolVolumeMount)
+
+	volumes := []*applyconfcore.VolumeApplyConfiguration{procfsVolume, sysfsVolume, spoolVolume}
+	if maxPcapSizeBytes > 0 {
+		pcapVolume := applyconfcore.Volume()
+		pcapVolume.WithName(pcapVolumeName).WithEmptyDir(applyconfcore.EmptyDirVolumeSource().WithSizeLimit(*resource.NewQuantity(maxPcapSizeBytes+pcapVolumeSizeMargin, resource.BinarySI)))
+		pcapVolumeMount := applyconfcore.VolumeMount().WithName(pcapVolumeName).WithMountPath(pcapMountPath)
+		agentContainer.WithVolumeMounts(pcapVolumeMount)
+		volumes = append(volumes, pcapVolume)
+	}
+
+	if extensionsHostPath != "" {
+		extensionsVolume := applyconfcore.Volume()
+		extensionsVolume.WithName(extensionsVolumeName).WithHostPath(applyconfcore.HostPathVolumeSource().WithPath(extensionsHostPath).WithType(core.HostPathDirectory))
+		extensionsVolumeMount := applyconfcore.VolumeMount().WithName(extensionsVolumeName).WithMountPath(extensionsMountPath).WithReadOnly(true)
+		agentContainer.WithVolumeMounts(extensionsVolumeMount)
+		volumes = append(volumes, extensionsVolume)
+	}
+
 	podSpec := applyconfcore.PodSpec()
 	podSpec.WithHostNetwork(true)
 	podSpec.WithDNSPolicy(core.DNSClusterFirstWithHostNet)
@@ -857,7 +966,7 @@ func (provider *Provider) ApplyMizuTapperDaemonSet(ctx context.Context, namespac
 	podSpec.WithContainers(agentContainer)
 	podSpec.WithAffinity(affinity)
 	podSpec.WithTolerations(noExecuteToleration, noScheduleToleration)
-	podSpec.WithVolumes(procfsVolume, sysfsVolume)
+	podSpec.WithVolumes(volumes...)
 
 	podTemplate := applyconfcore.PodTemplateSpec()
 	podTemplate.WithLabels(map[string]string{
@@ -1013,6 +1122,60 @@ func (provider *Provider) GetPodLogs(ctx context.Context, namespace string, podN
 	return str, nil
 }
 
+func (provider *Provider) GetSecretValue(ctx context.Context, namespace string, secretName string, key string) (string, error) {
+	secret, err := provider.clientSet.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("error getting secret on ns: %s, name: %s, %w", namespace, secretName, err)
+	}
+
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret on ns: %s, name: %s", key, namespace, secretName)
+	}
+
+	return string(value), nil
+}
+
+// ProxyPodRequest sends a request to a port of a pod through the proxy of the API server, so the pod doesn't have to be reachable from the CLI
+func (provider *Provider) ProxyPodRequest(ctx context.Context, namespace string, podName string, port int, method string, path string, headers map[string]string, params map[string]string, body []byte) ([]byte, error) {
+	response, err := provider.newProxyPodRequest(namespace, podName, port, method, path, headers, params, body).DoRaw(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error proxying %s %s to ns: %s, pod: %s, %w", method, path, namespace, podName, err)
+	}
+
+	return response, nil
+}
+
+// StreamProxyPodRequest is ProxyPodRequest for the large responses, the body is read while it's received rather than loaded to memory
+func (provider *Provider) StreamProxyPodRequest(ctx context.Context, namespace string, podName string, port int, method string, path string, headers map[string]string, params map[string]string) (io.ReadCloser, error) {
+	stream, err := provider.newProxyPodRequest(namespace, podName, port, method, path, headers, params, nil).Stream(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error proxying %s %s to ns: %s, pod: %s, %w", method, path, namespace, podName, err)
+	}
+
+	return stream, nil
+}
+
+func (provider *Provider) newProxyPodRequest(namespace string, podName string, port int, method string, path string, headers map[string]string, params map[string]string, body []byte) *rest.Request {
+	req := provider.clientSet.CoreV1().RESTClient().Verb(method).
+		Namespace(namespace).
+		Resource("pods").
+		Name(fmt.Sprintf("%s:%d", podName, port)).
+		SubResource("proxy").
+		Suffix(path)
+	for name, value := range headers {
+		req.SetHeader(name, value)
+	}
+	for name, value := range params {
+		req.Param(name, value)
+	}
+	if body != nil {
+		req.Body(body)
+	}
+
+	return req
+}
+
 func (provider *Provider) GetNamespaceEvents(ctx context.Context, namespace string) (string, error) {
 	eventList, err := provider.clientSet.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
@@ -31,6 +31,7 @@ func getMinimizedPod(fullPod core.Pod) core.Pod {
 		},
 		Status: core.PodStatus{
 			PodIP:             fullPod.Status.PodIP,
+			PodIPs:            fullPod.Status.PodIPs,
 			ContainerStatuses: getMinimizedContainerStatuses(fullPod),
 		},
 	}
@@ -2,8 +2,10 @@ package api
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"net"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -91,6 +93,40 @@ type CounterPair struct {
 	sync.Mutex
 }
 
+type TcpTermination string
+
+const (
+	TcpTerminationFin     TcpTermination = "fin"
+	TcpTerminationRst     TcpTermination = "rst"
+	TcpTerminationTimeout TcpTermination = "timeout" // Neither FIN nor RST was seen until the connection was flushed
+)
+
+const (
+	TcpClient = "client"
+	TcpServer = "server"
+)
+
+type TcpConnectionDirection struct {
+	Bytes           int `json:"bytes"`
+	Packets         int `json:"packets"`
+	Retransmissions int `json:"retransmissions"`
+	OutOfOrder      int `json:"outOfOrder"`
+}
+
+// TcpConnection is the summary of a TCP connection from its first packet to its termination.
+// The client is the side that sent the first packet, which is the SYN unless the handshake was missed.
+type TcpConnection struct {
+	ConnectionInfo *ConnectionInfo
+	Capture        Capture
+	StartTime      time.Time
+	EndTime        time.Time
+	HandshakeRtt   time.Duration // Zero if the handshake was not captured
+	Client         TcpConnectionDirection
+	Server         TcpConnectionDirection
+	Termination    TcpTermination
+	TerminatedBy   string // TcpClient or TcpServer, empty on timeout
+}
+
 type GenericMessage struct {
 	IsRequest   bool        `json:"isRequest"`
 	CaptureTime time.Time   `json:"captureTime"`
@@ -144,14 +180,195 @@ type Dissector interface {
 	NewResponseRequestMatcher() RequestResponseMatcher
 }
 
+// DatagramDissector is implemented by the dissectors of datagram-oriented protocols.
+// Unlike `Dissect`, which consumes a reassembled TCP byte stream, `DissectDatagram`
+// receives one UDP payload at a time and returns an error if it doesn't recognize it.
+type DatagramDissector interface {
+	DissectDatagram(datagram UdpDatagram, options *TrafficFilteringOptions) error
+}
+
+// FallbackDissector is implemented by the dissectors that take the TCP streams that no other dissector
+// identified. They are not ranked, `DissectFallback` is given the whole stream after the ranked dissectors failed.
+type FallbackDissector interface {
+	DissectFallback(b *bufio.Reader, reader TcpReader, options *TrafficFilteringOptions) error
+}
+
+// ConnectionDissector is implemented by the dissectors that turn the summaries of the TCP connections into entries.
+// Unlike the other dissectors, it is given the connections regardless of the protocols that were identified in them.
+type ConnectionDissector interface {
+	DissectConnection(connection *TcpConnection, emitter Emitter, options *TrafficFilteringOptions)
+}
+
+type Confidence int
+
+const (
+	ConfidenceNone    Confidence = iota // The data is not of the protocol
+	ConfidenceUnknown                   // The data is not enough to tell, the dissector has to be tried
+	ConfidenceLow                       // The data looks like the protocol
+	ConfidenceHigh                      // The data is of the protocol
+)
+
+// Prober is optionally implemented by the dissectors that can tell their protocol from the first bytes of
+// a stream. The readers try the dissectors in the order of their confidence, instead of rewinding the stream
+// after each dissector that fails.
+type Prober interface {
+	Probe(peek []byte, isClient bool) Confidence
+}
+
+// RankExtensions returns the indexes of the extensions in the order they should be tried on a stream that
+// starts with `peek`. The extensions that rule out the data are left out, and when any of them is sure about it
+// the rest are left out too. The ties are broken by the ports of the protocols, then by the priority.
+func RankExtensions(extensions []*Extension, peek []byte, isClient bool, serverPort string) []int {
+	type candidate struct {
+		index      int
+		confidence Confidence
+		portMatch  bool
+	}
+
+	candidates := make([]candidate, 0, len(extensions))
+	hasHigh := false
+	for i, extension := range extensions {
+		if _, ok := extension.Dissector.(FallbackDissector); ok {
+			continue
+		}
+		confidence := ConfidenceUnknown
+		if prober, ok := extension.Dissector.(Prober); ok {
+			confidence = prober.Probe(peek, isClient)
+		}
+		if confidence == ConfidenceNone {
+			continue
+		}
+		if confidence == ConfidenceHigh {
+			hasHigh = true
+		}
+		candidates = append(candidates, candidate{
+			index:      i,
+			confidence: confidence,
+			portMatch:  isProtocolPort(extension, serverPort),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].confidence != candidates[j].confidence {
+			return candidates[i].confidence > candidates[j].confidence
+		}
+		return candidates[i].portMatch && !candidates[j].portMatch
+	})
+
+	ranked := make([]int, 0, len(candidates))
+	for _, c := range candidates {
+		if hasHigh && c.confidence != ConfidenceHigh {
+			break
+		}
+		ranked = append(ranked, c.index)
+	}
+	return ranked
+}
+
+// FindFallbackExtension returns the index of the extension that takes the unidentified streams, or -1
+func FindFallbackExtension(extensions []*Extension) int {
+	for i, extension := range extensions {
+		if _, ok := extension.Dissector.(FallbackDissector); ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// FindConnectionExtension returns the index of the extension that takes the summaries of the TCP connections, or -1
+func FindConnectionExtension(extensions []*Extension) int {
+	for i, extension := range extensions {
+		if _, ok := extension.Dissector.(ConnectionDissector); ok {
+			return i
+		}
+	}
+	return -1
+}
+
+func isProtocolPort(extension *Extension, port string) bool {
+	for _, protocol := range extension.Dissector.GetProtocols() {
+		for _, p := range protocol.Ports {
+			if p == port {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type RequestResponseMatcher interface {
 	GetMap() *sync.Map
 	SetMaxTry(value int)
+	// NewUnansweredItem builds the item of a request from the map that never got a response. It returns nil if the value
+	// is not a request. The connection info is left nil, unless the key tells it unlike the stream of the matcher.
+	NewUnansweredItem(key interface{}, value interface{}) *OutputChannelItem
+}
+
+// The requests that were not answered in time are emitted with a synthetic response that has only this status
+const (
+	UnansweredStatus      = "timeout/no response"
+	UnansweredStatusQuery = `response.unanswered == true`
+)
+
+type UnansweredDetails struct {
+	Unanswered bool   `json:"unanswered"`
+	Status     string `json:"status"`
+}
+
+type unansweredPayload struct {
+	Details UnansweredDetails `json:"details"`
+}
+
+// NewUnansweredItem pairs a request that never got a response with the synthetic response
+func NewUnansweredItem(protocol Protocol, request *GenericMessage) *OutputChannelItem {
+	return &OutputChannelItem{
+		Protocol:  protocol,
+		Timestamp: request.CaptureTime.UnixNano() / int64(time.Millisecond),
+		Pair: &RequestResponsePair{
+			Request: *request,
+			Response: GenericMessage{
+				IsRequest:   false,
+				CaptureTime: request.CaptureTime,
+				Payload: unansweredPayload{
+					Details: UnansweredDetails{
+						Unanswered: true,
+						Status:     UnansweredStatus,
+					},
+				},
+			},
+		},
+	}
+}
+
+// IsUnanswered reports whether the response details of an entry are the synthetic ones
+func IsUnanswered(response map[string]interface{}) bool {
+	unanswered, _ := response["unanswered"].(bool)
+	return unanswered
 }
 
+// RepresentUnanswered is the representation of the synthetic response
+func RepresentUnanswered() []interface{} {
+	details, _ := json.Marshal([]TableData{
+		{
+			Name:     "Status",
+			Value:    UnansweredStatus,
+			Selector: `response.status`,
+		},
+	})
+	return []interface{}{
+		SectionData{
+			Type:  TABLE,
+			Title: "Details",
+			Data:  string(details),
+		},
+	}
+}
+
+// The items are sent to the output channel directly, unless there's a queue in between
 type Emitting struct {
 	AppStats      *AppStats
 	OutputChannel chan *OutputChannelItem
+	Queue         *EmitQueue
 }
 
 type Emitter interface {
@@ -161,7 +378,12 @@ type Emitter interface {
 func (e *Emitting) Emit(item *OutputChannelItem) {
 	e.AppStats.IncMatchedPairs()
 
-	if dbgctl.MizuTapperDisableEmitting {
+	if dbgctl.MizuTapperDisableEmitting.Enabled() {
+		return
+	}
+
+	if e.Queue != nil {
+		e.Queue.Enqueue(item)
 		return
 	}
 
@@ -247,6 +469,16 @@ type TcpReader interface {
 	GetIsClosed() bool
 }
 
+type UdpDatagram interface {
+	GetPayload() []byte
+	GetUdpID() *TcpID
+	GetCaptureTime() time.Time
+	GetOrigin() Capture
+	GetIsOutgoing() bool
+	GetReqResMatcher() RequestResponseMatcher
+	GetEmitter() Emitter
+}
+
 type TcpStream interface {
 	SetProtocol(protocol *Protocol)
 	GetOrigin() Capture
@@ -0,0 +1,60 @@
+// Package apitest has the checks that the tests of the extensions share.
+package apitest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// UnansweredItems builds the unanswered item of every request in the map of the matcher, the way the cleaner does
+func UnansweredItems(matcher api.RequestResponseMatcher) []*api.OutputChannelItem {
+	var items []*api.OutputChannelItem
+	matcher.GetMap().Range(func(key, value interface{}) bool {
+		if item := matcher.NewUnansweredItem(key, value); item != nil {
+			items = append(items, item)
+		}
+		return true
+	})
+	return items
+}
+
+/* AnalyzeUnanswered takes an unanswered item through the dissector and the JSON round trips of the items and the entries,
+ * checks that the entry is unanswered and that it can be represented.
+ * The entry and its summary are returned for the checks of the extension.
+ */
+func AnalyzeUnanswered(t *testing.T, dissector api.Dissector, item *api.OutputChannelItem) (*api.Entry, *api.BaseEntry) {
+	t.Helper()
+
+	marshaled, err := json.Marshal(item)
+	if err != nil {
+		t.Fatalf("error marshaling the item: %v", err)
+	}
+	var unmarshaled *api.OutputChannelItem
+	if err := json.Unmarshal(marshaled, &unmarshaled); err != nil {
+		t.Fatalf("error unmarshaling the item: %v", err)
+	}
+
+	entry := dissector.Analyze(unmarshaled, "", "", "")
+	if marshaled, err = json.Marshal(entry); err != nil {
+		t.Fatalf("error marshaling the entry: %v", err)
+	}
+	if err := json.Unmarshal(marshaled, &entry); err != nil {
+		t.Fatalf("error unmarshaling the entry: %v", err)
+	}
+	if status := entry.Response["status"]; status != api.UnansweredStatus {
+		t.Errorf("expected the response status %q, got %v", api.UnansweredStatus, status)
+	}
+
+	summary := dissector.Summarize(entry)
+	if summary.StatusQuery != api.UnansweredStatusQuery {
+		t.Errorf("expected the status query %q, got %q", api.UnansweredStatusQuery, summary.StatusQuery)
+	}
+
+	if _, err := dissector.Represent(entry.Request, entry.Response); err != nil {
+		t.Errorf("error representing the entry: %v", err)
+	}
+
+	return entry, summary
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// EmitPolicy tells what is done with the items emitted while the queue is full
+type EmitPolicy string
+
+const (
+	EmitPolicyBlock      EmitPolicy = "block"
+	EmitPolicyDropNewest EmitPolicy = "drop-newest"
+	EmitPolicyDropOldest EmitPolicy = "drop-oldest"
+)
+
+var EmitPolicies = []EmitPolicy{EmitPolicyBlock, EmitPolicyDropNewest, EmitPolicyDropOldest}
+
+func ParseEmitPolicy(value string) (EmitPolicy, error) {
+	for _, policy := range EmitPolicies {
+		if string(policy) == value {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown emit policy %q, expected one of %v", value, EmitPolicies)
+}
+
+/* EmitQueue is a bounded queue between the emitters and the output channel.
+ * A slow consumer of the output channel would otherwise stall the dissectors and the reassembly.
+ * While the queue is full, the block policy makes the emitters wait the way the unbuffered channel did,
+ * the drop-newest policy drops the emitted item, and the drop-oldest policy drops the oldest queued item for it.
+ * The drop-oldest policy samples the items it makes room for, only one in every sample rate is queued
+ * and the others are dropped as the newest. So a burst doesn't replace the whole queue.
+ */
+type EmitQueue struct {
+	items      chan *OutputChannelItem
+	policy     EmitPolicy
+	sampleRate uint64
+	overflows  uint64
+	appStats   *AppStats
+}
+
+func NewEmitQueue(output chan<- *OutputChannelItem, size int, policy EmitPolicy, sampleRate int, appStats *AppStats) *EmitQueue {
+	if size < 1 {
+		size = 1
+	}
+	if sampleRate < 1 {
+		sampleRate = 1
+	}
+
+	queue := &EmitQueue{
+		items:      make(chan *OutputChannelItem, size),
+		policy:     policy,
+		sampleRate: uint64(sampleRate),
+		appStats:   appStats,
+	}
+
+	go queue.forward(output)
+	return queue
+}
+
+func (q *EmitQueue) forward(output chan<- *OutputChannelItem) {
+	for item := range q.items {
+		output <- item
+	}
+
+	close(output)
+}
+
+// Close closes the output channel once the queued items are forwarded, nothing may be emitted after it
+func (q *EmitQueue) Close() {
+	close(q.items)
+}
+
+func (q *EmitQueue) Enqueue(item *OutputChannelItem) {
+	switch q.policy {
+	case EmitPolicyDropNewest:
+		select {
+		case q.items <- item:
+		default:
+			q.appStats.IncDroppedNewestItems()
+		}
+	case EmitPolicyDropOldest:
+		q.enqueueDroppingOldest(item)
+	default:
+		q.items <- item
+	}
+}
+
+func (q *EmitQueue) enqueueDroppingOldest(item *OutputChannelItem) {
+	select {
+	case q.items <- item:
+		return
+	default:
+	}
+
+	if atomic.AddUint64(&q.overflows, 1)%q.sampleRate != 0 {
+		q.appStats.IncDroppedNewestItems()
+		return
+	}
+
+	// The other emitters may fill the room meanwhile, so it's retried
+	for {
+		select {
+		case <-q.items:
+			q.appStats.IncDroppedOldestItems()
+		default:
+		}
+
+		select {
+		case q.items <- item:
+			return
+		default:
+		}
+	}
+}
+
+// Len is the number of the items waiting for the consumer of the output channel
+func (q *EmitQueue) Len() int {
+	return len(q.items)
+}
+
+func (q *EmitQueue) Cap() int {
+	return cap(q.items)
+}
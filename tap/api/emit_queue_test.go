@@ -0,0 +1,180 @@
+package api
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newQueuedItem(i int) *OutputChannelItem {
+	return &OutputChannelItem{Timestamp: int64(i)}
+}
+
+// fillEmitQueue fills the queue while the consumer doesn't read, one item waits in the forwarding goroutine meanwhile
+func fillEmitQueue(t *testing.T, queue *EmitQueue) int {
+	queue.Enqueue(newQueuedItem(0))
+	deadline := time.Now().Add(5 * time.Second)
+	for queue.Len() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the forwarding goroutine didn't take the first item")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := 1; i <= queue.Cap(); i++ {
+		queue.Enqueue(newQueuedItem(i))
+	}
+	if queue.Len() != queue.Cap() {
+		t.Fatalf("expected a full queue of %d items, got %d", queue.Cap(), queue.Len())
+	}
+	return queue.Cap() + 1
+}
+
+func readTimestamps(output <-chan *OutputChannelItem) []int64 {
+	timestamps := make([]int64, 0)
+	for item := range output {
+		timestamps = append(timestamps, item.Timestamp)
+	}
+	return timestamps
+}
+
+func TestEmitQueuePolicies(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     EmitPolicy
+		sampleRate int
+		overflow   int
+		expected   []int64
+		newest     uint64
+		oldest     uint64
+	}{
+		{"drop newest", EmitPolicyDropNewest, 1, 3, []int64{0, 1, 2}, 3, 0},
+		{"drop oldest", EmitPolicyDropOldest, 1, 3, []int64{0, 4, 5}, 0, 3},
+		{"drop oldest sampled", EmitPolicyDropOldest, 2, 4, []int64{0, 4, 6}, 2, 2},
+		{"drop oldest sampled, one in three", EmitPolicyDropOldest, 3, 6, []int64{0, 5, 8}, 4, 2},
+		{"drop oldest with no overflow", EmitPolicyDropOldest, 2, 0, []int64{0, 1, 2}, 0, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			appStats := &AppStats{}
+			output := make(chan *OutputChannelItem)
+			queue := NewEmitQueue(output, 2, test.policy, test.sampleRate, appStats)
+
+			next := fillEmitQueue(t, queue)
+			for i := 0; i < test.overflow; i++ {
+				queue.Enqueue(newQueuedItem(next + i))
+			}
+			if queue.Len() != queue.Cap() {
+				t.Errorf("expected the queue to stay full, got %d items", queue.Len())
+			}
+
+			// The queued items are flushed on Close
+			queue.Close()
+			if timestamps := readTimestamps(output); !reflect.DeepEqual(test.expected, timestamps) {
+				t.Errorf("expected %v, got %v", test.expected, timestamps)
+			}
+
+			if newest := atomic.LoadUint64(&appStats.DroppedNewestItems); newest != test.newest {
+				t.Errorf("expected %d dropped newest items, got %d", test.newest, newest)
+			}
+			if oldest := atomic.LoadUint64(&appStats.DroppedOldestItems); oldest != test.oldest {
+				t.Errorf("expected %d dropped oldest items, got %d", test.oldest, oldest)
+			}
+		})
+	}
+}
+
+func TestEmitQueueBlocks(t *testing.T) {
+	appStats := &AppStats{}
+	output := make(chan *OutputChannelItem)
+	queue := NewEmitQueue(output, 2, EmitPolicyBlock, 1, appStats)
+
+	next := fillEmitQueue(t, queue)
+	enqueued := make(chan struct{})
+	go func() {
+		queue.Enqueue(newQueuedItem(next))
+		close(enqueued)
+	}()
+
+	select {
+	case <-enqueued:
+		t.Fatal("expected the emitter to wait while the queue is full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The consumer makes room for the waiting emitter
+	if item := <-output; item.Timestamp != 0 {
+		t.Fatalf("expected the first item, got %d", item.Timestamp)
+	}
+	<-enqueued
+
+	queue.Close()
+	if timestamps := readTimestamps(output); !reflect.DeepEqual([]int64{1, 2, 3}, timestamps) {
+		t.Errorf("expected [1 2 3], got %v", timestamps)
+	}
+	if appStats.DroppedNewestItems != 0 || appStats.DroppedOldestItems != 0 {
+		t.Errorf("expected no dropped items, got %d newest and %d oldest", appStats.DroppedNewestItems, appStats.DroppedOldestItems)
+	}
+}
+
+// Every item is either forwarded or counted as dropped, whatever the emitters race for
+func TestEmitQueueConcurrentEmitters(t *testing.T) {
+	for _, policy := range EmitPolicies {
+		t.Run(string(policy), func(t *testing.T) {
+			appStats := &AppStats{}
+			output := make(chan *OutputChannelItem)
+			queue := NewEmitQueue(output, 4, policy, 3, appStats)
+
+			const emitters = 8
+			const itemsPerEmitter = 500
+			var wg sync.WaitGroup
+			for e := 0; e < emitters; e++ {
+				wg.Add(1)
+				go func(e int) {
+					defer wg.Done()
+					for i := 0; i < itemsPerEmitter; i++ {
+						queue.Enqueue(newQueuedItem(e*itemsPerEmitter + i))
+					}
+				}(e)
+			}
+
+			forwarded := make(chan int)
+			go func() {
+				count := 0
+				for range output {
+					count++
+					if count%16 == 0 {
+						time.Sleep(time.Microsecond)
+					}
+				}
+				forwarded <- count
+			}()
+
+			wg.Wait()
+			queue.Close()
+
+			count := uint64(<-forwarded)
+			dropped := atomic.LoadUint64(&appStats.DroppedNewestItems) + atomic.LoadUint64(&appStats.DroppedOldestItems)
+			if count+dropped != emitters*itemsPerEmitter {
+				t.Errorf("expected %d items, got %d forwarded and %d dropped", emitters*itemsPerEmitter, count, dropped)
+			}
+			if policy == EmitPolicyBlock && dropped != 0 {
+				t.Errorf("expected no dropped items with the block policy, got %d", dropped)
+			}
+		})
+	}
+}
+
+func TestParseEmitPolicy(t *testing.T) {
+	for _, policy := range EmitPolicies {
+		if parsed, err := ParseEmitPolicy(string(policy)); err != nil || parsed != policy {
+			t.Errorf("expected %q, got %q (%v)", policy, parsed, err)
+		}
+	}
+	if _, err := ParseEmitPolicy("drop-all"); err == nil {
+		t.Error("expected an error parsing an unknown policy")
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MetricsContentType is the content type of the Prometheus text exposition format
+const MetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type MetricType string
+
+const (
+	CounterMetric MetricType = "counter"
+	GaugeMetric   MetricType = "gauge"
+	// SummaryMetric is written with the _sum and the _count samples only, without quantiles
+	SummaryMetric MetricType = "summary"
+)
+
+type Metric struct {
+	Name    string
+	Help    string
+	Type    MetricType
+	Samples []*MetricSample
+}
+
+type MetricSample struct {
+	// Suffix is appended to the name of the metric, e.g. _sum and _count of a summary
+	Suffix string
+	Labels map[string]string
+	Value  float64
+}
+
+func NewMetric(name string, metricType MetricType, help string) *Metric {
+	return &Metric{
+		Name: name,
+		Help: help,
+		Type: metricType,
+	}
+}
+
+// AddSample adds a sample, the labels are nil for the metrics that have a single sample
+func (m *Metric) AddSample(value float64, labels map[string]string) *Metric {
+	m.Samples = append(m.Samples, &MetricSample{Labels: labels, Value: value})
+	return m
+}
+
+// AddSummary adds the _sum and the _count samples of an observed quantity
+func (m *Metric) AddSummary(sum float64, count uint64, labels map[string]string) *Metric {
+	m.Samples = append(m.Samples,
+		&MetricSample{Suffix: "_sum", Labels: labels, Value: sum},
+		&MetricSample{Suffix: "_count", Labels: labels, Value: float64(count)},
+	)
+	return m
+}
+
+// WriteMetrics writes the metrics in the Prometheus text exposition format
+func WriteMetrics(w io.Writer, metrics []*Metric) error {
+	writer := bufio.NewWriter(w)
+	for _, metric := range metrics {
+		writer.WriteString("# HELP " + metric.Name + " " + escapeMetricHelp(metric.Help) + "\n")
+		writer.WriteString("# TYPE " + metric.Name + " " + string(metric.Type) + "\n")
+		for _, sample := range metric.Samples {
+			writer.WriteString(metric.Name + sample.Suffix + formatMetricLabels(sample.Labels) + " " + formatMetricValue(sample.Value) + "\n")
+		}
+	}
+	return writer.Flush()
+}
+
+func formatMetricLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, len(labels))
+	for _, name := range names {
+		pairs = append(pairs, name+"=\""+escapeMetricLabelValue(labels[name])+"\"")
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatMetricValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
+
+var metricHelpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+var metricLabelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeMetricHelp(help string) string {
+	return metricHelpReplacer.Replace(help)
+}
+
+func escapeMetricLabelValue(value string) string {
+	return metricLabelValueReplacer.Replace(value)
+}
@@ -1,5 +1,8 @@
 package api
 
 type TrafficFilteringOptions struct {
-	IgnoredUserAgents []string
+	IgnoredUserAgents    []string
+	GrpcDescriptorSets   [][]byte
+	EnableTcpRaw         bool
+	EnableTcpConnections bool
 }
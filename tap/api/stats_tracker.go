@@ -1,15 +1,20 @@
 package api
 
 import (
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
+// The dumps move the counters to the totals, the totals are read under the same lock so nothing is counted twice
+var dumpStatsLock sync.Mutex
+
 type AppStats struct {
 	StartTime                   time.Time `json:"-"`
 	ProcessedBytes              uint64    `json:"processedBytes"`
 	PacketsCount                uint64    `json:"packetsCount"`
 	TcpPacketsCount             uint64    `json:"tcpPacketsCount"`
+	UdpPacketsCount             uint64    `json:"udpPacketsCount"`
 	IgnoredPacketsCount         uint64    `json:"ignoredPacketsCount"`
 	ReassembledTcpPayloadsCount uint64    `json:"reassembledTcpPayloadsCount"`
 	TlsConnectionsCount         uint64    `json:"tlsConnectionsCount"`
@@ -18,6 +23,11 @@ type AppStats struct {
 	LiveTcpStreams              uint64    `json:"liveTcpStreams"`
 	IgnoredLastAckCount         uint64    `json:"ignoredLastAckCount"`
 	ThrottledPackets            uint64    `json:"throttledPackets"`
+	DroppedNewestItems          uint64    `json:"droppedNewestItems"`
+	DroppedOldestItems          uint64    `json:"droppedOldestItems"`
+	TlsChunksCount              uint64    `json:"tlsChunksCount"`
+	TlsLostChunksCount          uint64    `json:"tlsLostChunksCount"`
+	totals                      *AppStats
 }
 
 func (as *AppStats) IncMatchedPairs() {
@@ -37,6 +47,10 @@ func (as *AppStats) IncTcpPacketsCount() {
 	atomic.AddUint64(&as.TcpPacketsCount, 1)
 }
 
+func (as *AppStats) IncUdpPacketsCount() {
+	atomic.AddUint64(&as.UdpPacketsCount, 1)
+}
+
 func (as *AppStats) IncIgnoredPacketsCount() {
 	atomic.AddUint64(&as.IgnoredPacketsCount, 1)
 }
@@ -49,6 +63,14 @@ func (as *AppStats) IncThrottledPackets() {
 	atomic.AddUint64(&as.ThrottledPackets, 1)
 }
 
+func (as *AppStats) IncDroppedNewestItems() {
+	atomic.AddUint64(&as.DroppedNewestItems, 1)
+}
+
+func (as *AppStats) IncDroppedOldestItems() {
+	atomic.AddUint64(&as.DroppedOldestItems, 1)
+}
+
 func (as *AppStats) IncReassembledTcpPayloadsCount() {
 	atomic.AddUint64(&as.ReassembledTcpPayloadsCount, 1)
 }
@@ -57,6 +79,14 @@ func (as *AppStats) IncTlsConnectionsCount() {
 	atomic.AddUint64(&as.TlsConnectionsCount, 1)
 }
 
+func (as *AppStats) IncTlsChunksCount() {
+	atomic.AddUint64(&as.TlsChunksCount, 1)
+}
+
+func (as *AppStats) UpdateTlsLostChunksCount(lost uint64) {
+	atomic.AddUint64(&as.TlsLostChunksCount, lost)
+}
+
 func (as *AppStats) IncLiveTcpStreams() {
 	atomic.AddUint64(&as.LiveTcpStreams, 1)
 }
@@ -73,26 +103,68 @@ func (as *AppStats) SetStartTime(startTime time.Time) {
 	as.StartTime = startTime
 }
 
+// DumpStats returns the counters since the previous dump and resets them
 func (as *AppStats) DumpStats() *AppStats {
-	currentAppStats := &AppStats{StartTime: as.StartTime}
+	dumpStatsLock.Lock()
+	defer dumpStatsLock.Unlock()
+
+	if as.totals == nil {
+		as.totals = &AppStats{}
+	}
 
-	currentAppStats.ProcessedBytes = resetUint64(&as.ProcessedBytes)
-	currentAppStats.PacketsCount = resetUint64(&as.PacketsCount)
-	currentAppStats.TcpPacketsCount = resetUint64(&as.TcpPacketsCount)
-	currentAppStats.IgnoredPacketsCount = resetUint64(&as.IgnoredPacketsCount)
-	currentAppStats.ReassembledTcpPayloadsCount = resetUint64(&as.ReassembledTcpPayloadsCount)
-	currentAppStats.TlsConnectionsCount = resetUint64(&as.TlsConnectionsCount)
-	currentAppStats.MatchedPairs = resetUint64(&as.MatchedPairs)
-	currentAppStats.DroppedTcpStreams = resetUint64(&as.DroppedTcpStreams)
-	currentAppStats.IgnoredLastAckCount = resetUint64(&as.IgnoredLastAckCount)
-	currentAppStats.ThrottledPackets = resetUint64(&as.ThrottledPackets)
-	currentAppStats.LiveTcpStreams = as.LiveTcpStreams
+	currentAppStats := &AppStats{StartTime: as.StartTime}
+	current := currentAppStats.counters()
+	totals := as.totals.counters()
+	for i, counter := range as.counters() {
+		*current[i] = resetUint64(counter)
+		*totals[i] += *current[i]
+	}
+	currentAppStats.LiveTcpStreams = atomic.LoadUint64(&as.LiveTcpStreams)
 
 	return currentAppStats
 }
 
-func resetUint64(ref *uint64) (val uint64) {
-	val = atomic.LoadUint64(ref)
-	atomic.StoreUint64(ref, 0)
-	return
+// Totals returns the counters since the start, regardless of the dumps
+func (as *AppStats) Totals() *AppStats {
+	dumpStatsLock.Lock()
+	defer dumpStatsLock.Unlock()
+
+	totalAppStats := &AppStats{StartTime: as.StartTime}
+	totals := totalAppStats.counters()
+	for i, counter := range as.counters() {
+		*totals[i] = atomic.LoadUint64(counter)
+	}
+	if as.totals != nil {
+		for i, dumped := range as.totals.counters() {
+			*totals[i] += *dumped
+		}
+	}
+	totalAppStats.LiveTcpStreams = atomic.LoadUint64(&as.LiveTcpStreams)
+
+	return totalAppStats
+}
+
+// counters are the stats that are reset by the dumps, LiveTcpStreams is a gauge so it isn't one of them
+func (as *AppStats) counters() []*uint64 {
+	return []*uint64{
+		&as.ProcessedBytes,
+		&as.PacketsCount,
+		&as.TcpPacketsCount,
+		&as.UdpPacketsCount,
+		&as.IgnoredPacketsCount,
+		&as.ReassembledTcpPayloadsCount,
+		&as.TlsConnectionsCount,
+		&as.MatchedPairs,
+		&as.DroppedTcpStreams,
+		&as.IgnoredLastAckCount,
+		&as.ThrottledPackets,
+		&as.DroppedNewestItems,
+		&as.DroppedOldestItems,
+		&as.TlsChunksCount,
+		&as.TlsLostChunksCount,
+	}
+}
+
+func resetUint64(ref *uint64) uint64 {
+	return atomic.SwapUint64(ref, 0)
 }
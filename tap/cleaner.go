@@ -20,18 +20,30 @@ type Cleaner struct {
 	stats             CleanerStats
 	statsMutex        sync.Mutex
 	streamsMap        api.TcpStreamMap
+	emitter           api.Emitter
+	stopChannel       chan struct{}
+	stoppedChannel    chan struct{}
+}
+
+// The streams that know their connection, the unanswered requests of the others have it in their keys
+type connectionStream interface {
+	GetConnectionInfo() *api.ConnectionInfo
+	GetEmitter() api.Emitter
 }
 
 func (cl *Cleaner) clean() {
 	startCleanTime := time.Now()
 
 	cl.streamsMap.Range(func(k, v interface{}) bool {
-		reqResMatchers := v.(api.TcpStream).GetReqResMatchers()
+		stream := v.(api.TcpStream)
+		reqResMatchers := stream.GetReqResMatchers()
 		for _, reqResMatcher := range reqResMatchers {
 			if reqResMatcher == nil {
 				continue
 			}
-			deleted := deleteOlderThan(reqResMatcher.GetMap(), startCleanTime.Add(-cl.connectionTimeout))
+			deleted := deleteOlderThan(reqResMatcher, startCleanTime.Add(-cl.connectionTimeout), func(item *api.OutputChannelItem) {
+				cl.emitUnanswered(stream, item)
+			})
 			cl.stats.deleted += deleted
 		}
 		return true
@@ -43,16 +55,31 @@ func (cl *Cleaner) clean() {
 }
 
 func (cl *Cleaner) start() {
+	cl.stopChannel = make(chan struct{})
+	cl.stoppedChannel = make(chan struct{})
+
 	go func() {
 		ticker := time.NewTicker(cl.cleanPeriod)
+		defer ticker.Stop()
+		defer close(cl.stoppedChannel)
 
 		for {
-			<-ticker.C
-			cl.clean()
+			select {
+			case <-ticker.C:
+				cl.clean()
+			case <-cl.stopChannel:
+				return
+			}
 		}
 	}()
 }
 
+// stop waits for a clean that is in progress to finish
+func (cl *Cleaner) stop() {
+	close(cl.stopChannel)
+	<-cl.stoppedChannel
+}
+
 func (cl *Cleaner) dumpStats() CleanerStats {
 	cl.statsMutex.Lock()
 
@@ -66,24 +93,58 @@ func (cl *Cleaner) dumpStats() CleanerStats {
 	return stats
 }
 
-func deleteOlderThan(matcherMap *sync.Map, t time.Time) int {
+// The requests that never got a response are emitted as unanswered before they are deleted
+func (cl *Cleaner) emitUnanswered(stream api.TcpStream, item *api.OutputChannelItem) {
+	emitter := cl.emitter
+	if connStream, ok := stream.(connectionStream); ok {
+		if item.ConnectionInfo == nil {
+			item.ConnectionInfo = connStream.GetConnectionInfo()
+		}
+		if streamEmitter := connStream.GetEmitter(); streamEmitter != nil {
+			emitter = streamEmitter
+		}
+	}
+
+	if item.ConnectionInfo == nil || emitter == nil {
+		return
+	}
+
+	item.Capture = stream.GetOrigin()
+	emitter.Emit(item)
+}
+
+func deleteOlderThan(matcher api.RequestResponseMatcher, t time.Time, emitUnanswered func(item *api.OutputChannelItem)) int {
 	numDeleted := 0
 
+	matcherMap := matcher.GetMap()
 	if matcherMap == nil {
 		return numDeleted
 	}
 
 	matcherMap.Range(func(key interface{}, value interface{}) bool {
-		message, _ := value.(*api.GenericMessage)
-		// TODO: Investigate the reason why `request` is `nil` in some rare occasion
-		if message != nil {
-			if message.CaptureTime.Before(t) {
-				matcherMap.Delete(key)
-				numDeleted++
-			}
+		if deleteMessageOlderThan(matcher, key, value, t, emitUnanswered) {
+			numDeleted++
 		}
 		return true
 	})
 
 	return numDeleted
 }
+
+// deleteMessageOlderThan deletes a message that Range loaded from the map of the matcher, if it's older than t
+func deleteMessageOlderThan(matcher api.RequestResponseMatcher, key interface{}, value interface{}, t time.Time, emitUnanswered func(item *api.OutputChannelItem)) bool {
+	message, _ := value.(*api.GenericMessage)
+	// TODO: Investigate the reason why `request` is `nil` in some rare occasion
+	if message == nil || !message.CaptureTime.Before(t) {
+		return false
+	}
+
+	// The message may have been paired meanwhile, then it's not deleted again
+	if _, loaded := matcher.GetMap().LoadAndDelete(key); !loaded {
+		return false
+	}
+	if item := matcher.NewUnansweredItem(key, value); item != nil {
+		emitUnanswered(item)
+	}
+	return true
+}
@@ -0,0 +1,241 @@
+package tap
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+var testProtocol = api.Protocol{
+	ProtocolSummary: api.ProtocolSummary{
+		Name:         "test",
+		Abbreviation: "TEST",
+	},
+}
+
+type testMatcher struct {
+	matcherMap *sync.Map
+}
+
+func (matcher *testMatcher) GetMap() *sync.Map {
+	return matcher.matcherMap
+}
+
+func (matcher *testMatcher) SetMaxTry(value int) {
+}
+
+func (matcher *testMatcher) NewUnansweredItem(key interface{}, value interface{}) *api.OutputChannelItem {
+	request, ok := value.(*api.GenericMessage)
+	if !ok || !request.IsRequest {
+		return nil
+	}
+	return api.NewUnansweredItem(testProtocol, request)
+}
+
+type testEmitter struct {
+	sync.Mutex
+	items []*api.OutputChannelItem
+}
+
+func (e *testEmitter) Emit(item *api.OutputChannelItem) {
+	e.Lock()
+	defer e.Unlock()
+	e.items = append(e.items, item)
+}
+
+// testStream is a stream without a connection, like the streams of the TLS tapper
+type testStream struct {
+	matcher *testMatcher
+}
+
+func (s *testStream) SetProtocol(protocol *api.Protocol) {}
+
+func (s *testStream) GetOrigin() api.Capture {
+	return api.Ebpf
+}
+
+func (s *testStream) GetReqResMatchers() []api.RequestResponseMatcher {
+	return []api.RequestResponseMatcher{s.matcher}
+}
+
+func (s *testStream) GetIsTapTarget() bool {
+	return true
+}
+
+func (s *testStream) GetIsClosed() bool {
+	return false
+}
+
+type testConnectionStream struct {
+	testStream
+	connectionInfo *api.ConnectionInfo
+	emitter        api.Emitter
+}
+
+func (s *testConnectionStream) GetOrigin() api.Capture {
+	return api.Pcap
+}
+
+func (s *testConnectionStream) GetConnectionInfo() *api.ConnectionInfo {
+	return s.connectionInfo
+}
+
+func (s *testConnectionStream) GetEmitter() api.Emitter {
+	return s.emitter
+}
+
+func newTestMessage(isRequest bool, captureTime time.Time) *api.GenericMessage {
+	return &api.GenericMessage{IsRequest: isRequest, CaptureTime: captureTime}
+}
+
+func TestCleanerEmitsOldRequests(t *testing.T) {
+	now := time.Now()
+	matcher := &testMatcher{matcherMap: &sync.Map{}}
+	matcher.matcherMap.Store("old-request", newTestMessage(true, now.Add(-time.Minute)))
+	matcher.matcherMap.Store("old-response", newTestMessage(false, now.Add(-time.Minute)))
+	matcher.matcherMap.Store("new-request", newTestMessage(true, now))
+
+	connectionInfo := &api.ConnectionInfo{ClientIP: "1", ClientPort: "40000", ServerIP: "2", ServerPort: "80"}
+	streamEmitter := &testEmitter{}
+	stream := &testConnectionStream{testStream: testStream{matcher: matcher}, connectionInfo: connectionInfo, emitter: streamEmitter}
+
+	cleanerEmitter := &testEmitter{}
+	cl := &Cleaner{emitter: cleanerEmitter}
+	emitUnanswered := func(item *api.OutputChannelItem) {
+		cl.emitUnanswered(stream, item)
+	}
+
+	deleted := deleteOlderThan(matcher, now.Add(-time.Second), emitUnanswered)
+	if deleted != 2 {
+		t.Errorf("expected the old request and response to be deleted, got %d deleted", deleted)
+	}
+
+	// A second clean finds nothing old, the request is not emitted again
+	if deleted = deleteOlderThan(matcher, now.Add(-time.Second), emitUnanswered); deleted != 0 {
+		t.Errorf("expected nothing to delete in the second clean, got %d deleted", deleted)
+	}
+
+	if _, ok := matcher.matcherMap.Load("new-request"); !ok {
+		t.Error("expected the new request to be kept")
+	}
+	if len(cleanerEmitter.items) != 0 {
+		t.Errorf("expected the items to go to the emitter of the stream, the cleaner got %d", len(cleanerEmitter.items))
+	}
+	if len(streamEmitter.items) != 1 {
+		t.Fatalf("expected only the old request to be emitted, got %d items", len(streamEmitter.items))
+	}
+
+	item := streamEmitter.items[0]
+	if !item.Pair.Request.IsRequest || !item.Pair.Request.CaptureTime.Equal(now.Add(-time.Minute)) {
+		t.Errorf("expected the old request, got %+v", item.Pair.Request)
+	}
+	if item.ConnectionInfo != connectionInfo {
+		t.Errorf("expected the connection of the stream, got %+v", item.ConnectionInfo)
+	}
+	if item.Capture != api.Pcap {
+		t.Errorf("expected the capture of the stream, got %s", item.Capture)
+	}
+}
+
+func TestCleanerEmitsWithoutConnectionStream(t *testing.T) {
+	now := time.Now()
+	matcher := &testMatcher{matcherMap: &sync.Map{}}
+	stream := &testStream{matcher: matcher}
+	cleanerEmitter := &testEmitter{}
+	cl := &Cleaner{emitter: cleanerEmitter}
+
+	// The connection is told by the key, like the datagrams do, or not at all
+	withConnection := api.NewUnansweredItem(testProtocol, newTestMessage(true, now))
+	withConnection.ConnectionInfo = &api.ConnectionInfo{ClientIP: "1", ClientPort: "40000", ServerIP: "2", ServerPort: "53"}
+	cl.emitUnanswered(stream, withConnection)
+	cl.emitUnanswered(stream, api.NewUnansweredItem(testProtocol, newTestMessage(true, now)))
+
+	if len(cleanerEmitter.items) != 1 || cleanerEmitter.items[0] != withConnection {
+		t.Fatalf("expected only the item with a connection on the emitter of the cleaner, got %d items", len(cleanerEmitter.items))
+	}
+	if withConnection.Capture != api.Ebpf {
+		t.Errorf("expected the capture of the stream, got %s", withConnection.Capture)
+	}
+}
+
+// The dissectors pair the requests while the cleaner ranges over the map, a request that was paired after Range
+// loaded it is not emitted
+func TestCleanerSkipsPairedRequest(t *testing.T) {
+	now := time.Now()
+	matcher := &testMatcher{matcherMap: &sync.Map{}}
+	matcher.matcherMap.Store("request", newTestMessage(true, now.Add(-time.Minute)))
+
+	var emitted []*api.OutputChannelItem
+	emitUnanswered := func(item *api.OutputChannelItem) {
+		emitted = append(emitted, item)
+	}
+
+	value, _ := matcher.matcherMap.Load("request")
+	if _, paired := matcher.matcherMap.LoadAndDelete("request"); !paired {
+		t.Fatal("expected the request to be paired")
+	}
+
+	if deleteMessageOlderThan(matcher, "request", value, now.Add(-time.Second), emitUnanswered) {
+		t.Error("expected the paired request not to be deleted again")
+	}
+	if len(emitted) != 0 {
+		t.Errorf("expected the paired request not to be emitted, got %d items", len(emitted))
+	}
+}
+
+// A request is either paired or emitted, while the pairing and the cleaning run at the same time
+func TestCleanerConcurrentPairing(t *testing.T) {
+	const requests = 1000
+
+	now := time.Now()
+	matcher := &testMatcher{matcherMap: &sync.Map{}}
+	for i := 0; i < requests; i++ {
+		// The size tells the requests apart
+		message := newTestMessage(true, now.Add(-time.Minute))
+		message.CaptureSize = i
+		matcher.matcherMap.Store(fmt.Sprint(i), message)
+	}
+
+	connectionInfo := &api.ConnectionInfo{ClientIP: "1", ClientPort: "40000", ServerIP: "2", ServerPort: "80"}
+	streamEmitter := &testEmitter{}
+	stream := &testConnectionStream{testStream: testStream{matcher: matcher}, connectionInfo: connectionInfo, emitter: streamEmitter}
+	cl := &Cleaner{}
+
+	paired := make(map[int]bool)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := requests - 1; i >= 0; i-- {
+			if value, loaded := matcher.matcherMap.LoadAndDelete(fmt.Sprint(i)); loaded {
+				paired[value.(*api.GenericMessage).CaptureSize] = true
+			}
+		}
+	}()
+
+	deleted := deleteOlderThan(matcher, now.Add(-time.Second), func(item *api.OutputChannelItem) {
+		cl.emitUnanswered(stream, item)
+	})
+	wg.Wait()
+
+	if deleted != len(streamEmitter.items) {
+		t.Errorf("expected the %d deleted requests to be emitted, got %d items", deleted, len(streamEmitter.items))
+	}
+	if deleted+len(paired) != requests {
+		t.Errorf("expected %d requests, got %d deleted and %d paired", requests, deleted, len(paired))
+	}
+	emitted := make(map[int]bool)
+	for _, item := range streamEmitter.items {
+		request := item.Pair.Request.CaptureSize
+		if paired[request] {
+			t.Errorf("expected the paired request %d not to be emitted", request)
+		}
+		if emitted[request] {
+			t.Errorf("expected the request %d to be emitted once", request)
+		}
+		emitted[request] = true
+	}
+}
@@ -2,14 +2,115 @@ package dbgctl
 
 import (
 	"os"
+	"sync/atomic"
 )
 
+// The debug control endpoint of the tappers, the requests carry the token of the session in TokenHeader
+const (
+	TokenHeader  = "X-Mizu-Debug-Token"
+	SwitchesPath = "/debug/switches"
+	ProfilePath  = "/debug/profile"
+	// PcapsPath lists the pcap files of the tapper, a file is fetched from PcapsPath/<name>
+	PcapsPath = "/debug/pcaps"
+
+	PcapFileSuffix = ".pcapng"
+)
+
+/* Switch disables a stage of the tapper while it's enabled, to bisect performance problems.
+ * The switches start from their environment variables and are toggled at runtime by the debug control endpoint.
+ * The checks are counted, so the stage counters tell how much went through the stage and how much skipped it.
+ */
+type Switch struct {
+	stage       string
+	envVar      string
+	startupOnly bool
+	enabled     int32
+	passed      uint64
+	skipped     uint64
+}
+
+type SwitchStatus struct {
+	Stage    string `json:"stage"`
+	EnvVar   string `json:"envVar"`
+	Disabled bool   `json:"disabled"`
+	// StartupOnly switches are read once at startup, toggling them takes effect after a restart
+	StartupOnly bool   `json:"startupOnly"`
+	Passed      uint64 `json:"passed"`
+	Skipped     uint64 `json:"skipped"`
+}
+
+type SwitchRequest struct {
+	Stage    string `json:"stage"`
+	Disabled bool   `json:"disabled"`
+}
+
+type PcapFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+func newSwitch(stage string, envVar string, startupOnly bool) *Switch {
+	s := &Switch{stage: stage, envVar: envVar, startupOnly: startupOnly}
+	s.Set(os.Getenv(envVar) == "true")
+	return s
+}
+
+// Enabled tells whether the stage is disabled, it's called on every pass through the stage
+func (s *Switch) Enabled() bool {
+	if atomic.LoadInt32(&s.enabled) == 1 {
+		atomic.AddUint64(&s.skipped, 1)
+		return true
+	}
+
+	atomic.AddUint64(&s.passed, 1)
+	return false
+}
+
+func (s *Switch) Set(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&s.enabled, value)
+}
+
+func (s *Switch) Status() *SwitchStatus {
+	return &SwitchStatus{
+		Stage:       s.stage,
+		EnvVar:      s.envVar,
+		Disabled:    atomic.LoadInt32(&s.enabled) == 1,
+		StartupOnly: s.startupOnly,
+		Passed:      atomic.LoadUint64(&s.passed),
+		Skipped:     atomic.LoadUint64(&s.skipped),
+	}
+}
+
 var (
-	MizuTapperDisablePcap              = os.Getenv("MIZU_DEBUG_DISABLE_PCAP") == "true"
-	MizuTapperDisableTcpReassembly     = os.Getenv("MIZU_DEBUG_DISABLE_TCP_REASSEMBLY") == "true"
-	MizuTapperDisableTcpStream         = os.Getenv("MIZU_DEBUG_DISABLE_TCP_STREAM") == "true"
-	MizuTapperDisableDissectors        = os.Getenv("MIZU_DEBUG_DISABLE_DISSECTORS") == "true"
-	MizuTapperDisableEmitting          = os.Getenv("MIZU_DEBUG_DISABLE_EMITTING") == "true"
-	MizuTapperDisableSending           = os.Getenv("MIZU_DEBUG_DISABLE_SENDING") == "true"
-	MizuTapperDisableNonHttpExtensions = os.Getenv("MIZU_DEBUG_DISABLE_NON_HTTP_EXTENSSION") == "true"
+	MizuTapperDisablePcap              = newSwitch("pcap", "MIZU_DEBUG_DISABLE_PCAP", false)
+	MizuTapperDisableTcpReassembly     = newSwitch("tcp-reassembly", "MIZU_DEBUG_DISABLE_TCP_REASSEMBLY", false)
+	MizuTapperDisableTcpStream         = newSwitch("tcp-stream", "MIZU_DEBUG_DISABLE_TCP_STREAM", false)
+	MizuTapperDisableDissectors        = newSwitch("dissectors", "MIZU_DEBUG_DISABLE_DISSECTORS", false)
+	MizuTapperDisableEmitting          = newSwitch("emitting", "MIZU_DEBUG_DISABLE_EMITTING", false)
+	MizuTapperDisableSending           = newSwitch("sending", "MIZU_DEBUG_DISABLE_SENDING", false)
+	MizuTapperDisableNonHttpExtensions = newSwitch("non-http-extensions", "MIZU_DEBUG_DISABLE_NON_HTTP_EXTENSSION", true)
 )
+
+// Switches are in the order of the stages a packet goes through
+var Switches = []*Switch{
+	MizuTapperDisablePcap,
+	MizuTapperDisableTcpReassembly,
+	MizuTapperDisableTcpStream,
+	MizuTapperDisableDissectors,
+	MizuTapperDisableEmitting,
+	MizuTapperDisableSending,
+	MizuTapperDisableNonHttpExtensions,
+}
+
+func GetSwitch(stage string) *Switch {
+	for _, s := range Switches {
+		if s.stage == stage {
+			return s
+		}
+	}
+	return nil
+}
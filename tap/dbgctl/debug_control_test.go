@@ -0,0 +1,56 @@
+package dbgctl
+
+import (
+	"testing"
+)
+
+func TestSwitchCounters(t *testing.T) {
+	s := newSwitch("test", "MIZU_DEBUG_TEST_SWITCH", false)
+
+	for i := 0; i < 3; i++ {
+		if s.Enabled() {
+			t.Fatal("expected the switch to start disabled without its environment variable")
+		}
+	}
+
+	s.Set(true)
+	for i := 0; i < 2; i++ {
+		if !s.Enabled() {
+			t.Fatal("expected the switch to be enabled after Set(true)")
+		}
+	}
+
+	status := s.Status()
+	if status.Stage != "test" || status.EnvVar != "MIZU_DEBUG_TEST_SWITCH" || !status.Disabled {
+		t.Errorf("unexpected status %+v", status)
+	}
+	if status.Passed != 3 || status.Skipped != 2 {
+		t.Errorf("expected 3 passed and 2 skipped, got %d passed and %d skipped", status.Passed, status.Skipped)
+	}
+
+	s.Set(false)
+	if s.Enabled() || s.Status().Disabled {
+		t.Error("expected the switch to be disabled after Set(false)")
+	}
+}
+
+func TestSwitchFromEnvironment(t *testing.T) {
+	t.Setenv("MIZU_DEBUG_TEST_SWITCH", "true")
+
+	s := newSwitch("test", "MIZU_DEBUG_TEST_SWITCH", true)
+	if !s.Status().Disabled || !s.Status().StartupOnly {
+		t.Errorf("expected a disabled startup only stage, got %+v", s.Status())
+	}
+}
+
+func TestGetSwitch(t *testing.T) {
+	for _, s := range Switches {
+		if GetSwitch(s.stage) != s {
+			t.Errorf("expected the switch of stage %s", s.stage)
+		}
+	}
+
+	if GetSwitch("unknown") != nil {
+		t.Error("expected no switch for an unknown stage")
+	}
+}
@@ -0,0 +1,174 @@
+package diagnose
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/up9inc/mizu/logger"
+	"github.com/up9inc/mizu/tap/dbgctl"
+)
+
+const (
+	defaultProfileSeconds = 30
+	maxProfileSeconds     = 300
+)
+
+/* NewDebugControlHandler serves the debug control endpoint of a tapper:
+ * GET on the switches path lists the switches of the stages with their counters,
+ * POST on it with a dbgctl.SwitchRequest toggles a stage,
+ * GET on the profile path with type=cpu|heap and seconds=N returns a pprof profile,
+ * GET on the pcaps path lists the pcap files written to pcapDir and GET on a file under it returns the file.
+ * Every request has to carry the token of the session.
+ */
+func NewDebugControlHandler(token string, pcapDir string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(dbgctl.SwitchesPath, handleSwitches)
+	mux.HandleFunc(dbgctl.ProfilePath, handleProfile)
+	mux.HandleFunc(dbgctl.PcapsPath, func(w http.ResponseWriter, r *http.Request) {
+		handlePcaps(w, r, pcapDir)
+	})
+	mux.HandleFunc(dbgctl.PcapsPath+"/", func(w http.ResponseWriter, r *http.Request) {
+		handlePcapFile(w, r, pcapDir)
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get(dbgctl.TokenHeader)), []byte(token)) != 1 {
+			http.Error(w, "invalid debug control token", http.StatusUnauthorized)
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+func handleSwitches(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var request dbgctl.SwitchRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, fmt.Sprintf("invalid switch request: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		s := dbgctl.GetSwitch(request.Stage)
+		if s == nil {
+			http.Error(w, fmt.Sprintf("unknown stage %q", request.Stage), http.StatusNotFound)
+			return
+		}
+
+		s.Set(request.Disabled)
+		logger.Log.Infof("Debug control - stage %s disabled: %v", request.Stage, request.Disabled)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	statuses := make([]*dbgctl.SwitchStatus, 0, len(dbgctl.Switches))
+	for _, s := range dbgctl.Switches {
+		statuses = append(statuses, s.Status())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(statuses); err != nil {
+		logger.Log.Errorf("Error writing the debug control switches: %v", err)
+	}
+}
+
+func handleProfile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	seconds := defaultProfileSeconds
+	if value := r.URL.Query().Get("seconds"); value != "" {
+		var err error
+		if seconds, err = strconv.Atoi(value); err != nil || seconds < 1 || seconds > maxProfileSeconds {
+			http.Error(w, fmt.Sprintf("seconds must be between 1 and %d", maxProfileSeconds), http.StatusBadRequest)
+			return
+		}
+	}
+
+	// The profile is buffered, so a failure is reported with a status rather than as a truncated profile
+	var profile bytes.Buffer
+	var err error
+	switch profileType := r.URL.Query().Get("type"); profileType {
+	case "cpu", "":
+		logger.Log.Infof("Debug control - profiling the CPU for %d seconds", seconds)
+		err = WriteCpuProfile(&profile, time.Duration(seconds)*time.Second)
+	case "heap":
+		err = WriteHeapProfile(&profile)
+	default:
+		http.Error(w, fmt.Sprintf("unknown profile type %q, expected cpu or heap", profileType), http.StatusBadRequest)
+		return
+	}
+
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error profiling: %v", err), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if _, err := w.Write(profile.Bytes()); err != nil {
+		logger.Log.Errorf("Error writing the profile: %v", err)
+	}
+}
+
+func handlePcaps(w http.ResponseWriter, r *http.Request, pcapDir string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if pcapDir == "" {
+		http.Error(w, "writing pcap files is disabled", http.StatusNotFound)
+		return
+	}
+
+	entries, err := ioutil.ReadDir(pcapDir)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error listing the pcap files: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	files := make([]*dbgctl.PcapFile, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), dbgctl.PcapFileSuffix) {
+			files = append(files, &dbgctl.PcapFile{Name: entry.Name(), Size: entry.Size()})
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(files); err != nil {
+		logger.Log.Errorf("Error writing the pcap files: %v", err)
+	}
+}
+
+func handlePcapFile(w http.ResponseWriter, r *http.Request, pcapDir string) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if pcapDir == "" {
+		http.Error(w, "writing pcap files is disabled", http.StatusNotFound)
+		return
+	}
+
+	// Only the pcap files right in the directory are served
+	name := strings.TrimPrefix(r.URL.Path, dbgctl.PcapsPath+"/")
+	if name != filepath.Base(name) || !strings.HasSuffix(name, dbgctl.PcapFileSuffix) {
+		http.Error(w, fmt.Sprintf("invalid pcap file %q", name), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	http.ServeFile(w, r, filepath.Join(pcapDir, name))
+}
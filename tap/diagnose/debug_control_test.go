@@ -0,0 +1,214 @@
+package diagnose
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/up9inc/mizu/tap/dbgctl"
+)
+
+const testToken = "test-token"
+
+func serveDebugControl(handler http.Handler, method string, target string, body string, token string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	if token != "" {
+		request.Header.Set(dbgctl.TokenHeader, token)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestDebugControlToken(t *testing.T) {
+	tests := []struct {
+		name           string
+		handlerToken   string
+		requestToken   string
+		expectedStatus int
+	}{
+		{"missing token", testToken, "", http.StatusUnauthorized},
+		{"wrong token", testToken, "wrong-token", http.StatusUnauthorized},
+		{"token prefix", testToken, testToken[:4], http.StatusUnauthorized},
+		{"no token in the session", "", "", http.StatusUnauthorized},
+		{"valid token", testToken, testToken, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := NewDebugControlHandler(test.handlerToken, "")
+			response := serveDebugControl(handler, http.MethodGet, dbgctl.SwitchesPath, "", test.requestToken)
+			if response.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, response.Code)
+			}
+		})
+	}
+}
+
+func TestDebugControlSwitches(t *testing.T) {
+	s := dbgctl.MizuTapperDisableEmitting
+	initial := s.Status().Disabled
+	t.Cleanup(func() { s.Set(initial) })
+
+	s.Set(false)
+	s.Enabled()
+	passed := s.Status().Passed
+
+	handler := NewDebugControlHandler(testToken, "")
+	response := serveDebugControl(handler, http.MethodPost, dbgctl.SwitchesPath, `{"stage": "emitting", "disabled": true}`, testToken)
+	if response.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d: %s", http.StatusOK, response.Code, response.Body.String())
+	}
+	if !s.Enabled() {
+		t.Error("expected the emitting stage to be disabled")
+	}
+
+	var statuses []*dbgctl.SwitchStatus
+	if err := json.Unmarshal(response.Body.Bytes(), &statuses); err != nil {
+		t.Fatalf("error parsing the switches: %v", err)
+	}
+	if len(statuses) != len(dbgctl.Switches) {
+		t.Fatalf("expected the %d switches, got %d", len(dbgctl.Switches), len(statuses))
+	}
+
+	var status *dbgctl.SwitchStatus
+	for _, candidate := range statuses {
+		if candidate.Stage == "emitting" {
+			status = candidate
+		}
+	}
+	if status == nil {
+		t.Fatal("expected the status of the emitting stage")
+	}
+	if !status.Disabled || status.EnvVar != "MIZU_DEBUG_DISABLE_EMITTING" || status.Passed != passed {
+		t.Errorf("unexpected status %+v, expected a disabled stage with %d passed", status, passed)
+	}
+
+	response = serveDebugControl(handler, http.MethodPost, dbgctl.SwitchesPath, `{"stage": "emitting", "disabled": false}`, testToken)
+	if response.Code != http.StatusOK || s.Enabled() {
+		t.Errorf("expected the emitting stage to be enabled again, got status %d", response.Code)
+	}
+}
+
+func TestDebugControlSwitchErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+	}{
+		{"unknown stage", http.MethodPost, `{"stage": "unknown", "disabled": true}`, http.StatusNotFound},
+		{"invalid request", http.MethodPost, `{"stage": `, http.StatusBadRequest},
+		{"method not allowed", http.MethodDelete, "", http.StatusMethodNotAllowed},
+	}
+
+	handler := NewDebugControlHandler(testToken, "")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := serveDebugControl(handler, test.method, dbgctl.SwitchesPath, test.body, testToken)
+			if response.Code != test.expectedStatus {
+				t.Errorf("expected status %d, got %d", test.expectedStatus, response.Code)
+			}
+		})
+	}
+}
+
+func TestDebugControlProfileSeconds(t *testing.T) {
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expectedError  string
+	}{
+		{"zero seconds", "?seconds=0", http.StatusBadRequest, "seconds must be between"},
+		{"negative seconds", "?seconds=-1", http.StatusBadRequest, "seconds must be between"},
+		{"too many seconds", "?seconds=301", http.StatusBadRequest, "seconds must be between"},
+		{"not a number", "?seconds=ten", http.StatusBadRequest, "seconds must be between"},
+		// The bounds are accepted, the unknown type fails right after them instead of profiling for minutes
+		{"one second", "?seconds=1&type=unknown", http.StatusBadRequest, "unknown profile type"},
+		{"max seconds", "?seconds=300&type=unknown", http.StatusBadRequest, "unknown profile type"},
+		{"heap", "?type=heap", http.StatusOK, ""},
+	}
+
+	handler := NewDebugControlHandler(testToken, "")
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			response := serveDebugControl(handler, http.MethodGet, dbgctl.ProfilePath+test.query, "", testToken)
+			if response.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", test.expectedStatus, response.Code, response.Body.String())
+			}
+			if !strings.Contains(response.Body.String(), test.expectedError) {
+				t.Errorf("expected the error %q, got %q", test.expectedError, response.Body.String())
+			}
+		})
+	}
+}
+
+func TestDebugControlPcapFile(t *testing.T) {
+	root := t.TempDir()
+	pcapDir := filepath.Join(root, "pcaps")
+	if err := os.Mkdir(pcapDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	content := []byte("pcapng content")
+	paths := []string{
+		filepath.Join(pcapDir, "capture.pcapng"),
+		filepath.Join(pcapDir, "notes.txt"),
+		filepath.Join(root, "secret.pcapng"),
+	}
+	for _, path := range paths {
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"pcap file", dbgctl.PcapsPath + "/capture.pcapng", http.StatusOK},
+		{"parent directory", dbgctl.PcapsPath + "/../secret.pcapng", http.StatusBadRequest},
+		{"sub directory", dbgctl.PcapsPath + "/sub/capture.pcapng", http.StatusBadRequest},
+		{"no pcapng suffix", dbgctl.PcapsPath + "/notes.txt", http.StatusBadRequest},
+		{"pcapng prefix", dbgctl.PcapsPath + "/capture.pcapng.txt", http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			// The handler is called directly, the mux would redirect the ../ path before it
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			request.URL.Path = test.path
+			response := httptest.NewRecorder()
+			handlePcapFile(response, request, pcapDir)
+
+			if response.Code != test.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", test.expectedStatus, response.Code, response.Body.String())
+			}
+			if test.expectedStatus == http.StatusOK && !bytes.Equal(response.Body.Bytes(), content) {
+				t.Errorf("expected the content of the file, got %q", response.Body.String())
+			}
+		})
+	}
+
+	t.Run("pcap files", func(t *testing.T) {
+		handler := NewDebugControlHandler(testToken, pcapDir)
+		response := serveDebugControl(handler, http.MethodGet, dbgctl.PcapsPath, "", testToken)
+
+		var pcapFiles []*dbgctl.PcapFile
+		if err := json.Unmarshal(response.Body.Bytes(), &pcapFiles); err != nil {
+			t.Fatalf("error parsing the pcap files: %v", err)
+		}
+		if len(pcapFiles) != 1 || pcapFiles[0].Name != "capture.pcapng" || pcapFiles[0].Size != int64(len(content)) {
+			t.Errorf("expected only capture.pcapng, got %+v", pcapFiles)
+		}
+	})
+}
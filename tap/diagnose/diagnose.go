@@ -2,6 +2,7 @@ package diagnose
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"runtime/pprof"
@@ -68,9 +69,21 @@ func DumpMemoryProfile(filename string) error {
 
 	defer f.Close()
 
-	if err := pprof.WriteHeapProfile(f); err != nil {
+	return WriteHeapProfile(f)
+}
+
+func WriteHeapProfile(w io.Writer) error {
+	runtime.GC() // get up-to-date statistics
+	return pprof.WriteHeapProfile(w)
+}
+
+// WriteCpuProfile profiles the CPU for the duration, only one CPU profile can run at a time
+func WriteCpuProfile(w io.Writer, duration time.Duration) error {
+	if err := pprof.StartCPUProfile(w); err != nil {
 		return err
 	}
 
+	time.Sleep(duration)
+	pprof.StopCPUProfile()
 	return nil
 }
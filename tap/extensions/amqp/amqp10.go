@@ -0,0 +1,312 @@
+package amqp
+
+import (
+	"bufio"
+	"fmt"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// The dispositions may settle a range of deliveries, only this many of them are paired
+const maxAmqp10DispositionRange = 1024
+
+type amqp10Link struct {
+	name    string
+	address string
+}
+
+// The channels are numbered by each side independently, so a session is known by the channel
+// of the side that began it
+type amqp10Session struct {
+	key   uint64
+	links map[uint64]*amqp10Link
+}
+
+// A transfer whose message continues in the next frames
+type amqp10Delivery struct {
+	transfer map[string]interface{}
+	payload  []byte
+}
+
+// The state of one direction of an AMQP 1.0 connection
+type amqp10Dissector struct {
+	reader        api.TcpReader
+	reqResMatcher *requestResponseMatcher
+	isClient      bool
+	ident         string
+	sessions      map[uint16]*amqp10Session
+	deliveries    map[string]*amqp10Delivery
+}
+
+func dissectAmqp10(b *bufio.Reader, reader api.TcpReader) error {
+	tcpID := reader.GetTcpID()
+	d := &amqp10Dissector{
+		reader:        reader,
+		reqResMatcher: reader.GetReqResMatcher().(*requestResponseMatcher),
+		isClient:      reader.GetIsClient(),
+		sessions:      make(map[uint16]*amqp10Session),
+		deliveries:    make(map[string]*amqp10Delivery),
+	}
+	if d.isClient {
+		d.ident = fmt.Sprintf("%s_%s_%s_%s", tcpID.SrcIP, tcpID.DstIP, tcpID.SrcPort, tcpID.DstPort)
+	} else {
+		d.ident = fmt.Sprintf("%s_%s_%s_%s", tcpID.DstIP, tcpID.SrcIP, tcpID.DstPort, tcpID.SrcPort)
+	}
+
+	for {
+		frame, err := readAmqp10Frame(b)
+		if err != nil {
+			return err
+		}
+
+		// Heartbeat
+		if len(frame.Body) == 0 {
+			continue
+		}
+
+		decoder := newAmqp10Decoder(frame.Body)
+		value, err := decoder.decode()
+		if err != nil {
+			return err
+		}
+		performative, ok := value.(*amqp10Described)
+		if !ok {
+			return ErrAmqp10Frame
+		}
+		code, ok := amqp10Code(performative.Descriptor)
+		if !ok {
+			return ErrAmqp10Frame
+		}
+		fields, ok := toAmqp10Value(performative).(map[string]interface{})
+		if !ok {
+			return ErrAmqp10Frame
+		}
+
+		reader.GetParent().SetProtocol(&amqp10Protocol)
+
+		if frame.Type == amqp10FrameTypeSasl {
+			d.handleSasl(code, fields)
+			continue
+		}
+
+		switch code {
+		case amqp10Open, amqp10Close:
+			method := amqp10Composites[code].Name
+			d.emit(d.isClient, fmt.Sprintf("%s_%s", d.ident, method), method, fields)
+
+		case amqp10Begin:
+			session := d.begin(frame.Channel, fields)
+			d.emit(d.isClient, fmt.Sprintf("%s_begin_%d", d.ident, session.key), "begin", fields)
+
+		case amqp10End:
+			session := d.session(frame.Channel)
+			delete(d.sessions, frame.Channel)
+			d.emit(d.isClient, fmt.Sprintf("%s_end_%d", d.ident, session.key), "end", fields)
+
+		case amqp10Attach:
+			session := d.session(frame.Channel)
+			link := d.attach(session, fields)
+			d.emit(d.isClient, fmt.Sprintf("%s_attach_%d_%s", d.ident, session.key, link.name), "attach", fields)
+
+		case amqp10Detach:
+			session := d.session(frame.Channel)
+			handle := toUint64(fields["handle"])
+			link, ok := session.links[handle]
+			if !ok {
+				link = &amqp10Link{name: fmt.Sprintf("%d", handle)}
+			}
+			delete(session.links, handle)
+			fields["name"] = link.name
+			fields["address"] = link.address
+			d.emit(d.isClient, fmt.Sprintf("%s_detach_%d_%s", d.ident, session.key, link.name), "detach", fields)
+
+		case amqp10Transfer:
+			d.transfer(frame.Channel, fields, frame.Body[decoder.pos:])
+
+		case amqp10Disposition:
+			d.disposition(frame.Channel, fields)
+		}
+	}
+}
+
+func (d *amqp10Dissector) emit(isRequest bool, ident string, method string, event interface{}) {
+	d.reqResMatcher.emitAmqp10Event(isRequest, ident, method, event, d.reader)
+}
+
+// The SASL layer is paired from the initial response of the client to the outcome
+func (d *amqp10Dissector) handleSasl(code uint64, fields map[string]interface{}) {
+	switch code {
+	case amqp10SaslInit:
+		// The credentials of the PLAIN mechanism are in the initial response
+		delete(fields, "initialResponse")
+		d.emit(true, fmt.Sprintf("%s_sasl", d.ident), "sasl-init", fields)
+	case amqp10SaslOutcome:
+		d.emit(false, fmt.Sprintf("%s_sasl", d.ident), "sasl-outcome", fields)
+	}
+}
+
+func (d *amqp10Dissector) session(channel uint16) *amqp10Session {
+	session, ok := d.sessions[channel]
+	if !ok {
+		// The session began before the capture
+		session = &amqp10Session{key: uint64(channel), links: make(map[uint64]*amqp10Link)}
+		d.sessions[channel] = session
+	}
+	return session
+}
+
+// The begin that answers the other side refers to its channel
+func (d *amqp10Dissector) begin(channel uint16, fields map[string]interface{}) *amqp10Session {
+	session := &amqp10Session{key: uint64(channel), links: make(map[uint64]*amqp10Link)}
+	if remoteChannel, ok := fields["remoteChannel"]; ok {
+		session.key = toUint64(remoteChannel)
+	}
+	d.sessions[channel] = session
+	return session
+}
+
+// The address of a link is the target of the sender and the source of the receiver
+func (d *amqp10Dissector) attach(session *amqp10Session, fields map[string]interface{}) *amqp10Link {
+	link := &amqp10Link{name: fmt.Sprintf("%v", fields["name"])}
+
+	terminals := []string{"target", "source"}
+	if role, _ := fields["role"].(bool); role {
+		terminals = []string{"source", "target"}
+	}
+	for _, terminal := range terminals {
+		if node, ok := fields[terminal].(map[string]interface{}); ok {
+			if address, ok := node["address"].(string); ok && address != "" {
+				link.address = address
+				break
+			}
+		}
+	}
+	fields["address"] = link.address
+
+	session.links[toUint64(fields["handle"])] = link
+	return link
+}
+
+func (d *amqp10Dissector) transfer(channel uint16, fields map[string]interface{}, payload []byte) {
+	session := d.session(channel)
+	handle := toUint64(fields["handle"])
+	key := fmt.Sprintf("%d_%d", channel, handle)
+
+	delivery, ok := d.deliveries[key]
+	if !ok {
+		delivery = &amqp10Delivery{transfer: fields}
+	}
+	if len(delivery.payload)+len(payload) <= maxAmqp10MessageSize {
+		delivery.payload = append(delivery.payload, payload...)
+	}
+
+	if aborted, _ := fields["aborted"].(bool); aborted {
+		delete(d.deliveries, key)
+		return
+	}
+	if more, _ := fields["more"].(bool); more {
+		d.deliveries[key] = delivery
+		return
+	}
+	delete(d.deliveries, key)
+
+	// The continuations may settle the delivery
+	transfer := delivery.transfer
+	if settled, ok := fields["settled"]; ok {
+		transfer["settled"] = settled
+	}
+	if link, ok := session.links[handle]; ok {
+		transfer["name"] = link.name
+		transfer["address"] = link.address
+	}
+	transfer["message"] = decodeAmqp10Message(delivery.payload)
+
+	ident := fmt.Sprintf("%s_transfer_%d_%t_%d", d.ident, session.key, d.isClient, toUint64(transfer["deliveryId"]))
+	d.emit(true, ident, "transfer", transfer)
+
+	// The deliveries settled by the sender are not disposed
+	if settled, _ := transfer["settled"].(bool); settled {
+		d.emit(false, ident, emptyMethod, &emptyResponse{})
+	}
+}
+
+// The receiver disposes the deliveries of the other side
+func (d *amqp10Dissector) disposition(channel uint16, fields map[string]interface{}) {
+	if role, _ := fields["role"].(bool); !role {
+		return
+	}
+	session := d.session(channel)
+
+	first := toUint64(fields["first"])
+	last := first
+	if _, ok := fields["last"]; ok {
+		last = toUint64(fields["last"])
+	}
+	if last < first {
+		return
+	}
+	if last-first >= maxAmqp10DispositionRange {
+		last = first + maxAmqp10DispositionRange - 1
+	}
+
+	for id := first; id <= last; id++ {
+		ident := fmt.Sprintf("%s_transfer_%d_%t_%d", d.ident, session.key, !d.isClient, id)
+		d.emit(false, ident, "disposition", fields)
+	}
+}
+
+// Decodes the sections of a message. The data and the sequence sections may repeat.
+func decodeAmqp10Message(payload []byte) map[string]interface{} {
+	message := make(map[string]interface{})
+	var data []byte
+	var sequence []interface{}
+
+	decoder := newAmqp10Decoder(payload)
+	for decoder.remaining() > 0 {
+		value, err := decoder.decode()
+		if err != nil {
+			// The message is truncated
+			break
+		}
+		section, ok := value.(*amqp10Described)
+		if !ok {
+			break
+		}
+		code, _ := amqp10Code(section.Descriptor)
+		switch code {
+		case amqp10Header, amqp10Properties:
+			message[amqp10Composites[code].Name] = toAmqp10Value(section)
+		case amqp10Data:
+			if b, ok := section.Value.([]byte); ok {
+				data = append(data, b...)
+			}
+		case amqp10Sequence:
+			if list, ok := toAmqp10Value(section.Value).([]interface{}); ok {
+				sequence = append(sequence, list...)
+			}
+		default:
+			if name, ok := amqp10Sections[code]; ok {
+				message[name] = toAmqp10Value(section.Value)
+			}
+		}
+	}
+
+	if data != nil {
+		message["data"] = data
+	}
+	if sequence != nil {
+		message["amqpSequence"] = sequence
+	}
+	return message
+}
+
+func toUint64(value interface{}) uint64 {
+	switch v := value.(type) {
+	case uint64:
+		return v
+	case int64:
+		return uint64(v)
+	default:
+		return 0
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 	"strconv"
+	"unicode"
 
 	"github.com/up9inc/mizu/logger"
 	"github.com/up9inc/mizu/tap/api"
@@ -970,3 +971,158 @@ func representEmpty(event map[string]interface{}) []interface{} {
 
 	return rep
 }
+
+var amqp10Methods = map[string]bool{
+	"open":         true,
+	"begin":        true,
+	"attach":       true,
+	"transfer":     true,
+	"detach":       true,
+	"end":          true,
+	"close":        true,
+	"sasl-init":    true,
+	"disposition":  true,
+	"sasl-outcome": true,
+}
+
+func isAmqp10Method(method string) bool {
+	return amqp10Methods[method]
+}
+
+func summarizeAmqp10(entry *api.Entry) (summary string, summaryQuery string) {
+	var field string
+	switch entry.Request["method"].(string) {
+	case "open":
+		field = "containerId"
+	case "attach", "detach", "transfer":
+		field = "address"
+	case "sasl-init":
+		field = "mechanism"
+	default:
+		return
+	}
+
+	summary, _ = entry.Request[field].(string)
+	summaryQuery = fmt.Sprintf(`request.%s == "%s"`, field, summary)
+	return
+}
+
+// The message and the composites, like the source and the target of a link, are represented in their own sections
+func representAmqp10(event map[string]interface{}, selectorPrefix string) []interface{} {
+	rep := make([]interface{}, 0)
+	if event["method"] == emptyMethod {
+		return rep
+	}
+
+	nested := make([]string, 0)
+	details := make([]api.TableData, 0)
+	for name, value := range event {
+		if name == "method" || name == "message" {
+			continue
+		}
+		if _, ok := value.(map[string]interface{}); ok {
+			nested = append(nested, name)
+			continue
+		}
+		details = append(details, api.TableData{
+			Name:     name,
+			Value:    formatAmqp10TableValue(value),
+			Selector: fmt.Sprintf("%s%s", selectorPrefix, name),
+		})
+	}
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].Name < details[j].Name
+	})
+	detailsMarshaled, _ := json.Marshal(details)
+	rep = append(rep, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Details",
+		Data:  string(detailsMarshaled),
+	})
+
+	sort.Strings(nested)
+	for _, name := range nested {
+		rep = representAmqp10Table(rep, name, event[name].(map[string]interface{}), fmt.Sprintf("%s%s", selectorPrefix, name))
+	}
+
+	message, ok := event["message"].(map[string]interface{})
+	if !ok {
+		return rep
+	}
+
+	for _, name := range []string{"header", "deliveryAnnotations", "messageAnnotations", "properties", "applicationProperties", "footer"} {
+		if section, ok := message[name].(map[string]interface{}); ok {
+			rep = representAmqp10Table(rep, name, section, fmt.Sprintf("%smessage.%s", selectorPrefix, name))
+		}
+	}
+
+	contentType := ""
+	if properties, ok := message["properties"].(map[string]interface{}); ok {
+		contentType, _ = properties["contentType"].(string)
+	}
+	if data, ok := message["data"].(string); ok {
+		rep = append(rep, api.SectionData{
+			Type:     api.BODY,
+			Title:    "Body",
+			Encoding: "base64",
+			MimeType: contentType,
+			Data:     data,
+			Selector: fmt.Sprintf("%smessage.data", selectorPrefix),
+		})
+	}
+	for _, name := range []string{"amqpValue", "amqpSequence"} {
+		if value, ok := message[name]; ok {
+			rep = append(rep, api.SectionData{
+				Type:     api.BODY,
+				Title:    "Body",
+				MimeType: contentType,
+				Data:     formatAmqp10TableValue(value),
+				Selector: fmt.Sprintf("%smessage.%s", selectorPrefix, name),
+			})
+		}
+	}
+
+	return rep
+}
+
+func representAmqp10Table(rep []interface{}, name string, fields map[string]interface{}, selector string) []interface{} {
+	table := make([]api.TableData, 0)
+	for key, value := range fields {
+		table = append(table, api.TableData{
+			Name:     key,
+			Value:    formatAmqp10TableValue(value),
+			Selector: fmt.Sprintf(`%s["%s"]`, selector, key),
+		})
+	}
+	sort.Slice(table, func(i, j int) bool {
+		return table[i].Name < table[j].Name
+	})
+	tableMarshaled, _ := json.Marshal(table)
+	return append(rep, api.SectionData{
+		Type:  api.TABLE,
+		Title: amqp10Title(name),
+		Data:  string(tableMarshaled),
+	})
+}
+
+func formatAmqp10TableValue(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	marshaled, _ := json.Marshal(value)
+	return string(marshaled)
+}
+
+// Turns the camel case names of the fields into titles, e.g. `applicationProperties` into `Application Properties`
+func amqp10Title(name string) string {
+	title := make([]rune, 0, len(name))
+	for i, r := range name {
+		if i == 0 {
+			r = unicode.ToUpper(r)
+		} else if unicode.IsUpper(r) {
+			title = append(title, ' ')
+		}
+		title = append(title, r)
+	}
+	return string(title)
+}
@@ -28,8 +28,25 @@ var protocol = api.Protocol{
 	Priority:        1,
 }
 
+var amqp10Protocol = api.Protocol{
+	ProtocolSummary: api.ProtocolSummary{
+		Name:         "amqp",
+		Version:      "1.0",
+		Abbreviation: "AMQP",
+	},
+	LongName:        "Advanced Message Queuing Protocol 1.0",
+	Macro:           "amqp",
+	BackgroundColor: "#ff6600",
+	ForegroundColor: "#ffffff",
+	FontSize:        12,
+	ReferenceLink:   "http://docs.oasis-open.org/amqp/core/v1.0/os/amqp-core-overview-v1.0-os.html",
+	Ports:           []string{"5671", "5672"},
+	Priority:        1,
+}
+
 var protocolsMap = map[string]*api.Protocol{
-	protocol.ToString(): &protocol,
+	protocol.ToString():       &protocol,
+	amqp10Protocol.ToString(): &amqp10Protocol,
 }
 
 type dissecting string
@@ -46,7 +63,16 @@ func (d dissecting) Ping() {
 	log.Printf("pong %s", protocol.Name)
 }
 
+func (d dissecting) Probe(peek []byte, isClient bool) api.Confidence {
+	return probe(peek, isClient)
+}
+
 func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.TrafficFilteringOptions) error {
+	// Both sides of AMQP 1.0 start with its protocol header
+	if header, err := b.Peek(amqp10FrameHeaderSize); err == nil && isAmqp10ProtocolHeader(header) {
+		return dissectAmqp10(b, reader)
+	}
+
 	r := AmqpReader{b}
 
 	var remaining int
@@ -276,7 +302,7 @@ func (d dissecting) Analyze(item *api.OutputChannelItem, resolvedSource string,
 	reqDetails["method"] = request["method"]
 	resDetails["method"] = response["method"]
 	return &api.Entry{
-		Protocol: protocol.ProtocolSummary,
+		Protocol: item.Protocol.ProtocolSummary,
 		Capture:  item.Capture,
 		Source: &api.TCP{
 			Name: resolvedSource,
@@ -306,6 +332,9 @@ func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
 	summaryQuery := ""
 	method := entry.Request["method"].(string)
 	methodQuery := fmt.Sprintf(`request.method == "%s"`, method)
+	if entry.Protocol.Version == amqp10Protocol.Version {
+		summary, summaryQuery = summarizeAmqp10(entry)
+	}
 	switch method {
 	case basicMethodMap[40]:
 		summary = entry.Request["exchange"].(string)
@@ -354,6 +383,11 @@ func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
 		summaryQuery = fmt.Sprintf(`request.consumerTag == "%s"`, summary)
 	}
 
+	statusQuery := ""
+	if api.IsUnanswered(entry.Response) {
+		statusQuery = api.UnansweredStatusQuery
+	}
+
 	return &api.BaseEntry{
 		Id:           entry.Id,
 		Protocol:     *protocolsMap[entry.Protocol.ToString()],
@@ -361,7 +395,7 @@ func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
 		Summary:      summary,
 		SummaryQuery: summaryQuery,
 		Status:       0,
-		StatusQuery:  "",
+		StatusQuery:  statusQuery,
 		Method:       method,
 		MethodQuery:  methodQuery,
 		Timestamp:    entry.Timestamp,
@@ -377,6 +411,19 @@ func (d dissecting) Represent(request map[string]interface{}, response map[strin
 	var repRequest []interface{}
 	var repResponse []interface{}
 
+	unanswered := api.IsUnanswered(response)
+
+	if isAmqp10Method(request["method"].(string)) {
+		representation["request"] = representAmqp10(request, `request.`)
+		if unanswered {
+			representation["response"] = api.RepresentUnanswered()
+		} else {
+			representation["response"] = representAmqp10(response, `response.`)
+		}
+		object, err = json.Marshal(representation)
+		return
+	}
+
 	switch request["method"].(string) {
 	case basicMethodMap[40]:
 		repRequest = representBasicPublish(request)
@@ -404,7 +451,8 @@ func (d dissecting) Represent(request map[string]interface{}, response map[strin
 		repRequest = representBasicCancel(request)
 	}
 
-	switch response["method"].(string) {
+	method, _ := response["method"].(string)
+	switch method {
 	case queueMethodMap[11]:
 		repResponse = representQueueDeclareOk(response)
 	case exchangeMethodMap[11]:
@@ -428,6 +476,9 @@ func (d dissecting) Represent(request map[string]interface{}, response map[strin
 	case emptyMethod:
 		repResponse = representEmpty(response)
 	}
+	if unanswered {
+		repResponse = api.RepresentUnanswered()
+	}
 
 	representation["request"] = repRequest
 	representation["response"] = repResponse
@@ -2,6 +2,9 @@ package amqp
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,6 +19,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/api/apitest"
 )
 
 const (
@@ -56,6 +60,28 @@ func TestPing(t *testing.T) {
 	dissector.Ping()
 }
 
+func TestProbe(t *testing.T) {
+	dissector := NewDissector().(api.Prober)
+
+	tests := []struct {
+		peek       string
+		isClient   bool
+		confidence api.Confidence
+	}{
+		{"AMQP\x00\x00\x09\x01", true, api.ConfidenceHigh},
+		{"AMQP\x03\x01\x00\x00", false, api.ConfidenceHigh},
+		{"AM", true, api.ConfidenceUnknown},
+		{"\x08\x00\x00\x00\x00\x00\x00\xce", false, api.ConfidenceHigh},
+		{"\x01\x00\x01\x00\x00\x00\x40\x00\x3c", false, api.ConfidenceLow},
+		{"\x00\x00\x00\x08\x02\x00\x00\x00", true, api.ConfidenceLow},
+		{"GET / HTTP/1.1\r\n", true, api.ConfidenceNone},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.confidence, dissector.Probe([]byte(test.peek), test.isClient), test.peek)
+	}
+}
+
 func TestDissect(t *testing.T) {
 	_, testUpdateEnabled := os.LookupEnv(testUpdate)
 
@@ -372,3 +398,221 @@ func TestRepresent(t *testing.T) {
 		}
 	}
 }
+
+// Encoders of the AMQP 1.0 types, the compound ones take the encoded items
+func amqp10Str(s string) []byte {
+	return append([]byte{0xa1, byte(len(s))}, s...)
+}
+
+func amqp10Sym(s string) []byte {
+	return append([]byte{0xa3, byte(len(s))}, s...)
+}
+
+func amqp10Bin(b []byte) []byte {
+	return append([]byte{0xa0, byte(len(b))}, b...)
+}
+
+func amqp10Uint(v uint32) []byte {
+	encoded := []byte{0x70, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(encoded[1:], v)
+	return encoded
+}
+
+func amqp10Ushort(v uint16) []byte {
+	encoded := []byte{0x60, 0, 0}
+	binary.BigEndian.PutUint16(encoded[1:], v)
+	return encoded
+}
+
+func amqp10Bool(v bool) []byte {
+	if v {
+		return []byte{0x41}
+	}
+	return []byte{0x42}
+}
+
+var amqp10Nil = []byte{0x40}
+
+func amqp10Compound(constructor byte, items ...[]byte) []byte {
+	body := bytes.Join(items, nil)
+	encoded := make([]byte, 9)
+	encoded[0] = constructor
+	binary.BigEndian.PutUint32(encoded[1:5], uint32(len(body)+4))
+	binary.BigEndian.PutUint32(encoded[5:9], uint32(len(items)))
+	return append(encoded, body...)
+}
+
+func amqp10List(items ...[]byte) []byte {
+	return amqp10Compound(0xd0, items...)
+}
+
+func amqp10Map(items ...[]byte) []byte {
+	return amqp10Compound(0xd1, items...)
+}
+
+func encodeAmqp10Described(code byte, value []byte) []byte {
+	return append([]byte{0x00, 0x53, code}, value...)
+}
+
+func encodeAmqp10Frame(frameType byte, channel uint16, body ...[]byte) []byte {
+	payload := bytes.Join(body, nil)
+	frame := make([]byte, 8)
+	binary.BigEndian.PutUint32(frame[0:4], uint32(len(payload)+8))
+	frame[4] = 2
+	frame[5] = frameType
+	binary.BigEndian.PutUint16(frame[6:8], channel)
+	return append(frame, payload...)
+}
+
+func TestDissectAmqp10(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 16)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+	stream := NewTcpStream(api.Pcap)
+
+	saslHeader := []byte("AMQP\x03\x01\x00\x00")
+	amqpHeader := []byte("AMQP\x00\x01\x00\x00")
+	accepted := encodeAmqp10Described(0x24, amqp10List())
+
+	message := bytes.Join([][]byte{
+		encodeAmqp10Described(0x70, amqp10List(amqp10Bool(true))),
+		encodeAmqp10Described(0x74, amqp10Map(amqp10Str("tenant"), amqp10Str("acme"))),
+		encodeAmqp10Described(0x75, amqp10Bin([]byte(`{"id":1}`))),
+	}, nil)
+
+	client := bytes.Join([][]byte{
+		saslHeader,
+		encodeAmqp10Frame(amqp10FrameTypeSasl, 0, encodeAmqp10Described(0x41, amqp10List(amqp10Sym("ANONYMOUS")))),
+		amqpHeader,
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 0, encodeAmqp10Described(0x10, amqp10List(amqp10Str("client"), amqp10Str("broker")))),
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 0, encodeAmqp10Described(0x11, amqp10List(amqp10Nil, amqp10Uint(0), amqp10Uint(100), amqp10Uint(100)))),
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 0, encodeAmqp10Described(0x12, amqp10List(
+			amqp10Str("sender"), amqp10Uint(0), amqp10Bool(false), amqp10Nil, amqp10Nil,
+			encodeAmqp10Described(0x28, amqp10List()),
+			encodeAmqp10Described(0x29, amqp10List(amqp10Str("orders"))),
+		))),
+		// The message spans two frames
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 0,
+			encodeAmqp10Described(0x14, amqp10List(amqp10Uint(0), amqp10Uint(0), amqp10Bin([]byte{1}), amqp10Uint(0), amqp10Bool(false), amqp10Bool(true))),
+			message[:10],
+		),
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 0, encodeAmqp10Described(0x14, amqp10List(amqp10Uint(0))), message[10:]),
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 0, encodeAmqp10Described(0x12, amqp10List(
+			amqp10Str("receiver"), amqp10Uint(1), amqp10Bool(true), amqp10Nil, amqp10Nil,
+			encodeAmqp10Described(0x28, amqp10List(amqp10Str("events"))),
+			encodeAmqp10Described(0x29, amqp10List()),
+		))),
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 0, encodeAmqp10Described(0x15, amqp10List(amqp10Bool(true), amqp10Uint(0), amqp10Nil, amqp10Bool(true), accepted))),
+		// Heartbeat
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 0),
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 0, encodeAmqp10Described(0x18, amqp10List())),
+	}, nil)
+
+	server := bytes.Join([][]byte{
+		saslHeader,
+		encodeAmqp10Frame(amqp10FrameTypeSasl, 0, encodeAmqp10Described(0x40, amqp10List(amqp10Sym("ANONYMOUS")))),
+		encodeAmqp10Frame(amqp10FrameTypeSasl, 0, encodeAmqp10Described(0x44, amqp10List([]byte{0x50, 0}))),
+		amqpHeader,
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 0, encodeAmqp10Described(0x10, amqp10List(amqp10Str("broker")))),
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 1, encodeAmqp10Described(0x11, amqp10List(amqp10Ushort(0), amqp10Uint(0), amqp10Uint(100), amqp10Uint(100)))),
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 1, encodeAmqp10Described(0x12, amqp10List(amqp10Str("sender"), amqp10Uint(0), amqp10Bool(true)))),
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 1, encodeAmqp10Described(0x12, amqp10List(
+			amqp10Str("receiver"), amqp10Uint(0), amqp10Bool(false), amqp10Nil, amqp10Nil,
+			encodeAmqp10Described(0x28, amqp10List(amqp10Str("events"))),
+		))),
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 1, encodeAmqp10Described(0x15, amqp10List(amqp10Bool(true), amqp10Uint(0), amqp10Uint(0), amqp10Bool(true), accepted))),
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 1,
+			encodeAmqp10Described(0x14, amqp10List(amqp10Uint(0), amqp10Uint(0), amqp10Bin([]byte{2}), amqp10Uint(0), amqp10Bool(false))),
+			encodeAmqp10Described(0x73, amqp10List(amqp10Str("m-1"), amqp10Nil, amqp10Nil, amqp10Nil, amqp10Nil, amqp10Nil, amqp10Sym("text/plain"))),
+			encodeAmqp10Described(0x77, amqp10Str("hello")),
+		),
+		encodeAmqp10Frame(amqp10FrameTypeAmqp, 0, encodeAmqp10Described(0x18, amqp10List())),
+	}, nil)
+
+	for _, side := range []struct {
+		data     []byte
+		isClient bool
+		tcpID    *api.TcpID
+	}{
+		{client, true, &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "1", DstPort: "5672"}},
+		{server, false, &api.TcpID{SrcIP: "2", DstIP: "1", SrcPort: "5672", DstPort: "1"}},
+	} {
+		reader := NewTcpReader(&api.ReadProgress{}, "", side.tcpID, time.Time{}, stream, side.isClient, false, nil, emitter, &api.CounterPair{}, reqResMatcher)
+		err := dissector.Dissect(bufio.NewReader(bytes.NewReader(side.data)), reader, &api.TrafficFilteringOptions{})
+		assert.Equal(t, io.EOF, err)
+	}
+
+	close(itemChannel)
+	entries := make(map[string]*api.Entry)
+	for item := range itemChannel {
+		// Simulate the round trip through the JSON encoding as the items do in Mizu
+		marshaled, err := json.Marshal(item)
+		assert.Nil(t, err)
+		var unmarshaled *api.OutputChannelItem
+		err = json.Unmarshal(marshaled, &unmarshaled)
+		assert.Nil(t, err)
+		entry := dissector.Analyze(unmarshaled, "", "", "")
+		assert.Equal(t, "1.0", entry.Protocol.Version)
+		assert.Equal(t, "1", entry.Source.IP)
+
+		representation, err := dissector.Represent(entry.Request, entry.Response)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, representation)
+		summary := dissector.Summarize(entry)
+		entries[summary.Method+" "+summary.Summary] = entry
+	}
+	assert.Len(t, entries, 8)
+
+	assert.Equal(t, "sasl-outcome", entries["sasl-init ANONYMOUS"].Response["method"])
+	assert.Equal(t, "broker", entries["open client"].Response["containerId"])
+	assert.Equal(t, float64(0), entries["begin "].Response["remoteChannel"])
+	assert.Contains(t, entries, "attach events")
+	assert.Contains(t, entries, "close ")
+
+	publish := entries["transfer orders"]
+	assert.Equal(t, "sender", publish.Request["name"])
+	published := publish.Request["message"].(map[string]interface{})
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(`{"id":1}`)), published["data"])
+	assert.Equal(t, "acme", published["applicationProperties"].(map[string]interface{})["tenant"])
+	assert.Equal(t, true, published["header"].(map[string]interface{})["durable"])
+	assert.Equal(t, "accepted", publish.Response["state"].(map[string]interface{})["outcome"])
+
+	deliver := entries["transfer events"]
+	delivered := deliver.Request["message"].(map[string]interface{})
+	assert.Equal(t, "hello", delivered["amqpValue"])
+	assert.Equal(t, "text/plain", delivered["properties"].(map[string]interface{})["contentType"])
+	assert.Equal(t, "disposition", deliver.Response["method"])
+}
+
+func TestNewUnansweredItem(t *testing.T) {
+	dissector := NewDissector()
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+	matcher := reqResMatcher.(*requestResponseMatcher)
+
+	// The AMQP 1.0 requests are told by their keys
+	declare := &QueueDeclare{Queue: "jobs", Durable: true, Arguments: Table{}}
+	assert.Nil(t, matcher.registerRequest("1_40000_2_5672_1_50_10", queueMethodMap[10], declare, time.Unix(0, 0), 32))
+	assert.Nil(t, matcher.registerRequest("1_40000_2_5672_1_attach", "attach", map[string]interface{}{"name": "jobs"}, time.Unix(0, 0), 32))
+
+	items := make(map[string]*api.OutputChannelItem)
+	reqResMatcher.GetMap().Range(func(key, value interface{}) bool {
+		if item := reqResMatcher.NewUnansweredItem(key, value); item != nil {
+			items[key.(string)] = item
+		}
+		return true
+	})
+	assert.Len(t, items, 2)
+	assert.Equal(t, amqp10Protocol.Version, items["1_40000_2_5672_1_attach"].Protocol.Version)
+
+	item := items["1_40000_2_5672_1_50_10"]
+	assert.Equal(t, protocol.Version, item.Protocol.Version)
+
+	item.ConnectionInfo = &api.ConnectionInfo{ClientIP: "1", ClientPort: "40000", ServerIP: "2", ServerPort: "5672"}
+	_, summary := apitest.AnalyzeUnanswered(t, dissector, item)
+	assert.Equal(t, queueMethodMap[10], summary.Method)
+	assert.Equal(t, "jobs", summary.Summary)
+}
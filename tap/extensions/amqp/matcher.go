@@ -46,6 +46,40 @@ func (matcher *requestResponseMatcher) emitEvent(isRequest bool, ident string, m
 	}
 }
 
+// The AMQP 1.0 events are told apart from the 0-9-1 ones by the protocol of their items
+func (matcher *requestResponseMatcher) emitAmqp10Event(isRequest bool, ident string, method string, event interface{}, reader api.TcpReader) {
+	var item *api.OutputChannelItem
+	if isRequest {
+		item = matcher.registerRequest(ident, method, event, reader.GetCaptureTime(), reader.GetReadProgress().Current())
+	} else {
+		item = matcher.registerResponse(ident, method, event, reader.GetCaptureTime(), reader.GetReadProgress().Current())
+	}
+
+	if item != nil {
+		item.Protocol = amqp10Protocol
+		tcpID := reader.GetTcpID()
+		if reader.GetIsClient() {
+			item.ConnectionInfo = &api.ConnectionInfo{
+				ClientIP:   tcpID.SrcIP,
+				ClientPort: tcpID.SrcPort,
+				ServerIP:   tcpID.DstIP,
+				ServerPort: tcpID.DstPort,
+				IsOutgoing: true,
+			}
+		} else {
+			item.ConnectionInfo = &api.ConnectionInfo{
+				ClientIP:   tcpID.DstIP,
+				ClientPort: tcpID.DstPort,
+				ServerIP:   tcpID.SrcIP,
+				ServerPort: tcpID.SrcPort,
+				IsOutgoing: true,
+			}
+		}
+		item.Capture = reader.GetParent().GetOrigin()
+		reader.GetEmitter().Emit(item)
+	}
+}
+
 func (matcher *requestResponseMatcher) registerRequest(ident string, method string, request interface{}, captureTime time.Time, captureSize int) *api.OutputChannelItem {
 	requestAMQPMessage := api.GenericMessage{
 		IsRequest:   true,
@@ -111,3 +145,15 @@ func (matcher *requestResponseMatcher) preparePair(requestAMQPMessage *api.Gener
 		},
 	}
 }
+
+func (matcher *requestResponseMatcher) NewUnansweredItem(key interface{}, value interface{}) *api.OutputChannelItem {
+	request, ok := value.(*api.GenericMessage)
+	if !ok || !request.IsRequest {
+		return nil
+	}
+
+	if payload, ok := request.Payload.(AMQPPayload); ok && isAmqp10Method(payload.Data.(*AMQPWrapper).Method) {
+		return api.NewUnansweredItem(amqp10Protocol, request)
+	}
+	return api.NewUnansweredItem(protocol, request)
+}
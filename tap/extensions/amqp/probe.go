@@ -0,0 +1,52 @@
+package amqp
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// Either side starts with a protocol header in AMQP 1.0, only the client does in AMQP 0-9-1
+func probe(peek []byte, isClient bool) api.Confidence {
+	if len(peek) < 4 {
+		if bytes.HasPrefix(amqp10ProtocolHeaderPrefix, peek) {
+			return api.ConfidenceUnknown
+		}
+		return probeFrame(peek)
+	}
+	if bytes.HasPrefix(peek, amqp10ProtocolHeaderPrefix) {
+		return api.ConfidenceHigh
+	}
+	return probeFrame(peek)
+}
+
+// The capture may start in the middle of a connection, where the frames are told apart by their headers
+func probeFrame(peek []byte) api.Confidence {
+	if len(peek) < amqp10FrameHeaderSize {
+		return api.ConfidenceUnknown
+	}
+
+	// AMQP 0-9-1: type (octet), channel (short), size (long), payload, frame-end
+	switch peek[0] {
+	case frameMethod, frameHeader, frameBody, frameHeartbeat:
+		size := int(binary.BigEndian.Uint32(peek[3:7]))
+		end := 7 + size
+		if end < len(peek) && peek[end] == frameEnd {
+			return api.ConfidenceHigh
+		}
+		if end >= len(peek) && size <= maxAmqp10FrameSize {
+			return api.ConfidenceLow
+		}
+	}
+
+	// AMQP 1.0: size (uint), doff (octet), type (octet), channel (ushort)
+	size := binary.BigEndian.Uint32(peek[0:4])
+	doff := uint32(peek[4]) * 4
+	if size <= maxAmqp10FrameSize && doff >= amqp10FrameHeaderSize && doff <= size &&
+		(peek[5] == amqp10FrameTypeAmqp || peek[5] == amqp10FrameTypeSasl) {
+		return api.ConfidenceLow
+	}
+
+	return api.ConfidenceNone
+}
@@ -0,0 +1,401 @@
+package amqp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+	"unicode/utf8"
+)
+
+/*
+AMQP 1.0 frames consist of a header (8 octets), an extended header and a frame body:
+
+  0             4      5      6         8                doff*4
+  +-------------+------+------+---------+  +------------+  +------------+
+  |    size     | doff | type | channel |  |  extended  |  |    body    |
+  +-------------+------+------+---------+  +------------+  +------------+
+       uint         octet  octet   ushort
+
+The body of an AMQP frame is a performative, which is followed by the payload in the case of a transfer.
+The body of a SASL frame is a SASL performative. A frame without a body is a heartbeat.
+*/
+
+const (
+	amqp10FrameHeaderSize = 8
+
+	amqp10FrameTypeAmqp = 0
+	amqp10FrameTypeSasl = 1
+
+	amqp10ProtocolIdAmqp = 0
+	amqp10ProtocolIdTls  = 2
+	amqp10ProtocolIdSasl = 3
+)
+
+var amqp10ProtocolHeaderPrefix = []byte("AMQP")
+
+var ErrAmqp10Frame = errors.New("Invalid AMQP 1.0 frame")
+var ErrAmqp10Type = errors.New("Invalid AMQP 1.0 type")
+
+type amqp10Frame struct {
+	Type    uint8
+	Channel uint16
+	Body    []byte
+}
+
+// The protocol headers are exchanged at the start of the connection and again after the SASL layer.
+// Only AMQP 1.0 (`AMQP 0 1 0 0`) and its SASL layer (`AMQP 3 1 0 0`) are accepted.
+func isAmqp10ProtocolHeader(header []byte) bool {
+	if len(header) < amqp10FrameHeaderSize || !bytes.Equal(header[:4], amqp10ProtocolHeaderPrefix) {
+		return false
+	}
+	switch header[4] {
+	case amqp10ProtocolIdAmqp, amqp10ProtocolIdTls, amqp10ProtocolIdSasl:
+	default:
+		return false
+	}
+	return header[5] == 1 && header[6] == 0 && header[7] == 0
+}
+
+// Reads the next frame, skipping the protocol headers
+func readAmqp10Frame(r *bufio.Reader) (*amqp10Frame, error) {
+	var scratch [amqp10FrameHeaderSize]byte
+
+	for {
+		if _, err := io.ReadFull(r, scratch[:]); err != nil {
+			return nil, err
+		}
+		if !bytes.Equal(scratch[:4], amqp10ProtocolHeaderPrefix) {
+			break
+		}
+		if !isAmqp10ProtocolHeader(scratch[:]) {
+			return nil, ErrAmqp10Frame
+		}
+	}
+
+	size := binary.BigEndian.Uint32(scratch[0:4])
+	doff := uint32(scratch[4]) * 4
+
+	if size > maxAmqp10FrameSize || doff < amqp10FrameHeaderSize || doff > size {
+		return nil, ErrAmqp10Frame
+	}
+
+	frame := &amqp10Frame{
+		Type:    scratch[5],
+		Channel: binary.BigEndian.Uint16(scratch[6:8]),
+	}
+
+	// The extended header is not used by AMQP 1.0
+	if _, err := r.Discard(int(doff - amqp10FrameHeaderSize)); err != nil {
+		return nil, err
+	}
+
+	frame.Body = make([]byte, size-doff)
+	if _, err := io.ReadFull(r, frame.Body); err != nil {
+		return nil, err
+	}
+
+	return frame, nil
+}
+
+// A value of a described type, like the performatives, the message sections and the delivery states
+type amqp10Described struct {
+	Descriptor interface{}
+	Value      interface{}
+}
+
+type amqp10Symbol string
+
+type amqp10Decoder struct {
+	data []byte
+	pos  int
+}
+
+func newAmqp10Decoder(data []byte) *amqp10Decoder {
+	return &amqp10Decoder{data: data}
+}
+
+func (d *amqp10Decoder) remaining() int {
+	return len(d.data) - d.pos
+}
+
+func (d *amqp10Decoder) read(n int) ([]byte, error) {
+	if n < 0 || d.remaining() < n {
+		return nil, ErrAmqp10Type
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *amqp10Decoder) readUint8() (uint8, error) {
+	b, err := d.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (d *amqp10Decoder) readUint16() (uint16, error) {
+	b, err := d.read(2)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b), nil
+}
+
+func (d *amqp10Decoder) readUint32() (uint32, error) {
+	b, err := d.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(b), nil
+}
+
+func (d *amqp10Decoder) readUint64() (uint64, error) {
+	b, err := d.read(8)
+	if err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+// Reads the size of a variable width value, which is either one or four octets
+func (d *amqp10Decoder) readSize(wide bool) (int, error) {
+	if wide {
+		size, err := d.readUint32()
+		return int(size), err
+	}
+	size, err := d.readUint8()
+	return int(size), err
+}
+
+func (d *amqp10Decoder) decode() (interface{}, error) {
+	constructor, err := d.readUint8()
+	if err != nil {
+		return nil, err
+	}
+	if constructor == 0x00 {
+		descriptor, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		value, err := d.decode()
+		if err != nil {
+			return nil, err
+		}
+		return &amqp10Described{Descriptor: descriptor, Value: value}, nil
+	}
+	return d.decodeValue(constructor)
+}
+
+func (d *amqp10Decoder) decodeValue(constructor uint8) (interface{}, error) {
+	switch constructor {
+	case 0x40: // null
+		return nil, nil
+	case 0x41: // true
+		return true, nil
+	case 0x42: // false
+		return false, nil
+	case 0x56: // boolean
+		b, err := d.readUint8()
+		return b != 0, err
+	case 0x43, 0x44: // uint0, ulong0
+		return uint64(0), nil
+	case 0x50, 0x52, 0x53: // ubyte, smalluint, smallulong
+		b, err := d.readUint8()
+		return uint64(b), err
+	case 0x60: // ushort
+		v, err := d.readUint16()
+		return uint64(v), err
+	case 0x70: // uint
+		v, err := d.readUint32()
+		return uint64(v), err
+	case 0x80: // ulong
+		return d.readUint64()
+	case 0x51, 0x54, 0x55: // byte, smallint, smalllong
+		b, err := d.readUint8()
+		return int64(int8(b)), err
+	case 0x61: // short
+		v, err := d.readUint16()
+		return int64(int16(v)), err
+	case 0x71: // int
+		v, err := d.readUint32()
+		return int64(int32(v)), err
+	case 0x81: // long
+		v, err := d.readUint64()
+		return int64(v), err
+	case 0x72: // float
+		v, err := d.readUint32()
+		return toFiniteFloat(float64(math.Float32frombits(v))), err
+	case 0x82: // double
+		v, err := d.readUint64()
+		return toFiniteFloat(math.Float64frombits(v)), err
+	case 0x74, 0x84, 0x94: // decimal32, decimal64, decimal128
+		b, err := d.read(4 << ((constructor >> 4) - 7))
+		return hex.EncodeToString(b), err
+	case 0x73: // char
+		v, err := d.readUint32()
+		return string(rune(v)), err
+	case 0x83: // timestamp
+		v, err := d.readUint64()
+		timestamp := time.UnixMilli(int64(v)).UTC()
+		// Workaround for `Time.MarshalJSON: year outside of range [0,9999]` error
+		if timestamp.Year() > 9999 || timestamp.Year() < 0 {
+			timestamp = time.Time{}.UTC()
+		}
+		return timestamp, err
+	case 0x98: // uuid
+		b, err := d.read(16)
+		if err != nil {
+			return nil, err
+		}
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+	case 0xa0, 0xb0: // vbin8, vbin32
+		size, err := d.readSize(constructor == 0xb0)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.read(size)
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{}, b...), nil
+	case 0xa1, 0xb1: // str8-utf8, str32-utf8
+		size, err := d.readSize(constructor == 0xb1)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.read(size)
+		return string(b), err
+	case 0xa3, 0xb3: // sym8, sym32
+		size, err := d.readSize(constructor == 0xb3)
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.read(size)
+		return amqp10Symbol(b), err
+	case 0x45: // list0
+		return []interface{}{}, nil
+	case 0xc0, 0xd0: // list8, list32
+		count, err := d.readCompound(constructor == 0xd0)
+		if err != nil {
+			return nil, err
+		}
+		list := make([]interface{}, 0)
+		for i := 0; i < count; i++ {
+			item, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case 0xc1, 0xd1: // map8, map32
+		count, err := d.readCompound(constructor == 0xd1)
+		if err != nil {
+			return nil, err
+		}
+		m := make(map[string]interface{})
+		for i := 0; i+1 < count; i += 2 {
+			key, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			m[formatAmqp10Key(key)] = value
+		}
+		return m, nil
+	case 0xe0, 0xf0: // array8, array32
+		count, err := d.readCompound(constructor == 0xf0)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(count)
+	default:
+		return nil, ErrAmqp10Type
+	}
+}
+
+// Reads the size and the count of a list, a map or an array. The size is checked against the remaining data
+// to not allocate for the garbage.
+func (d *amqp10Decoder) readCompound(wide bool) (int, error) {
+	size, err := d.readSize(wide)
+	if err != nil {
+		return 0, err
+	}
+	if size > d.remaining() {
+		return 0, ErrAmqp10Type
+	}
+	count, err := d.readSize(wide)
+	if err != nil {
+		return 0, err
+	}
+	if count > size {
+		return 0, ErrAmqp10Type
+	}
+	return count, nil
+}
+
+// All the elements of an array share a single constructor
+func (d *amqp10Decoder) decodeArray(count int) ([]interface{}, error) {
+	constructor, err := d.readUint8()
+	if err != nil {
+		return nil, err
+	}
+	var descriptor interface{}
+	described := constructor == 0x00
+	if described {
+		if descriptor, err = d.decode(); err != nil {
+			return nil, err
+		}
+		if constructor, err = d.readUint8(); err != nil {
+			return nil, err
+		}
+	}
+
+	array := make([]interface{}, 0)
+	for i := 0; i < count; i++ {
+		item, err := d.decodeValue(constructor)
+		if err != nil {
+			return nil, err
+		}
+		if described {
+			item = &amqp10Described{Descriptor: descriptor, Value: item}
+		}
+		array = append(array, item)
+	}
+	return array, nil
+}
+
+// The values that JSON can not represent are kept as strings
+func toFiniteFloat(v float64) interface{} {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Sprintf("%v", v)
+	}
+	return v
+}
+
+func formatAmqp10Key(key interface{}) string {
+	switch k := key.(type) {
+	case string:
+		return k
+	case amqp10Symbol:
+		return string(k)
+	case []byte:
+		if utf8.Valid(k) {
+			return string(k)
+		}
+		return hex.EncodeToString(k)
+	default:
+		return fmt.Sprintf("%v", k)
+	}
+}
@@ -0,0 +1,183 @@
+package amqp
+
+import "strings"
+
+// The AMQP 1.0 frames are limited the same as the 0-9-1 ones
+const maxAmqp10FrameSize = 1000000 * 16
+
+// The payload of a transfer may span frames, it's kept up to this size
+const maxAmqp10MessageSize = 1000000 * 16
+
+// The composite types are lists, whose fields are named by their position
+type amqp10Composite struct {
+	Name   string
+	Fields []string
+}
+
+const (
+	amqp10Open        = 0x10
+	amqp10Begin       = 0x11
+	amqp10Attach      = 0x12
+	amqp10Flow        = 0x13
+	amqp10Transfer    = 0x14
+	amqp10Disposition = 0x15
+	amqp10Detach      = 0x16
+	amqp10End         = 0x17
+	amqp10Close       = 0x18
+
+	amqp10SaslMechanisms = 0x40
+	amqp10SaslInit       = 0x41
+	amqp10SaslChallenge  = 0x42
+	amqp10SaslResponse   = 0x43
+	amqp10SaslOutcome    = 0x44
+
+	amqp10Header                = 0x70
+	amqp10DeliveryAnnotations   = 0x71
+	amqp10MessageAnnotations    = 0x72
+	amqp10Properties            = 0x73
+	amqp10ApplicationProperties = 0x74
+	amqp10Data                  = 0x75
+	amqp10Sequence              = 0x76
+	amqp10Value                 = 0x77
+	amqp10Footer                = 0x78
+)
+
+var amqp10Composites = map[uint64]amqp10Composite{
+	amqp10Open: {"open", []string{
+		"containerId", "hostname", "maxFrameSize", "channelMax", "idleTimeOut", "outgoingLocales",
+		"incomingLocales", "offeredCapabilities", "desiredCapabilities", "properties",
+	}},
+	amqp10Begin: {"begin", []string{
+		"remoteChannel", "nextOutgoingId", "incomingWindow", "outgoingWindow", "handleMax",
+		"offeredCapabilities", "desiredCapabilities", "properties",
+	}},
+	amqp10Attach: {"attach", []string{
+		"name", "handle", "role", "sndSettleMode", "rcvSettleMode", "source", "target", "unsettled",
+		"incompleteUnsettled", "initialDeliveryCount", "maxMessageSize", "offeredCapabilities",
+		"desiredCapabilities", "properties",
+	}},
+	amqp10Flow: {"flow", []string{
+		"nextIncomingId", "incomingWindow", "nextOutgoingId", "outgoingWindow", "handle", "deliveryCount",
+		"linkCredit", "available", "drain", "echo", "properties",
+	}},
+	amqp10Transfer: {"transfer", []string{
+		"handle", "deliveryId", "deliveryTag", "messageFormat", "settled", "more", "rcvSettleMode", "state",
+		"resume", "aborted", "batchable",
+	}},
+	amqp10Disposition: {"disposition", []string{
+		"role", "first", "last", "settled", "state", "batchable",
+	}},
+	amqp10Detach: {"detach", []string{"handle", "closed", "error"}},
+	amqp10End:    {"end", []string{"error"}},
+	amqp10Close:  {"close", []string{"error"}},
+
+	0x1d: {"error", []string{"condition", "description", "info"}},
+
+	0x23: {"received", []string{"sectionNumber", "sectionOffset"}},
+	0x24: {"accepted", []string{}},
+	0x25: {"rejected", []string{"error"}},
+	0x26: {"released", []string{}},
+	0x27: {"modified", []string{"deliveryFailed", "undeliverableHere", "messageAnnotations"}},
+
+	0x28: {"source", []string{
+		"address", "durable", "expiryPolicy", "timeout", "dynamic", "dynamicNodeProperties",
+		"distributionMode", "filter", "defaultOutcome", "outcomes", "capabilities",
+	}},
+	0x29: {"target", []string{
+		"address", "durable", "expiryPolicy", "timeout", "dynamic", "dynamicNodeProperties", "capabilities",
+	}},
+
+	amqp10SaslMechanisms: {"sasl-mechanisms", []string{"saslServerMechanisms"}},
+	amqp10SaslInit:       {"sasl-init", []string{"mechanism", "initialResponse", "hostname"}},
+	amqp10SaslChallenge:  {"sasl-challenge", []string{"challenge"}},
+	amqp10SaslResponse:   {"sasl-response", []string{"response"}},
+	amqp10SaslOutcome:    {"sasl-outcome", []string{"code", "additionalData"}},
+
+	amqp10Header: {"header", []string{"durable", "priority", "ttl", "firstAcquirer", "deliveryCount"}},
+	amqp10Properties: {"properties", []string{
+		"messageId", "userId", "to", "subject", "replyTo", "correlationId", "contentType", "contentEncoding",
+		"absoluteExpiryTime", "creationTime", "groupId", "groupSequence", "replyToGroupId",
+	}},
+}
+
+// The names of the sections of a message, the rest of the sections are composites
+var amqp10Sections = map[uint64]string{
+	amqp10DeliveryAnnotations:   "deliveryAnnotations",
+	amqp10MessageAnnotations:    "messageAnnotations",
+	amqp10ApplicationProperties: "applicationProperties",
+	amqp10Data:                  "data",
+	amqp10Sequence:              "amqpSequence",
+	amqp10Value:                 "amqpValue",
+	amqp10Footer:                "footer",
+}
+
+// The descriptors are either numeric codes or symbolic names like `amqp:open:list`
+func amqp10Code(descriptor interface{}) (uint64, bool) {
+	switch d := descriptor.(type) {
+	case uint64:
+		// The domain of the codes is 0x00000000 for AMQP
+		return d, d>>32 == 0
+	case amqp10Symbol:
+		name := strings.TrimPrefix(string(d), "amqp:")
+		name = strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(name, ":list"), ":map"), ":binary")
+		for code, composite := range amqp10Composites {
+			if composite.Name == name {
+				return code, true
+			}
+		}
+		for code, section := range amqp10Sections {
+			if strings.ReplaceAll(name, "-", "") == strings.ToLower(section) {
+				return code, true
+			}
+		}
+		if name == "amqp-value:*" {
+			return amqp10Value, true
+		}
+	}
+	return 0, false
+}
+
+// Converts the described values into the JSON friendly maps, naming the fields of the composites
+func toAmqp10Value(value interface{}) interface{} {
+	switch v := value.(type) {
+	case *amqp10Described:
+		code, ok := amqp10Code(v.Descriptor)
+		composite, isComposite := amqp10Composites[code]
+		list, isList := v.Value.([]interface{})
+		if !ok || !isComposite || !isList {
+			return map[string]interface{}{
+				"descriptor": toAmqp10Value(v.Descriptor),
+				"value":      toAmqp10Value(v.Value),
+			}
+		}
+		fields := make(map[string]interface{})
+		for i, item := range list {
+			// The trailing fields, and the ones in the middle, are omitted when they are null
+			if i >= len(composite.Fields) || item == nil {
+				continue
+			}
+			fields[composite.Fields[i]] = toAmqp10Value(item)
+		}
+		// The delivery states are told apart by their names
+		if code >= 0x23 && code <= 0x27 {
+			fields["outcome"] = composite.Name
+		}
+		return fields
+	case []interface{}:
+		list := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			list = append(list, toAmqp10Value(item))
+		}
+		return list
+	case map[string]interface{}:
+		m := make(map[string]interface{})
+		for key, item := range v {
+			m[key] = toAmqp10Value(item)
+		}
+		return m
+	case amqp10Symbol:
+		return string(v)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,20 @@
+module github.com/up9inc/mizu/tap/extensions/dns
+
+go 1.17
+
+require (
+	github.com/stretchr/testify v1.7.0
+	github.com/up9inc/mizu/tap/api v0.0.0
+	golang.org/x/net v0.0.0-20220127200216-cd36cc0744dd
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/up9inc/mizu/tap/dbgctl v0.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
+)
+
+replace github.com/up9inc/mizu/tap/api v0.0.0 => ../../api
+
+replace github.com/up9inc/mizu/tap/dbgctl v0.0.0 => ../../dbgctl
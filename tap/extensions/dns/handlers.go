@@ -0,0 +1,57 @@
+package dns
+
+import (
+	"fmt"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+func handleDatagram(datagram api.UdpDatagram, message *DNSMessage, reqResMatcher *requestResponseMatcher) {
+	udpID := datagram.GetUdpID()
+	captureSize := len(datagram.GetPayload())
+
+	var item *api.OutputChannelItem
+	var connectionInfo *api.ConnectionInfo
+
+	if message.Request != nil {
+		ident := fmt.Sprintf(
+			"%s_%s_%s_%s_%d",
+			udpID.SrcIP,
+			udpID.SrcPort,
+			udpID.DstIP,
+			udpID.DstPort,
+			message.Request.TransactionID,
+		)
+		item = reqResMatcher.registerRequest(ident, message.Request, datagram.GetCaptureTime(), captureSize)
+		connectionInfo = &api.ConnectionInfo{
+			ClientIP:   udpID.SrcIP,
+			ClientPort: udpID.SrcPort,
+			ServerIP:   udpID.DstIP,
+			ServerPort: udpID.DstPort,
+			IsOutgoing: datagram.GetIsOutgoing(),
+		}
+	} else {
+		ident := fmt.Sprintf(
+			"%s_%s_%s_%s_%d",
+			udpID.DstIP,
+			udpID.DstPort,
+			udpID.SrcIP,
+			udpID.SrcPort,
+			message.Reply.TransactionID,
+		)
+		item = reqResMatcher.registerResponse(ident, message.Reply, datagram.GetCaptureTime(), captureSize)
+		connectionInfo = &api.ConnectionInfo{
+			ClientIP:   udpID.DstIP,
+			ClientPort: udpID.DstPort,
+			ServerIP:   udpID.SrcIP,
+			ServerPort: udpID.SrcPort,
+			IsOutgoing: datagram.GetIsOutgoing(),
+		}
+	}
+
+	if item != nil {
+		item.Capture = datagram.GetOrigin()
+		item.ConnectionInfo = connectionInfo
+		datagram.GetEmitter().Emit(item)
+	}
+}
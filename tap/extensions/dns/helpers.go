@@ -0,0 +1,149 @@
+package dns
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+type DNSPayload struct {
+	Data interface{}
+}
+
+type DNSPayloader interface {
+	MarshalJSON() ([]byte, error)
+}
+
+func (h DNSPayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Data)
+}
+
+type DNSWrapper struct {
+	Method  string      `json:"method"`
+	Url     string      `json:"url"`
+	Details interface{} `json:"details"`
+}
+
+func representRequest(request map[string]interface{}) (repRequest []interface{}) {
+	details, _ := json.Marshal([]api.TableData{
+		{
+			Name:     "Transaction ID",
+			Value:    int64(request["transactionId"].(float64)),
+			Selector: `request.transactionId`,
+		},
+		{
+			Name:     "Opcode",
+			Value:    request["opcode"].(string),
+			Selector: `request.opcode`,
+		},
+		{
+			Name:     "Recursion Desired",
+			Value:    request["recursionDesired"].(bool),
+			Selector: `request.recursionDesired`,
+		},
+	})
+	repRequest = append(repRequest, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Details",
+		Data:  string(details),
+	})
+
+	repRequest = append(repRequest, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Questions",
+		Data:  representQuestions(request["questions"], `request.questions`),
+	})
+
+	return
+}
+
+func representResponse(response map[string]interface{}) (repResponse []interface{}) {
+	repResponse = make([]interface{}, 0)
+
+	details, _ := json.Marshal([]api.TableData{
+		{
+			Name:     "Transaction ID",
+			Value:    int64(response["transactionId"].(float64)),
+			Selector: `response.transactionId`,
+		},
+		{
+			Name:     "Response Code",
+			Value:    fmt.Sprintf("%s (%d)", response["rcodeName"].(string), int64(response["rcode"].(float64))),
+			Selector: `response.rcode`,
+		},
+		{
+			Name:     "Authoritative",
+			Value:    response["authoritative"].(bool),
+			Selector: `response.authoritative`,
+		},
+		{
+			Name:     "Truncated",
+			Value:    response["truncated"].(bool),
+			Selector: `response.truncated`,
+		},
+		{
+			Name:     "Recursion Available",
+			Value:    response["recursionAvailable"].(bool),
+			Selector: `response.recursionAvailable`,
+		},
+	})
+	repResponse = append(repResponse, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Details",
+		Data:  string(details),
+	})
+
+	sections := []struct {
+		title string
+		key   string
+	}{
+		{"Answers", "answers"},
+		{"Authorities", "authorities"},
+		{"Additionals", "additionals"},
+	}
+	for _, section := range sections {
+		resources, _ := response[section.key].([]interface{})
+		if len(resources) == 0 {
+			continue
+		}
+		repResponse = append(repResponse, api.SectionData{
+			Type:  api.TABLE,
+			Title: section.title,
+			Data:  representResources(resources, fmt.Sprintf("response.%s", section.key)),
+		})
+	}
+
+	return
+}
+
+func representQuestions(questions interface{}, selector string) string {
+	var table []api.TableData
+	_questions, _ := questions.([]interface{})
+	for i, _question := range _questions {
+		question := _question.(map[string]interface{})
+		table = append(table, api.TableData{
+			Name:     question["name"].(string),
+			Value:    fmt.Sprintf("%s %s", question["class"].(string), question["type"].(string)),
+			Selector: fmt.Sprintf("%s[%d].name", selector, i),
+		})
+	}
+
+	obj, _ := json.Marshal(table)
+	return string(obj)
+}
+
+func representResources(resources []interface{}, selector string) string {
+	var table []api.TableData
+	for i, _resource := range resources {
+		resource := _resource.(map[string]interface{})
+		table = append(table, api.TableData{
+			Name:     fmt.Sprintf("%s %s", resource["name"].(string), resource["type"].(string)),
+			Value:    fmt.Sprintf("%s (TTL %d)", resource["data"].(string), int64(resource["ttl"].(float64))),
+			Selector: fmt.Sprintf("%s[%d].data", selector, i),
+		})
+	}
+
+	obj, _ := json.Marshal(table)
+	return string(obj)
+}
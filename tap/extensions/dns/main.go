@@ -0,0 +1,168 @@
+package dns
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+var protocol = api.Protocol{
+	ProtocolSummary: api.ProtocolSummary{
+		Name:         "dns",
+		Version:      "0",
+		Abbreviation: "DNS",
+	},
+	LongName:        "Domain Name System",
+	Macro:           "dns",
+	BackgroundColor: "#606060",
+	ForegroundColor: "#ffffff",
+	FontSize:        12,
+	ReferenceLink:   "https://datatracker.ietf.org/doc/html/rfc1035",
+	Ports:           []string{"53", "5353"},
+	Priority:        4,
+}
+
+var protocolsMap = map[string]*api.Protocol{
+	protocol.ToString(): &protocol,
+}
+
+type dissecting string
+
+func (d dissecting) Register(extension *api.Extension) {
+	extension.Protocol = &protocol
+}
+
+func (d dissecting) GetProtocols() map[string]*api.Protocol {
+	return protocolsMap
+}
+
+func (d dissecting) Ping() {
+	log.Printf("pong %s", protocol.Name)
+}
+
+func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.TrafficFilteringOptions) error {
+	// DNS over TCP is consumed by the TCP reassembly itself, only the UDP datagrams are dissected.
+	return errors.New("DNS is only dissected from UDP datagrams")
+}
+
+func (d dissecting) DissectDatagram(datagram api.UdpDatagram, options *api.TrafficFilteringOptions) error {
+	reqResMatcher := datagram.GetReqResMatcher().(*requestResponseMatcher)
+
+	message, err := readMessage(datagram.GetPayload())
+	if err != nil {
+		return err
+	}
+
+	handleDatagram(datagram, message, reqResMatcher)
+	return nil
+}
+
+func (d dissecting) Analyze(item *api.OutputChannelItem, resolvedSource string, resolvedDestination string, namespace string) *api.Entry {
+	request := item.Pair.Request.Payload.(map[string]interface{})
+	response := item.Pair.Response.Payload.(map[string]interface{})
+	reqDetails := request["details"].(map[string]interface{})
+	resDetails := response["details"].(map[string]interface{})
+
+	elapsedTime := item.Pair.Response.CaptureTime.Sub(item.Pair.Request.CaptureTime).Round(time.Millisecond).Milliseconds()
+	if elapsedTime < 0 {
+		elapsedTime = 0
+	}
+	return &api.Entry{
+		Protocol: protocol.ProtocolSummary,
+		Capture:  item.Capture,
+		Source: &api.TCP{
+			Name: resolvedSource,
+			IP:   item.ConnectionInfo.ClientIP,
+			Port: item.ConnectionInfo.ClientPort,
+		},
+		Destination: &api.TCP{
+			Name: resolvedDestination,
+			IP:   item.ConnectionInfo.ServerIP,
+			Port: item.ConnectionInfo.ServerPort,
+		},
+		Namespace:    namespace,
+		Outgoing:     item.ConnectionInfo.IsOutgoing,
+		Request:      reqDetails,
+		Response:     resDetails,
+		RequestSize:  item.Pair.Request.CaptureSize,
+		ResponseSize: item.Pair.Response.CaptureSize,
+		Timestamp:    item.Timestamp,
+		StartTime:    item.Pair.Request.CaptureTime,
+		ElapsedTime:  elapsedTime,
+	}
+}
+
+func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
+	status := 0
+	statusQuery := ""
+	if api.IsUnanswered(entry.Response) {
+		statusQuery = api.UnansweredStatusQuery
+	} else if entry.Response["rcode"] != nil {
+		status = int(entry.Response["rcode"].(float64))
+		statusQuery = fmt.Sprintf(`response.rcode == %d`, status)
+	}
+
+	method := ""
+	methodQuery := ""
+	summary := ""
+	summaryQuery := ""
+	questions, _ := entry.Request["questions"].([]interface{})
+	if len(questions) > 0 {
+		question := questions[0].(map[string]interface{})
+		method = question["type"].(string)
+		methodQuery = fmt.Sprintf(`request.questions[0].type == "%s"`, method)
+		summary = question["name"].(string)
+		summaryQuery = fmt.Sprintf(`request.questions[0].name == "%s"`, summary)
+	}
+
+	return &api.BaseEntry{
+		Id:           entry.Id,
+		Protocol:     *protocolsMap[entry.Protocol.ToString()],
+		Capture:      entry.Capture,
+		Summary:      summary,
+		SummaryQuery: summaryQuery,
+		Status:       status,
+		StatusQuery:  statusQuery,
+		Method:       method,
+		MethodQuery:  methodQuery,
+		Timestamp:    entry.Timestamp,
+		Source:       entry.Source,
+		Destination:  entry.Destination,
+		IsOutgoing:   entry.Outgoing,
+		Latency:      entry.ElapsedTime,
+	}
+}
+
+func (d dissecting) Represent(request map[string]interface{}, response map[string]interface{}) (object []byte, err error) {
+	representation := make(map[string]interface{})
+	repRequest := representRequest(request)
+	repResponse := api.RepresentUnanswered()
+	if !api.IsUnanswered(response) {
+		repResponse = representResponse(response)
+	}
+	representation["request"] = repRequest
+	representation["response"] = repResponse
+	object, err = json.Marshal(representation)
+	return
+}
+
+func (d dissecting) Macros() map[string]string {
+	return map[string]string{
+		`dns`: fmt.Sprintf(`protocol.name == "%s"`, protocol.Name),
+	}
+}
+
+func (d dissecting) NewResponseRequestMatcher() api.RequestResponseMatcher {
+	return createResponseRequestMatcher()
+}
+
+var Dissector dissecting
+
+func NewDissector() api.Dissector {
+	return Dissector
+}
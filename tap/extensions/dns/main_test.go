@@ -0,0 +1,168 @@
+package dns
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/api/apitest"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestRegister(t *testing.T) {
+	dissector := NewDissector()
+	extension := &api.Extension{}
+	dissector.Register(extension)
+	assert.Equal(t, "dns", extension.Protocol.Name)
+}
+
+func TestMacros(t *testing.T) {
+	expectedMacros := map[string]string{
+		"dns": `protocol.name == "dns"`,
+	}
+	dissector := NewDissector()
+	macros := dissector.Macros()
+	assert.Equal(t, expectedMacros, macros)
+}
+
+func TestPing(t *testing.T) {
+	dissector := NewDissector()
+	dissector.Ping()
+}
+
+func buildMessage(t *testing.T, response bool, rcode dnsmessage.RCode) []byte {
+	name := dnsmessage.MustNewName("orders.default.svc.cluster.local.")
+	builder := dnsmessage.NewBuilder(nil, dnsmessage.Header{
+		ID:               0x1234,
+		Response:         response,
+		RecursionDesired: true,
+		RCode:            rcode,
+	})
+	builder.EnableCompression()
+	assert.Nil(t, builder.StartQuestions())
+	assert.Nil(t, builder.Question(dnsmessage.Question{
+		Name:  name,
+		Type:  dnsmessage.TypeA,
+		Class: dnsmessage.ClassINET,
+	}))
+	if response && rcode == dnsmessage.RCodeSuccess {
+		assert.Nil(t, builder.StartAnswers())
+		assert.Nil(t, builder.AResource(dnsmessage.ResourceHeader{
+			Name:  name,
+			Class: dnsmessage.ClassINET,
+			TTL:   30,
+		}, dnsmessage.AResource{A: [4]byte{10, 0, 0, 7}}))
+	}
+	data, err := builder.Finish()
+	assert.Nil(t, err)
+	return data
+}
+
+func dissectPair(t *testing.T, rcode dnsmessage.RCode) []*api.OutputChannelItem {
+	dissector := NewDissector().(api.DatagramDissector)
+	itemChannel := make(chan *api.OutputChannelItem, 2)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	reqResMatcher := NewDissector().NewResponseRequestMatcher()
+	options := &api.TrafficFilteringOptions{}
+
+	clientID := &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "40000", DstPort: "53"}
+	serverID := &api.TcpID{SrcIP: "2", DstIP: "1", SrcPort: "53", DstPort: "40000"}
+
+	query := NewUdpDatagram(buildMessage(t, false, rcode), clientID, time.Unix(0, 0), api.Pcap, true, reqResMatcher, emitter)
+	assert.Nil(t, dissector.DissectDatagram(query, options))
+	answer := NewUdpDatagram(buildMessage(t, true, rcode), serverID, time.Unix(0, int64(3*time.Millisecond)), api.Pcap, true, reqResMatcher, emitter)
+	assert.Nil(t, dissector.DissectDatagram(answer, options))
+
+	close(itemChannel)
+	var items []*api.OutputChannelItem
+	for item := range itemChannel {
+		items = append(items, item)
+	}
+	return items
+}
+
+func TestDissectDatagram(t *testing.T) {
+	items := dissectPair(t, dnsmessage.RCodeSuccess)
+	assert.Len(t, items, 1)
+
+	item := items[0]
+	assert.Equal(t, "1", item.ConnectionInfo.ClientIP)
+	assert.Equal(t, "53", item.ConnectionInfo.ServerPort)
+
+	// Round-trip through JSON the same way the items travel to the API server
+	marshaled, err := json.Marshal(item)
+	assert.Nil(t, err)
+	var unmarshaled api.OutputChannelItem
+	assert.Nil(t, json.Unmarshal(marshaled, &unmarshaled))
+
+	dissector := NewDissector()
+	entry := dissector.Analyze(&unmarshaled, "", "", "")
+	assert.Equal(t, int64(3), entry.ElapsedTime)
+
+	baseEntry := dissector.Summarize(entry)
+	assert.Equal(t, "A", baseEntry.Method)
+	assert.Equal(t, "orders.default.svc.cluster.local.", baseEntry.Summary)
+	assert.Equal(t, 0, baseEntry.Status)
+
+	answers := entry.Response["answers"].([]interface{})
+	assert.Len(t, answers, 1)
+	assert.Equal(t, "10.0.0.7", answers[0].(map[string]interface{})["data"])
+
+	_, err = dissector.Represent(entry.Request, entry.Response)
+	assert.Nil(t, err)
+}
+
+func TestDissectDatagramNameError(t *testing.T) {
+	items := dissectPair(t, dnsmessage.RCodeNameError)
+	assert.Len(t, items, 1)
+
+	marshaled, err := json.Marshal(items[0])
+	assert.Nil(t, err)
+	var unmarshaled api.OutputChannelItem
+	assert.Nil(t, json.Unmarshal(marshaled, &unmarshaled))
+
+	dissector := NewDissector()
+	entry := dissector.Analyze(&unmarshaled, "", "", "")
+	baseEntry := dissector.Summarize(entry)
+	assert.Equal(t, 3, baseEntry.Status)
+	assert.Equal(t, "NameError", entry.Response["rcodeName"])
+}
+
+func TestDissectDatagramGarbage(t *testing.T) {
+	dissector := NewDissector().(api.DatagramDissector)
+	reqResMatcher := NewDissector().NewResponseRequestMatcher()
+	datagram := NewUdpDatagram([]byte{0x01, 0x02, 0x03}, &api.TcpID{}, time.Time{}, api.Pcap, false, reqResMatcher, nil)
+	assert.NotNil(t, dissector.DissectDatagram(datagram, &api.TrafficFilteringOptions{}))
+}
+
+func TestNewUnansweredItem(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 1)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+
+	clientID := &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "40000", DstPort: "53"}
+	query := NewUdpDatagram(buildMessage(t, false, dnsmessage.RCodeSuccess), clientID, time.Unix(0, 0), api.Pcap, true, reqResMatcher, emitter)
+	assert.Nil(t, dissector.(api.DatagramDissector).DissectDatagram(query, &api.TrafficFilteringOptions{}))
+
+	items := apitest.UnansweredItems(reqResMatcher)
+	assert.Len(t, items, 1)
+
+	// The datagrams have no stream, the connection is told by the key
+	item := items[0]
+	assert.Equal(t, "1", item.ConnectionInfo.ClientIP)
+	assert.Equal(t, "40000", item.ConnectionInfo.ClientPort)
+	assert.Equal(t, "2", item.ConnectionInfo.ServerIP)
+	assert.Equal(t, "53", item.ConnectionInfo.ServerPort)
+
+	_, summary := apitest.AnalyzeUnanswered(t, dissector, item)
+	assert.Equal(t, "orders.default.svc.cluster.local.", summary.Summary)
+}
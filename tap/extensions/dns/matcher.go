@@ -0,0 +1,112 @@
+package dns
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// Key is {client_addr}_{client_port}_{dest_addr}_{dest_port}_{transaction_id}
+type requestResponseMatcher struct {
+	openMessagesMap *sync.Map
+}
+
+func createResponseRequestMatcher() api.RequestResponseMatcher {
+	return &requestResponseMatcher{openMessagesMap: &sync.Map{}}
+}
+
+func (matcher *requestResponseMatcher) GetMap() *sync.Map {
+	return matcher.openMessagesMap
+}
+
+func (matcher *requestResponseMatcher) SetMaxTry(value int) {
+}
+
+func (matcher *requestResponseMatcher) registerRequest(ident string, request *DNSRequest, captureTime time.Time, captureSize int) *api.OutputChannelItem {
+	requestDNSMessage := api.GenericMessage{
+		IsRequest:   true,
+		CaptureTime: captureTime,
+		CaptureSize: captureSize,
+		Payload: DNSPayload{
+			Data: &DNSWrapper{
+				Method:  request.Opcode,
+				Url:     "",
+				Details: request,
+			},
+		},
+	}
+
+	if response, found := matcher.openMessagesMap.LoadAndDelete(ident); found {
+		// Type assertion always succeeds because all of the map's values are of api.GenericMessage type
+		responseDNSMessage := response.(*api.GenericMessage)
+		if responseDNSMessage.IsRequest {
+			return nil
+		}
+		return matcher.preparePair(&requestDNSMessage, responseDNSMessage)
+	}
+
+	matcher.openMessagesMap.Store(ident, &requestDNSMessage)
+	return nil
+}
+
+func (matcher *requestResponseMatcher) registerResponse(ident string, response *DNSResponse, captureTime time.Time, captureSize int) *api.OutputChannelItem {
+	responseDNSMessage := api.GenericMessage{
+		IsRequest:   false,
+		CaptureTime: captureTime,
+		CaptureSize: captureSize,
+		Payload: DNSPayload{
+			Data: &DNSWrapper{
+				Method:  response.RCodeName,
+				Url:     "",
+				Details: response,
+			},
+		},
+	}
+
+	if request, found := matcher.openMessagesMap.LoadAndDelete(ident); found {
+		// Type assertion always succeeds because all of the map's values are of api.GenericMessage type
+		requestDNSMessage := request.(*api.GenericMessage)
+		if !requestDNSMessage.IsRequest {
+			return nil
+		}
+		return matcher.preparePair(requestDNSMessage, &responseDNSMessage)
+	}
+
+	matcher.openMessagesMap.Store(ident, &responseDNSMessage)
+	return nil
+}
+
+func (matcher *requestResponseMatcher) preparePair(requestDNSMessage *api.GenericMessage, responseDNSMessage *api.GenericMessage) *api.OutputChannelItem {
+	return &api.OutputChannelItem{
+		Protocol:       protocol,
+		Timestamp:      requestDNSMessage.CaptureTime.UnixNano() / int64(time.Millisecond),
+		ConnectionInfo: nil,
+		Pair: &api.RequestResponsePair{
+			Request:  *requestDNSMessage,
+			Response: *responseDNSMessage,
+		},
+	}
+}
+
+// The datagrams have no stream, so the connection is told by the key
+func (matcher *requestResponseMatcher) NewUnansweredItem(key interface{}, value interface{}) *api.OutputChannelItem {
+	request, ok := value.(*api.GenericMessage)
+	if !ok || !request.IsRequest {
+		return nil
+	}
+
+	item := api.NewUnansweredItem(protocol, request)
+	if ident, ok := key.(string); ok {
+		if fields := strings.Split(ident, "_"); len(fields) == 5 {
+			item.ConnectionInfo = &api.ConnectionInfo{
+				ClientIP:   fields[0],
+				ClientPort: fields[1],
+				ServerIP:   fields[2],
+				ServerPort: fields[3],
+			}
+		}
+	}
+	return item
+}
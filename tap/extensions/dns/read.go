@@ -0,0 +1,73 @@
+package dns
+
+import (
+	"errors"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type DNSMessage struct {
+	Header  dnsmessage.Header
+	Request *DNSRequest
+	Reply   *DNSResponse
+}
+
+func readMessage(data []byte) (*DNSMessage, error) {
+	var parser dnsmessage.Parser
+
+	header, err := parser.Start(data)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := opcodes[header.OpCode]; !ok {
+		return nil, errors.New("Unrecognized DNS opcode")
+	}
+
+	questions, err := parser.AllQuestions()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(questions) == 0 {
+		return nil, errors.New("DNS message has no questions")
+	}
+
+	message := &DNSMessage{
+		Header: header,
+	}
+
+	if !header.Response {
+		message.Request = &DNSRequest{
+			TransactionID:    header.ID,
+			Opcode:           opcodeName(header.OpCode),
+			RecursionDesired: header.RecursionDesired,
+			Questions:        newQuestions(questions),
+		}
+		return message, nil
+	}
+
+	answers, err := parser.AllAnswers()
+	if err != nil {
+		return nil, err
+	}
+
+	// The answers are the interesting part. Tolerate malformed trailing sections.
+	authorities, _ := parser.AllAuthorities()
+	additionals, _ := parser.AllAdditionals()
+
+	message.Reply = &DNSResponse{
+		TransactionID:      header.ID,
+		RCode:              int(header.RCode),
+		RCodeName:          rcodeName(header.RCode),
+		Authoritative:      header.Authoritative,
+		Truncated:          header.Truncated,
+		RecursionAvailable: header.RecursionAvailable,
+		Questions:          newQuestions(questions),
+		Answers:            newResources(answers),
+		Authorities:        newResources(authorities),
+		Additionals:        newResources(additionals),
+	}
+
+	return message, nil
+}
@@ -0,0 +1,132 @@
+package dns
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type DNSQuestion struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Class string `json:"class"`
+}
+
+type DNSResource struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Class string `json:"class"`
+	TTL   uint32 `json:"ttl"`
+	Data  string `json:"data"`
+}
+
+type DNSRequest struct {
+	TransactionID    uint16        `json:"transactionId"`
+	Opcode           string        `json:"opcode"`
+	RecursionDesired bool          `json:"recursionDesired"`
+	Questions        []DNSQuestion `json:"questions"`
+}
+
+type DNSResponse struct {
+	TransactionID      uint16        `json:"transactionId"`
+	RCode              int           `json:"rcode"`
+	RCodeName          string        `json:"rcodeName"`
+	Authoritative      bool          `json:"authoritative"`
+	Truncated          bool          `json:"truncated"`
+	RecursionAvailable bool          `json:"recursionAvailable"`
+	Questions          []DNSQuestion `json:"questions"`
+	Answers            []DNSResource `json:"answers"`
+	Authorities        []DNSResource `json:"authorities"`
+	Additionals        []DNSResource `json:"additionals"`
+}
+
+var opcodes = map[dnsmessage.OpCode]string{
+	0: "QUERY",
+	1: "IQUERY",
+	2: "STATUS",
+	4: "NOTIFY",
+	5: "UPDATE",
+}
+
+func opcodeName(opcode dnsmessage.OpCode) string {
+	if name, ok := opcodes[opcode]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", opcode)
+}
+
+func rcodeName(rcode dnsmessage.RCode) string {
+	// dnsmessage names the codes like `RCodeNameError`
+	return strings.TrimPrefix(rcode.String(), "RCode")
+}
+
+func typeName(t dnsmessage.Type) string {
+	// dnsmessage names the types like `TypeAAAA`
+	return strings.TrimPrefix(t.String(), "Type")
+}
+
+func className(c dnsmessage.Class) string {
+	// dnsmessage names the classes like `ClassINET`
+	name := strings.TrimPrefix(c.String(), "Class")
+	if name == "INET" {
+		return "IN"
+	}
+	return name
+}
+
+func newQuestions(questions []dnsmessage.Question) []DNSQuestion {
+	result := make([]DNSQuestion, 0, len(questions))
+	for _, question := range questions {
+		result = append(result, DNSQuestion{
+			Name:  question.Name.String(),
+			Type:  typeName(question.Type),
+			Class: className(question.Class),
+		})
+	}
+	return result
+}
+
+func newResources(resources []dnsmessage.Resource) []DNSResource {
+	result := make([]DNSResource, 0, len(resources))
+	for _, resource := range resources {
+		result = append(result, DNSResource{
+			Name:  resource.Header.Name.String(),
+			Type:  typeName(resource.Header.Type),
+			Class: className(resource.Header.Class),
+			TTL:   resource.Header.TTL,
+			Data:  resourceData(resource.Body),
+		})
+	}
+	return result
+}
+
+func resourceData(body dnsmessage.ResourceBody) string {
+	switch b := body.(type) {
+	case *dnsmessage.AResource:
+		return net.IP(b.A[:]).String()
+	case *dnsmessage.AAAAResource:
+		return net.IP(b.AAAA[:]).String()
+	case *dnsmessage.CNAMEResource:
+		return b.CNAME.String()
+	case *dnsmessage.NSResource:
+		return b.NS.String()
+	case *dnsmessage.PTRResource:
+		return b.PTR.String()
+	case *dnsmessage.MXResource:
+		return fmt.Sprintf("%d %s", b.Pref, b.MX.String())
+	case *dnsmessage.SRVResource:
+		return fmt.Sprintf("%d %d %d %s", b.Priority, b.Weight, b.Port, b.Target.String())
+	case *dnsmessage.TXTResource:
+		return strings.Join(b.TXT, " ")
+	case *dnsmessage.SOAResource:
+		return fmt.Sprintf("%s %s %d %d %d %d %d", b.NS.String(), b.MBox.String(), b.Serial, b.Refresh, b.Retry, b.Expire, b.MinTTL)
+	case *dnsmessage.OPTResource:
+		return fmt.Sprintf("%d option(s)", len(b.Options))
+	case *dnsmessage.UnknownResource:
+		return fmt.Sprintf("%x", b.Data)
+	default:
+		return ""
+	}
+}
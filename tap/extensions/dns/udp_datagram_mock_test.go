@@ -0,0 +1,57 @@
+package dns
+
+import (
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+type udpDatagram struct {
+	payload       []byte
+	udpID         *api.TcpID
+	captureTime   time.Time
+	origin        api.Capture
+	isOutgoing    bool
+	reqResMatcher api.RequestResponseMatcher
+	emitter       api.Emitter
+}
+
+func NewUdpDatagram(payload []byte, udpID *api.TcpID, captureTime time.Time, origin api.Capture, isOutgoing bool, reqResMatcher api.RequestResponseMatcher, emitter api.Emitter) api.UdpDatagram {
+	return &udpDatagram{
+		payload:       payload,
+		udpID:         udpID,
+		captureTime:   captureTime,
+		origin:        origin,
+		isOutgoing:    isOutgoing,
+		reqResMatcher: reqResMatcher,
+		emitter:       emitter,
+	}
+}
+
+func (datagram *udpDatagram) GetPayload() []byte {
+	return datagram.payload
+}
+
+func (datagram *udpDatagram) GetUdpID() *api.TcpID {
+	return datagram.udpID
+}
+
+func (datagram *udpDatagram) GetCaptureTime() time.Time {
+	return datagram.captureTime
+}
+
+func (datagram *udpDatagram) GetOrigin() api.Capture {
+	return datagram.origin
+}
+
+func (datagram *udpDatagram) GetIsOutgoing() bool {
+	return datagram.isOutgoing
+}
+
+func (datagram *udpDatagram) GetReqResMatcher() api.RequestResponseMatcher {
+	return datagram.reqResMatcher
+}
+
+func (datagram *udpDatagram) GetEmitter() api.Emitter {
+	return datagram.emitter
+}
@@ -3,12 +3,12 @@ module github.com/up9inc/mizu/tap/extensions/http
 go 1.17
 
 require (
-	github.com/beevik/etree v1.1.0
 	github.com/google/martian v2.1.0+incompatible
 	github.com/mertyildiran/gqlparser/v2 v2.4.6
 	github.com/stretchr/testify v1.7.0
 	github.com/up9inc/mizu/tap/api v0.0.0
 	golang.org/x/net v0.0.0-20220127200216-cd36cc0744dd
+	google.golang.org/protobuf v1.27.1
 )
 
 require (
@@ -0,0 +1,312 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/up9inc/mizu/tap/api"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Every gRPC message is prefixed by a compressed flag and a 4-byte big-endian length
+const grpcMessageHeaderLen = 5
+
+// The nested messages are only guessed up to this depth when there is no descriptor
+const maxWireFormatDepth = 16
+
+// The decoded messages of a gRPC call. The service and the method are only set on the request.
+type GrpcMessages struct {
+	Service   string
+	RpcMethod string
+	Messages  []*GrpcMessage
+}
+
+type GrpcMessage struct {
+	Compressed bool        `json:"compressed"`
+	Encoding   string      `json:"encoding,omitempty"`
+	Size       int         `json:"size"`
+	Truncated  bool        `json:"truncated,omitempty"`
+	Type       string      `json:"type"`
+	Data       interface{} `json:"data"`
+}
+
+// A field of a message decoded without its descriptor, as it appears on the wire
+type GrpcField struct {
+	Number   int32       `json:"number"`
+	WireType string      `json:"wireType"`
+	Value    interface{} `json:"value"`
+}
+
+type grpcDescriptors []*protoregistry.Files
+
+// The descriptors are parsed once for the options they are supplied in
+var grpcDescriptorsCache sync.Map
+
+func loadGrpcDescriptors(options *api.TrafficFilteringOptions) grpcDescriptors {
+	if options == nil || len(options.GrpcDescriptorSets) == 0 {
+		return nil
+	}
+	if descriptors, ok := grpcDescriptorsCache.Load(options); ok {
+		return descriptors.(grpcDescriptors)
+	}
+
+	descriptors := make(grpcDescriptors, 0)
+	for _, data := range options.GrpcDescriptorSets {
+		set := &descriptorpb.FileDescriptorSet{}
+		if err := proto.Unmarshal(data, set); err != nil {
+			log.Printf("Invalid gRPC FileDescriptorSet: %v", err)
+			continue
+		}
+		files, err := protodesc.NewFiles(set)
+		if err != nil {
+			log.Printf("Invalid gRPC FileDescriptorSet, it must include its imports: %v", err)
+			continue
+		}
+		descriptors = append(descriptors, files)
+	}
+
+	actual, _ := grpcDescriptorsCache.LoadOrStore(options, descriptors)
+	return actual.(grpcDescriptors)
+}
+
+func (descriptors grpcDescriptors) findMethod(service string, rpcMethod string) protoreflect.MethodDescriptor {
+	for _, files := range descriptors {
+		descriptor, err := files.FindDescriptorByName(protoreflect.FullName(service))
+		if err != nil {
+			continue
+		}
+		if serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor); ok {
+			if method := serviceDescriptor.Methods().ByName(protoreflect.Name(rpcMethod)); method != nil {
+				return method
+			}
+		}
+	}
+	return nil
+}
+
+// The path of a gRPC call is `/{package}.{Service}/{Method}`
+func parseGrpcPath(path string) (service string, rpcMethod string) {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(parts) != 2 {
+		return
+	}
+	return parts[0], parts[1]
+}
+
+// The messages are decoded once the call is paired, because the type of the response
+// depends on the method of the request.
+func decodeGrpcPair(item *api.OutputChannelItem, options *api.TrafficFilteringOptions) {
+	requestPayload := item.Pair.Request.Payload.(HTTPPayload)
+	responsePayload := item.Pair.Response.Payload.(HTTPPayload)
+	request := requestPayload.Data.(*http.Request)
+	response := responsePayload.Data.(*http.Response)
+
+	service, rpcMethod := parseGrpcPath(request.Header.Get(":path"))
+
+	var input, output protoreflect.MessageDescriptor
+	if method := loadGrpcDescriptors(options).findMethod(service, rpcMethod); method != nil {
+		input = method.Input()
+		output = method.Output()
+	}
+
+	requestPayload.Grpc = &GrpcMessages{
+		Service:   service,
+		RpcMethod: rpcMethod,
+		Messages:  decodeGrpcMessages(readHTTP2Body(&request.Body), request.Header.Get("Grpc-Encoding"), input),
+	}
+	responsePayload.Grpc = &GrpcMessages{
+		Messages: decodeGrpcMessages(readHTTP2Body(&response.Body), response.Header.Get("Grpc-Encoding"), output),
+	}
+
+	item.Pair.Request.Payload = requestPayload
+	item.Pair.Response.Payload = responsePayload
+}
+
+// The HTTP/2 assembler keeps the bodies base64 encoded
+func readHTTP2Body(body *io.ReadCloser) []byte {
+	encoded, err := ioutil.ReadAll(*body)
+	*body = io.NopCloser(bytes.NewBuffer(encoded)) // rewind
+	if err != nil {
+		return nil
+	}
+
+	data, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func decodeGrpcMessages(data []byte, encoding string, descriptor protoreflect.MessageDescriptor) []*GrpcMessage {
+	messages := make([]*GrpcMessage, 0)
+
+	for len(data) >= grpcMessageHeaderLen {
+		message := &GrpcMessage{
+			Compressed: data[0] == 1,
+			Size:       int(binary.BigEndian.Uint32(data[1:grpcMessageHeaderLen])),
+		}
+		messages = append(messages, message)
+		data = data[grpcMessageHeaderLen:]
+
+		// The bodies are cut at maxHTTP2DataLen
+		if message.Size > len(data) {
+			message.Truncated = true
+			break
+		}
+		payload := data[:message.Size]
+		data = data[message.Size:]
+
+		if message.Compressed {
+			message.Encoding = encoding
+			// Only the compression available in the standard library is decoded
+			if encoding != "gzip" {
+				message.Data = payload
+				continue
+			}
+			reader, err := gzip.NewReader(bytes.NewReader(payload))
+			if err != nil {
+				message.Data = payload
+				continue
+			}
+			uncompressed, err := ioutil.ReadAll(reader)
+			if err != nil {
+				message.Data = payload
+				continue
+			}
+			payload = uncompressed
+		}
+
+		message.decode(payload, descriptor)
+	}
+
+	return messages
+}
+
+func (message *GrpcMessage) decode(payload []byte, descriptor protoreflect.MessageDescriptor) {
+	if descriptor != nil {
+		dynamicMessage := dynamicpb.NewMessage(descriptor)
+		if err := proto.Unmarshal(payload, dynamicMessage); err == nil {
+			if data, err := protojson.Marshal(dynamicMessage); err == nil {
+				message.Type = string(descriptor.FullName())
+				message.Data = json.RawMessage(data)
+				return
+			}
+		}
+	}
+
+	fields, err := decodeWireFormat(payload, 0)
+	if err != nil {
+		message.Data = payload
+		return
+	}
+	message.Data = fields
+}
+
+// Decodes a message without its descriptor, into the field numbers and the wire types
+func decodeWireFormat(b []byte, depth int) ([]*GrpcField, error) {
+	fields := make([]*GrpcField, 0)
+
+	for len(b) > 0 {
+		number, wireType, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		field := &GrpcField{Number: int32(number)}
+		switch wireType {
+		case protowire.VarintType:
+			var value uint64
+			value, n = protowire.ConsumeVarint(b)
+			field.WireType = "varint"
+			field.Value = value
+		case protowire.Fixed32Type:
+			var value uint32
+			value, n = protowire.ConsumeFixed32(b)
+			field.WireType = "fixed32"
+			field.Value = value
+		case protowire.Fixed64Type:
+			var value uint64
+			value, n = protowire.ConsumeFixed64(b)
+			field.WireType = "fixed64"
+			field.Value = value
+		case protowire.BytesType:
+			var value []byte
+			value, n = protowire.ConsumeBytes(b)
+			field.WireType = "bytes"
+			field.Value = guessBytes(value, depth)
+		case protowire.StartGroupType:
+			var value []byte
+			value, n = protowire.ConsumeGroup(number, b)
+			field.WireType = "group"
+			if n >= 0 {
+				field.Value, _ = decodeWireFormat(value, depth+1)
+			}
+		default:
+			n = -1
+		}
+		if n < 0 {
+			return nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// The length-delimited fields are either strings, bytes, packed repeated fields or nested messages.
+// Printable text is taken as a string, then a nested message is tried, otherwise the bytes are kept.
+func guessBytes(value []byte, depth int) interface{} {
+	if isPrintable(value) {
+		return string(value)
+	}
+	if depth < maxWireFormatDepth {
+		if fields, err := decodeWireFormat(value, depth+1); err == nil {
+			return fields
+		}
+	}
+	return value
+}
+
+func isPrintable(value []byte) bool {
+	if !utf8.Valid(value) {
+		return false
+	}
+	for _, r := range string(value) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func representGrpcMessages(messages interface{}, selector string) api.SectionData {
+	data, _ := json.Marshal(messages)
+	return api.SectionData{
+		Type:     api.BODY,
+		Title:    "gRPC Messages",
+		MimeType: "application/json",
+		Data:     string(data),
+		Selector: selector,
+	}
+}
@@ -110,18 +110,36 @@ var graphQL2Protocol = api.Protocol{
 	Priority:        0,
 }
 
+var webSocketProtocol = api.Protocol{
+	ProtocolSummary: api.ProtocolSummary{
+		Name:         "http",
+		Version:      "1.1",
+		Abbreviation: "WS",
+	},
+	LongName:        "Hypertext Transfer Protocol -- HTTP/1.1 [ WebSocket over HTTP/1.1 ]",
+	Macro:           "ws",
+	BackgroundColor: "#4f3a65",
+	ForegroundColor: "#ffffff",
+	FontSize:        12,
+	ReferenceLink:   "https://datatracker.ietf.org/doc/html/rfc6455",
+	Ports:           []string{"80", "443", "8080"},
+	Priority:        0,
+}
+
 var protocolsMap = map[string]*api.Protocol{
-	http10protocol.ToString():   &http10protocol,
-	http11protocol.ToString():   &http11protocol,
-	http2Protocol.ToString():    &http2Protocol,
-	grpcProtocol.ToString():     &grpcProtocol,
-	graphQL1Protocol.ToString(): &graphQL1Protocol,
-	graphQL2Protocol.ToString(): &graphQL2Protocol,
+	http10protocol.ToString():    &http10protocol,
+	http11protocol.ToString():    &http11protocol,
+	http2Protocol.ToString():     &http2Protocol,
+	grpcProtocol.ToString():      &grpcProtocol,
+	graphQL1Protocol.ToString():  &graphQL1Protocol,
+	graphQL2Protocol.ToString():  &graphQL2Protocol,
+	webSocketProtocol.ToString(): &webSocketProtocol,
 }
 
 const (
 	TypeHttpRequest = iota
 	TypeHttpResponse
+	TypeWebSocketMessage
 )
 
 type dissecting string
@@ -138,6 +156,10 @@ func (d dissecting) Ping() {
 	log.Printf("pong %s", http11protocol.Name)
 }
 
+func (d dissecting) Probe(peek []byte, isClient bool) api.Confidence {
+	return probe(peek, isClient)
+}
+
 func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.TrafficFilteringOptions) error {
 	reqResMatcher := reader.GetReqResMatcher().(*requestResponseMatcher)
 
@@ -154,6 +176,7 @@ func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.
 	}
 
 	switchingProtocolsHTTP2 := false
+	var webSocket *webSocketReader
 	for {
 		if switchingProtocolsHTTP2 {
 			switchingProtocolsHTTP2 = false
@@ -168,6 +191,17 @@ func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.
 			http2Assembler = createHTTP2Assembler(b)
 		}
 
+		if webSocket != nil {
+			err = handleWebSocketStream(webSocket, reqResMatcher.webSocketUpgrade, reader.GetReadProgress(), reader.GetParent().GetOrigin(), reader.GetTcpID(), reader.GetCaptureTime(), reader.GetEmitter(), reqResMatcher)
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			} else if err != nil {
+				// Such as the upgrade being refused, the frames are peeked until they are known to be valid
+				webSocket = nil
+			}
+			continue
+		}
+
 		if isHTTP2 {
 			err = handleHTTP2Stream(http2Assembler, reader.GetReadProgress(), reader.GetParent().GetOrigin(), reader.GetTcpID(), reader.GetCaptureTime(), reader.GetEmitter(), options, reqResMatcher)
 			if err == io.EOF || err == io.ErrUnexpectedEOF {
@@ -186,6 +220,11 @@ func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.
 			}
 			reader.GetParent().SetProtocol(&http11protocol)
 
+			if isWebSocketUpgrade(req.Header) {
+				reqResMatcher.webSocketUpgrade.fromRequest(req)
+				webSocket = &webSocketReader{b: b, isClient: true}
+			}
+
 			// In case of an HTTP2 upgrade, duplicate the HTTP1 request into HTTP2 with stream ID 1
 			if switchingProtocolsHTTP2 {
 				ident := fmt.Sprintf(
@@ -210,13 +249,19 @@ func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.
 				}
 			}
 		} else {
-			switchingProtocolsHTTP2, err = handleHTTP1ServerStream(b, reader.GetReadProgress(), reader.GetParent().GetOrigin(), reader.GetTcpID(), reader.GetCounterPair(), reader.GetCaptureTime(), reader.GetEmitter(), options, reqResMatcher)
+			var res *http.Response
+			switchingProtocolsHTTP2, res, err = handleHTTP1ServerStream(b, reader.GetReadProgress(), reader.GetParent().GetOrigin(), reader.GetTcpID(), reader.GetCounterPair(), reader.GetCaptureTime(), reader.GetEmitter(), options, reqResMatcher)
 			if err == io.EOF || err == io.ErrUnexpectedEOF {
 				break
 			} else if err != nil {
 				continue
 			}
 			reader.GetParent().SetProtocol(&http11protocol)
+
+			if res.StatusCode == 101 && isWebSocketUpgrade(res.Header) {
+				reqResMatcher.webSocketUpgrade.fromResponse(res)
+				webSocket = &webSocketReader{b: b, isClient: false}
+			}
 		}
 	}
 
@@ -224,6 +269,10 @@ func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.
 }
 
 func (d dissecting) Analyze(item *api.OutputChannelItem, resolvedSource string, resolvedDestination string, namespace string) *api.Entry {
+	if item.Protocol.Abbreviation == webSocketProtocol.Abbreviation {
+		return analyzeWebSocket(item, resolvedSource, resolvedDestination, namespace)
+	}
+
 	var host, authority, path string
 
 	request := item.Pair.Request.Payload.(map[string]interface{})
@@ -260,7 +309,9 @@ func (d dissecting) Analyze(item *api.OutputChannelItem, resolvedSource string,
 		}
 	}
 
-	if resDetails["bodySize"].(float64) < 0 {
+	unanswered := api.IsUnanswered(resDetails)
+
+	if !unanswered && resDetails["bodySize"].(float64) < 0 {
 		resDetails["bodySize"] = 0
 	}
 
@@ -287,10 +338,11 @@ func (d dissecting) Analyze(item *api.OutputChannelItem, resolvedSource string,
 
 	// Rearrange the maps for the querying
 	reqDetails["headers"] = mapSliceRebuildAsMergedMap(reqDetails["headers"].([]interface{}))
-	resDetails["headers"] = mapSliceRebuildAsMergedMap(resDetails["headers"].([]interface{}))
-
 	reqDetails["cookies"] = mapSliceRebuildAsMergedMap(reqDetails["cookies"].([]interface{}))
-	resDetails["cookies"] = mapSliceRebuildAsMergedMap(resDetails["cookies"].([]interface{}))
+	if !unanswered {
+		resDetails["headers"] = mapSliceRebuildAsMergedMap(resDetails["headers"].([]interface{}))
+		resDetails["cookies"] = mapSliceRebuildAsMergedMap(resDetails["cookies"].([]interface{}))
+	}
 
 	reqDetails["queryString"] = mapSliceRebuildAsMap(reqDetails["queryString"].([]interface{}))
 
@@ -325,12 +377,26 @@ func (d dissecting) Analyze(item *api.OutputChannelItem, resolvedSource string,
 }
 
 func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
+	if entry.Protocol.Abbreviation == webSocketProtocol.Abbreviation {
+		return summarizeWebSocket(entry)
+	}
+
 	summary := entry.Request["path"].(string)
 	summaryQuery := fmt.Sprintf(`request.path == "%s"`, summary)
 	method := entry.Request["method"].(string)
 	methodQuery := fmt.Sprintf(`request.method == "%s"`, method)
-	status := int(entry.Response["status"].(float64))
-	statusQuery := fmt.Sprintf(`response.status == %d`, status)
+	if service, _ := entry.Request["service"].(string); service != "" {
+		summary = service
+		summaryQuery = fmt.Sprintf(`request.service == "%s"`, summary)
+		method = entry.Request["rpcMethod"].(string)
+		methodQuery = fmt.Sprintf(`request.rpcMethod == "%s"`, method)
+	}
+	status := 0
+	statusQuery := api.UnansweredStatusQuery
+	if !api.IsUnanswered(entry.Response) {
+		status = int(entry.Response["status"].(float64))
+		statusQuery = fmt.Sprintf(`response.status == %d`, status)
+	}
 
 	return &api.BaseEntry{
 		Id:           entry.Id,
@@ -379,6 +445,26 @@ func representRequest(request map[string]interface{}) (repRequest []interface{})
 		Data:  string(details),
 	})
 
+	if service, ok := request["service"].(string); ok {
+		grpcDetails, _ := json.Marshal([]api.TableData{
+			{
+				Name:     "Service",
+				Value:    service,
+				Selector: `request.service`,
+			},
+			{
+				Name:     "Method",
+				Value:    request["rpcMethod"].(string),
+				Selector: `request.rpcMethod`,
+			},
+		})
+		repRequest = append(repRequest, api.SectionData{
+			Type:  api.TABLE,
+			Title: "gRPC",
+			Data:  string(grpcDetails),
+		})
+	}
+
 	pathSegments := request["pathSegments"].([]interface{})
 	if len(pathSegments) > 1 {
 		repRequest = append(repRequest, api.SectionData{
@@ -406,6 +492,10 @@ func representRequest(request map[string]interface{}) (repRequest []interface{})
 		Data:  representMapAsTable(request["queryString"].(map[string]interface{}), `request.queryString`),
 	})
 
+	if messages, ok := request["messages"]; ok {
+		repRequest = append(repRequest, representGrpcMessages(messages, `request.messages`))
+	}
+
 	postData, _ := request["postData"].(map[string]interface{})
 	mimeType := postData["mimeType"]
 	if mimeType == nil {
@@ -488,6 +578,10 @@ func representResponse(response map[string]interface{}) (repResponse []interface
 		Data:  representMapAsTable(response["cookies"].(map[string]interface{}), `response.cookies`),
 	})
 
+	if messages, ok := response["messages"]; ok {
+		repResponse = append(repResponse, representGrpcMessages(messages, `response.messages`))
+	}
+
 	content, _ := response["content"].(map[string]interface{})
 	mimeType := content["mimeType"]
 	if mimeType == nil {
@@ -511,8 +605,18 @@ func representResponse(response map[string]interface{}) (repResponse []interface
 
 func (d dissecting) Represent(request map[string]interface{}, response map[string]interface{}) (object []byte, err error) {
 	representation := make(map[string]interface{})
+	if _, ok := request["opcode"]; ok {
+		representation["request"] = representWebSocketMessage(request)
+		representation["response"] = representWebSocketMessage(response)
+		object, err = json.Marshal(representation)
+		return
+	}
+
 	repRequest := representRequest(request)
-	repResponse := representResponse(response)
+	repResponse := api.RepresentUnanswered()
+	if !api.IsUnanswered(response) {
+		repResponse = representResponse(response)
+	}
 	representation["request"] = repRequest
 	representation["response"] = repResponse
 	object, err = json.Marshal(representation)
@@ -525,6 +629,7 @@ func (d dissecting) Macros() map[string]string {
 		`http2`: fmt.Sprintf(`protocol.abbr == "%s"`, http2Protocol.Abbreviation),
 		`grpc`:  fmt.Sprintf(`protocol.abbr == "%s"`, grpcProtocol.Abbreviation),
 		`gql`:   fmt.Sprintf(`protocol.abbr == "%s"`, graphQL1Protocol.Abbreviation),
+		`ws`:    fmt.Sprintf(`protocol.abbr == "%s"`, webSocketProtocol.Abbreviation),
 	}
 }
 
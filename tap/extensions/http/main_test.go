@@ -2,6 +2,9 @@ package http
 
 import (
 	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -16,6 +19,12 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/api/apitest"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/hpack"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
 )
 
 const (
@@ -48,6 +57,7 @@ func TestMacros(t *testing.T) {
 		"http2": `protocol.abbr == "HTTP/2"`,
 		"grpc":  `protocol.abbr == "gRPC"`,
 		"gql":   `protocol.abbr == "GQL"`,
+		"ws":    `protocol.abbr == "WS"`,
 	}
 	dissector := NewDissector()
 	macros := dissector.Macros()
@@ -59,6 +69,31 @@ func TestPing(t *testing.T) {
 	dissector.Ping()
 }
 
+func TestProbe(t *testing.T) {
+	dissector := NewDissector().(api.Prober)
+
+	tests := []struct {
+		peek       string
+		isClient   bool
+		confidence api.Confidence
+	}{
+		{"GET / HTTP/1.1\r\n", true, api.ConfidenceHigh},
+		{"PROPFIND /dav HTTP/1.1\r\n", true, api.ConfidenceLow},
+		{http2.ClientPreface, true, api.ConfidenceHigh},
+		{"POS", true, api.ConfidenceUnknown},
+		{"\x00\x00\x00\x08\x04\xd2\x00\x00", true, api.ConfidenceNone},
+		{"HTTP/1.1 200 OK\r\n", false, api.ConfidenceHigh},
+		{"HTTP", false, api.ConfidenceUnknown},
+		{"\x00\x00\x00\x04\x00\x00\x00\x00\x00", false, api.ConfidenceLow},
+		{"{\"continued\": \"body\"}", false, api.ConfidenceUnknown},
+		{"+OK\r\n\x01", false, api.ConfidenceNone},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.confidence, dissector.Probe([]byte(test.peek), test.isClient), test.peek)
+	}
+}
+
 func TestDissect(t *testing.T) {
 	_, testUpdateEnabled := os.LookupEnv(testUpdate)
 
@@ -375,3 +410,341 @@ func TestRepresent(t *testing.T) {
 		}
 	}
 }
+
+func encodeWebSocketFrame(firstByte byte, payload []byte, masked bool) []byte {
+	frame := []byte{firstByte}
+	maskBit := byte(0)
+	if masked {
+		maskBit = 0x80
+	}
+
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, maskBit|byte(len(payload)))
+	case len(payload) < 65536:
+		frame = append(frame, maskBit|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(len(payload)))
+	}
+
+	if !masked {
+		return append(frame, payload...)
+	}
+
+	mask := []byte{0x12, 0x34, 0x56, 0x78}
+	frame = append(frame, mask...)
+	for i, c := range payload {
+		frame = append(frame, c^mask[i%4])
+	}
+	return frame
+}
+
+// Compresses the messages in the same context, as permessage-deflate does by default
+type webSocketCompressor struct {
+	buffer bytes.Buffer
+	writer *flate.Writer
+}
+
+func (c *webSocketCompressor) compress(message string) []byte {
+	if c.writer == nil {
+		c.writer, _ = flate.NewWriter(&c.buffer, flate.BestCompression)
+	}
+	c.buffer.Reset()
+	_, _ = c.writer.Write([]byte(message))
+	_ = c.writer.Flush()
+	compressed := c.buffer.Bytes()
+	return append([]byte(nil), compressed[:len(compressed)-4]...)
+}
+
+func TestDissectWebSocket(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 16)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	options := &api.TrafficFilteringOptions{}
+	counterPair := &api.CounterPair{}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+	stream := NewTcpStream(api.Pcap)
+
+	var client bytes.Buffer
+	client.WriteString("GET /chat?room=1 HTTP/1.1\r\nHost: example.com\r\nConnection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\nSec-WebSocket-Protocol: chat\r\nSec-WebSocket-Extensions: permessage-deflate\r\n\r\n")
+	client.Write(encodeWebSocketFrame(0x01, []byte("Hello, "), true))
+	client.Write(encodeWebSocketFrame(0x89, []byte("ping"), true))
+	client.Write(encodeWebSocketFrame(0x80, []byte("world!"), true))
+	client.Write(encodeWebSocketFrame(0x82, []byte{0x00, 0xff}, true))
+	client.Write(encodeWebSocketFrame(0x88, []byte{0x03, 0xe8}, true))
+
+	compressor := &webSocketCompressor{}
+	var server bytes.Buffer
+	server.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Accept: s3pPLMBiTxaQ9kYGzzhZRbK+xOo=\r\nSec-WebSocket-Protocol: chat\r\nSec-WebSocket-Extensions: permessage-deflate\r\n\r\n")
+	server.Write(encodeWebSocketFrame(0xc1, compressor.compress(`{"message":"welcome to the room"}`), false))
+	server.Write(encodeWebSocketFrame(0xc1, compressor.compress(`{"message":"welcome to the room"}`), false))
+	server.Write(encodeWebSocketFrame(0x88, append([]byte{0x03, 0xf3}, "internal error"...), false))
+
+	for _, side := range []struct {
+		data     []byte
+		isClient bool
+		tcpID    *api.TcpID
+	}{
+		{client.Bytes(), true, &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "1", DstPort: "2"}},
+		{server.Bytes(), false, &api.TcpID{SrcIP: "2", DstIP: "1", SrcPort: "2", DstPort: "1"}},
+	} {
+		reader := NewTcpReader(&api.ReadProgress{}, "", side.tcpID, time.Time{}, stream, side.isClient, false, nil, emitter, counterPair, reqResMatcher)
+		err := dissector.Dissect(bufio.NewReader(bytes.NewReader(side.data)), reader, options)
+		assert.Nil(t, err)
+	}
+
+	close(itemChannel)
+	var entries []*api.Entry
+	for item := range itemChannel {
+		// Simulate the round trip through the JSON encoding as the items do in Mizu
+		marshaled, err := json.Marshal(item)
+		assert.Nil(t, err)
+		var unmarshaled *api.OutputChannelItem
+		err = json.Unmarshal(marshaled, &unmarshaled)
+		assert.Nil(t, err)
+		entry := dissector.Analyze(unmarshaled, "", "", "")
+
+		// And the entries through the database
+		marshaled, err = json.Marshal(entry)
+		assert.Nil(t, err)
+		err = json.Unmarshal(marshaled, &entry)
+		assert.Nil(t, err)
+
+		representation, err := dissector.Represent(entry.Request, entry.Response)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, representation)
+		entries = append(entries, entry)
+	}
+	assert.Len(t, entries, 7)
+
+	text := entries[0]
+	assert.Equal(t, "WS", text.Protocol.Abbreviation)
+	assert.Equal(t, "Hello, world!", text.Request["data"])
+	assert.Equal(t, float64(2), text.Request["fragments"])
+	assert.Equal(t, "dGhlIHNhbXBsZSBub25jZQ==", text.Request["key"])
+	summary := dissector.Summarize(text)
+	assert.Equal(t, "text", summary.Method)
+	assert.Equal(t, "/chat", summary.Summary)
+
+	binaryMessage := entries[1]
+	assert.Equal(t, "AP8=", binaryMessage.Request["data"])
+	assert.Equal(t, "base64", binaryMessage.Request["encoding"])
+
+	assert.Equal(t, float64(1000), entries[2].Request["closeCode"])
+	assert.Equal(t, 0, dissector.Summarize(entries[2]).Status)
+
+	upgrade := entries[3]
+	assert.Equal(t, "HTTP", upgrade.Protocol.Abbreviation)
+	assert.Equal(t, 101, dissector.Summarize(upgrade).Status)
+
+	for _, compressed := range entries[4:6] {
+		assert.Equal(t, `{"message":"welcome to the room"}`, compressed.Request["data"])
+		assert.Equal(t, true, compressed.Request["compressed"])
+		assert.Equal(t, "server", compressed.Request["sender"])
+		assert.Equal(t, "chat", compressed.Request["subprotocol"])
+	}
+
+	summary = dissector.Summarize(entries[6])
+	assert.Equal(t, 1011, summary.Status)
+	assert.Equal(t, `request.closeCode == 1011`, summary.StatusQuery)
+}
+
+func encodeGrpcMessage(fieldNumber protowire.Number, value string) []byte {
+	message := protowire.AppendTag(nil, fieldNumber, protowire.BytesType)
+	message = protowire.AppendString(message, value)
+	message = protowire.AppendTag(message, 2, protowire.VarintType)
+	message = protowire.AppendVarint(message, 150)
+
+	header := make([]byte, grpcMessageHeaderLen)
+	binary.BigEndian.PutUint32(header[1:], uint32(len(message)))
+	return append(header, message...)
+}
+
+func writeHTTP2Headers(framer *http2.Framer, streamID uint32, endStream bool, fields ...string) {
+	var block bytes.Buffer
+	encoder := hpack.NewEncoder(&block)
+	for i := 0; i < len(fields); i += 2 {
+		_ = encoder.WriteField(hpack.HeaderField{Name: fields[i], Value: fields[i+1]})
+	}
+	_ = framer.WriteHeaders(http2.HeadersFrameParam{
+		StreamID:      streamID,
+		BlockFragment: block.Bytes(),
+		EndStream:     endStream,
+		EndHeaders:    true,
+	})
+}
+
+func greeterDescriptorSet(t *testing.T) []byte {
+	field := func(name string) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(1),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+		}
+	}
+	set, err := proto.Marshal(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{
+			{
+				Name:    proto.String("helloworld.proto"),
+				Package: proto.String("helloworld"),
+				Syntax:  proto.String("proto3"),
+				MessageType: []*descriptorpb.DescriptorProto{
+					{Name: proto.String("HelloRequest"), Field: []*descriptorpb.FieldDescriptorProto{field("name")}},
+					{Name: proto.String("HelloReply"), Field: []*descriptorpb.FieldDescriptorProto{field("message")}},
+				},
+				Service: []*descriptorpb.ServiceDescriptorProto{
+					{
+						Name: proto.String("Greeter"),
+						Method: []*descriptorpb.MethodDescriptorProto{
+							{
+								Name:       proto.String("SayHello"),
+								InputType:  proto.String(".helloworld.HelloRequest"),
+								OutputType: proto.String(".helloworld.HelloReply"),
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	assert.Nil(t, err)
+	return set
+}
+
+func dissectGrpc(t *testing.T, options *api.TrafficFilteringOptions) *api.Entry {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 16)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	counterPair := &api.CounterPair{}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+	stream := NewTcpStream(api.Pcap)
+
+	var client bytes.Buffer
+	client.WriteString(http2.ClientPreface)
+	clientFramer := http2.NewFramer(&client, nil)
+	_ = clientFramer.WriteSettings()
+	writeHTTP2Headers(clientFramer, 1, false, ":method", "POST", ":scheme", "http", ":path", "/helloworld.Greeter/SayHello", ":authority", "greeter:50051", "content-type", "application/grpc")
+	_ = clientFramer.WriteData(1, true, encodeGrpcMessage(1, "world"))
+
+	var server bytes.Buffer
+	serverFramer := http2.NewFramer(&server, nil)
+	_ = serverFramer.WriteSettings()
+	writeHTTP2Headers(serverFramer, 1, false, ":status", "200", "content-type", "application/grpc")
+	_ = serverFramer.WriteData(1, false, encodeGrpcMessage(1, "Hello world"))
+	writeHTTP2Headers(serverFramer, 1, true, "grpc-status", "0")
+
+	for _, side := range []struct {
+		data     []byte
+		isClient bool
+		tcpID    *api.TcpID
+	}{
+		{client.Bytes(), true, &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "1", DstPort: "2"}},
+		{server.Bytes(), false, &api.TcpID{SrcIP: "2", DstIP: "1", SrcPort: "2", DstPort: "1"}},
+	} {
+		reader := NewTcpReader(&api.ReadProgress{}, "", side.tcpID, time.Time{}, stream, side.isClient, false, nil, emitter, counterPair, reqResMatcher)
+		err := dissector.Dissect(bufio.NewReader(bytes.NewReader(side.data)), reader, options)
+		assert.Nil(t, err)
+	}
+
+	close(itemChannel)
+	item := <-itemChannel
+	assert.NotNil(t, item)
+	assert.Equal(t, "gRPC", item.Protocol.Abbreviation)
+
+	marshaled, err := json.Marshal(item)
+	assert.Nil(t, err)
+	var unmarshaled *api.OutputChannelItem
+	err = json.Unmarshal(marshaled, &unmarshaled)
+	assert.Nil(t, err)
+	entry := dissector.Analyze(unmarshaled, "", "", "")
+
+	marshaled, err = json.Marshal(entry)
+	assert.Nil(t, err)
+	err = json.Unmarshal(marshaled, &entry)
+	assert.Nil(t, err)
+
+	representation, err := dissector.Represent(entry.Request, entry.Response)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, representation)
+
+	summary := dissector.Summarize(entry)
+	assert.Equal(t, "helloworld.Greeter", summary.Summary)
+	assert.Equal(t, `request.service == "helloworld.Greeter"`, summary.SummaryQuery)
+	assert.Equal(t, "SayHello", summary.Method)
+	assert.Equal(t, `request.rpcMethod == "SayHello"`, summary.MethodQuery)
+	return entry
+}
+
+func TestDissectGrpc(t *testing.T) {
+	entry := dissectGrpc(t, &api.TrafficFilteringOptions{
+		GrpcDescriptorSets: [][]byte{greeterDescriptorSet(t)},
+	})
+
+	request := entry.Request["messages"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "helloworld.HelloRequest", request["type"])
+	assert.Equal(t, map[string]interface{}{"name": "world"}, request["data"])
+
+	response := entry.Response["messages"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "helloworld.HelloReply", response["type"])
+	assert.Equal(t, map[string]interface{}{"message": "Hello world"}, response["data"])
+}
+
+func TestDissectGrpcSchemaless(t *testing.T) {
+	entry := dissectGrpc(t, &api.TrafficFilteringOptions{})
+
+	request := entry.Request["messages"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "", request["type"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"number": float64(1), "wireType": "bytes", "value": "world"},
+		map[string]interface{}{"number": float64(2), "wireType": "varint", "value": float64(150)},
+	}, request["data"])
+}
+
+func TestNewUnansweredItem(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 1)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+
+	var grpcClient bytes.Buffer
+	grpcClient.WriteString(http2.ClientPreface)
+	clientFramer := http2.NewFramer(&grpcClient, nil)
+	_ = clientFramer.WriteSettings()
+	writeHTTP2Headers(clientFramer, 1, false, ":method", "POST", ":scheme", "http", ":path", "/helloworld.Greeter/SayHello", ":authority", "greeter:50051", "content-type", "application/grpc")
+	_ = clientFramer.WriteData(1, true, encodeGrpcMessage(1, "world"))
+
+	for _, test := range []struct {
+		data         []byte
+		abbreviation string
+		version      string
+		summary      string
+	}{
+		{[]byte("GET /slow HTTP/1.1\r\nHost: example.com\r\n\r\n"), "HTTP", "1.1", "/slow"},
+		{[]byte("GET /slow HTTP/1.0\r\n\r\n"), "HTTP", "1.0", "/slow"},
+		{grpcClient.Bytes(), "gRPC", "2.0", "/helloworld.Greeter/SayHello"},
+	} {
+		reqResMatcher := dissector.NewResponseRequestMatcher()
+		tcpID := &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "40000", DstPort: "80"}
+		reader := NewTcpReader(&api.ReadProgress{}, "", tcpID, time.Time{}, NewTcpStream(api.Pcap), true, false, nil, emitter, &api.CounterPair{}, reqResMatcher)
+		_ = dissector.Dissect(bufio.NewReader(bytes.NewReader(test.data)), reader, &api.TrafficFilteringOptions{})
+
+		items := apitest.UnansweredItems(reqResMatcher)
+		assert.Len(t, items, 1)
+		assert.Equal(t, test.abbreviation, items[0].Protocol.Abbreviation)
+		assert.Equal(t, test.version, items[0].Protocol.Version)
+
+		items[0].ConnectionInfo = &api.ConnectionInfo{ClientIP: "1", ClientPort: "40000", ServerIP: "2", ServerPort: "80"}
+		_, summary := apitest.AnalyzeUnanswered(t, dissector, items[0])
+		assert.Equal(t, test.summary, summary.Summary)
+		assert.Equal(t, 0, summary.Status)
+	}
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
@@ -9,12 +10,17 @@ import (
 )
 
 // Key is {client_addr}_{client_port}_{dest_addr}_{dest_port}_{incremental_counter}_{proto_ident}
+// The matchers are created per TCP stream, so they also keep the WebSocket upgrade of the connection.
 type requestResponseMatcher struct {
-	openMessagesMap *sync.Map
+	openMessagesMap  *sync.Map
+	webSocketUpgrade *webSocketUpgrade
 }
 
 func createResponseRequestMatcher() api.RequestResponseMatcher {
-	return &requestResponseMatcher{openMessagesMap: &sync.Map{}}
+	return &requestResponseMatcher{
+		openMessagesMap:  &sync.Map{},
+		webSocketUpgrade: &webSocketUpgrade{},
+	}
 }
 
 func (matcher *requestResponseMatcher) GetMap() *sync.Map {
@@ -87,3 +93,56 @@ func (matcher *requestResponseMatcher) preparePair(requestHTTPMessage *api.Gener
 		},
 	}
 }
+
+func (matcher *requestResponseMatcher) NewUnansweredItem(key interface{}, value interface{}) *api.OutputChannelItem {
+	request, ok := value.(*api.GenericMessage)
+	if !ok || !request.IsRequest {
+		return nil
+	}
+
+	payload, ok := request.Payload.(HTTPPayload)
+	if !ok || payload.Type != TypeHttpRequest {
+		return nil
+	}
+
+	protocol := http11protocol
+	httpRequest := payload.Data.(*http.Request)
+	switch {
+	case httpRequest.ProtoMajor == 2 && strings.Contains(httpRequest.Header.Get("Content-Type"), "application/grpc"):
+		protocol = grpcProtocol
+	case httpRequest.ProtoMajor == 2:
+		protocol = http2Protocol
+	case httpRequest.ProtoMinor == 0:
+		protocol = http10protocol
+	}
+
+	return api.NewUnansweredItem(protocol, request)
+}
+
+// The WebSocket messages are not answered, each is paired with an empty response
+func (matcher *requestResponseMatcher) registerWebSocketMessage(message *WebSocketMessage, captureTime time.Time, captureSize int) *api.OutputChannelItem {
+	return &api.OutputChannelItem{
+		Protocol:       webSocketProtocol,
+		Timestamp:      captureTime.UnixNano() / int64(time.Millisecond),
+		ConnectionInfo: nil,
+		Pair: &api.RequestResponsePair{
+			Request: api.GenericMessage{
+				IsRequest:   true,
+				CaptureTime: captureTime,
+				CaptureSize: captureSize,
+				Payload: HTTPPayload{
+					Type: TypeWebSocketMessage,
+					Data: message,
+				},
+			},
+			Response: api.GenericMessage{
+				IsRequest:   false,
+				CaptureTime: captureTime,
+				Payload: HTTPPayload{
+					Type: TypeWebSocketMessage,
+					Data: &WebSocketMessage{},
+				},
+			},
+		},
+	}
+}
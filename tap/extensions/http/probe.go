@@ -0,0 +1,87 @@
+package http
+
+import (
+	"bytes"
+
+	"github.com/up9inc/mizu/tap/api"
+	"golang.org/x/net/http2"
+)
+
+var knownMethods = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"POST":    true,
+	"PUT":     true,
+	"DELETE":  true,
+	"CONNECT": true,
+	"OPTIONS": true,
+	"TRACE":   true,
+	"PATCH":   true,
+}
+
+var http1ResponsePrefix = []byte("HTTP/1.")
+
+// The methods are tokens, the ones other than the known methods (e.g. WebDAV) are only likely
+const maxMethodLength = 20
+
+func probe(peek []byte, isClient bool) api.Confidence {
+	if isClient {
+		return probeRequest(peek)
+	}
+	return probeResponse(peek)
+}
+
+func probeRequest(peek []byte) api.Confidence {
+	if bytes.HasPrefix(peek, clientPreface) {
+		return api.ConfidenceHigh
+	}
+
+	for i, c := range peek {
+		if c == ' ' {
+			if i == 0 {
+				return api.ConfidenceNone
+			}
+			if knownMethods[string(peek[:i])] {
+				return api.ConfidenceHigh
+			}
+			return api.ConfidenceLow
+		}
+		if i >= maxMethodLength || !isMethodChar(c) {
+			return probeText(peek)
+		}
+	}
+
+	return api.ConfidenceUnknown
+}
+
+func probeResponse(peek []byte) api.Confidence {
+	if len(peek) < len(http1ResponsePrefix) {
+		if bytes.HasPrefix(http1ResponsePrefix, peek) {
+			return api.ConfidenceUnknown
+		}
+	} else if bytes.HasPrefix(peek, http1ResponsePrefix) {
+		return api.ConfidenceHigh
+	}
+
+	// The server connection preface of HTTP/2 is a settings frame
+	if len(peek) >= frameHeaderLen && http2.FrameType(peek[3]) == http2.FrameSettings {
+		return api.ConfidenceLow
+	}
+
+	return probeText(peek)
+}
+
+// The capture may start in the middle of a connection, where the messages are resynchronized from.
+// The binary data can not be an HTTP/1.x stream though.
+func probeText(peek []byte) api.Confidence {
+	for _, c := range peek {
+		if c < 0x20 && c != '\r' && c != '\n' && c != '\t' {
+			return api.ConfidenceNone
+		}
+	}
+	return api.ConfidenceUnknown
+}
+
+func isMethodChar(c byte) bool {
+	return (c >= 'A' && c <= 'Z') || c == '-' || c == '_'
+}
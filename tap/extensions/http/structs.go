@@ -13,6 +13,7 @@ import (
 type HTTPPayload struct {
 	Type uint8
 	Data interface{}
+	Grpc *GrpcMessages
 }
 
 type HTTPPayloader interface {
@@ -25,6 +26,18 @@ type HTTPWrapper struct {
 	Details interface{} `json:"details"`
 }
 
+type grpcRequestDetails struct {
+	*har.Request
+	Service   string         `json:"service"`
+	RpcMethod string         `json:"rpcMethod"`
+	Messages  []*GrpcMessage `json:"messages"`
+}
+
+type grpcResponseDetails struct {
+	*har.Response
+	Messages []*GrpcMessage `json:"messages"`
+}
+
 func (h HTTPPayload) MarshalJSON() ([]byte, error) {
 	switch h.Type {
 	case TypeHttpRequest:
@@ -61,10 +74,19 @@ func (h HTTPPayload) MarshalJSON() ([]byte, error) {
 				return harRequest.PostData.Params[i].Value < harRequest.PostData.Params[j].Value
 			})
 		}
+		var details interface{} = harRequest
+		if h.Grpc != nil {
+			details = &grpcRequestDetails{
+				Request:   harRequest,
+				Service:   h.Grpc.Service,
+				RpcMethod: h.Grpc.RpcMethod,
+				Messages:  h.Grpc.Messages,
+			}
+		}
 		return json.Marshal(&HTTPWrapper{
 			Method:  harRequest.Method,
 			Url:     "",
-			Details: harRequest,
+			Details: details,
 		})
 	case TypeHttpResponse:
 		harResponse, err := har.NewResponse(h.Data.(*http.Response), true)
@@ -89,10 +111,24 @@ func (h HTTPPayload) MarshalJSON() ([]byte, error) {
 			}
 			return harResponse.Cookies[i].Value < harResponse.Cookies[j].Value
 		})
+		var details interface{} = harResponse
+		if h.Grpc != nil {
+			details = &grpcResponseDetails{
+				Response: harResponse,
+				Messages: h.Grpc.Messages,
+			}
+		}
 		return json.Marshal(&HTTPWrapper{
 			Method:  "",
 			Url:     "",
-			Details: harResponse,
+			Details: details,
+		})
+	case TypeWebSocketMessage:
+		message := h.Data.(*WebSocketMessage)
+		return json.Marshal(&HTTPWrapper{
+			Method:  message.Opcode,
+			Url:     message.Url,
+			Details: message,
 		})
 	default:
 		panic(fmt.Sprintf("HTTP payload cannot be marshaled: %v", h.Type))
@@ -0,0 +1,477 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+const (
+	webSocketContinuation = 0x0
+	webSocketText         = 0x1
+	webSocketBinary       = 0x2
+	webSocketClose        = 0x8
+	webSocketPing         = 0x9
+	webSocketPong         = 0xa
+)
+
+var webSocketOpcodes = map[byte]string{
+	webSocketContinuation: "continuation",
+	webSocketText:         "text",
+	webSocketBinary:       "binary",
+	webSocketClose:        "close",
+	webSocketPing:         "ping",
+	webSocketPong:         "pong",
+}
+
+const (
+	maxWebSocketMessageSize = 64 * 1024 * 1024
+	maxDeflateWindow        = 32 * 1024
+)
+
+var errMalformedWebSocketFrame = errors.New("Malformed WebSocket frame")
+
+// The upgrade request and the 101 Switching Protocols response are seen by the two sides
+// of the connection, so what each of them knows is kept in the matcher of the connection.
+type webSocketUpgrade struct {
+	url         string
+	path        string
+	host        string
+	key         string
+	subprotocol string
+	sync.Mutex
+}
+
+type WebSocketMessage struct {
+	Opcode      string `json:"opcode"`
+	Sender      string `json:"sender"`
+	Url         string `json:"url"`
+	Path        string `json:"path"`
+	Host        string `json:"host"`
+	Key         string `json:"key"`
+	Subprotocol string `json:"subprotocol"`
+	Compressed  bool   `json:"compressed"`
+	Fragments   int    `json:"fragments"`
+	Size        int    `json:"size"`
+	Data        string `json:"data"`
+	Encoding    string `json:"encoding"`
+	CloseCode   int    `json:"closeCode"`
+	CloseReason string `json:"closeReason"`
+}
+
+type webSocketFrame struct {
+	fin        bool
+	compressed bool
+	opcode     byte
+	payload    []byte
+}
+
+// Reads the messages of one direction of the connection
+type webSocketReader struct {
+	b        *bufio.Reader
+	isClient bool
+	// The last 32KB of the decompressed messages, since permessage-deflate may refer back to them
+	window []byte
+}
+
+func isWebSocketUpgrade(header http.Header) bool {
+	return strings.Contains(strings.ToLower(header.Get("Connection")), "upgrade") && strings.ToLower(header.Get("Upgrade")) == "websocket"
+}
+
+func (upgrade *webSocketUpgrade) fromRequest(req *http.Request) {
+	upgrade.Lock()
+	defer upgrade.Unlock()
+	upgrade.url = req.URL.String()
+	upgrade.path = req.URL.Path
+	upgrade.host = req.Host
+	upgrade.key = req.Header.Get("Sec-WebSocket-Key")
+}
+
+func (upgrade *webSocketUpgrade) fromResponse(res *http.Response) {
+	upgrade.Lock()
+	defer upgrade.Unlock()
+	upgrade.subprotocol = res.Header.Get("Sec-WebSocket-Protocol")
+}
+
+func (upgrade *webSocketUpgrade) label(message *WebSocketMessage) {
+	upgrade.Lock()
+	defer upgrade.Unlock()
+	message.Url = upgrade.url
+	message.Path = upgrade.path
+	message.Host = upgrade.host
+	message.Key = upgrade.key
+	message.Subprotocol = upgrade.subprotocol
+}
+
+// The frame is peeked until it's known to be valid, so that the stream can fall back to HTTP
+// if the upgrade was refused.
+func (r *webSocketReader) readFrame() (*webSocketFrame, error) {
+	header, err := r.b.Peek(2)
+	if err != nil {
+		return nil, err
+	}
+
+	frame := &webSocketFrame{
+		fin:        header[0]&0x80 != 0,
+		compressed: header[0]&0x40 != 0,
+		opcode:     header[0] & 0x0f,
+	}
+	if header[0]&0x30 != 0 {
+		return nil, errMalformedWebSocketFrame
+	}
+	if _, ok := webSocketOpcodes[frame.opcode]; !ok {
+		return nil, fmt.Errorf("Unrecognized WebSocket opcode: %d", frame.opcode)
+	}
+
+	// The clients must mask their frames and the servers must not
+	masked := header[1]&0x80 != 0
+	if masked != r.isClient {
+		return nil, errMalformedWebSocketFrame
+	}
+
+	headerLength := 2
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		headerLength = 4
+		if header, err = r.b.Peek(headerLength); err != nil {
+			return nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(header[2:]))
+	case 127:
+		headerLength = 10
+		if header, err = r.b.Peek(headerLength); err != nil {
+			return nil, err
+		}
+		length = binary.BigEndian.Uint64(header[2:])
+	}
+
+	if length > maxWebSocketMessageSize {
+		return nil, fmt.Errorf("WebSocket frame is too large: %d", length)
+	}
+	if frame.opcode >= webSocketClose && (length > 125 || !frame.fin) {
+		return nil, errMalformedWebSocketFrame
+	}
+
+	var mask []byte
+	if masked {
+		if header, err = r.b.Peek(headerLength + 4); err != nil {
+			return nil, err
+		}
+		mask = append(mask, header[headerLength:headerLength+4]...)
+		headerLength += 4
+	}
+
+	if _, err = r.b.Discard(headerLength); err != nil {
+		return nil, err
+	}
+	frame.payload = make([]byte, length)
+	if _, err = io.ReadFull(r.b, frame.payload); err != nil {
+		return nil, err
+	}
+
+	if mask != nil {
+		for i := range frame.payload {
+			frame.payload[i] ^= mask[i%4]
+		}
+	}
+
+	return frame, nil
+}
+
+// Reads the frames until a whole data message or a close frame. The control frames may come
+// in between the fragments of a message, and the pings and pongs are left out.
+func (r *webSocketReader) readMessage() (*WebSocketMessage, error) {
+	var message *WebSocketMessage
+	var payload []byte
+	compressed := false
+
+	for {
+		frame, err := r.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch frame.opcode {
+		case webSocketPing, webSocketPong:
+			continue
+		case webSocketClose:
+			close := &WebSocketMessage{
+				Opcode:    webSocketOpcodes[frame.opcode],
+				Fragments: 1,
+				Size:      len(frame.payload),
+			}
+			if len(frame.payload) >= 2 {
+				close.CloseCode = int(binary.BigEndian.Uint16(frame.payload))
+				close.CloseReason = string(frame.payload[2:])
+			}
+			return close, nil
+		case webSocketText, webSocketBinary:
+			if message != nil {
+				return nil, errMalformedWebSocketFrame
+			}
+			message = &WebSocketMessage{Opcode: webSocketOpcodes[frame.opcode]}
+			compressed = frame.compressed
+		case webSocketContinuation:
+			if message == nil {
+				return nil, errMalformedWebSocketFrame
+			}
+		}
+
+		message.Fragments++
+		payload = append(payload, frame.payload...)
+		if len(payload) > maxWebSocketMessageSize {
+			return nil, fmt.Errorf("WebSocket message is too large: %d", len(payload))
+		}
+		if frame.fin {
+			break
+		}
+	}
+
+	if compressed {
+		inflated, err := r.inflate(payload)
+		if err != nil {
+			return nil, err
+		}
+		message.Compressed = true
+		payload = inflated
+	}
+
+	message.Size = len(payload)
+	if message.Opcode == webSocketOpcodes[webSocketText] && utf8.Valid(payload) {
+		message.Data = string(payload)
+	} else {
+		message.Data = base64.StdEncoding.EncodeToString(payload)
+		message.Encoding = "base64"
+	}
+	return message, nil
+}
+
+// permessage-deflate strips the trailing empty block of each message. Unless the context takeover
+// is disabled the compressor refers back to the previous messages, which are given as the dictionary.
+// Giving it when the takeover is disabled is harmless, since nothing refers to it then.
+func (r *webSocketReader) inflate(payload []byte) ([]byte, error) {
+	reader := flate.NewReaderDict(io.MultiReader(bytes.NewReader(payload), bytes.NewReader([]byte{0x00, 0x00, 0xff, 0xff})), r.window)
+	inflated, err := ioutil.ReadAll(io.LimitReader(reader, maxWebSocketMessageSize))
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	r.window = append(r.window, inflated...)
+	if len(r.window) > maxDeflateWindow {
+		r.window = append([]byte(nil), r.window[len(r.window)-maxDeflateWindow:]...)
+	}
+	return inflated, nil
+}
+
+func handleWebSocketStream(r *webSocketReader, upgrade *webSocketUpgrade, progress *api.ReadProgress, capture api.Capture, tcpID *api.TcpID, captureTime time.Time, emitter api.Emitter, reqResMatcher *requestResponseMatcher) error {
+	message, err := r.readMessage()
+	if err != nil {
+		return err
+	}
+
+	upgrade.label(message)
+	if r.isClient {
+		message.Sender = "client"
+	} else {
+		message.Sender = "server"
+	}
+
+	item := reqResMatcher.registerWebSocketMessage(message, captureTime, progress.Current())
+	if r.isClient {
+		item.ConnectionInfo = &api.ConnectionInfo{
+			ClientIP:   tcpID.SrcIP,
+			ClientPort: tcpID.SrcPort,
+			ServerIP:   tcpID.DstIP,
+			ServerPort: tcpID.DstPort,
+			IsOutgoing: true,
+		}
+	} else {
+		item.ConnectionInfo = &api.ConnectionInfo{
+			ClientIP:   tcpID.DstIP,
+			ClientPort: tcpID.DstPort,
+			ServerIP:   tcpID.SrcIP,
+			ServerPort: tcpID.SrcPort,
+			IsOutgoing: false,
+		}
+	}
+	item.Capture = capture
+	emitter.Emit(item)
+
+	return nil
+}
+
+func analyzeWebSocket(item *api.OutputChannelItem, resolvedSource string, resolvedDestination string, namespace string) *api.Entry {
+	request := item.Pair.Request.Payload.(map[string]interface{})
+	response := item.Pair.Response.Payload.(map[string]interface{})
+	reqDetails := request["details"].(map[string]interface{})
+	resDetails := response["details"].(map[string]interface{})
+
+	if resolvedDestination == "" {
+		resolvedDestination = reqDetails["host"].(string)
+	}
+
+	return &api.Entry{
+		Protocol: item.Protocol.ProtocolSummary,
+		Capture:  item.Capture,
+		Source: &api.TCP{
+			Name: resolvedSource,
+			IP:   item.ConnectionInfo.ClientIP,
+			Port: item.ConnectionInfo.ClientPort,
+		},
+		Destination: &api.TCP{
+			Name: resolvedDestination,
+			IP:   item.ConnectionInfo.ServerIP,
+			Port: item.ConnectionInfo.ServerPort,
+		},
+		Namespace:    namespace,
+		Outgoing:     item.ConnectionInfo.IsOutgoing,
+		Request:      reqDetails,
+		Response:     resDetails,
+		RequestSize:  item.Pair.Request.CaptureSize,
+		ResponseSize: item.Pair.Response.CaptureSize,
+		Timestamp:    item.Timestamp,
+		StartTime:    item.Pair.Request.CaptureTime,
+		ElapsedTime:  0,
+	}
+}
+
+func summarizeWebSocket(entry *api.Entry) *api.BaseEntry {
+	summary := entry.Request["path"].(string)
+	summaryQuery := fmt.Sprintf(`request.path == "%s"`, summary)
+	method := entry.Request["opcode"].(string)
+	methodQuery := fmt.Sprintf(`request.opcode == "%s"`, method)
+
+	// The closures other than the normal ones and the endpoints going away are the errors
+	status := 0
+	statusQuery := ""
+	closeCode := int(entry.Request["closeCode"].(float64))
+	if closeCode > 1001 {
+		status = closeCode
+		statusQuery = fmt.Sprintf(`request.closeCode == %d`, status)
+	}
+
+	return &api.BaseEntry{
+		Id:           entry.Id,
+		Protocol:     *protocolsMap[entry.Protocol.ToString()],
+		Capture:      entry.Capture,
+		Summary:      summary,
+		SummaryQuery: summaryQuery,
+		Status:       status,
+		StatusQuery:  statusQuery,
+		Method:       method,
+		MethodQuery:  methodQuery,
+		Timestamp:    entry.Timestamp,
+		Source:       entry.Source,
+		Destination:  entry.Destination,
+		IsOutgoing:   entry.Outgoing,
+		Latency:      entry.ElapsedTime,
+	}
+}
+
+func representWebSocketMessage(message map[string]interface{}) (repMessage []interface{}) {
+	repMessage = make([]interface{}, 0)
+
+	// The empty response of a message
+	if message["opcode"].(string) == "" {
+		return
+	}
+
+	details, _ := json.Marshal([]api.TableData{
+		{
+			Name:     "Opcode",
+			Value:    message["opcode"].(string),
+			Selector: `request.opcode`,
+		},
+		{
+			Name:     "Sender",
+			Value:    message["sender"].(string),
+			Selector: `request.sender`,
+		},
+		{
+			Name:     "URL",
+			Value:    message["url"].(string),
+			Selector: `request.url`,
+		},
+		{
+			Name:     "Subprotocol",
+			Value:    message["subprotocol"].(string),
+			Selector: `request.subprotocol`,
+		},
+		{
+			Name:     "Sec-WebSocket-Key",
+			Value:    message["key"].(string),
+			Selector: `request.key`,
+		},
+		{
+			Name:     "Size (bytes)",
+			Value:    int64(message["size"].(float64)),
+			Selector: `request.size`,
+		},
+		{
+			Name:     "Fragments",
+			Value:    int64(message["fragments"].(float64)),
+			Selector: `request.fragments`,
+		},
+		{
+			Name:     "Compressed",
+			Value:    message["compressed"].(bool),
+			Selector: `request.compressed`,
+		},
+	})
+	repMessage = append(repMessage, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Details",
+		Data:  string(details),
+	})
+
+	if message["opcode"].(string) == webSocketOpcodes[webSocketClose] {
+		closure, _ := json.Marshal([]api.TableData{
+			{
+				Name:     "Close Code",
+				Value:    int64(message["closeCode"].(float64)),
+				Selector: `request.closeCode`,
+			},
+			{
+				Name:     "Close Reason",
+				Value:    message["closeReason"].(string),
+				Selector: `request.closeReason`,
+			},
+		})
+		repMessage = append(repMessage, api.SectionData{
+			Type:  api.TABLE,
+			Title: "Close",
+			Data:  string(closure),
+		})
+		return
+	}
+
+	mimeType := "application/octet-stream"
+	if message["opcode"].(string) == webSocketOpcodes[webSocketText] {
+		mimeType = "text/plain"
+	}
+	repMessage = append(repMessage, api.SectionData{
+		Type:     api.BODY,
+		Title:    "Data",
+		Encoding: message["encoding"].(string),
+		MimeType: mimeType,
+		Data:     message["data"].(string),
+		Selector: `request.data`,
+	})
+
+	return
+}
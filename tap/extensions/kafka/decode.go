@@ -153,6 +153,15 @@ func (d *decoder) decodeRecordV0(v value) {
 	v.val.Set(valueOf(x).val)
 }
 
+// The tagged fields of the request and response headers are skipped, none of them are known
+func (d *decoder) discardTaggedFields() {
+	n := int(d.readUnsignedVarInt())
+	for i := 0; i < n && d.err == nil; i++ {
+		d.readUnsignedVarInt() // tag
+		d.discard(int(d.readUnsignedVarInt()))
+	}
+}
+
 func (d *decoder) discardAll() {
 	d.discard(d.remain)
 }
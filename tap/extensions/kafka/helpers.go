@@ -872,6 +872,89 @@ func representDeleteTopicsResponse(data map[string]interface{}) []interface{} {
 	return rep
 }
 
+// The consumer group APIs are summarized by their group and member IDs. The member ID of a new
+// member is assigned by the coordinator, so it's only in the response of its first JoinGroup.
+func summarizeGroup(apiKey ApiKey, entry *api.Entry) (summary string, summaryQuery string) {
+	request := entry.Request["payload"].(map[string]interface{})
+	response := entry.Response["payload"].(map[string]interface{})
+
+	var values []string
+	var queries []string
+	add := func(value interface{}, selector string) {
+		if value, ok := value.(string); ok && value != "" {
+			values = append(values, value)
+			queries = append(queries, fmt.Sprintf(`%s == "%s"`, selector, value))
+		}
+	}
+
+	switch apiKey {
+	case FindCoordinator:
+		add(request["key"], `request.payload.key`)
+		if keys, ok := request["coordinatorKeys"].([]interface{}); ok {
+			for i, key := range keys {
+				add(key, fmt.Sprintf(`request.payload.coordinatorKeys[%d]`, i))
+			}
+		}
+	case OffsetFetch:
+		add(request["groupId"], `request.payload.groupId`)
+		if groups, ok := request["groups"].([]interface{}); ok {
+			for i, group := range groups {
+				add(group.(map[string]interface{})["groupId"], fmt.Sprintf(`request.payload.groups[%d].groupId`, i))
+			}
+		}
+	case LeaveGroup:
+		add(request["groupId"], `request.payload.groupId`)
+		add(request["memberId"], `request.payload.memberId`)
+		if members, ok := request["members"].([]interface{}); ok {
+			for i, member := range members {
+				add(member.(map[string]interface{})["memberId"], fmt.Sprintf(`request.payload.members[%d].memberId`, i))
+			}
+		}
+	case JoinGroup:
+		add(request["groupId"], `request.payload.groupId`)
+		if memberId, _ := request["memberId"].(string); memberId != "" {
+			add(memberId, `request.payload.memberId`)
+		} else {
+			add(response["memberId"], `response.payload.memberId`)
+		}
+	default:
+		add(request["groupId"], `request.payload.groupId`)
+		add(request["memberId"], `request.payload.memberId`)
+	}
+
+	summary = strings.Join(values, ", ")
+	summaryQuery = strings.Join(queries, " and ")
+	return
+}
+
+func representGroupRequest(data map[string]interface{}) []interface{} {
+	rep := make([]interface{}, 0)
+
+	rep = representRequestHeader(data, rep)
+
+	rep = append(rep, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Payload",
+		Data:  representMapAsTable(data["payload"].(map[string]interface{}), `request.payload`, []string{}),
+	})
+
+	return rep
+}
+
+func representGroupResponse(data map[string]interface{}) []interface{} {
+	rep := make([]interface{}, 0)
+
+	rep = representResponseHeader(data, rep)
+
+	rep = append(rep, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Payload",
+		Data:  representMapAsTable(data["payload"].(map[string]interface{}), `response.payload`, []string{}),
+	})
+
+	return rep
+}
+
 func contains(s []string, str string) bool {
 	for _, v := range s {
 		if v == str {
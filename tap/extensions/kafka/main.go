@@ -44,6 +44,10 @@ func (d dissecting) Ping() {
 	log.Printf("pong %s", _protocol.Name)
 }
 
+func (d dissecting) Probe(peek []byte, isClient bool) api.Confidence {
+	return probe(peek, isClient)
+}
+
 func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.TrafficFilteringOptions) error {
 	reqResMatcher := reader.GetReqResMatcher().(*requestResponseMatcher)
 	for {
@@ -100,6 +104,10 @@ func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
 	status := 0
 	statusQuery := ""
 
+	if api.IsUnanswered(entry.Response) {
+		statusQuery = api.UnansweredStatusQuery
+	}
+
 	apiKey := ApiKey(entry.Request["apiKey"].(float64))
 	method := entry.Request["apiKeyName"].(string)
 	methodQuery := fmt.Sprintf(`request.apiKeyName == "%s"`, method)
@@ -193,6 +201,12 @@ func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
 			summary = summary[:len(summary)-2]
 			summaryQuery = summaryQuery[:len(summaryQuery)-4]
 		}
+	case FindCoordinator, JoinGroup, SyncGroup, Heartbeat, LeaveGroup, OffsetCommit, OffsetFetch:
+		summary, summaryQuery = summarizeGroup(apiKey, entry)
+		if errorCode, ok := entry.Response["payload"].(map[string]interface{})["errorCode"].(float64); ok {
+			status = int(errorCode)
+			statusQuery = fmt.Sprintf(`response.payload.errorCode == %d`, status)
+		}
 	}
 
 	return &api.BaseEntry{
@@ -218,36 +232,59 @@ func (d dissecting) Represent(request map[string]interface{}, response map[strin
 
 	apiKey := ApiKey(request["apiKey"].(float64))
 
-	var repRequest []interface{}
-	var repResponse []interface{}
+	representation["request"] = representRequest(apiKey, request)
+	// The responses of the produce requests with no acknowledgements are never sent
+	if api.IsUnanswered(response) {
+		representation["response"] = api.RepresentUnanswered()
+	} else {
+		representation["response"] = representResponse(apiKey, response)
+	}
+	object, err = json.Marshal(representation)
+	return
+}
+
+func representRequest(apiKey ApiKey, request map[string]interface{}) []interface{} {
 	switch apiKey {
 	case Metadata:
-		repRequest = representMetadataRequest(request)
-		repResponse = representMetadataResponse(response)
+		return representMetadataRequest(request)
 	case ApiVersions:
-		repRequest = representApiVersionsRequest(request)
-		repResponse = representApiVersionsResponse(response)
+		return representApiVersionsRequest(request)
 	case Produce:
-		repRequest = representProduceRequest(request)
-		repResponse = representProduceResponse(response)
+		return representProduceRequest(request)
 	case Fetch:
-		repRequest = representFetchRequest(request)
-		repResponse = representFetchResponse(response)
+		return representFetchRequest(request)
 	case ListOffsets:
-		repRequest = representListOffsetsRequest(request)
-		repResponse = representListOffsetsResponse(response)
+		return representListOffsetsRequest(request)
 	case CreateTopics:
-		repRequest = representCreateTopicsRequest(request)
-		repResponse = representCreateTopicsResponse(response)
+		return representCreateTopicsRequest(request)
 	case DeleteTopics:
-		repRequest = representDeleteTopicsRequest(request)
-		repResponse = representDeleteTopicsResponse(response)
+		return representDeleteTopicsRequest(request)
+	case FindCoordinator, JoinGroup, SyncGroup, Heartbeat, LeaveGroup, OffsetCommit, OffsetFetch:
+		return representGroupRequest(request)
 	}
+	return nil
+}
 
-	representation["request"] = repRequest
-	representation["response"] = repResponse
-	object, err = json.Marshal(representation)
-	return
+func representResponse(apiKey ApiKey, response map[string]interface{}) []interface{} {
+	switch apiKey {
+	case Metadata:
+		return representMetadataResponse(response)
+	case ApiVersions:
+		return representApiVersionsResponse(response)
+	case Produce:
+		return representProduceResponse(response)
+	case Fetch:
+		return representFetchResponse(response)
+	case ListOffsets:
+		return representListOffsetsResponse(response)
+	case CreateTopics:
+		return representCreateTopicsResponse(response)
+	case DeleteTopics:
+		return representDeleteTopicsResponse(response)
+	case FindCoordinator, JoinGroup, SyncGroup, Heartbeat, LeaveGroup, OffsetCommit, OffsetFetch:
+		return representGroupResponse(response)
+	}
+	return nil
 }
 
 func (d dissecting) Macros() map[string]string {
@@ -2,6 +2,8 @@ package kafka
 
 import (
 	"bufio"
+	"bytes"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -56,6 +58,31 @@ func TestPing(t *testing.T) {
 	dissector.Ping()
 }
 
+func TestProbe(t *testing.T) {
+	dissector := NewDissector().(api.Prober)
+
+	tests := []struct {
+		peek       string
+		isClient   bool
+		confidence api.Confidence
+	}{
+		{"\x00\x00\x00\x20\x00\x03\x00\x09", true, api.ConfidenceLow},
+		{"\x00\x00\x00\x20\x01\x03\x00\x09", true, api.ConfidenceNone},
+		{"\x00\x00\x00\x20\x00\x00\x00\x01", false, api.ConfidenceUnknown},
+		{"\x00\x00\x00\x04\x00\x00\x00\x01", false, api.ConfidenceUnknown},
+		{"\x00\x00\x00\x03\x00\x00\x00\x01", false, api.ConfidenceNone},
+		{"\x00\x10\x00\x00\x00\x03\x00\x09", true, api.ConfidenceLow},
+		{"\x00\x10\x00\x01\x00\x03\x00\x09", true, api.ConfidenceNone},
+		{"\x00\x00\x00\x07\x00\x03\x00\x09", true, api.ConfidenceNone},
+		{"\x00\x00\x00", true, api.ConfidenceUnknown},
+		{"GET / HTTP/1.1\r\n", true, api.ConfidenceNone},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.confidence, dissector.Probe([]byte(test.peek), test.isClient), test.peek)
+	}
+}
+
 func TestDissect(t *testing.T) {
 	_, testUpdateEnabled := os.LookupEnv(testUpdate)
 
@@ -373,3 +400,236 @@ func TestRepresent(t *testing.T) {
 		}
 	}
 }
+
+func TestUnansweredRequest(t *testing.T) {
+	matcher := createResponseRequestMatcher().(*requestResponseMatcher)
+	captureTime := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	request := &Request{
+		Size:        48,
+		ApiKeyName:  apiNames[Produce],
+		ApiKey:      Produce,
+		ApiVersion:  3,
+		ClientID:    "producer",
+		Payload:     &ProduceRequestV3{RequiredAcks: RequireNone, TopicData: []TopicData{{Topic: "orders"}}},
+		CaptureTime: captureTime,
+	}
+	assert.Nil(t, matcher.registerRequest("key", request))
+
+	// The cleaner ages out the generic messages only
+	value, ok := matcher.GetMap().Load("key")
+	assert.True(t, ok)
+	message, ok := value.(*api.GenericMessage)
+	assert.True(t, ok)
+	assert.Equal(t, captureTime, message.CaptureTime)
+
+	item := matcher.NewUnansweredItem("key", value)
+	assert.NotNil(t, item)
+	item.ConnectionInfo = &api.ConnectionInfo{ClientIP: "10.0.0.1", ClientPort: "51234", ServerIP: "10.0.0.2", ServerPort: "9092"}
+
+	// The API server analyzes the items after a JSON round trip
+	marshaled, err := json.Marshal(item)
+	assert.Nil(t, err)
+	var unmarshaled api.OutputChannelItem
+	assert.Nil(t, json.Unmarshal(marshaled, &unmarshaled))
+
+	dissector := NewDissector()
+	entry := dissector.Analyze(&unmarshaled, "producer", "kafka", "default")
+	assert.True(t, api.IsUnanswered(entry.Response))
+	assert.Equal(t, api.UnansweredStatusQuery, dissector.Summarize(entry).StatusQuery)
+	_, err = dissector.Represent(entry.Request, entry.Response)
+	assert.Nil(t, err)
+
+	// A response that is matched meanwhile gets the request back
+	assert.Nil(t, matcher.registerRequest("other", request))
+	pair := matcher.registerResponse("other", &Response{Size: 8, CaptureTime: captureTime.Add(time.Millisecond)})
+	assert.NotNil(t, pair)
+	assert.Equal(t, *request, pair.Request)
+}
+
+// Encodes the messages of the tests, with the compact strings and the tagged fields of the flexible versions
+type kafkaWriter struct {
+	bytes.Buffer
+	flexible bool
+}
+
+func (w *kafkaWriter) int16(v int16) *kafkaWriter {
+	_ = binary.Write(w, binary.BigEndian, v)
+	return w
+}
+
+func (w *kafkaWriter) int32(v int32) *kafkaWriter {
+	_ = binary.Write(w, binary.BigEndian, v)
+	return w
+}
+
+func (w *kafkaWriter) int64(v int64) *kafkaWriter {
+	_ = binary.Write(w, binary.BigEndian, v)
+	return w
+}
+
+func (w *kafkaWriter) uvarint(v uint64) *kafkaWriter {
+	b := make([]byte, binary.MaxVarintLen64)
+	w.Write(b[:binary.PutUvarint(b, v)])
+	return w
+}
+
+func (w *kafkaWriter) string(v string) *kafkaWriter {
+	if w.flexible {
+		w.uvarint(uint64(len(v) + 1))
+	} else {
+		w.int16(int16(len(v)))
+	}
+	w.WriteString(v)
+	return w
+}
+
+func (w *kafkaWriter) bytes(v []byte) *kafkaWriter {
+	if w.flexible {
+		w.uvarint(uint64(len(v) + 1))
+	} else {
+		w.int32(int32(len(v)))
+	}
+	w.Write(v)
+	return w
+}
+
+func (w *kafkaWriter) array(n int) *kafkaWriter {
+	if w.flexible {
+		return w.uvarint(uint64(n + 1))
+	}
+	return w.int32(int32(n))
+}
+
+func (w *kafkaWriter) tags() *kafkaWriter {
+	if w.flexible {
+		w.uvarint(0)
+	}
+	return w
+}
+
+func (w *kafkaWriter) request(apiKey ApiKey, apiVersion int16, correlationID int32, body *kafkaWriter) []byte {
+	header := &kafkaWriter{}
+	header.int16(int16(apiKey)).int16(apiVersion).int32(correlationID).string("consumer-1")
+	if body.flexible {
+		// The header has a tagged field with an unknown tag
+		header.uvarint(1).uvarint(7).uvarint(2).int16(0)
+	}
+	w.int32(int32(header.Len() + body.Len()))
+	w.Write(header.Bytes())
+	w.Write(body.Bytes())
+	return w.Bytes()
+}
+
+func (w *kafkaWriter) response(correlationID int32, body *kafkaWriter) []byte {
+	header := &kafkaWriter{flexible: body.flexible}
+	header.int32(correlationID).tags()
+	w.int32(int32(header.Len() + body.Len()))
+	w.Write(header.Bytes())
+	w.Write(body.Bytes())
+	return w.Bytes()
+}
+
+func TestDissectConsumerGroup(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 16)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	options := &api.TrafficFilteringOptions{}
+	counterPair := &api.CounterPair{}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+	stream := NewTcpStream(api.Pcap)
+
+	client := &kafkaWriter{}
+	server := &kafkaWriter{}
+
+	// A new member joins with the legacy version and is asked to rejoin with its assigned member ID
+	v5 := &kafkaWriter{}
+	v5.string("orders").int32(45000).int32(300000).string("").int16(-1).string("consumer").array(1).string("range").bytes([]byte{0, 1})
+	client.request(JoinGroup, 5, 1, v5)
+	v5 = &kafkaWriter{}
+	v5.int32(0).int16(79).int32(-1).string("").string("").string("consumer-1-a1b2").array(0)
+	server.response(1, v5)
+
+	// The flexible versions
+	v7 := &kafkaWriter{flexible: true}
+	v7.string("orders").int32(45000).int32(300000).string("consumer-1-a1b2").uvarint(0).string("consumer")
+	v7.array(1).string("range").bytes([]byte{0, 1}).tags().tags()
+	client.request(JoinGroup, 7, 2, v7)
+	v7 = &kafkaWriter{flexible: true}
+	v7.int32(0).int16(0).int32(3).string("consumer").string("range").string("consumer-1-a1b2").string("consumer-1-a1b2")
+	v7.array(1).string("consumer-1-a1b2").uvarint(0).bytes([]byte{0, 1}).tags().tags()
+	server.response(2, v7)
+
+	v4 := &kafkaWriter{flexible: true}
+	v4.string("orders").int32(3).string("consumer-1-a1b2").uvarint(0).tags()
+	client.request(Heartbeat, 4, 3, v4)
+	v4 = &kafkaWriter{flexible: true}
+	v4.int32(0).int16(27).tags()
+	server.response(3, v4)
+
+	v8 := &kafkaWriter{flexible: true}
+	v8.string("orders").int32(3).string("consumer-1-a1b2").uvarint(0)
+	v8.array(1).string("payments").array(1).int32(0).int64(42).int32(-1).string("").tags().tags().tags()
+	client.request(OffsetCommit, 8, 4, v8)
+	v8 = &kafkaWriter{flexible: true}
+	v8.int32(0).array(1).string("payments").array(1).int32(0).int16(0).tags().tags().tags()
+	server.response(4, v8)
+
+	for _, side := range []struct {
+		data     []byte
+		isClient bool
+		tcpID    *api.TcpID
+	}{
+		{client.Bytes(), true, &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "1", DstPort: "2"}},
+		{server.Bytes(), false, &api.TcpID{SrcIP: "2", DstIP: "1", SrcPort: "2", DstPort: "1"}},
+	} {
+		reader := NewTcpReader(&api.ReadProgress{}, "", side.tcpID, time.Time{}, stream, side.isClient, false, nil, emitter, counterPair, reqResMatcher)
+		err := dissector.Dissect(bufio.NewReader(bytes.NewReader(side.data)), reader, options)
+		assert.Equal(t, io.EOF, err)
+	}
+
+	close(itemChannel)
+	var summaries []*api.BaseEntry
+	var entries []*api.Entry
+	for item := range itemChannel {
+		// Simulate the round trip through the JSON encoding as the items do in Mizu
+		marshaled, err := json.Marshal(item)
+		assert.Nil(t, err)
+		var unmarshaled *api.OutputChannelItem
+		err = json.Unmarshal(marshaled, &unmarshaled)
+		assert.Nil(t, err)
+		entry := dissector.Analyze(unmarshaled, "", "", "")
+
+		representation, err := dissector.Represent(entry.Request, entry.Response)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, representation)
+		entries = append(entries, entry)
+		summaries = append(summaries, dissector.Summarize(entry))
+	}
+	assert.Len(t, summaries, 4)
+
+	assert.Equal(t, "JoinGroup", summaries[0].Method)
+	assert.Equal(t, "orders, consumer-1-a1b2", summaries[0].Summary)
+	assert.Equal(t, `request.payload.groupId == "orders" and response.payload.memberId == "consumer-1-a1b2"`, summaries[0].SummaryQuery)
+	assert.Equal(t, 79, summaries[0].Status)
+
+	assert.Equal(t, "orders, consumer-1-a1b2", summaries[1].Summary)
+	assert.Equal(t, `request.payload.groupId == "orders" and request.payload.memberId == "consumer-1-a1b2"`, summaries[1].SummaryQuery)
+	assert.Equal(t, 0, summaries[1].Status)
+	joinGroupResponse := entries[1].Response["payload"].(map[string]interface{})
+	assert.Equal(t, "consumer", joinGroupResponse["protocolType"])
+	assert.Equal(t, float64(3), joinGroupResponse["generationId"])
+	assert.Len(t, joinGroupResponse["members"], 1)
+
+	assert.Equal(t, "Heartbeat", summaries[2].Method)
+	assert.Equal(t, 27, summaries[2].Status)
+	assert.Equal(t, `response.payload.errorCode == 27`, summaries[2].StatusQuery)
+
+	assert.Equal(t, "OffsetCommit", summaries[3].Method)
+	assert.Equal(t, "orders, consumer-1-a1b2", summaries[3].Summary)
+	partition := entries[3].Request["payload"].(map[string]interface{})["topics"].([]interface{})[0].(map[string]interface{})["partitions"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, float64(42), partition["committedOffset"])
+	assert.Equal(t, float64(-1), partition["committedLeaderEpoch"])
+}
@@ -30,6 +30,7 @@ func (matcher *requestResponseMatcher) SetMaxTry(value int) {
 	matcher.maxTry = value
 }
 
+// The requests are stored as the generic messages they're emitted as, so the cleaner ages them out like the other protocols' ones
 func (matcher *requestResponseMatcher) registerRequest(key string, request *Request) *RequestResponsePair {
 	if response, found := matcher.openMessagesMap.LoadAndDelete(key); found {
 		// Check for a situation that only occurs when a Kafka broker is initiating
@@ -39,7 +40,7 @@ func (matcher *requestResponseMatcher) registerRequest(key string, request *Requ
 		}
 	}
 
-	matcher.openMessagesMap.Store(key, request)
+	matcher.openMessagesMap.Store(key, newRequestMessage(request))
 	return nil
 }
 
@@ -50,8 +51,8 @@ func (matcher *requestResponseMatcher) registerResponse(key string, response *Re
 		if try > matcher.maxTry {
 			return nil
 		}
-		if request, found := matcher.openMessagesMap.LoadAndDelete(key); found {
-			return matcher.preparePair(request.(*Request), response)
+		if message, found := matcher.openMessagesMap.LoadAndDelete(key); found {
+			return matcher.preparePair(requestOf(message.(*api.GenericMessage)), response)
 		}
 		time.Sleep(1 * time.Millisecond)
 	}
@@ -63,3 +64,31 @@ func (matcher *requestResponseMatcher) preparePair(request *Request, response *R
 		Response: *response,
 	}
 }
+
+func newRequestMessage(request *Request) *api.GenericMessage {
+	return &api.GenericMessage{
+		IsRequest:   true,
+		CaptureTime: request.CaptureTime,
+		CaptureSize: int(request.Size),
+		Payload: KafkaPayload{
+			Data: &KafkaWrapper{
+				Method:  apiNames[request.ApiKey],
+				Url:     "",
+				Details: request,
+			},
+		},
+	}
+}
+
+func requestOf(message *api.GenericMessage) *Request {
+	return message.Payload.(KafkaPayload).Data.(*KafkaWrapper).Details.(*Request)
+}
+
+// The produce requests with no acknowledgements are never answered, they're emitted as unanswered too
+func (matcher *requestResponseMatcher) NewUnansweredItem(key interface{}, value interface{}) *api.OutputChannelItem {
+	request, ok := value.(*api.GenericMessage)
+	if !ok || !request.IsRequest {
+		return nil
+	}
+	return api.NewUnansweredItem(_protocol, request)
+}
@@ -0,0 +1,43 @@
+package kafka
+
+import (
+	"encoding/binary"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// The APIs have no more versions than this, leaving room for the newer ones
+const maxApiVersion = 20
+
+// The size limits of the messages, the readers reject the others. The cap is the default max.request.size of Kafka.
+const (
+	minRequestSize  = 8
+	minResponseSize = 4
+	maxMessageSize  = 1048576
+)
+
+// The requests start with the size, the API key, the API version and the correlation ID. The responses
+// start with the size and the correlation ID only, so they can't be told apart from the other binary protocols.
+func probe(peek []byte, isClient bool) api.Confidence {
+	if len(peek) < 8 {
+		return api.ConfidenceUnknown
+	}
+
+	size := int32(binary.BigEndian.Uint32(peek[0:4]))
+	if !isClient {
+		if size < minResponseSize || size > maxMessageSize {
+			return api.ConfidenceNone
+		}
+		return api.ConfidenceUnknown
+	}
+	if size < minRequestSize || size > maxMessageSize {
+		return api.ConfidenceNone
+	}
+
+	apiKey := int16(binary.BigEndian.Uint16(peek[4:6]))
+	apiVersion := int16(binary.BigEndian.Uint16(peek[6:8]))
+	if apiKey < 0 || int(apiKey) >= numApis || apiVersion < 0 || apiVersion > maxApiVersion {
+		return api.ConfidenceNone
+	}
+	return api.ConfidenceLow
+}
@@ -195,6 +195,34 @@ func makeTypes(t reflect.Type) []messageType {
 	return types
 }
 
+// The first flexible versions of the consumer group APIs. The strings and the arrays of the flexible
+// versions are compact, and both the headers and the structures end with tagged fields.
+var flexibleVersions = map[ApiKey]int16{
+	OffsetCommit:    v8,
+	OffsetFetch:     v6,
+	FindCoordinator: v3,
+	JoinGroup:       v6,
+	Heartbeat:       v4,
+	LeaveGroup:      v4,
+	SyncGroup:       v4,
+}
+
+func isFlexible(apiKey ApiKey, apiVersion int16) bool {
+	minVersion, ok := flexibleVersions[apiKey]
+	return ok && apiVersion >= minVersion
+}
+
+// The message types without struct tags are decoded the same way for all of their versions,
+// so whether the version is flexible is decided by the caller.
+func makeMessageType(t reflect.Type, flexible bool) messageType {
+	return messageType{
+		version:  -1,
+		gotype:   t,
+		flexible: flexible,
+		decode:   decodeFuncOf(t, -1, flexible, structTag{}),
+	}
+}
+
 type structTag struct {
 	MinVersion int16
 	MaxVersion int16
@@ -24,11 +24,11 @@ func ReadRequest(r io.Reader, tcpID *api.TcpID, counterPair *api.CounterPair, ca
 	d := &decoder{reader: r, remain: 4}
 	size := d.readInt32()
 
-	if size > 1000000 {
+	if size > maxMessageSize {
 		return 0, 0, fmt.Errorf("A Kafka message cannot be bigger than 1MB")
 	}
 
-	if size < 8 {
+	if size < minRequestSize {
 		if size == 0 {
 			return 0, 0, io.EOF
 		}
@@ -56,6 +56,11 @@ func ReadRequest(r io.Reader, tcpID *api.TcpID, counterPair *api.CounterPair, ca
 		return apiKey, apiVersion, err
 	}
 
+	flexible := isFlexible(apiKey, apiVersion)
+	if flexible {
+		d.discardTaggedFields()
+	}
+
 	var payload interface{}
 
 	switch apiKey {
@@ -197,6 +202,89 @@ func ReadRequest(r io.Reader, tcpID *api.TcpID, counterPair *api.CounterPair, ca
 		}
 		mt.(messageType).decode(d, valueOf(deleteTopicsRequest))
 		payload = deleteTopicsRequest
+	case FindCoordinator:
+		var findCoordinatorRequest interface{}
+		if apiVersion >= v4 {
+			findCoordinatorRequest = &FindCoordinatorRequestV4{}
+		} else if apiVersion >= v1 {
+			findCoordinatorRequest = &FindCoordinatorRequestV1{}
+		} else {
+			findCoordinatorRequest = &FindCoordinatorRequestV0{}
+		}
+		makeMessageType(reflect.TypeOf(findCoordinatorRequest).Elem(), flexible).decode(d, valueOf(findCoordinatorRequest))
+		payload = findCoordinatorRequest
+	case JoinGroup:
+		var joinGroupRequest interface{}
+		if apiVersion >= v8 {
+			joinGroupRequest = &JoinGroupRequestV8{}
+		} else if apiVersion >= v5 {
+			joinGroupRequest = &JoinGroupRequestV5{}
+		} else if apiVersion >= v1 {
+			joinGroupRequest = &JoinGroupRequestV1{}
+		} else {
+			joinGroupRequest = &JoinGroupRequestV0{}
+		}
+		makeMessageType(reflect.TypeOf(joinGroupRequest).Elem(), flexible).decode(d, valueOf(joinGroupRequest))
+		payload = joinGroupRequest
+	case SyncGroup:
+		var syncGroupRequest interface{}
+		if apiVersion >= v5 {
+			syncGroupRequest = &SyncGroupRequestV5{}
+		} else if apiVersion >= v3 {
+			syncGroupRequest = &SyncGroupRequestV3{}
+		} else {
+			syncGroupRequest = &SyncGroupRequestV0{}
+		}
+		makeMessageType(reflect.TypeOf(syncGroupRequest).Elem(), flexible).decode(d, valueOf(syncGroupRequest))
+		payload = syncGroupRequest
+	case Heartbeat:
+		var heartbeatRequest interface{}
+		if apiVersion >= v3 {
+			heartbeatRequest = &HeartbeatRequestV3{}
+		} else {
+			heartbeatRequest = &HeartbeatRequestV0{}
+		}
+		makeMessageType(reflect.TypeOf(heartbeatRequest).Elem(), flexible).decode(d, valueOf(heartbeatRequest))
+		payload = heartbeatRequest
+	case LeaveGroup:
+		var leaveGroupRequest interface{}
+		if apiVersion >= v5 {
+			leaveGroupRequest = &LeaveGroupRequestV5{}
+		} else if apiVersion >= v3 {
+			leaveGroupRequest = &LeaveGroupRequestV3{}
+		} else {
+			leaveGroupRequest = &LeaveGroupRequestV0{}
+		}
+		makeMessageType(reflect.TypeOf(leaveGroupRequest).Elem(), flexible).decode(d, valueOf(leaveGroupRequest))
+		payload = leaveGroupRequest
+	case OffsetCommit:
+		var offsetCommitRequest interface{}
+		if apiVersion >= v7 {
+			offsetCommitRequest = &OffsetCommitRequestV7{}
+		} else if apiVersion >= v6 {
+			offsetCommitRequest = &OffsetCommitRequestV6{}
+		} else if apiVersion >= v5 {
+			offsetCommitRequest = &OffsetCommitRequestV5{}
+		} else if apiVersion >= v2 {
+			offsetCommitRequest = &OffsetCommitRequestV2{}
+		} else if apiVersion >= v1 {
+			offsetCommitRequest = &OffsetCommitRequestV1{}
+		} else {
+			offsetCommitRequest = &OffsetCommitRequestV0{}
+		}
+		makeMessageType(reflect.TypeOf(offsetCommitRequest).Elem(), flexible).decode(d, valueOf(offsetCommitRequest))
+		payload = offsetCommitRequest
+	case OffsetFetch:
+		var offsetFetchRequest interface{}
+		if apiVersion >= v8 {
+			offsetFetchRequest = &OffsetFetchRequestV8{}
+		} else if apiVersion >= v7 {
+			offsetFetchRequest = &OffsetFetchRequestV7{}
+		} else {
+			offsetFetchRequest = &OffsetFetchRequestV0{}
+		}
+		makeMessageType(reflect.TypeOf(offsetFetchRequest).Elem(), flexible).decode(d, valueOf(offsetFetchRequest))
+		payload = offsetFetchRequest
 	default:
 		return apiKey, 0, fmt.Errorf("(Request) Not implemented: %s", apiKey)
 	}
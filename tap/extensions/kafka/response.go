@@ -20,15 +20,15 @@ func ReadResponse(r io.Reader, capture api.Capture, tcpID *api.TcpID, counterPai
 	d := &decoder{reader: r, remain: 4}
 	size := d.readInt32()
 
-	if size > 1000000 {
+	if size > maxMessageSize {
 		return fmt.Errorf("A Kafka message cannot be bigger than 1MB")
 	}
 
-	if size < 4 {
+	if size < minResponseSize {
 		if size == 0 {
 			return io.EOF
 		}
-		return fmt.Errorf("A Kafka response header cannot be smaller than 8 bytes")
+		return fmt.Errorf("A Kafka response header cannot be smaller than 4 bytes")
 	}
 
 	if err = d.err; err != nil {
@@ -61,6 +61,11 @@ func ReadResponse(r io.Reader, capture api.Capture, tcpID *api.TcpID, counterPai
 	apiKey := reqResPair.Request.ApiKey
 	apiVersion := reqResPair.Request.ApiVersion
 
+	flexible := isFlexible(apiKey, apiVersion)
+	if flexible {
+		d.discardTaggedFields()
+	}
+
 	switch apiKey {
 	case Metadata:
 		var mt interface{}
@@ -244,6 +249,87 @@ func ReadResponse(r io.Reader, capture api.Capture, tcpID *api.TcpID, counterPai
 		}
 		mt.(messageType).decode(d, valueOf(deleteTopicsResponse))
 		reqResPair.Response.Payload = deleteTopicsResponse
+	case FindCoordinator:
+		var findCoordinatorResponse interface{}
+		if apiVersion >= v4 {
+			findCoordinatorResponse = &FindCoordinatorResponseV4{}
+		} else if apiVersion >= v1 {
+			findCoordinatorResponse = &FindCoordinatorResponseV1{}
+		} else {
+			findCoordinatorResponse = &FindCoordinatorResponseV0{}
+		}
+		makeMessageType(reflect.TypeOf(findCoordinatorResponse).Elem(), flexible).decode(d, valueOf(findCoordinatorResponse))
+		reqResPair.Response.Payload = findCoordinatorResponse
+	case JoinGroup:
+		var joinGroupResponse interface{}
+		if apiVersion >= v9 {
+			joinGroupResponse = &JoinGroupResponseV9{}
+		} else if apiVersion >= v7 {
+			joinGroupResponse = &JoinGroupResponseV7{}
+		} else if apiVersion >= v5 {
+			joinGroupResponse = &JoinGroupResponseV5{}
+		} else if apiVersion >= v2 {
+			joinGroupResponse = &JoinGroupResponseV2{}
+		} else {
+			joinGroupResponse = &JoinGroupResponseV0{}
+		}
+		makeMessageType(reflect.TypeOf(joinGroupResponse).Elem(), flexible).decode(d, valueOf(joinGroupResponse))
+		reqResPair.Response.Payload = joinGroupResponse
+	case SyncGroup:
+		var syncGroupResponse interface{}
+		if apiVersion >= v5 {
+			syncGroupResponse = &SyncGroupResponseV5{}
+		} else if apiVersion >= v1 {
+			syncGroupResponse = &SyncGroupResponseV1{}
+		} else {
+			syncGroupResponse = &SyncGroupResponseV0{}
+		}
+		makeMessageType(reflect.TypeOf(syncGroupResponse).Elem(), flexible).decode(d, valueOf(syncGroupResponse))
+		reqResPair.Response.Payload = syncGroupResponse
+	case Heartbeat:
+		var heartbeatResponse interface{}
+		if apiVersion >= v1 {
+			heartbeatResponse = &HeartbeatResponseV1{}
+		} else {
+			heartbeatResponse = &HeartbeatResponseV0{}
+		}
+		makeMessageType(reflect.TypeOf(heartbeatResponse).Elem(), flexible).decode(d, valueOf(heartbeatResponse))
+		reqResPair.Response.Payload = heartbeatResponse
+	case LeaveGroup:
+		var leaveGroupResponse interface{}
+		if apiVersion >= v3 {
+			leaveGroupResponse = &LeaveGroupResponseV3{}
+		} else if apiVersion >= v1 {
+			leaveGroupResponse = &LeaveGroupResponseV1{}
+		} else {
+			leaveGroupResponse = &LeaveGroupResponseV0{}
+		}
+		makeMessageType(reflect.TypeOf(leaveGroupResponse).Elem(), flexible).decode(d, valueOf(leaveGroupResponse))
+		reqResPair.Response.Payload = leaveGroupResponse
+	case OffsetCommit:
+		var offsetCommitResponse interface{}
+		if apiVersion >= v3 {
+			offsetCommitResponse = &OffsetCommitResponseV3{}
+		} else {
+			offsetCommitResponse = &OffsetCommitResponseV0{}
+		}
+		makeMessageType(reflect.TypeOf(offsetCommitResponse).Elem(), flexible).decode(d, valueOf(offsetCommitResponse))
+		reqResPair.Response.Payload = offsetCommitResponse
+	case OffsetFetch:
+		var offsetFetchResponse interface{}
+		if apiVersion >= v8 {
+			offsetFetchResponse = &OffsetFetchResponseV8{}
+		} else if apiVersion >= v5 {
+			offsetFetchResponse = &OffsetFetchResponseV5{}
+		} else if apiVersion >= v3 {
+			offsetFetchResponse = &OffsetFetchResponseV3{}
+		} else if apiVersion >= v2 {
+			offsetFetchResponse = &OffsetFetchResponseV2{}
+		} else {
+			offsetFetchResponse = &OffsetFetchResponseV0{}
+		}
+		makeMessageType(reflect.TypeOf(offsetFetchResponse).Elem(), flexible).decode(d, valueOf(offsetFetchResponse))
+		reqResPair.Response.Payload = offsetFetchResponse
 	default:
 		return fmt.Errorf("(Response) Not implemented: %s", apiKey)
 	}
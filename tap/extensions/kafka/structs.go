@@ -983,3 +983,544 @@ type DeleteTopicsReponseV6 struct {
 	ThrottleTimeMs int32                           `json:"throttleTimeMs"`
 	Responses      []DeleteTopicsReponseResponseV6 `json:"responses"`
 }
+
+// FindCoordinator Request (Version: 0)
+
+type FindCoordinatorRequestV0 struct {
+	Key string `json:"key"`
+}
+
+// FindCoordinator Request (Version: 1)
+
+type FindCoordinatorRequestV1 struct {
+	Key     string `json:"key"`
+	KeyType int8   `json:"keyType"`
+}
+
+// FindCoordinator Request (Version: 4)
+
+type FindCoordinatorRequestV4 struct {
+	KeyType         int8     `json:"keyType"`
+	CoordinatorKeys []string `json:"coordinatorKeys"`
+}
+
+// FindCoordinator Response (Version: 0)
+
+type FindCoordinatorResponseV0 struct {
+	ErrorCode int16  `json:"errorCode"`
+	NodeId    int32  `json:"nodeId"`
+	Host      string `json:"host"`
+	Port      int32  `json:"port"`
+}
+
+// FindCoordinator Response (Version: 1)
+
+type FindCoordinatorResponseV1 struct {
+	ThrottleTimeMs int32  `json:"throttleTimeMs"`
+	ErrorCode      int16  `json:"errorCode"`
+	ErrorMessage   string `json:"errorMessage"`
+	NodeId         int32  `json:"nodeId"`
+	Host           string `json:"host"`
+	Port           int32  `json:"port"`
+}
+
+// FindCoordinator Response (Version: 4)
+
+type FindCoordinatorResponseCoordinatorV4 struct {
+	Key          string `json:"key"`
+	NodeId       int32  `json:"nodeId"`
+	Host         string `json:"host"`
+	Port         int32  `json:"port"`
+	ErrorCode    int16  `json:"errorCode"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+type FindCoordinatorResponseV4 struct {
+	ThrottleTimeMs int32                                  `json:"throttleTimeMs"`
+	Coordinators   []FindCoordinatorResponseCoordinatorV4 `json:"coordinators"`
+}
+
+// JoinGroup Request (Version: 0)
+
+type JoinGroupRequestProtocolV0 struct {
+	Name     string `json:"name"`
+	Metadata []byte `json:"metadata"`
+}
+
+type JoinGroupRequestV0 struct {
+	GroupId          string                       `json:"groupId"`
+	SessionTimeoutMs int32                        `json:"sessionTimeoutMs"`
+	MemberId         string                       `json:"memberId"`
+	ProtocolType     string                       `json:"protocolType"`
+	Protocols        []JoinGroupRequestProtocolV0 `json:"protocols"`
+}
+
+// JoinGroup Request (Version: 1)
+
+type JoinGroupRequestV1 struct {
+	GroupId            string                       `json:"groupId"`
+	SessionTimeoutMs   int32                        `json:"sessionTimeoutMs"`
+	RebalanceTimeoutMs int32                        `json:"rebalanceTimeoutMs"`
+	MemberId           string                       `json:"memberId"`
+	ProtocolType       string                       `json:"protocolType"`
+	Protocols          []JoinGroupRequestProtocolV0 `json:"protocols"`
+}
+
+// JoinGroup Request (Version: 5)
+
+type JoinGroupRequestV5 struct {
+	GroupId            string                       `json:"groupId"`
+	SessionTimeoutMs   int32                        `json:"sessionTimeoutMs"`
+	RebalanceTimeoutMs int32                        `json:"rebalanceTimeoutMs"`
+	MemberId           string                       `json:"memberId"`
+	GroupInstanceId    string                       `json:"groupInstanceId"`
+	ProtocolType       string                       `json:"protocolType"`
+	Protocols          []JoinGroupRequestProtocolV0 `json:"protocols"`
+}
+
+// JoinGroup Request (Version: 8)
+
+type JoinGroupRequestV8 struct {
+	GroupId            string                       `json:"groupId"`
+	SessionTimeoutMs   int32                        `json:"sessionTimeoutMs"`
+	RebalanceTimeoutMs int32                        `json:"rebalanceTimeoutMs"`
+	MemberId           string                       `json:"memberId"`
+	GroupInstanceId    string                       `json:"groupInstanceId"`
+	ProtocolType       string                       `json:"protocolType"`
+	Protocols          []JoinGroupRequestProtocolV0 `json:"protocols"`
+	Reason             string                       `json:"reason"`
+}
+
+// JoinGroup Response (Version: 0)
+
+type JoinGroupResponseMemberV0 struct {
+	MemberId string `json:"memberId"`
+	Metadata []byte `json:"metadata"`
+}
+
+type JoinGroupResponseV0 struct {
+	ErrorCode    int16                       `json:"errorCode"`
+	GenerationId int32                       `json:"generationId"`
+	ProtocolName string                      `json:"protocolName"`
+	Leader       string                      `json:"leader"`
+	MemberId     string                      `json:"memberId"`
+	Members      []JoinGroupResponseMemberV0 `json:"members"`
+}
+
+// JoinGroup Response (Version: 2)
+
+type JoinGroupResponseV2 struct {
+	ThrottleTimeMs int32                       `json:"throttleTimeMs"`
+	ErrorCode      int16                       `json:"errorCode"`
+	GenerationId   int32                       `json:"generationId"`
+	ProtocolName   string                      `json:"protocolName"`
+	Leader         string                      `json:"leader"`
+	MemberId       string                      `json:"memberId"`
+	Members        []JoinGroupResponseMemberV0 `json:"members"`
+}
+
+// JoinGroup Response (Version: 5)
+
+type JoinGroupResponseMemberV5 struct {
+	MemberId        string `json:"memberId"`
+	GroupInstanceId string `json:"groupInstanceId"`
+	Metadata        []byte `json:"metadata"`
+}
+
+type JoinGroupResponseV5 struct {
+	ThrottleTimeMs int32                       `json:"throttleTimeMs"`
+	ErrorCode      int16                       `json:"errorCode"`
+	GenerationId   int32                       `json:"generationId"`
+	ProtocolName   string                      `json:"protocolName"`
+	Leader         string                      `json:"leader"`
+	MemberId       string                      `json:"memberId"`
+	Members        []JoinGroupResponseMemberV5 `json:"members"`
+}
+
+// JoinGroup Response (Version: 7)
+
+type JoinGroupResponseV7 struct {
+	ThrottleTimeMs int32                       `json:"throttleTimeMs"`
+	ErrorCode      int16                       `json:"errorCode"`
+	GenerationId   int32                       `json:"generationId"`
+	ProtocolType   string                      `json:"protocolType"`
+	ProtocolName   string                      `json:"protocolName"`
+	Leader         string                      `json:"leader"`
+	MemberId       string                      `json:"memberId"`
+	Members        []JoinGroupResponseMemberV5 `json:"members"`
+}
+
+// JoinGroup Response (Version: 9)
+
+type JoinGroupResponseV9 struct {
+	ThrottleTimeMs int32                       `json:"throttleTimeMs"`
+	ErrorCode      int16                       `json:"errorCode"`
+	GenerationId   int32                       `json:"generationId"`
+	ProtocolType   string                      `json:"protocolType"`
+	ProtocolName   string                      `json:"protocolName"`
+	Leader         string                      `json:"leader"`
+	SkipAssignment bool                        `json:"skipAssignment"`
+	MemberId       string                      `json:"memberId"`
+	Members        []JoinGroupResponseMemberV5 `json:"members"`
+}
+
+// SyncGroup Request (Version: 0)
+
+type SyncGroupRequestAssignmentV0 struct {
+	MemberId   string `json:"memberId"`
+	Assignment []byte `json:"assignment"`
+}
+
+type SyncGroupRequestV0 struct {
+	GroupId      string                         `json:"groupId"`
+	GenerationId int32                          `json:"generationId"`
+	MemberId     string                         `json:"memberId"`
+	Assignments  []SyncGroupRequestAssignmentV0 `json:"assignments"`
+}
+
+// SyncGroup Request (Version: 3)
+
+type SyncGroupRequestV3 struct {
+	GroupId         string                         `json:"groupId"`
+	GenerationId    int32                          `json:"generationId"`
+	MemberId        string                         `json:"memberId"`
+	GroupInstanceId string                         `json:"groupInstanceId"`
+	Assignments     []SyncGroupRequestAssignmentV0 `json:"assignments"`
+}
+
+// SyncGroup Request (Version: 5)
+
+type SyncGroupRequestV5 struct {
+	GroupId         string                         `json:"groupId"`
+	GenerationId    int32                          `json:"generationId"`
+	MemberId        string                         `json:"memberId"`
+	GroupInstanceId string                         `json:"groupInstanceId"`
+	ProtocolType    string                         `json:"protocolType"`
+	ProtocolName    string                         `json:"protocolName"`
+	Assignments     []SyncGroupRequestAssignmentV0 `json:"assignments"`
+}
+
+// SyncGroup Response (Version: 0)
+
+type SyncGroupResponseV0 struct {
+	ErrorCode  int16  `json:"errorCode"`
+	Assignment []byte `json:"assignment"`
+}
+
+// SyncGroup Response (Version: 1)
+
+type SyncGroupResponseV1 struct {
+	ThrottleTimeMs int32  `json:"throttleTimeMs"`
+	ErrorCode      int16  `json:"errorCode"`
+	Assignment     []byte `json:"assignment"`
+}
+
+// SyncGroup Response (Version: 5)
+
+type SyncGroupResponseV5 struct {
+	ThrottleTimeMs int32  `json:"throttleTimeMs"`
+	ErrorCode      int16  `json:"errorCode"`
+	ProtocolType   string `json:"protocolType"`
+	ProtocolName   string `json:"protocolName"`
+	Assignment     []byte `json:"assignment"`
+}
+
+// Heartbeat Request (Version: 0)
+
+type HeartbeatRequestV0 struct {
+	GroupId      string `json:"groupId"`
+	GenerationId int32  `json:"generationId"`
+	MemberId     string `json:"memberId"`
+}
+
+// Heartbeat Request (Version: 3)
+
+type HeartbeatRequestV3 struct {
+	GroupId         string `json:"groupId"`
+	GenerationId    int32  `json:"generationId"`
+	MemberId        string `json:"memberId"`
+	GroupInstanceId string `json:"groupInstanceId"`
+}
+
+// Heartbeat Response (Version: 0)
+
+type HeartbeatResponseV0 struct {
+	ErrorCode int16 `json:"errorCode"`
+}
+
+// Heartbeat Response (Version: 1)
+
+type HeartbeatResponseV1 struct {
+	ThrottleTimeMs int32 `json:"throttleTimeMs"`
+	ErrorCode      int16 `json:"errorCode"`
+}
+
+// LeaveGroup Request (Version: 0)
+
+type LeaveGroupRequestV0 struct {
+	GroupId  string `json:"groupId"`
+	MemberId string `json:"memberId"`
+}
+
+// LeaveGroup Request (Version: 3)
+
+type LeaveGroupRequestMemberV3 struct {
+	MemberId        string `json:"memberId"`
+	GroupInstanceId string `json:"groupInstanceId"`
+}
+
+type LeaveGroupRequestV3 struct {
+	GroupId string                      `json:"groupId"`
+	Members []LeaveGroupRequestMemberV3 `json:"members"`
+}
+
+// LeaveGroup Request (Version: 5)
+
+type LeaveGroupRequestMemberV5 struct {
+	MemberId        string `json:"memberId"`
+	GroupInstanceId string `json:"groupInstanceId"`
+	Reason          string `json:"reason"`
+}
+
+type LeaveGroupRequestV5 struct {
+	GroupId string                      `json:"groupId"`
+	Members []LeaveGroupRequestMemberV5 `json:"members"`
+}
+
+// LeaveGroup Response (Version: 0)
+
+type LeaveGroupResponseV0 struct {
+	ErrorCode int16 `json:"errorCode"`
+}
+
+// LeaveGroup Response (Version: 1)
+
+type LeaveGroupResponseV1 struct {
+	ThrottleTimeMs int32 `json:"throttleTimeMs"`
+	ErrorCode      int16 `json:"errorCode"`
+}
+
+// LeaveGroup Response (Version: 3)
+
+type LeaveGroupResponseMemberV3 struct {
+	MemberId        string `json:"memberId"`
+	GroupInstanceId string `json:"groupInstanceId"`
+	ErrorCode       int16  `json:"errorCode"`
+}
+
+type LeaveGroupResponseV3 struct {
+	ThrottleTimeMs int32                        `json:"throttleTimeMs"`
+	ErrorCode      int16                        `json:"errorCode"`
+	Members        []LeaveGroupResponseMemberV3 `json:"members"`
+}
+
+// OffsetCommit Request (Version: 0)
+
+type OffsetCommitRequestPartitionV0 struct {
+	PartitionIndex    int32  `json:"partitionIndex"`
+	CommittedOffset   int64  `json:"committedOffset"`
+	CommittedMetadata string `json:"committedMetadata"`
+}
+
+type OffsetCommitRequestTopicV0 struct {
+	Name       string                           `json:"name"`
+	Partitions []OffsetCommitRequestPartitionV0 `json:"partitions"`
+}
+
+type OffsetCommitRequestV0 struct {
+	GroupId string                       `json:"groupId"`
+	Topics  []OffsetCommitRequestTopicV0 `json:"topics"`
+}
+
+// OffsetCommit Request (Version: 1)
+
+type OffsetCommitRequestPartitionV1 struct {
+	PartitionIndex    int32  `json:"partitionIndex"`
+	CommittedOffset   int64  `json:"committedOffset"`
+	CommitTimestamp   int64  `json:"commitTimestamp"`
+	CommittedMetadata string `json:"committedMetadata"`
+}
+
+type OffsetCommitRequestTopicV1 struct {
+	Name       string                           `json:"name"`
+	Partitions []OffsetCommitRequestPartitionV1 `json:"partitions"`
+}
+
+type OffsetCommitRequestV1 struct {
+	GroupId      string                       `json:"groupId"`
+	GenerationId int32                        `json:"generationId"`
+	MemberId     string                       `json:"memberId"`
+	Topics       []OffsetCommitRequestTopicV1 `json:"topics"`
+}
+
+// OffsetCommit Request (Version: 2)
+
+type OffsetCommitRequestV2 struct {
+	GroupId         string                       `json:"groupId"`
+	GenerationId    int32                        `json:"generationId"`
+	MemberId        string                       `json:"memberId"`
+	RetentionTimeMs int64                        `json:"retentionTimeMs"`
+	Topics          []OffsetCommitRequestTopicV0 `json:"topics"`
+}
+
+// OffsetCommit Request (Version: 5)
+
+type OffsetCommitRequestV5 struct {
+	GroupId      string                       `json:"groupId"`
+	GenerationId int32                        `json:"generationId"`
+	MemberId     string                       `json:"memberId"`
+	Topics       []OffsetCommitRequestTopicV0 `json:"topics"`
+}
+
+// OffsetCommit Request (Version: 6)
+
+type OffsetCommitRequestPartitionV6 struct {
+	PartitionIndex       int32  `json:"partitionIndex"`
+	CommittedOffset      int64  `json:"committedOffset"`
+	CommittedLeaderEpoch int32  `json:"committedLeaderEpoch"`
+	CommittedMetadata    string `json:"committedMetadata"`
+}
+
+type OffsetCommitRequestTopicV6 struct {
+	Name       string                           `json:"name"`
+	Partitions []OffsetCommitRequestPartitionV6 `json:"partitions"`
+}
+
+type OffsetCommitRequestV6 struct {
+	GroupId      string                       `json:"groupId"`
+	GenerationId int32                        `json:"generationId"`
+	MemberId     string                       `json:"memberId"`
+	Topics       []OffsetCommitRequestTopicV6 `json:"topics"`
+}
+
+// OffsetCommit Request (Version: 7)
+
+type OffsetCommitRequestV7 struct {
+	GroupId         string                       `json:"groupId"`
+	GenerationId    int32                        `json:"generationId"`
+	MemberId        string                       `json:"memberId"`
+	GroupInstanceId string                       `json:"groupInstanceId"`
+	Topics          []OffsetCommitRequestTopicV6 `json:"topics"`
+}
+
+// OffsetCommit Response (Version: 0)
+
+type OffsetCommitResponsePartitionV0 struct {
+	PartitionIndex int32 `json:"partitionIndex"`
+	ErrorCode      int16 `json:"errorCode"`
+}
+
+type OffsetCommitResponseTopicV0 struct {
+	Name       string                            `json:"name"`
+	Partitions []OffsetCommitResponsePartitionV0 `json:"partitions"`
+}
+
+type OffsetCommitResponseV0 struct {
+	Topics []OffsetCommitResponseTopicV0 `json:"topics"`
+}
+
+// OffsetCommit Response (Version: 3)
+
+type OffsetCommitResponseV3 struct {
+	ThrottleTimeMs int32                         `json:"throttleTimeMs"`
+	Topics         []OffsetCommitResponseTopicV0 `json:"topics"`
+}
+
+// OffsetFetch Request (Version: 0)
+
+type OffsetFetchRequestTopicV0 struct {
+	Name             string  `json:"name"`
+	PartitionIndexes []int32 `json:"partitionIndexes"`
+}
+
+type OffsetFetchRequestV0 struct {
+	GroupId string                      `json:"groupId"`
+	Topics  []OffsetFetchRequestTopicV0 `json:"topics"`
+}
+
+// OffsetFetch Request (Version: 7)
+
+type OffsetFetchRequestV7 struct {
+	GroupId       string                      `json:"groupId"`
+	Topics        []OffsetFetchRequestTopicV0 `json:"topics"`
+	RequireStable bool                        `json:"requireStable"`
+}
+
+// OffsetFetch Request (Version: 8)
+
+type OffsetFetchRequestGroupV8 struct {
+	GroupId string                      `json:"groupId"`
+	Topics  []OffsetFetchRequestTopicV0 `json:"topics"`
+}
+
+type OffsetFetchRequestV8 struct {
+	Groups        []OffsetFetchRequestGroupV8 `json:"groups"`
+	RequireStable bool                        `json:"requireStable"`
+}
+
+// OffsetFetch Response (Version: 0)
+
+type OffsetFetchResponsePartitionV0 struct {
+	PartitionIndex  int32  `json:"partitionIndex"`
+	CommittedOffset int64  `json:"committedOffset"`
+	Metadata        string `json:"metadata"`
+	ErrorCode       int16  `json:"errorCode"`
+}
+
+type OffsetFetchResponseTopicV0 struct {
+	Name       string                           `json:"name"`
+	Partitions []OffsetFetchResponsePartitionV0 `json:"partitions"`
+}
+
+type OffsetFetchResponseV0 struct {
+	Topics []OffsetFetchResponseTopicV0 `json:"topics"`
+}
+
+// OffsetFetch Response (Version: 2)
+
+type OffsetFetchResponseV2 struct {
+	Topics    []OffsetFetchResponseTopicV0 `json:"topics"`
+	ErrorCode int16                        `json:"errorCode"`
+}
+
+// OffsetFetch Response (Version: 3)
+
+type OffsetFetchResponseV3 struct {
+	ThrottleTimeMs int32                        `json:"throttleTimeMs"`
+	Topics         []OffsetFetchResponseTopicV0 `json:"topics"`
+	ErrorCode      int16                        `json:"errorCode"`
+}
+
+// OffsetFetch Response (Version: 5)
+
+type OffsetFetchResponsePartitionV5 struct {
+	PartitionIndex       int32  `json:"partitionIndex"`
+	CommittedOffset      int64  `json:"committedOffset"`
+	CommittedLeaderEpoch int32  `json:"committedLeaderEpoch"`
+	Metadata             string `json:"metadata"`
+	ErrorCode            int16  `json:"errorCode"`
+}
+
+type OffsetFetchResponseTopicV5 struct {
+	Name       string                           `json:"name"`
+	Partitions []OffsetFetchResponsePartitionV5 `json:"partitions"`
+}
+
+type OffsetFetchResponseV5 struct {
+	ThrottleTimeMs int32                        `json:"throttleTimeMs"`
+	Topics         []OffsetFetchResponseTopicV5 `json:"topics"`
+	ErrorCode      int16                        `json:"errorCode"`
+}
+
+// OffsetFetch Response (Version: 8)
+
+type OffsetFetchResponseGroupV8 struct {
+	GroupId   string                       `json:"groupId"`
+	Topics    []OffsetFetchResponseTopicV5 `json:"topics"`
+	ErrorCode int16                        `json:"errorCode"`
+}
+
+type OffsetFetchResponseV8 struct {
+	ThrottleTimeMs int32                        `json:"throttleTimeMs"`
+	Groups         []OffsetFetchResponseGroupV8 `json:"groups"`
+}
@@ -0,0 +1,218 @@
+package mongodb
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const maxDocumentDepth = 100
+
+var errMalformedDocument = errors.New("Malformed BSON document")
+
+// BSON element types
+const (
+	bsonDouble     = 0x01
+	bsonString     = 0x02
+	bsonDocument   = 0x03
+	bsonArray      = 0x04
+	bsonBinary     = 0x05
+	bsonUndefined  = 0x06
+	bsonObjectID   = 0x07
+	bsonBoolean    = 0x08
+	bsonDateTime   = 0x09
+	bsonNull       = 0x0a
+	bsonRegex      = 0x0b
+	bsonDBPointer  = 0x0c
+	bsonJavaScript = 0x0d
+	bsonSymbol     = 0x0e
+	bsonCodeWScope = 0x0f
+	bsonInt32      = 0x10
+	bsonTimestamp  = 0x11
+	bsonInt64      = 0x12
+	bsonDecimal128 = 0x13
+	bsonMinKey     = 0xff
+	bsonMaxKey     = 0x7f
+)
+
+// Decodes a BSON document into a map with JSON friendly values.
+// The keys are also returned in their original order, since the first key names the command.
+func readDocument(data []byte) (document map[string]interface{}, keys []string, size int, err error) {
+	return readDocumentAt(data, 0)
+}
+
+func readDocumentAt(data []byte, depth int) (document map[string]interface{}, keys []string, size int, err error) {
+	if depth > maxDocumentDepth {
+		err = errMalformedDocument
+		return
+	}
+
+	if len(data) < 5 {
+		err = errMalformedDocument
+		return
+	}
+	size = int(int32(binary.LittleEndian.Uint32(data)))
+	if size < 5 || size > len(data) || data[size-1] != 0 {
+		err = errMalformedDocument
+		return
+	}
+
+	document = make(map[string]interface{})
+	r := &bsonReader{data: data[:size-1], offset: 4, depth: depth}
+	for r.offset < len(r.data) && r.err == nil {
+		elementType := r.readByte()
+		key := r.readCString()
+		value := r.readValue(elementType)
+		if r.err != nil {
+			break
+		}
+		document[key] = value
+		keys = append(keys, key)
+	}
+	err = r.err
+	return
+}
+
+type bsonReader struct {
+	data   []byte
+	offset int
+	depth  int
+	err    error
+}
+
+func (r *bsonReader) readBytes(n int) []byte {
+	if r.err != nil || n < 0 || r.offset+n > len(r.data) {
+		r.err = errMalformedDocument
+		return nil
+	}
+	value := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return value
+}
+
+func (r *bsonReader) readByte() byte {
+	value := r.readBytes(1)
+	if value == nil {
+		return 0
+	}
+	return value[0]
+}
+
+func (r *bsonReader) readInt32() int32 {
+	value := r.readBytes(4)
+	if value == nil {
+		return 0
+	}
+	return int32(binary.LittleEndian.Uint32(value))
+}
+
+func (r *bsonReader) readInt64() int64 {
+	value := r.readBytes(8)
+	if value == nil {
+		return 0
+	}
+	return int64(binary.LittleEndian.Uint64(value))
+}
+
+func (r *bsonReader) readCString() string {
+	for i := r.offset; i < len(r.data) && r.err == nil; i++ {
+		if r.data[i] == 0 {
+			value := string(r.data[r.offset:i])
+			r.offset = i + 1
+			return value
+		}
+	}
+	r.err = errMalformedDocument
+	return ""
+}
+
+func (r *bsonReader) readString() string {
+	length := int(r.readInt32())
+	value := r.readBytes(length)
+	if value == nil || length < 1 {
+		r.err = errMalformedDocument
+		return ""
+	}
+	return string(value[:length-1])
+}
+
+func (r *bsonReader) readEmbeddedDocument() (map[string]interface{}, []string) {
+	if r.err != nil {
+		return nil, nil
+	}
+	document, keys, size, err := readDocumentAt(r.data[r.offset:], r.depth+1)
+	if err != nil {
+		r.err = err
+		return nil, nil
+	}
+	r.offset += size
+	return document, keys
+}
+
+func (r *bsonReader) readValue(elementType byte) interface{} {
+	switch elementType {
+	case bsonDouble:
+		return math.Float64frombits(uint64(r.readInt64()))
+	case bsonString, bsonJavaScript, bsonSymbol:
+		return r.readString()
+	case bsonDocument:
+		document, _ := r.readEmbeddedDocument()
+		return document
+	case bsonArray:
+		document, keys := r.readEmbeddedDocument()
+		array := make([]interface{}, 0, len(keys))
+		for _, key := range keys {
+			array = append(array, document[key])
+		}
+		return array
+	case bsonBinary:
+		length := int(r.readInt32())
+		r.readByte() // subtype
+		return base64.StdEncoding.EncodeToString(r.readBytes(length))
+	case bsonUndefined, bsonNull:
+		return nil
+	case bsonObjectID:
+		return fmt.Sprintf("ObjectId(%s)", hex.EncodeToString(r.readBytes(12)))
+	case bsonBoolean:
+		return r.readByte() != 0
+	case bsonDateTime:
+		return time.Unix(0, r.readInt64()*int64(time.Millisecond)).UTC().Format(time.RFC3339Nano)
+	case bsonRegex:
+		pattern := r.readCString()
+		options := r.readCString()
+		return fmt.Sprintf("/%s/%s", pattern, options)
+	case bsonDBPointer:
+		namespace := r.readString()
+		id := r.readBytes(12)
+		return fmt.Sprintf("DBPointer(%s, %s)", namespace, hex.EncodeToString(id))
+	case bsonCodeWScope:
+		r.readInt32() // total length
+		code := r.readString()
+		r.readEmbeddedDocument()
+		return code
+	case bsonInt32:
+		return r.readInt32()
+	case bsonTimestamp:
+		increment := uint32(r.readInt32())
+		seconds := uint32(r.readInt32())
+		return map[string]interface{}{
+			"t": seconds,
+			"i": increment,
+		}
+	case bsonInt64:
+		return r.readInt64()
+	case bsonDecimal128:
+		return fmt.Sprintf("NumberDecimal(%s)", hex.EncodeToString(r.readBytes(16)))
+	case bsonMinKey:
+		return "MinKey"
+	case bsonMaxKey:
+		return "MaxKey"
+	default:
+		r.err = fmt.Errorf("Unrecognized BSON element type: 0x%02x", elementType)
+		return nil
+	}
+}
@@ -0,0 +1,93 @@
+package mongodb
+
+import (
+	"bufio"
+	"fmt"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// The replies refer to the requests by their IDs, so both directions are dissected the same way.
+func dissectMessages(b *bufio.Reader, reader api.TcpReader, reqResMatcher *requestResponseMatcher) error {
+	for {
+		message, err := readMessage(b)
+		if err != nil {
+			return err
+		}
+		reader.GetParent().SetProtocol(&protocol)
+		captureTime := reader.GetCaptureTime()
+
+		if message.ResponseTo != 0 {
+			handleResponse(reader, captureTime, message, reqResMatcher)
+			continue
+		}
+
+		if !expectsReply(message) {
+			continue
+		}
+		handleRequest(reader, captureTime, message, reqResMatcher)
+	}
+}
+
+// The legacy write operations and the OP_MSG requests with the moreToCome flag are not answered.
+func expectsReply(message *MongoDBMessage) bool {
+	switch message.OpCode {
+	case opCodes[opInsert], opCodes[opUpdate], opCodes[opDelete], opCodes[opKillCursors]:
+		return false
+	case opCodes[opMsg]:
+		return message.Flags&msgMoreToCome == 0
+	default:
+		return true
+	}
+}
+
+func handleRequest(reader api.TcpReader, captureTime time.Time, message *MongoDBMessage, reqResMatcher *requestResponseMatcher) {
+	tcpID := reader.GetTcpID()
+	ident := fmt.Sprintf(
+		"%s_%s_%s_%s_%d",
+		tcpID.SrcIP,
+		tcpID.DstIP,
+		tcpID.SrcPort,
+		tcpID.DstPort,
+		message.RequestID,
+	)
+
+	item := reqResMatcher.registerRequest(ident, message, captureTime, reader.GetReadProgress().Current())
+	if item != nil {
+		item.Capture = reader.GetParent().GetOrigin()
+		item.ConnectionInfo = &api.ConnectionInfo{
+			ClientIP:   tcpID.SrcIP,
+			ClientPort: tcpID.SrcPort,
+			ServerIP:   tcpID.DstIP,
+			ServerPort: tcpID.DstPort,
+			IsOutgoing: true,
+		}
+		reader.GetEmitter().Emit(item)
+	}
+}
+
+func handleResponse(reader api.TcpReader, captureTime time.Time, message *MongoDBMessage, reqResMatcher *requestResponseMatcher) {
+	tcpID := reader.GetTcpID()
+	ident := fmt.Sprintf(
+		"%s_%s_%s_%s_%d",
+		tcpID.DstIP,
+		tcpID.SrcIP,
+		tcpID.DstPort,
+		tcpID.SrcPort,
+		message.ResponseTo,
+	)
+
+	item := reqResMatcher.registerResponse(ident, message, captureTime, reader.GetReadProgress().Current())
+	if item != nil {
+		item.Capture = reader.GetParent().GetOrigin()
+		item.ConnectionInfo = &api.ConnectionInfo{
+			ClientIP:   tcpID.DstIP,
+			ClientPort: tcpID.DstPort,
+			ServerIP:   tcpID.SrcIP,
+			ServerPort: tcpID.SrcPort,
+			IsOutgoing: false,
+		}
+		reader.GetEmitter().Emit(item)
+	}
+}
@@ -0,0 +1,200 @@
+package mongodb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+type MongoDBPayload struct {
+	Data interface{}
+}
+
+type MongoDBPayloader interface {
+	MarshalJSON() ([]byte, error)
+}
+
+func (h MongoDBPayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Data)
+}
+
+type MongoDBWrapper struct {
+	Method  string      `json:"method"`
+	Url     string      `json:"url"`
+	Details interface{} `json:"details"`
+}
+
+func representRequest(request map[string]interface{}) (repRequest []interface{}) {
+	details, _ := json.Marshal([]api.TableData{
+		{
+			Name:     "OpCode",
+			Value:    request["opCode"].(string),
+			Selector: `request.opCode`,
+		},
+		{
+			Name:     "Request ID",
+			Value:    int64(request["requestId"].(float64)),
+			Selector: `request.requestId`,
+		},
+		{
+			Name:     "Command",
+			Value:    request["command"].(string),
+			Selector: `request.command`,
+		},
+		{
+			Name:     "Collection",
+			Value:    request["collection"].(string),
+			Selector: `request.collection`,
+		},
+		{
+			Name:     "Database",
+			Value:    request["database"].(string),
+			Selector: `request.database`,
+		},
+		{
+			Name:     "Flags",
+			Value:    int64(request["flags"].(float64)),
+			Selector: `request.flags`,
+		},
+		{
+			Name:     "Compressor",
+			Value:    request["compressor"].(string),
+			Selector: `request.compressor`,
+		},
+	})
+	repRequest = append(repRequest, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Details",
+		Data:  string(details),
+	})
+
+	repRequest = append(repRequest, representDocuments(request, "request")...)
+	return
+}
+
+func representResponse(response map[string]interface{}) (repResponse []interface{}) {
+	repResponse = make([]interface{}, 0)
+
+	details, _ := json.Marshal([]api.TableData{
+		{
+			Name:     "OpCode",
+			Value:    response["opCode"].(string),
+			Selector: `response.opCode`,
+		},
+		{
+			Name:     "Request ID",
+			Value:    int64(response["requestId"].(float64)),
+			Selector: `response.requestId`,
+		},
+		{
+			Name:     "Response To",
+			Value:    int64(response["responseTo"].(float64)),
+			Selector: `response.responseTo`,
+		},
+		{
+			Name:     "Ok",
+			Value:    response["ok"].(float64),
+			Selector: `response.ok`,
+		},
+		{
+			Name:     "Cursor ID",
+			Value:    int64(response["cursorId"].(float64)),
+			Selector: `response.cursorId`,
+		},
+		{
+			Name:     "Flags",
+			Value:    int64(response["flags"].(float64)),
+			Selector: `response.flags`,
+		},
+		{
+			Name:     "Compressor",
+			Value:    response["compressor"].(string),
+			Selector: `response.compressor`,
+		},
+	})
+	repResponse = append(repResponse, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Details",
+		Data:  string(details),
+	})
+
+	if isError(response) {
+		_error, _ := json.Marshal([]api.TableData{
+			{
+				Name:     "Error Code",
+				Value:    int64(response["errorCode"].(float64)),
+				Selector: `response.errorCode`,
+			},
+			{
+				Name:     "Code Name",
+				Value:    response["codeName"].(string),
+				Selector: `response.codeName`,
+			},
+			{
+				Name:     "Error Message",
+				Value:    response["errmsg"].(string),
+				Selector: `response.errmsg`,
+			},
+		})
+		repResponse = append(repResponse, api.SectionData{
+			Type:  api.TABLE,
+			Title: "Error",
+			Data:  string(_error),
+		})
+	}
+
+	repResponse = append(repResponse, representDocuments(response, "response")...)
+	return
+}
+
+func representDocuments(message map[string]interface{}, side string) (sections []interface{}) {
+	if document, ok := message["document"].(map[string]interface{}); ok {
+		obj, _ := json.MarshalIndent(document, "", "  ")
+		sections = append(sections, api.SectionData{
+			Type:     api.BODY,
+			Title:    "Document",
+			Data:     string(obj),
+			MimeType: "application/json",
+			Selector: fmt.Sprintf(`%s.document`, side),
+		})
+	}
+
+	// The document sequences of OP_MSG, e.g. the `documents` of an insert command
+	sequences, _ := message["sections"].(map[string]interface{})
+	identifiers := make([]string, 0, len(sequences))
+	for identifier := range sequences {
+		identifiers = append(identifiers, identifier)
+	}
+	sort.Strings(identifiers)
+	for _, identifier := range identifiers {
+		obj, _ := json.MarshalIndent(sequences[identifier], "", "  ")
+		sections = append(sections, api.SectionData{
+			Type:     api.BODY,
+			Title:    identifier,
+			Data:     string(obj),
+			MimeType: "application/json",
+			Selector: fmt.Sprintf(`%s.sections["%s"]`, side, identifier),
+		})
+	}
+
+	documents, _ := message["documents"].([]interface{})
+	if len(documents) > 0 {
+		obj, _ := json.MarshalIndent(documents, "", "  ")
+		sections = append(sections, api.SectionData{
+			Type:     api.BODY,
+			Title:    "Documents",
+			Data:     string(obj),
+			MimeType: "application/json",
+			Selector: fmt.Sprintf(`%s.documents`, side),
+		})
+	}
+
+	return
+}
+
+// A reply is an error if it has `ok: 0` and the error details, legacy query results don't have `ok` at all
+func isError(response map[string]interface{}) bool {
+	return response["errorCode"].(float64) != 0 || response["errmsg"].(string) != ""
+}
@@ -0,0 +1,162 @@
+package mongodb
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+var protocol = api.Protocol{
+	ProtocolSummary: api.ProtocolSummary{
+		Name:         "mongodb",
+		Version:      "3.6",
+		Abbreviation: "MONGO",
+	},
+	LongName:        "MongoDB Wire Protocol",
+	Macro:           "mongodb",
+	BackgroundColor: "#13aa52",
+	ForegroundColor: "#ffffff",
+	FontSize:        11,
+	ReferenceLink:   "https://www.mongodb.com/docs/manual/reference/mongodb-wire-protocol/",
+	Ports:           []string{"27017"},
+	Priority:        7,
+}
+
+var protocolsMap = map[string]*api.Protocol{
+	protocol.ToString(): &protocol,
+}
+
+type dissecting string
+
+func (d dissecting) Register(extension *api.Extension) {
+	extension.Protocol = &protocol
+}
+
+func (d dissecting) GetProtocols() map[string]*api.Protocol {
+	return protocolsMap
+}
+
+func (d dissecting) Ping() {
+	log.Printf("pong %s", protocol.Name)
+}
+
+func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.TrafficFilteringOptions) error {
+	reqResMatcher := reader.GetReqResMatcher().(*requestResponseMatcher)
+	return dissectMessages(b, reader, reqResMatcher)
+}
+
+func (d dissecting) Analyze(item *api.OutputChannelItem, resolvedSource string, resolvedDestination string, namespace string) *api.Entry {
+	request := item.Pair.Request.Payload.(map[string]interface{})
+	response := item.Pair.Response.Payload.(map[string]interface{})
+	reqDetails := request["details"].(map[string]interface{})
+	resDetails := response["details"].(map[string]interface{})
+
+	elapsedTime := item.Pair.Response.CaptureTime.Sub(item.Pair.Request.CaptureTime).Round(time.Millisecond).Milliseconds()
+	if elapsedTime < 0 {
+		elapsedTime = 0
+	}
+	return &api.Entry{
+		Protocol: protocol.ProtocolSummary,
+		Capture:  item.Capture,
+		Source: &api.TCP{
+			Name: resolvedSource,
+			IP:   item.ConnectionInfo.ClientIP,
+			Port: item.ConnectionInfo.ClientPort,
+		},
+		Destination: &api.TCP{
+			Name: resolvedDestination,
+			IP:   item.ConnectionInfo.ServerIP,
+			Port: item.ConnectionInfo.ServerPort,
+		},
+		Namespace:    namespace,
+		Outgoing:     item.ConnectionInfo.IsOutgoing,
+		Request:      reqDetails,
+		Response:     resDetails,
+		RequestSize:  item.Pair.Request.CaptureSize,
+		ResponseSize: item.Pair.Response.CaptureSize,
+		Timestamp:    item.Timestamp,
+		StartTime:    item.Pair.Request.CaptureTime,
+		ElapsedTime:  elapsedTime,
+	}
+}
+
+func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
+	status := 0
+	statusQuery := ""
+	if api.IsUnanswered(entry.Response) {
+		statusQuery = api.UnansweredStatusQuery
+	} else if isError(entry.Response) {
+		status = int(entry.Response["errorCode"].(float64))
+		statusQuery = fmt.Sprintf(`response.errorCode == %d`, status)
+	}
+
+	method := ""
+	methodQuery := ""
+	if command, _ := entry.Request["command"].(string); command != "" {
+		method = command
+		methodQuery = fmt.Sprintf(`request.command == "%s"`, method)
+	} else if opCode, _ := entry.Request["opCode"].(string); opCode != "" {
+		method = opCode
+		methodQuery = fmt.Sprintf(`request.opCode == "%s"`, method)
+	}
+
+	summary := ""
+	summaryQuery := ""
+	if collection, _ := entry.Request["collection"].(string); collection != "" {
+		summary = collection
+		summaryQuery = fmt.Sprintf(`request.collection == "%s"`, summary)
+	} else if database, _ := entry.Request["database"].(string); database != "" {
+		summary = database
+		summaryQuery = fmt.Sprintf(`request.database == "%s"`, summary)
+	}
+
+	return &api.BaseEntry{
+		Id:           entry.Id,
+		Protocol:     *protocolsMap[entry.Protocol.ToString()],
+		Capture:      entry.Capture,
+		Summary:      summary,
+		SummaryQuery: summaryQuery,
+		Status:       status,
+		StatusQuery:  statusQuery,
+		Method:       method,
+		MethodQuery:  methodQuery,
+		Timestamp:    entry.Timestamp,
+		Source:       entry.Source,
+		Destination:  entry.Destination,
+		IsOutgoing:   entry.Outgoing,
+		Latency:      entry.ElapsedTime,
+	}
+}
+
+func (d dissecting) Represent(request map[string]interface{}, response map[string]interface{}) (object []byte, err error) {
+	representation := make(map[string]interface{})
+	repRequest := representRequest(request)
+	repResponse := api.RepresentUnanswered()
+	if !api.IsUnanswered(response) {
+		repResponse = representResponse(response)
+	}
+	representation["request"] = repRequest
+	representation["response"] = repResponse
+	object, err = json.Marshal(representation)
+	return
+}
+
+func (d dissecting) Macros() map[string]string {
+	return map[string]string{
+		`mongodb`: fmt.Sprintf(`protocol.name == "%s"`, protocol.Name),
+	}
+}
+
+func (d dissecting) NewResponseRequestMatcher() api.RequestResponseMatcher {
+	return createResponseRequestMatcher()
+}
+
+var Dissector dissecting
+
+func NewDissector() api.Dissector {
+	return Dissector
+}
@@ -0,0 +1,300 @@
+package mongodb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/api/apitest"
+)
+
+func TestRegister(t *testing.T) {
+	dissector := NewDissector()
+	extension := &api.Extension{}
+	dissector.Register(extension)
+	assert.Equal(t, "mongodb", extension.Protocol.Name)
+}
+
+func TestMacros(t *testing.T) {
+	expectedMacros := map[string]string{
+		"mongodb": `protocol.name == "mongodb"`,
+	}
+	dissector := NewDissector()
+	macros := dissector.Macros()
+	assert.Equal(t, expectedMacros, macros)
+}
+
+func TestPing(t *testing.T) {
+	dissector := NewDissector()
+	dissector.Ping()
+}
+
+// An ordered document, since the first key of a command names it
+type element struct {
+	key   string
+	value interface{}
+}
+
+type document []element
+
+func encodeDocument(d document) []byte {
+	var body bytes.Buffer
+	for _, e := range d {
+		switch value := e.value.(type) {
+		case float64:
+			body.WriteByte(bsonDouble)
+			body.WriteString(e.key + "\x00")
+			_ = binary.Write(&body, binary.LittleEndian, math.Float64bits(value))
+		case string:
+			body.WriteByte(bsonString)
+			body.WriteString(e.key + "\x00")
+			_ = binary.Write(&body, binary.LittleEndian, int32(len(value)+1))
+			body.WriteString(value + "\x00")
+		case document:
+			body.WriteByte(bsonDocument)
+			body.WriteString(e.key + "\x00")
+			body.Write(encodeDocument(value))
+		case []interface{}:
+			array := document{}
+			for i, item := range value {
+				array = append(array, element{string(rune('0' + i)), item})
+			}
+			body.WriteByte(bsonArray)
+			body.WriteString(e.key + "\x00")
+			body.Write(encodeDocument(array))
+		case bool:
+			body.WriteByte(bsonBoolean)
+			body.WriteString(e.key + "\x00")
+			if value {
+				body.WriteByte(1)
+			} else {
+				body.WriteByte(0)
+			}
+		case nil:
+			body.WriteByte(bsonNull)
+			body.WriteString(e.key + "\x00")
+		case int32:
+			body.WriteByte(bsonInt32)
+			body.WriteString(e.key + "\x00")
+			_ = binary.Write(&body, binary.LittleEndian, value)
+		case int64:
+			body.WriteByte(bsonInt64)
+			body.WriteString(e.key + "\x00")
+			_ = binary.Write(&body, binary.LittleEndian, value)
+		case time.Time:
+			body.WriteByte(bsonDateTime)
+			body.WriteString(e.key + "\x00")
+			_ = binary.Write(&body, binary.LittleEndian, value.UnixNano()/int64(time.Millisecond))
+		}
+	}
+
+	var encoded bytes.Buffer
+	_ = binary.Write(&encoded, binary.LittleEndian, int32(body.Len()+5))
+	encoded.Write(body.Bytes())
+	encoded.WriteByte(0)
+	return encoded.Bytes()
+}
+
+func encodeMessage(requestID int32, responseTo int32, opCode int32, fields ...interface{}) []byte {
+	var body bytes.Buffer
+	for _, field := range fields {
+		switch value := field.(type) {
+		case document:
+			body.Write(encodeDocument(value))
+		case string:
+			body.WriteString(value + "\x00")
+		case []byte:
+			body.Write(value)
+		case byte:
+			body.WriteByte(value)
+		default:
+			_ = binary.Write(&body, binary.LittleEndian, value)
+		}
+	}
+
+	var message bytes.Buffer
+	_ = binary.Write(&message, binary.LittleEndian, []int32{int32(body.Len() + headerBytes), requestID, responseTo, opCode})
+	message.Write(body.Bytes())
+	return message.Bytes()
+}
+
+func sequence(identifier string, documents ...document) []byte {
+	var body bytes.Buffer
+	body.WriteString(identifier + "\x00")
+	for _, d := range documents {
+		body.Write(encodeDocument(d))
+	}
+
+	var section bytes.Buffer
+	section.WriteByte(1)
+	_ = binary.Write(&section, binary.LittleEndian, int32(body.Len()+4))
+	section.Write(body.Bytes())
+	return section.Bytes()
+}
+
+func TestReadDocument(t *testing.T) {
+	created := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	data := encodeDocument(document{
+		{"find", "orders"},
+		{"filter", document{{"status", "paid"}, {"total", document{{"$gt", float64(10)}}}}},
+		{"tags", []interface{}{"a", int32(2)}},
+		{"limit", int64(5)},
+		{"deleted", false},
+		{"note", nil},
+		{"created", created},
+	})
+
+	decoded, keys, size, err := readDocument(data)
+	assert.Nil(t, err)
+	assert.Equal(t, len(data), size)
+	assert.Equal(t, []string{"find", "filter", "tags", "limit", "deleted", "note", "created"}, keys)
+	assert.Equal(t, "orders", decoded["find"])
+	assert.Equal(t, map[string]interface{}{"status": "paid", "total": map[string]interface{}{"$gt": float64(10)}}, decoded["filter"])
+	assert.Equal(t, []interface{}{"a", int32(2)}, decoded["tags"])
+	assert.Equal(t, int64(5), decoded["limit"])
+	assert.Equal(t, false, decoded["deleted"])
+	assert.Nil(t, decoded["note"])
+	assert.Equal(t, "2022-03-01T12:00:00Z", decoded["created"])
+
+	_, _, _, err = readDocument(data[:len(data)-3])
+	assert.NotNil(t, err)
+}
+
+func dissect(t *testing.T, dissector api.Dissector, data []byte, isClient bool, reqResMatcher api.RequestResponseMatcher, emitter api.Emitter) {
+	tcpID := &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "40000", DstPort: "27017"}
+	captureTime := time.Unix(0, 0)
+	if !isClient {
+		tcpID = &api.TcpID{SrcIP: "2", DstIP: "1", SrcPort: "27017", DstPort: "40000"}
+		captureTime = time.Unix(0, int64(time.Millisecond))
+	}
+
+	reader := NewTcpReader(&api.ReadProgress{}, "", tcpID, captureTime, NewTcpStream(api.Pcap), isClient, false, nil, emitter, &api.CounterPair{}, reqResMatcher)
+	err := dissector.Dissect(bufio.NewReader(bytes.NewReader(data)), reader, &api.TrafficFilteringOptions{})
+	assert.Equal(t, io.EOF, err)
+}
+
+func toEntry(t *testing.T, item *api.OutputChannelItem) *api.Entry {
+	// Simulate the round trip through the JSON encoding as the items do in Mizu
+	marshaled, err := json.Marshal(item)
+	assert.Nil(t, err)
+	var unmarshaled *api.OutputChannelItem
+	err = json.Unmarshal(marshaled, &unmarshaled)
+	assert.Nil(t, err)
+
+	return NewDissector().Analyze(unmarshaled, "client", "server", "default")
+}
+
+func TestDissect(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 16)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	countCommand := encodeMessage(0, 0, opMsg, uint32(0), byte(0), document{{"count", "customers"}, {"$db", "shop"}})[headerBytes:]
+	_, _ = writer.Write(countCommand)
+	writer.Close()
+
+	var client bytes.Buffer
+	client.Write(encodeMessage(1, 0, opMsg, uint32(0), byte(0), document{{"find", "orders"}, {"filter", document{{"status", "paid"}}}, {"$db", "shop"}}))
+	client.Write(encodeMessage(2, 0, opMsg, uint32(0), byte(0), document{{"insert", "orders"}, {"$db", "shop"}}, sequence("documents", document{{"_id", int32(1)}}, document{{"_id", int32(2)}})))
+	// Unacknowledged writes are not answered
+	client.Write(encodeMessage(3, 0, opMsg, uint32(msgMoreToCome), byte(0), document{{"delete", "orders"}, {"$db", "shop"}}))
+	client.Write(encodeMessage(4, 0, opQuery, uint32(0), "admin.$cmd", int32(0), int32(-1), document{{"isMaster", int32(1)}}))
+	client.Write(encodeMessage(5, 0, opMsg, uint32(0), byte(0), document{{"drop", "missing"}, {"$db", "shop"}}))
+	client.Write(encodeMessage(6, 0, opCompressed, int32(opMsg), int32(len(countCommand)), byte(compressorZlib), compressed.Bytes()))
+	dissect(t, dissector, client.Bytes(), true, reqResMatcher, emitter)
+
+	// The replies may come in any order
+	var server bytes.Buffer
+	server.Write(encodeMessage(101, 2, opMsg, uint32(0), byte(0), document{{"n", int32(2)}, {"ok", float64(1)}}))
+	server.Write(encodeMessage(102, 1, opMsg, uint32(0), byte(0), document{{"cursor", document{{"firstBatch", []interface{}{document{{"_id", int32(1)}}}}, {"id", int64(0)}, {"ns", "shop.orders"}}}, {"ok", float64(1)}}))
+	server.Write(encodeMessage(103, 4, opReply, uint32(0), int64(0), int32(0), int32(1), document{{"ismaster", true}, {"ok", float64(1)}}))
+	server.Write(encodeMessage(104, 5, opMsg, uint32(0), byte(0), document{{"ok", float64(0)}, {"errmsg", "ns not found"}, {"code", int32(26)}, {"codeName", "NamespaceNotFound"}}))
+	server.Write(encodeMessage(105, 6, opMsg, uint32(0), byte(0), document{{"n", int32(7)}, {"ok", float64(1)}}))
+	dissect(t, dissector, server.Bytes(), false, reqResMatcher, emitter)
+
+	close(itemChannel)
+	var items []*api.OutputChannelItem
+	for item := range itemChannel {
+		items = append(items, item)
+	}
+	assert.Len(t, items, 5)
+
+	insert := toEntry(t, items[0])
+	assert.Equal(t, "insert", insert.Request["command"])
+	assert.Len(t, insert.Request["sections"].(map[string]interface{})["documents"], 2)
+	assert.Equal(t, float64(2), insert.Response["document"].(map[string]interface{})["n"])
+
+	find := toEntry(t, items[1])
+	summary := dissector.Summarize(find)
+	assert.Equal(t, "find", summary.Method)
+	assert.Equal(t, `request.command == "find"`, summary.MethodQuery)
+	assert.Equal(t, "orders", summary.Summary)
+	assert.Equal(t, `request.collection == "orders"`, summary.SummaryQuery)
+	assert.Equal(t, 0, summary.Status)
+	assert.Equal(t, "shop", find.Request["database"])
+
+	isMaster := toEntry(t, items[2])
+	assert.Equal(t, "OP_QUERY", isMaster.Request["opCode"])
+	assert.Equal(t, "isMaster", isMaster.Request["command"])
+	assert.Equal(t, "admin", isMaster.Request["database"])
+	assert.Equal(t, "OP_REPLY", isMaster.Response["opCode"])
+	assert.Equal(t, float64(1), isMaster.Response["ok"])
+
+	drop := toEntry(t, items[3])
+	summary = dissector.Summarize(drop)
+	assert.Equal(t, 26, summary.Status)
+	assert.Equal(t, `response.errorCode == 26`, summary.StatusQuery)
+	assert.Equal(t, "NamespaceNotFound", drop.Response["codeName"])
+
+	count := toEntry(t, items[4])
+	assert.Equal(t, "zlib", count.Request["compressor"])
+	assert.Equal(t, "count", count.Request["command"])
+	assert.Equal(t, "customers", count.Request["collection"])
+
+	for _, entry := range []*api.Entry{insert, find, isMaster, drop, count} {
+		representation, err := dissector.Represent(entry.Request, entry.Response)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, representation)
+	}
+}
+
+func TestDissectGarbage(t *testing.T) {
+	dissector := NewDissector()
+	reader := NewTcpReader(&api.ReadProgress{}, "", &api.TcpID{}, time.Time{}, NewTcpStream(api.Pcap), true, false, nil, nil, &api.CounterPair{}, dissector.NewResponseRequestMatcher())
+	err := dissector.Dissect(bufio.NewReader(bytes.NewBufferString("GET / HTTP/1.1\r\n\r\n")), reader, &api.TrafficFilteringOptions{})
+	assert.NotNil(t, err)
+	assert.NotEqual(t, io.EOF, err)
+}
+
+func TestNewUnansweredItem(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 1)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+
+	dissect(t, dissector, encodeMessage(1, 0, opMsg, uint32(0), byte(0), document{{"find", "orders"}, {"$db", "shop"}}), true, reqResMatcher, emitter)
+
+	items := apitest.UnansweredItems(reqResMatcher)
+	assert.Len(t, items, 1)
+
+	items[0].ConnectionInfo = &api.ConnectionInfo{ClientIP: "1", ClientPort: "40000", ServerIP: "2", ServerPort: "27017"}
+	_, summary := apitest.AnalyzeUnanswered(t, dissector, items[0])
+	assert.Equal(t, "find", summary.Method)
+}
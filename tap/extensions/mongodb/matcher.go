@@ -0,0 +1,99 @@
+package mongodb
+
+import (
+	"sync"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// Key is `{client_ip}_{server_ip}_{client_port}_{server_port}_{request_id}`
+// The replies carry the ID of the request in their responseTo field.
+type requestResponseMatcher struct {
+	openMessagesMap *sync.Map
+}
+
+func createResponseRequestMatcher() api.RequestResponseMatcher {
+	return &requestResponseMatcher{openMessagesMap: &sync.Map{}}
+}
+
+func (matcher *requestResponseMatcher) GetMap() *sync.Map {
+	return matcher.openMessagesMap
+}
+
+func (matcher *requestResponseMatcher) SetMaxTry(value int) {
+}
+
+func (matcher *requestResponseMatcher) registerRequest(ident string, request *MongoDBMessage, captureTime time.Time, captureSize int) *api.OutputChannelItem {
+	requestMongoDBMessage := api.GenericMessage{
+		IsRequest:   true,
+		CaptureTime: captureTime,
+		CaptureSize: captureSize,
+		Payload: MongoDBPayload{
+			Data: &MongoDBWrapper{
+				Method:  request.Command,
+				Url:     request.Collection,
+				Details: request,
+			},
+		},
+	}
+
+	if response, found := matcher.openMessagesMap.LoadAndDelete(ident); found {
+		// Type assertion always succeeds because all of the map's values are of api.GenericMessage type
+		responseMongoDBMessage := response.(*api.GenericMessage)
+		if responseMongoDBMessage.IsRequest {
+			return nil
+		}
+		return matcher.preparePair(&requestMongoDBMessage, responseMongoDBMessage)
+	}
+
+	matcher.openMessagesMap.Store(ident, &requestMongoDBMessage)
+	return nil
+}
+
+func (matcher *requestResponseMatcher) registerResponse(ident string, response *MongoDBMessage, captureTime time.Time, captureSize int) *api.OutputChannelItem {
+	responseMongoDBMessage := api.GenericMessage{
+		IsRequest:   false,
+		CaptureTime: captureTime,
+		CaptureSize: captureSize,
+		Payload: MongoDBPayload{
+			Data: &MongoDBWrapper{
+				Method:  response.OpCode,
+				Url:     "",
+				Details: response,
+			},
+		},
+	}
+
+	if request, found := matcher.openMessagesMap.LoadAndDelete(ident); found {
+		// Type assertion always succeeds because all of the map's values are of api.GenericMessage type
+		requestMongoDBMessage := request.(*api.GenericMessage)
+		if !requestMongoDBMessage.IsRequest {
+			return nil
+		}
+		return matcher.preparePair(requestMongoDBMessage, &responseMongoDBMessage)
+	}
+
+	matcher.openMessagesMap.Store(ident, &responseMongoDBMessage)
+	return nil
+}
+
+func (matcher *requestResponseMatcher) preparePair(requestMongoDBMessage *api.GenericMessage, responseMongoDBMessage *api.GenericMessage) *api.OutputChannelItem {
+	return &api.OutputChannelItem{
+		Protocol:       protocol,
+		Timestamp:      requestMongoDBMessage.CaptureTime.UnixNano() / int64(time.Millisecond),
+		ConnectionInfo: nil,
+		Pair: &api.RequestResponsePair{
+			Request:  *requestMongoDBMessage,
+			Response: *responseMongoDBMessage,
+		},
+	}
+}
+
+func (matcher *requestResponseMatcher) NewUnansweredItem(key interface{}, value interface{}) *api.OutputChannelItem {
+	request, ok := value.(*api.GenericMessage)
+	if !ok || !request.IsRequest {
+		return nil
+	}
+	return api.NewUnansweredItem(protocol, request)
+}
@@ -0,0 +1,280 @@
+package mongodb
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var errMalformedMessage = errors.New("Malformed MongoDB message")
+
+// The header is peeked and validated first, so a stream of another protocol isn't buffered
+// for the length it would imply.
+func readMessage(b *bufio.Reader) (*MongoDBMessage, error) {
+	data, err := b.Peek(headerBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	header := &MongoDBHeader{
+		MessageLength: int32(binary.LittleEndian.Uint32(data[0:4])),
+		RequestID:     int32(binary.LittleEndian.Uint32(data[4:8])),
+		ResponseTo:    int32(binary.LittleEndian.Uint32(data[8:12])),
+		OpCode:        int32(binary.LittleEndian.Uint32(data[12:16])),
+	}
+
+	if _, ok := opCodes[header.OpCode]; !ok {
+		return nil, fmt.Errorf("Unrecognized MongoDB opcode: %d", header.OpCode)
+	}
+	if header.MessageLength <= headerBytes || header.MessageLength > maxMessageSize {
+		return nil, fmt.Errorf("Invalid MongoDB message length: %d", header.MessageLength)
+	}
+
+	message := make([]byte, header.MessageLength)
+	if _, err = io.ReadFull(b, message); err != nil {
+		return nil, err
+	}
+
+	return parseMessage(header, message[headerBytes:])
+}
+
+func parseMessage(header *MongoDBHeader, body []byte) (*MongoDBMessage, error) {
+	message := &MongoDBMessage{
+		OpCode:     opCodes[header.OpCode],
+		RequestID:  header.RequestID,
+		ResponseTo: header.ResponseTo,
+		Sections:   make(map[string][]map[string]interface{}),
+		Documents:  make([]map[string]interface{}, 0),
+	}
+
+	var err error
+	switch header.OpCode {
+	case opMsg:
+		err = parseMsg(message, body)
+	case opQuery:
+		err = parseQuery(message, body)
+	case opReply:
+		err = parseReply(message, body)
+	case opGetMore:
+		err = parseGetMore(message, body)
+	case opCompressed:
+		err = parseCompressed(message, body)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	message.setStatus()
+	return message, nil
+}
+
+func parseMsg(message *MongoDBMessage, body []byte) error {
+	if len(body) < 5 {
+		return errMalformedMessage
+	}
+
+	message.Flags = binary.LittleEndian.Uint32(body)
+	end := len(body)
+	if message.Flags&msgChecksumPresent != 0 {
+		end -= 4
+	}
+
+	offset := 4
+	for offset < end {
+		kind := body[offset]
+		offset++
+
+		switch kind {
+		// A single document, the body of the command
+		case 0:
+			document, keys, size, err := readDocument(body[offset:end])
+			if err != nil {
+				return err
+			}
+			message.Document = document
+			if len(keys) > 0 {
+				message.Command = keys[0]
+			}
+			offset += size
+		// A sequence of documents, e.g. the documents of an insert command
+		case 1:
+			if offset+4 > end {
+				return errMalformedMessage
+			}
+			size := int(int32(binary.LittleEndian.Uint32(body[offset:])))
+			sectionEnd := offset + size
+			if size < 4 || sectionEnd > end {
+				return errMalformedMessage
+			}
+
+			identifierEnd := bytes.IndexByte(body[offset+4:sectionEnd], 0)
+			if identifierEnd < 0 {
+				return errMalformedMessage
+			}
+			identifier := string(body[offset+4 : offset+4+identifierEnd])
+
+			documents := make([]map[string]interface{}, 0)
+			for position := offset + 4 + identifierEnd + 1; position < sectionEnd; {
+				document, _, size, err := readDocument(body[position:sectionEnd])
+				if err != nil {
+					return err
+				}
+				documents = append(documents, document)
+				position += size
+			}
+			message.Sections[identifier] = documents
+			offset = sectionEnd
+		default:
+			return fmt.Errorf("Unrecognized MongoDB section kind: %d", kind)
+		}
+	}
+
+	if message.Document != nil {
+		message.Database, _ = message.Document["$db"].(string)
+		message.Collection = commandCollection(message.Command, message.Document)
+	}
+	return nil
+}
+
+// Before OP_MSG, the commands were sent as queries on the `$cmd` collection of the database.
+func parseQuery(message *MongoDBMessage, body []byte) error {
+	if len(body) < 4 {
+		return errMalformedMessage
+	}
+	message.Flags = binary.LittleEndian.Uint32(body)
+
+	namespaceEnd := bytes.IndexByte(body[4:], 0)
+	if namespaceEnd < 0 || 4+namespaceEnd+1+8 > len(body) {
+		return errMalformedMessage
+	}
+	namespace := string(body[4 : 4+namespaceEnd])
+	offset := 4 + namespaceEnd + 1 + 8 // numberToSkip and numberToReturn
+
+	document, keys, _, err := readDocument(body[offset:])
+	if err != nil {
+		return err
+	}
+	message.Document = document
+
+	database, collection := splitNamespace(namespace)
+	message.Database = database
+	if collection == "$cmd" {
+		if len(keys) > 0 {
+			message.Command = keys[0]
+		}
+		message.Collection = commandCollection(message.Command, document)
+	} else {
+		message.Command = "find"
+		message.Collection = collection
+	}
+	return nil
+}
+
+func parseReply(message *MongoDBMessage, body []byte) error {
+	if len(body) < 20 {
+		return errMalformedMessage
+	}
+	message.Flags = binary.LittleEndian.Uint32(body)
+	message.CursorID = int64(binary.LittleEndian.Uint64(body[4:]))
+	numberReturned := int(int32(binary.LittleEndian.Uint32(body[16:])))
+
+	offset := 20
+	for i := 0; i < numberReturned && offset < len(body); i++ {
+		document, _, size, err := readDocument(body[offset:])
+		if err != nil {
+			return err
+		}
+		message.Documents = append(message.Documents, document)
+		offset += size
+	}
+
+	// The reply of a command is a single document
+	if len(message.Documents) == 1 {
+		message.Document = message.Documents[0]
+		message.Documents = make([]map[string]interface{}, 0)
+	}
+	return nil
+}
+
+func parseGetMore(message *MongoDBMessage, body []byte) error {
+	if len(body) < 4 {
+		return errMalformedMessage
+	}
+	namespaceEnd := bytes.IndexByte(body[4:], 0)
+	if namespaceEnd < 0 || 4+namespaceEnd+1+12 > len(body) {
+		return errMalformedMessage
+	}
+	message.Database, message.Collection = splitNamespace(string(body[4 : 4+namespaceEnd]))
+	message.Command = "getMore"
+	message.CursorID = int64(binary.LittleEndian.Uint64(body[4+namespaceEnd+1+4:]))
+	return nil
+}
+
+// Only the compressors available in the standard library are decoded. The messages compressed
+// with the others are still paired, with the compressor shown in place of their contents.
+func parseCompressed(message *MongoDBMessage, body []byte) error {
+	if len(body) < 9 {
+		return errMalformedMessage
+	}
+	originalOpCode := int32(binary.LittleEndian.Uint32(body))
+	uncompressedSize := int32(binary.LittleEndian.Uint32(body[4:]))
+	compressor := body[8]
+	data := body[9:]
+
+	if _, ok := opCodes[originalOpCode]; !ok || originalOpCode == opCompressed {
+		return fmt.Errorf("Unrecognized MongoDB opcode: %d", originalOpCode)
+	}
+	if uncompressedSize < 0 || uncompressedSize > maxMessageSize {
+		return errMalformedMessage
+	}
+
+	message.OpCode = opCodes[originalOpCode]
+	if name, ok := compressors[compressor]; ok {
+		message.Compressor = name
+	} else {
+		message.Compressor = fmt.Sprintf("%d", compressor)
+	}
+
+	switch compressor {
+	case compressorNoop:
+	case compressorZlib:
+		reader, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		data = make([]byte, uncompressedSize)
+		if _, err = io.ReadFull(reader, data); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	original, err := parseMessage(&MongoDBHeader{
+		MessageLength: headerBytes + uncompressedSize,
+		RequestID:     message.RequestID,
+		ResponseTo:    message.ResponseTo,
+		OpCode:        originalOpCode,
+	}, data)
+	if err != nil {
+		return err
+	}
+
+	original.Compressor = message.Compressor
+	*message = *original
+	return nil
+}
+
+func splitNamespace(namespace string) (database string, collection string) {
+	parts := strings.SplitN(namespace, ".", 2)
+	database = parts[0]
+	if len(parts) > 1 {
+		collection = parts[1]
+	}
+	return
+}
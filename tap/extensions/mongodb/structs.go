@@ -0,0 +1,125 @@
+package mongodb
+
+const (
+	opReply        = 1
+	opUpdate       = 2001
+	opInsert       = 2002
+	opQuery        = 2004
+	opGetMore      = 2005
+	opDelete       = 2006
+	opKillCursors  = 2007
+	opCompressed   = 2012
+	opMsg          = 2013
+	headerBytes    = 16
+	maxMessageSize = 48000000
+)
+
+var opCodes = map[int32]string{
+	opReply:       "OP_REPLY",
+	opUpdate:      "OP_UPDATE",
+	opInsert:      "OP_INSERT",
+	opQuery:       "OP_QUERY",
+	opGetMore:     "OP_GET_MORE",
+	opDelete:      "OP_DELETE",
+	opKillCursors: "OP_KILL_CURSORS",
+	opCompressed:  "OP_COMPRESSED",
+	opMsg:         "OP_MSG",
+}
+
+// OP_MSG flag bits
+const (
+	msgChecksumPresent = 1 << 0
+	msgMoreToCome      = 1 << 1
+)
+
+const (
+	compressorNoop   = 0
+	compressorSnappy = 1
+	compressorZlib   = 2
+	compressorZstd   = 3
+)
+
+var compressors = map[uint8]string{
+	compressorNoop:   "noop",
+	compressorSnappy: "snappy",
+	compressorZlib:   "zlib",
+	compressorZstd:   "zstd",
+}
+
+type MongoDBHeader struct {
+	MessageLength int32 `json:"messageLength"`
+	RequestID     int32 `json:"requestId"`
+	ResponseTo    int32 `json:"responseTo"`
+	OpCode        int32 `json:"opCode"`
+}
+
+type MongoDBMessage struct {
+	OpCode     string                              `json:"opCode"`
+	RequestID  int32                               `json:"requestId"`
+	ResponseTo int32                               `json:"responseTo"`
+	Flags      uint32                              `json:"flags"`
+	Compressor string                              `json:"compressor"`
+	Command    string                              `json:"command"`
+	Collection string                              `json:"collection"`
+	Database   string                              `json:"database"`
+	Document   map[string]interface{}              `json:"document"`
+	Sections   map[string][]map[string]interface{} `json:"sections"`
+	Documents  []map[string]interface{}            `json:"documents"`
+	CursorID   int64                               `json:"cursorId"`
+	Ok         float64                             `json:"ok"`
+	ErrorCode  int                                 `json:"errorCode"`
+	CodeName   string                              `json:"codeName"`
+	ErrorMsg   string                              `json:"errmsg"`
+}
+
+// Some commands name the collection as the value of the command itself, the rest in a separate field.
+func commandCollection(command string, document map[string]interface{}) string {
+	if collection, ok := document[command].(string); ok {
+		return collection
+	}
+	if collection, ok := document["collection"].(string); ok {
+		return collection
+	}
+	return ""
+}
+
+// The errors of the commands are reported in the reply as `ok: 0` with the code and the message
+func (message *MongoDBMessage) setStatus() {
+	if message.Document == nil {
+		return
+	}
+
+	switch ok := message.Document["ok"].(type) {
+	case float64:
+		message.Ok = ok
+	case int32:
+		message.Ok = float64(ok)
+	case int64:
+		message.Ok = float64(ok)
+	case bool:
+		if ok {
+			message.Ok = 1
+		}
+	}
+
+	switch code := message.Document["code"].(type) {
+	case int32:
+		message.ErrorCode = int(code)
+	case int64:
+		message.ErrorCode = int(code)
+	case float64:
+		message.ErrorCode = int(code)
+	}
+	message.CodeName, _ = message.Document["codeName"].(string)
+	message.ErrorMsg, _ = message.Document["errmsg"].(string)
+	if message.ErrorMsg == "" {
+		// The failed legacy queries
+		message.ErrorMsg, _ = message.Document["$err"].(string)
+	}
+
+	if cursor, ok := message.Document["cursor"].(map[string]interface{}); ok {
+		if id, ok := cursor["id"].(int64); ok {
+			message.CursorID = id
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package mqtt
+
+import (
+	"bufio"
+	"fmt"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// Both the clients and the broker publish, so both directions are dissected the same way.
+func dissectPackets(b *bufio.Reader, reader api.TcpReader, reqResMatcher *requestResponseMatcher) error {
+	identified := false
+
+	for {
+		packetType, flags, body, err := readPacket(b, identified)
+		if err != nil {
+			return err
+		}
+
+		version := reqResMatcher.getVersion()
+		packet, err := parsePacket(packetType, flags, body, version)
+		if err != nil {
+			// The packets are framed the same way in all versions, so the rest of an identified stream is still readable
+			if identified {
+				continue
+			}
+			return err
+		}
+		reader.GetParent().SetProtocol(&protocol)
+		identified = true
+		captureTime := reader.GetCaptureTime()
+
+		switch packetType {
+		case packetConnect:
+			reqResMatcher.setVersion(packet.protocolLevel)
+			handleRequest(reader, captureTime, "connect", packet, reqResMatcher)
+		case packetConnack:
+			handleResponse(reader, captureTime, "connect", packet, reqResMatcher)
+		case packetPublish:
+			reqResMatcher.resolveTopicAlias(reader.GetTcpID(), packet)
+			if packet.QoS == 0 {
+				handleRequestOnly(reader, captureTime, packet, reqResMatcher)
+			} else {
+				handleRequest(reader, captureTime, fmt.Sprintf("publish_%d", packet.PacketID), packet, reqResMatcher)
+			}
+		// QoS 1 is acknowledged with PUBACK, QoS 2 with PUBREC. The PUBREL and PUBCOMP that follow
+		// the latter only complete the exchange.
+		case packetPuback, packetPubrec:
+			handleResponse(reader, captureTime, fmt.Sprintf("publish_%d", packet.PacketID), packet, reqResMatcher)
+		case packetSubscribe:
+			handleRequest(reader, captureTime, fmt.Sprintf("subscribe_%d", packet.PacketID), packet, reqResMatcher)
+		case packetSuback:
+			handleResponse(reader, captureTime, fmt.Sprintf("subscribe_%d", packet.PacketID), packet, reqResMatcher)
+		case packetUnsubscribe:
+			handleRequest(reader, captureTime, fmt.Sprintf("unsubscribe_%d", packet.PacketID), packet, reqResMatcher)
+		case packetUnsuback:
+			handleResponse(reader, captureTime, fmt.Sprintf("unsubscribe_%d", packet.PacketID), packet, reqResMatcher)
+		case packetPingreq:
+			handleRequest(reader, captureTime, "ping", packet, reqResMatcher)
+		case packetPingresp:
+			handleResponse(reader, captureTime, "ping", packet, reqResMatcher)
+		case packetDisconnect, packetAuth:
+			handleRequestOnly(reader, captureTime, packet, reqResMatcher)
+		}
+	}
+}
+
+func handleRequest(reader api.TcpReader, captureTime time.Time, key string, packet *MQTTPacket, reqResMatcher *requestResponseMatcher) {
+	tcpID := reader.GetTcpID()
+	ident := fmt.Sprintf(
+		"%s_%s_%s_%s_%s",
+		tcpID.SrcIP,
+		tcpID.DstIP,
+		tcpID.SrcPort,
+		tcpID.DstPort,
+		key,
+	)
+
+	item := reqResMatcher.registerRequest(ident, packet, captureTime, reader.GetReadProgress().Current())
+	emitItem(reader, item, true)
+}
+
+func handleResponse(reader api.TcpReader, captureTime time.Time, key string, packet *MQTTPacket, reqResMatcher *requestResponseMatcher) {
+	tcpID := reader.GetTcpID()
+	ident := fmt.Sprintf(
+		"%s_%s_%s_%s_%s",
+		tcpID.DstIP,
+		tcpID.SrcIP,
+		tcpID.DstPort,
+		tcpID.SrcPort,
+		key,
+	)
+
+	item := reqResMatcher.registerResponse(ident, packet, captureTime, reader.GetReadProgress().Current())
+	emitItem(reader, item, false)
+}
+
+func handleRequestOnly(reader api.TcpReader, captureTime time.Time, packet *MQTTPacket, reqResMatcher *requestResponseMatcher) {
+	item := reqResMatcher.registerRequestOnly(packet, captureTime, reader.GetReadProgress().Current())
+	emitItem(reader, item, true)
+}
+
+func emitItem(reader api.TcpReader, item *api.OutputChannelItem, isRequest bool) {
+	if item == nil {
+		return
+	}
+
+	tcpID := reader.GetTcpID()
+	item.Capture = reader.GetParent().GetOrigin()
+	if isRequest {
+		item.ConnectionInfo = &api.ConnectionInfo{
+			ClientIP:   tcpID.SrcIP,
+			ClientPort: tcpID.SrcPort,
+			ServerIP:   tcpID.DstIP,
+			ServerPort: tcpID.DstPort,
+			IsOutgoing: true,
+		}
+	} else {
+		item.ConnectionInfo = &api.ConnectionInfo{
+			ClientIP:   tcpID.DstIP,
+			ClientPort: tcpID.DstPort,
+			ServerIP:   tcpID.SrcIP,
+			ServerPort: tcpID.SrcPort,
+			IsOutgoing: false,
+		}
+	}
+	reader.GetEmitter().Emit(item)
+}
@@ -0,0 +1,224 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+type MQTTPayload struct {
+	Data interface{}
+}
+
+type MQTTPayloader interface {
+	MarshalJSON() ([]byte, error)
+}
+
+func (h MQTTPayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Data)
+}
+
+type MQTTWrapper struct {
+	Method  string      `json:"method"`
+	Url     string      `json:"url"`
+	Details interface{} `json:"details"`
+}
+
+// The CONNACK of MQTT 3.1.1 fails with any non-zero return code, the rest with a reason code of at least 0x80
+func isError(packet map[string]interface{}) bool {
+	reasonCode, _ := packet["reasonCode"].(float64)
+	if packet["type"] == packetTypes[packetConnack] {
+		return reasonCode != 0
+	}
+	return reasonCode >= 0x80
+}
+
+func representPacket(packet map[string]interface{}, side string) (rep []interface{}) {
+	rep = make([]interface{}, 0)
+
+	// The empty response of the packets that are not acknowledged
+	packetType, ok := packet["type"].(string)
+	if !ok {
+		return
+	}
+
+	details := []api.TableData{
+		{
+			Name:     "Type",
+			Value:    packetType,
+			Selector: fmt.Sprintf(`%s.type`, side),
+		},
+		{
+			Name:     "Protocol Version",
+			Value:    packet["protocolVersion"].(string),
+			Selector: fmt.Sprintf(`%s.protocolVersion`, side),
+		},
+		{
+			Name:     "Packet ID",
+			Value:    int64(packet["packetId"].(float64)),
+			Selector: fmt.Sprintf(`%s.packetId`, side),
+		},
+	}
+
+	switch packetType {
+	case packetTypes[packetConnect]:
+		details = append(details, []api.TableData{
+			{
+				Name:     "Client ID",
+				Value:    packet["clientId"].(string),
+				Selector: fmt.Sprintf(`%s.clientId`, side),
+			},
+			{
+				Name:     "Username",
+				Value:    packet["username"].(string),
+				Selector: fmt.Sprintf(`%s.username`, side),
+			},
+			{
+				Name:     "Clean Session",
+				Value:    packet["cleanSession"].(bool),
+				Selector: fmt.Sprintf(`%s.cleanSession`, side),
+			},
+			{
+				Name:     "Keep Alive",
+				Value:    int64(packet["keepAlive"].(float64)),
+				Selector: fmt.Sprintf(`%s.keepAlive`, side),
+			},
+			{
+				Name:     "Will Topic",
+				Value:    packet["willTopic"].(string),
+				Selector: fmt.Sprintf(`%s.willTopic`, side),
+			},
+		}...)
+	case packetTypes[packetPublish]:
+		details = append(details, []api.TableData{
+			{
+				Name:     "Topic",
+				Value:    packet["topic"].(string),
+				Selector: fmt.Sprintf(`%s.topic`, side),
+			},
+			{
+				Name:     "QoS",
+				Value:    int64(packet["qos"].(float64)),
+				Selector: fmt.Sprintf(`%s.qos`, side),
+			},
+			{
+				Name:     "Retain",
+				Value:    packet["retain"].(bool),
+				Selector: fmt.Sprintf(`%s.retain`, side),
+			},
+			{
+				Name:     "Duplicate",
+				Value:    packet["dup"].(bool),
+				Selector: fmt.Sprintf(`%s.dup`, side),
+			},
+			{
+				Name:     "Payload Size",
+				Value:    int64(packet["payloadSize"].(float64)),
+				Selector: fmt.Sprintf(`%s.payloadSize`, side),
+			},
+		}...)
+	case packetTypes[packetConnack]:
+		details = append(details, api.TableData{
+			Name:     "Session Present",
+			Value:    packet["sessionPresent"].(bool),
+			Selector: fmt.Sprintf(`%s.sessionPresent`, side),
+		})
+	}
+
+	if packetType != packetTypes[packetConnect] && packetType != packetTypes[packetPublish] {
+		details = append(details, []api.TableData{
+			{
+				Name:     "Reason Code",
+				Value:    int64(packet["reasonCode"].(float64)),
+				Selector: fmt.Sprintf(`%s.reasonCode`, side),
+			},
+			{
+				Name:     "Reason",
+				Value:    packet["reason"].(string),
+				Selector: fmt.Sprintf(`%s.reason`, side),
+			},
+		}...)
+	}
+
+	obj, _ := json.Marshal(details)
+	rep = append(rep, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Details",
+		Data:  string(obj),
+	})
+
+	subscriptions, _ := packet["subscriptions"].([]interface{})
+	if len(subscriptions) > 0 {
+		var table []api.TableData
+		for i, _subscription := range subscriptions {
+			subscription := _subscription.(map[string]interface{})
+			table = append(table, api.TableData{
+				Name:     subscription["topicFilter"].(string),
+				Value:    fmt.Sprintf("QoS %g", subscription["qos"].(float64)),
+				Selector: fmt.Sprintf(`%s.subscriptions[%d].topicFilter`, side, i),
+			})
+		}
+		obj, _ := json.Marshal(table)
+		rep = append(rep, api.SectionData{
+			Type:  api.TABLE,
+			Title: "Subscriptions",
+			Data:  string(obj),
+		})
+	}
+
+	reasonCodes, _ := packet["reasonCodes"].([]interface{})
+	if len(reasonCodes) > 0 {
+		var table []api.TableData
+		for i, reasonCode := range reasonCodes {
+			table = append(table, api.TableData{
+				Name:     fmt.Sprintf("[%d]", i),
+				Value:    int64(reasonCode.(float64)),
+				Selector: fmt.Sprintf(`%s.reasonCodes[%d]`, side, i),
+			})
+		}
+		obj, _ := json.Marshal(table)
+		rep = append(rep, api.SectionData{
+			Type:  api.TABLE,
+			Title: "Reason Codes",
+			Data:  string(obj),
+		})
+	}
+
+	properties, _ := packet["properties"].(map[string]interface{})
+	if len(properties) > 0 {
+		var table []api.TableData
+		for name, value := range properties {
+			table = append(table, api.TableData{
+				Name:     name,
+				Value:    value,
+				Selector: fmt.Sprintf(`%s.properties.%s`, side, name),
+			})
+		}
+		sort.Slice(table, func(i, j int) bool {
+			return table[i].Name < table[j].Name
+		})
+		obj, _ := json.Marshal(table)
+		rep = append(rep, api.SectionData{
+			Type:  api.TABLE,
+			Title: "Properties",
+			Data:  string(obj),
+		})
+	}
+
+	if payload, ok := packet["payload"].(string); ok && payload != "" {
+		// Set by the publishers of MQTT 5.0 only
+		contentType, _ := properties["contentType"].(string)
+		rep = append(rep, api.SectionData{
+			Type:     api.BODY,
+			Title:    "Payload",
+			Encoding: "base64",
+			MimeType: contentType,
+			Data:     payload,
+			Selector: fmt.Sprintf(`%s.payload`, side),
+		})
+	}
+
+	return
+}
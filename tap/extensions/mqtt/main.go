@@ -0,0 +1,165 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+var protocol = api.Protocol{
+	ProtocolSummary: api.ProtocolSummary{
+		Name:         "mqtt",
+		Version:      "5.0",
+		Abbreviation: "MQTT",
+	},
+	LongName:        "Message Queuing Telemetry Transport",
+	Macro:           "mqtt",
+	BackgroundColor: "#660066",
+	ForegroundColor: "#ffffff",
+	FontSize:        12,
+	ReferenceLink:   "https://docs.oasis-open.org/mqtt/mqtt/v5.0/mqtt-v5.0.html",
+	Ports:           []string{"1883", "8883"},
+	Priority:        8,
+}
+
+var protocolsMap = map[string]*api.Protocol{
+	protocol.ToString(): &protocol,
+}
+
+type dissecting string
+
+func (d dissecting) Register(extension *api.Extension) {
+	extension.Protocol = &protocol
+}
+
+func (d dissecting) GetProtocols() map[string]*api.Protocol {
+	return protocolsMap
+}
+
+func (d dissecting) Ping() {
+	log.Printf("pong %s", protocol.Name)
+}
+
+func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.TrafficFilteringOptions) error {
+	reqResMatcher := reader.GetReqResMatcher().(*requestResponseMatcher)
+	return dissectPackets(b, reader, reqResMatcher)
+}
+
+func (d dissecting) Analyze(item *api.OutputChannelItem, resolvedSource string, resolvedDestination string, namespace string) *api.Entry {
+	request := item.Pair.Request.Payload.(map[string]interface{})
+	response := item.Pair.Response.Payload.(map[string]interface{})
+	reqDetails := request["details"].(map[string]interface{})
+	resDetails := response["details"].(map[string]interface{})
+
+	elapsedTime := item.Pair.Response.CaptureTime.Sub(item.Pair.Request.CaptureTime).Round(time.Millisecond).Milliseconds()
+	if elapsedTime < 0 {
+		elapsedTime = 0
+	}
+	return &api.Entry{
+		Protocol: protocol.ProtocolSummary,
+		Capture:  item.Capture,
+		Source: &api.TCP{
+			Name: resolvedSource,
+			IP:   item.ConnectionInfo.ClientIP,
+			Port: item.ConnectionInfo.ClientPort,
+		},
+		Destination: &api.TCP{
+			Name: resolvedDestination,
+			IP:   item.ConnectionInfo.ServerIP,
+			Port: item.ConnectionInfo.ServerPort,
+		},
+		Namespace:    namespace,
+		Outgoing:     item.ConnectionInfo.IsOutgoing,
+		Request:      reqDetails,
+		Response:     resDetails,
+		RequestSize:  item.Pair.Request.CaptureSize,
+		ResponseSize: item.Pair.Response.CaptureSize,
+		Timestamp:    item.Timestamp,
+		StartTime:    item.Pair.Request.CaptureTime,
+		ElapsedTime:  elapsedTime,
+	}
+}
+
+func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
+	status := 0
+	statusQuery := ""
+	if api.IsUnanswered(entry.Response) {
+		statusQuery = api.UnansweredStatusQuery
+	} else if isError(entry.Response) {
+		status = int(entry.Response["reasonCode"].(float64))
+		statusQuery = fmt.Sprintf(`response.reasonCode == %d`, status)
+	}
+
+	method := entry.Request["type"].(string)
+	methodQuery := fmt.Sprintf(`request.type == "%s"`, method)
+
+	summary := ""
+	summaryQuery := ""
+	switch method {
+	case packetTypes[packetPublish]:
+		summary = entry.Request["topic"].(string)
+		summaryQuery = fmt.Sprintf(`request.topic == "%s"`, summary)
+	case packetTypes[packetSubscribe], packetTypes[packetUnsubscribe]:
+		subscriptions := entry.Request["subscriptions"].([]interface{})
+		if len(subscriptions) > 0 {
+			summary = subscriptions[0].(map[string]interface{})["topicFilter"].(string)
+			summaryQuery = fmt.Sprintf(`request.subscriptions[0].topicFilter == "%s"`, summary)
+		}
+	case packetTypes[packetConnect]:
+		summary = entry.Request["clientId"].(string)
+		summaryQuery = fmt.Sprintf(`request.clientId == "%s"`, summary)
+	case packetTypes[packetDisconnect], packetTypes[packetAuth]:
+		summary = entry.Request["reason"].(string)
+		summaryQuery = fmt.Sprintf(`request.reason == "%s"`, summary)
+	}
+
+	return &api.BaseEntry{
+		Id:           entry.Id,
+		Protocol:     *protocolsMap[entry.Protocol.ToString()],
+		Capture:      entry.Capture,
+		Summary:      summary,
+		SummaryQuery: summaryQuery,
+		Status:       status,
+		StatusQuery:  statusQuery,
+		Method:       method,
+		MethodQuery:  methodQuery,
+		Timestamp:    entry.Timestamp,
+		Source:       entry.Source,
+		Destination:  entry.Destination,
+		IsOutgoing:   entry.Outgoing,
+		Latency:      entry.ElapsedTime,
+	}
+}
+
+func (d dissecting) Represent(request map[string]interface{}, response map[string]interface{}) (object []byte, err error) {
+	representation := make(map[string]interface{})
+	repRequest := representPacket(request, "request")
+	repResponse := api.RepresentUnanswered()
+	if !api.IsUnanswered(response) {
+		repResponse = representPacket(response, "response")
+	}
+	representation["request"] = repRequest
+	representation["response"] = repResponse
+	object, err = json.Marshal(representation)
+	return
+}
+
+func (d dissecting) Macros() map[string]string {
+	return map[string]string{
+		`mqtt`: fmt.Sprintf(`protocol.name == "%s"`, protocol.Name),
+	}
+}
+
+func (d dissecting) NewResponseRequestMatcher() api.RequestResponseMatcher {
+	return createResponseRequestMatcher()
+}
+
+var Dissector dissecting
+
+func NewDissector() api.Dissector {
+	return Dissector
+}
@@ -0,0 +1,259 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/api/apitest"
+)
+
+func TestRegister(t *testing.T) {
+	dissector := NewDissector()
+	extension := &api.Extension{}
+	dissector.Register(extension)
+	assert.Equal(t, "mqtt", extension.Protocol.Name)
+}
+
+func TestMacros(t *testing.T) {
+	expectedMacros := map[string]string{
+		"mqtt": `protocol.name == "mqtt"`,
+	}
+	dissector := NewDissector()
+	macros := dissector.Macros()
+	assert.Equal(t, expectedMacros, macros)
+}
+
+func TestPing(t *testing.T) {
+	dissector := NewDissector()
+	dissector.Ping()
+}
+
+// The strings are prefixed with their length, the rest is written as is
+func encodePacket(firstByte byte, fields ...interface{}) []byte {
+	var body bytes.Buffer
+	for _, field := range fields {
+		switch value := field.(type) {
+		case string:
+			_ = binary.Write(&body, binary.BigEndian, uint16(len(value)))
+			body.WriteString(value)
+		case []byte:
+			body.Write(value)
+		case byte:
+			body.WriteByte(value)
+		default:
+			_ = binary.Write(&body, binary.BigEndian, value)
+		}
+	}
+
+	packet := []byte{firstByte}
+	length := body.Len()
+	for {
+		digit := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			digit |= 0x80
+		}
+		packet = append(packet, digit)
+		if length == 0 {
+			break
+		}
+	}
+	return append(packet, body.Bytes()...)
+}
+
+func dissect(t *testing.T, dissector api.Dissector, data []byte, isClient bool, reqResMatcher api.RequestResponseMatcher, emitter api.Emitter) {
+	tcpID := &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "40000", DstPort: "1883"}
+	captureTime := time.Unix(0, 0)
+	if !isClient {
+		tcpID = &api.TcpID{SrcIP: "2", DstIP: "1", SrcPort: "1883", DstPort: "40000"}
+		captureTime = time.Unix(0, int64(time.Millisecond))
+	}
+
+	reader := NewTcpReader(&api.ReadProgress{}, "", tcpID, captureTime, NewTcpStream(api.Pcap), isClient, false, nil, emitter, &api.CounterPair{}, reqResMatcher)
+	err := dissector.Dissect(bufio.NewReader(bytes.NewReader(data)), reader, &api.TrafficFilteringOptions{})
+	assert.Equal(t, io.EOF, err)
+}
+
+func toEntry(t *testing.T, item *api.OutputChannelItem) *api.Entry {
+	// Simulate the round trip through the JSON encoding as the items do in Mizu
+	marshaled, err := json.Marshal(item)
+	assert.Nil(t, err)
+	var unmarshaled *api.OutputChannelItem
+	err = json.Unmarshal(marshaled, &unmarshaled)
+	assert.Nil(t, err)
+
+	return NewDissector().Analyze(unmarshaled, "client", "server", "default")
+}
+
+func collect(itemChannel chan *api.OutputChannelItem) (items []*api.OutputChannelItem) {
+	close(itemChannel)
+	for item := range itemChannel {
+		items = append(items, item)
+	}
+	return
+}
+
+func TestDissect(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 16)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+
+	var client bytes.Buffer
+	client.Write(encodePacket(0x10, "MQTT", byte(version311), byte(0xc2), uint16(60), "sensor-1", "device", "secret"))
+	client.Write(encodePacket(0x82, uint16(1), "sensors/+/temperature", byte(1)))
+	client.Write(encodePacket(0x30, "sensors/1/temperature", []byte(`{"celsius":21.5}`)))
+	client.Write(encodePacket(0x32, "sensors/1/humidity", uint16(2), []byte("40")))
+	client.Write(encodePacket(0x34, "alerts", uint16(3), []byte("overheat")))
+	client.Write(encodePacket(0x62, uint16(3)))
+	client.Write(encodePacket(0xc0))
+	client.Write(encodePacket(0xe0))
+	dissect(t, dissector, client.Bytes(), true, reqResMatcher, emitter)
+
+	var server bytes.Buffer
+	server.Write(encodePacket(0x20, byte(0), byte(0)))
+	server.Write(encodePacket(0x90, uint16(1), byte(1)))
+	server.Write(encodePacket(0x40, uint16(2)))
+	server.Write(encodePacket(0x50, uint16(3)))
+	server.Write(encodePacket(0x70, uint16(3)))
+	server.Write(encodePacket(0xd0))
+	// Delivered to the client by the broker
+	server.Write(encodePacket(0x30, "sensors/2/temperature", []byte("19")))
+	dissect(t, dissector, server.Bytes(), false, reqResMatcher, emitter)
+
+	items := collect(itemChannel)
+	assert.Len(t, items, 8)
+
+	entries := make(map[string]*api.Entry)
+	for _, item := range items {
+		entry := toEntry(t, item)
+		summary := dissector.Summarize(entry)
+		entries[summary.Method+" "+summary.Summary] = entry
+
+		representation, err := dissector.Represent(entry.Request, entry.Response)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, representation)
+	}
+
+	connect := entries["CONNECT sensor-1"]
+	assert.Equal(t, "device", connect.Request["username"])
+	assert.Equal(t, "Connection Accepted", connect.Response["reason"])
+
+	subscribe := entries["SUBSCRIBE sensors/+/temperature"]
+	assert.Equal(t, []interface{}{float64(1)}, subscribe.Response["reasonCodes"])
+
+	// QoS 0
+	publish := entries["PUBLISH sensors/1/temperature"]
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(`{"celsius":21.5}`)), publish.Request["payload"])
+	assert.Empty(t, publish.Response)
+
+	// QoS 1
+	publish = entries["PUBLISH sensors/1/humidity"]
+	assert.Equal(t, "PUBACK", publish.Response["type"])
+
+	// QoS 2
+	publish = entries["PUBLISH alerts"]
+	assert.Equal(t, "PUBREC", publish.Response["type"])
+
+	publish = entries["PUBLISH sensors/2/temperature"]
+	assert.Equal(t, "1883", publish.Source.Port)
+
+	assert.Contains(t, entries, "PINGREQ ")
+	assert.Contains(t, entries, "DISCONNECT Success")
+}
+
+func TestDissectVersion5(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 16)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+
+	contentType := append([]byte{0x03}, encodeString("application/json")...)
+	topicAlias := []byte{0x23, 0x00, 0x07}
+	properties := append(contentType, topicAlias...)
+
+	var client bytes.Buffer
+	client.Write(encodePacket(0x10, "MQTT", byte(version5), byte(0x02), uint16(30), byte(0), "sensor-5"))
+	client.Write(encodePacket(0x32, "sensors/5", uint16(1), byte(len(properties)), properties, []byte(`{"on":true}`)))
+	client.Write(encodePacket(0x32, "", uint16(2), byte(len(topicAlias)), topicAlias, []byte(`{"on":false}`)))
+	client.Write(encodePacket(0x82, uint16(3), byte(0), "$share/group/#", byte(2)))
+	dissect(t, dissector, client.Bytes(), true, reqResMatcher, emitter)
+
+	var server bytes.Buffer
+	server.Write(encodePacket(0x20, byte(0), byte(0), byte(3), []byte{0x21, 0x00, 0x0a}))
+	server.Write(encodePacket(0x40, uint16(1)))
+	server.Write(encodePacket(0x40, uint16(2), byte(0x10), byte(0)))
+	server.Write(encodePacket(0x90, uint16(3), byte(0), byte(0x9e)))
+	dissect(t, dissector, server.Bytes(), false, reqResMatcher, emitter)
+
+	items := collect(itemChannel)
+	assert.Len(t, items, 4)
+
+	connect := toEntry(t, items[0])
+	assert.Equal(t, "5.0", connect.Request["protocolVersion"])
+	assert.Equal(t, float64(10), connect.Response["properties"].(map[string]interface{})["receiveMaximum"])
+
+	first := toEntry(t, items[1])
+	assert.Equal(t, "application/json", first.Request["properties"].(map[string]interface{})["contentType"])
+
+	// Resolved from the alias
+	second := toEntry(t, items[2])
+	assert.Equal(t, "sensors/5", second.Request["topic"])
+	assert.Equal(t, "No matching subscribers", second.Response["reason"])
+
+	subscribe := toEntry(t, items[3])
+	summary := dissector.Summarize(subscribe)
+	assert.Equal(t, 0x9e, summary.Status)
+	assert.Equal(t, `response.reasonCode == 158`, summary.StatusQuery)
+	assert.Equal(t, "$share/group/#", summary.Summary)
+}
+
+func encodeString(value string) []byte {
+	return append([]byte{byte(len(value) >> 8), byte(len(value))}, value...)
+}
+
+func TestDissectGarbage(t *testing.T) {
+	dissector := NewDissector()
+	for _, data := range []string{"GET / HTTP/1.1\r\n\r\n", "\x40\x02\x00\x01", "\x30\x05\x00\x03a#b"} {
+		reader := NewTcpReader(&api.ReadProgress{}, "", &api.TcpID{}, time.Time{}, NewTcpStream(api.Pcap), true, false, nil, nil, &api.CounterPair{}, dissector.NewResponseRequestMatcher())
+		err := dissector.Dissect(bufio.NewReader(bytes.NewBufferString(data)), reader, &api.TrafficFilteringOptions{})
+		assert.NotNil(t, err)
+		assert.NotEqual(t, io.EOF, err)
+	}
+}
+
+func TestNewUnansweredItem(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 1)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+
+	// A SUBSCRIBE without its SUBACK
+	dissect(t, dissector, encodePacket(0x82, uint16(1), "sensors/+/temperature", byte(1)), true, reqResMatcher, emitter)
+	assert.Len(t, collect(itemChannel), 0)
+
+	items := apitest.UnansweredItems(reqResMatcher)
+	assert.Len(t, items, 1)
+
+	items[0].ConnectionInfo = &api.ConnectionInfo{ClientIP: "1", ClientPort: "40000", ServerIP: "2", ServerPort: "1883"}
+	_, summary := apitest.AnalyzeUnanswered(t, dissector, items[0])
+	assert.Equal(t, "SUBSCRIBE", summary.Method)
+	assert.Equal(t, "sensors/+/temperature", summary.Summary)
+}
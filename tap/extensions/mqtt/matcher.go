@@ -0,0 +1,168 @@
+package mqtt
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+type emptyResponse struct {
+}
+
+// Key is `{sender_ip}_{receiver_ip}_{sender_port}_{receiver_port}_{packet}`
+// Either side may publish, so the one that sends the request counts as the sender.
+// The matchers are created per TCP stream, so they also keep the state of the connection.
+type requestResponseMatcher struct {
+	openMessagesMap *sync.Map
+	version         uint32
+	topicAliases    *sync.Map
+}
+
+func createResponseRequestMatcher() api.RequestResponseMatcher {
+	return &requestResponseMatcher{
+		openMessagesMap: &sync.Map{},
+		topicAliases:    &sync.Map{},
+	}
+}
+
+func (matcher *requestResponseMatcher) GetMap() *sync.Map {
+	return matcher.openMessagesMap
+}
+
+func (matcher *requestResponseMatcher) SetMaxTry(value int) {
+}
+
+func (matcher *requestResponseMatcher) setVersion(version uint8) {
+	atomic.StoreUint32(&matcher.version, uint32(version))
+}
+
+// MQTT 3.1.1 is assumed if the CONNECT packet wasn't captured
+func (matcher *requestResponseMatcher) getVersion() uint8 {
+	if version := atomic.LoadUint32(&matcher.version); version != 0 {
+		return uint8(version)
+	}
+	return version311
+}
+
+// The topic aliases of MQTT 5.0 are set by the first PUBLISH that has both the topic and the alias,
+// and are specific to the direction of the connection.
+func (matcher *requestResponseMatcher) resolveTopicAlias(tcpID *api.TcpID, packet *MQTTPacket) {
+	alias, ok := packet.Properties["topicAlias"].(uint16)
+	if !ok {
+		return
+	}
+
+	key := fmt.Sprintf("%s_%s_%d", tcpID.SrcIP, tcpID.SrcPort, alias)
+	if packet.Topic != "" {
+		matcher.topicAliases.Store(key, packet.Topic)
+	} else if topic, found := matcher.topicAliases.Load(key); found {
+		packet.Topic = topic.(string)
+	}
+}
+
+func (matcher *requestResponseMatcher) registerRequest(ident string, request *MQTTPacket, captureTime time.Time, captureSize int) *api.OutputChannelItem {
+	requestMQTTMessage := api.GenericMessage{
+		IsRequest:   true,
+		CaptureTime: captureTime,
+		CaptureSize: captureSize,
+		Payload: MQTTPayload{
+			Data: &MQTTWrapper{
+				Method:  request.Type,
+				Url:     request.Topic,
+				Details: request,
+			},
+		},
+	}
+
+	if response, found := matcher.openMessagesMap.LoadAndDelete(ident); found {
+		// Type assertion always succeeds because all of the map's values are of api.GenericMessage type
+		responseMQTTMessage := response.(*api.GenericMessage)
+		if responseMQTTMessage.IsRequest {
+			return nil
+		}
+		return matcher.preparePair(&requestMQTTMessage, responseMQTTMessage)
+	}
+
+	matcher.openMessagesMap.Store(ident, &requestMQTTMessage)
+	return nil
+}
+
+func (matcher *requestResponseMatcher) registerResponse(ident string, response *MQTTPacket, captureTime time.Time, captureSize int) *api.OutputChannelItem {
+	responseMQTTMessage := api.GenericMessage{
+		IsRequest:   false,
+		CaptureTime: captureTime,
+		CaptureSize: captureSize,
+		Payload: MQTTPayload{
+			Data: &MQTTWrapper{
+				Method:  response.Type,
+				Url:     "",
+				Details: response,
+			},
+		},
+	}
+
+	if request, found := matcher.openMessagesMap.LoadAndDelete(ident); found {
+		// Type assertion always succeeds because all of the map's values are of api.GenericMessage type
+		requestMQTTMessage := request.(*api.GenericMessage)
+		if !requestMQTTMessage.IsRequest {
+			return nil
+		}
+		return matcher.preparePair(requestMQTTMessage, &responseMQTTMessage)
+	}
+
+	matcher.openMessagesMap.Store(ident, &responseMQTTMessage)
+	return nil
+}
+
+// The packets that are not acknowledged, like the QoS 0 publishes, are paired with an empty response
+func (matcher *requestResponseMatcher) registerRequestOnly(request *MQTTPacket, captureTime time.Time, captureSize int) *api.OutputChannelItem {
+	requestMQTTMessage := api.GenericMessage{
+		IsRequest:   true,
+		CaptureTime: captureTime,
+		CaptureSize: captureSize,
+		Payload: MQTTPayload{
+			Data: &MQTTWrapper{
+				Method:  request.Type,
+				Url:     request.Topic,
+				Details: request,
+			},
+		},
+	}
+
+	responseMQTTMessage := api.GenericMessage{
+		IsRequest:   false,
+		CaptureTime: captureTime,
+		Payload: MQTTPayload{
+			Data: &MQTTWrapper{
+				Method:  "",
+				Url:     "",
+				Details: &emptyResponse{},
+			},
+		},
+	}
+
+	return matcher.preparePair(&requestMQTTMessage, &responseMQTTMessage)
+}
+
+func (matcher *requestResponseMatcher) preparePair(requestMQTTMessage *api.GenericMessage, responseMQTTMessage *api.GenericMessage) *api.OutputChannelItem {
+	return &api.OutputChannelItem{
+		Protocol:       protocol,
+		Timestamp:      requestMQTTMessage.CaptureTime.UnixNano() / int64(time.Millisecond),
+		ConnectionInfo: nil,
+		Pair: &api.RequestResponsePair{
+			Request:  *requestMQTTMessage,
+			Response: *responseMQTTMessage,
+		},
+	}
+}
+
+func (matcher *requestResponseMatcher) NewUnansweredItem(key interface{}, value interface{}) *api.OutputChannelItem {
+	request, ok := value.(*api.GenericMessage)
+	if !ok || !request.IsRequest {
+		return nil
+	}
+	return api.NewUnansweredItem(protocol, request)
+}
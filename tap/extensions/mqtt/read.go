@@ -0,0 +1,470 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"unicode"
+	"unicode/utf8"
+)
+
+var errMalformedPacket = errors.New("Malformed MQTT packet")
+
+// Only for telling MQTT apart from the other protocols, the limit of the topic itself is 65535 bytes
+const maxTopicLength = 1024
+
+type packetReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *packetReader) remaining() int {
+	return len(r.data) - r.offset
+}
+
+func (r *packetReader) readBytes(n int) []byte {
+	if r.err != nil || n < 0 || r.offset+n > len(r.data) {
+		r.err = errMalformedPacket
+		return nil
+	}
+	value := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return value
+}
+
+func (r *packetReader) readUint8() uint8 {
+	value := r.readBytes(1)
+	if value == nil {
+		return 0
+	}
+	return value[0]
+}
+
+func (r *packetReader) readUint16() uint16 {
+	value := r.readBytes(2)
+	if value == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint16(value)
+}
+
+func (r *packetReader) readUint32() uint32 {
+	value := r.readBytes(4)
+	if value == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(value)
+}
+
+func (r *packetReader) readVariableByteInteger() int {
+	value := 0
+	for i := 0; i < 4; i++ {
+		digit := r.readUint8()
+		if r.err != nil {
+			return 0
+		}
+		value |= int(digit&0x7f) << (7 * i)
+		if digit&0x80 == 0 {
+			return value
+		}
+	}
+	r.err = errMalformedPacket
+	return 0
+}
+
+func (r *packetReader) readBinary() []byte {
+	length := int(r.readUint16())
+	return r.readBytes(length)
+}
+
+func (r *packetReader) readString() string {
+	return string(r.readBinary())
+}
+
+func (r *packetReader) readRest() []byte {
+	return r.readBytes(r.remaining())
+}
+
+// The fixed header is the packet type and flags, followed by the remaining length
+// encoded as a variable byte integer of at most four bytes.
+func peekFixedHeader(b *bufio.Reader) (packetType uint8, flags uint8, remainingLength int, headerLength int, err error) {
+	var data []byte
+	for headerLength = 2; headerLength <= 5; headerLength++ {
+		data, err = b.Peek(headerLength)
+		if err != nil {
+			return
+		}
+
+		digit := data[headerLength-1]
+		remainingLength |= int(digit&0x7f) << (7 * (headerLength - 2))
+		if digit&0x80 == 0 {
+			break
+		}
+	}
+	if headerLength > 5 {
+		err = errMalformedPacket
+		return
+	}
+
+	packetType = data[0] >> 4
+	flags = data[0] & 0x0f
+	err = checkFlags(packetType, flags)
+	return
+}
+
+func checkFlags(packetType uint8, flags uint8) error {
+	switch packetType {
+	case packetPublish:
+		if flags>>1&0x03 == 3 {
+			return errors.New("Invalid MQTT QoS level: 3")
+		}
+		return nil
+	case packetPubrel, packetSubscribe, packetUnsubscribe:
+		if flags == 0x02 {
+			return nil
+		}
+	default:
+		if _, ok := packetTypes[packetType]; ok && flags == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("Unrecognized MQTT fixed header: 0x%02x", packetType<<4|flags)
+}
+
+// Until the protocol is identified, only the packets with a distinctive shape are accepted.
+// The acknowledgements, for example, are just a couple of bytes that any protocol could start with.
+// The packet is only peeked, so a stream of another protocol isn't buffered for the length it would imply.
+func checkPacketStart(b *bufio.Reader, packetType uint8, remainingLength int, headerLength int) error {
+	errUnrecognized := errors.New("Unrecognized MQTT packet")
+
+	switch packetType {
+	case packetConnect:
+		data, err := b.Peek(headerLength + 8)
+		if err != nil {
+			return err
+		}
+		name := data[headerLength:]
+		if string(name[:6]) != "\x00\x04MQTT" && string(name[:8]) != "\x00\x06MQIsdp" {
+			return errUnrecognized
+		}
+	case packetConnack:
+		if remainingLength < 2 || remainingLength > 256 {
+			return errUnrecognized
+		}
+		data, err := b.Peek(headerLength + 2)
+		if err != nil {
+			return err
+		}
+		if data[headerLength] > 1 {
+			return errUnrecognized
+		}
+	case packetPublish:
+		if remainingLength < 3 {
+			return errUnrecognized
+		}
+		data, err := b.Peek(headerLength + 2)
+		if err != nil {
+			return err
+		}
+		topicLength := int(binary.BigEndian.Uint16(data[headerLength:]))
+		if topicLength == 0 || topicLength > maxTopicLength || 2+topicLength > remainingLength {
+			return errUnrecognized
+		}
+		data, err = b.Peek(headerLength + 2 + topicLength)
+		if err != nil {
+			return err
+		}
+		if !isTopicName(data[headerLength+2:]) {
+			return errUnrecognized
+		}
+	case packetSubscribe:
+		if remainingLength < 5 {
+			return errUnrecognized
+		}
+		data, err := b.Peek(headerLength + 2)
+		if err != nil {
+			return err
+		}
+		if binary.BigEndian.Uint16(data[headerLength:]) == 0 {
+			return errUnrecognized
+		}
+	case packetPingreq, packetPingresp:
+		if remainingLength != 0 {
+			return errUnrecognized
+		}
+	default:
+		return errUnrecognized
+	}
+
+	return nil
+}
+
+func isTopicName(topic []byte) bool {
+	if !utf8.Valid(topic) {
+		return false
+	}
+	for _, r := range string(topic) {
+		if r == '+' || r == '#' || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func readPacket(b *bufio.Reader, identified bool) (packetType uint8, flags uint8, body []byte, err error) {
+	packetType, flags, remainingLength, headerLength, err := peekFixedHeader(b)
+	if err != nil {
+		return
+	}
+	if !identified {
+		if err = checkPacketStart(b, packetType, remainingLength, headerLength); err != nil {
+			return
+		}
+	}
+
+	data := make([]byte, headerLength+remainingLength)
+	if _, err = io.ReadFull(b, data); err != nil {
+		return
+	}
+
+	body = data[headerLength:]
+	return
+}
+
+func parsePacket(packetType uint8, flags uint8, body []byte, version uint8) (*MQTTPacket, error) {
+	r := &packetReader{data: body}
+	if packetType == packetConnect {
+		return parseConnect(r, flags)
+	}
+
+	packet := newPacket(packetType, flags, version)
+	isVersion5 := version == version5
+
+	switch packetType {
+	case packetConnack:
+		packet.SessionPresent = r.readUint8()&0x01 != 0
+		packet.ReasonCode = int(r.readUint8())
+		if isVersion5 && r.remaining() > 0 {
+			packet.Properties = readProperties(r)
+		}
+		packet.Reason = describeConnectReason(packet.ReasonCode, isVersion5)
+	case packetPublish:
+		packet.Dup = flags&0x08 != 0
+		packet.QoS = flags >> 1 & 0x03
+		packet.Retain = flags&0x01 != 0
+		packet.Topic = r.readString()
+		if packet.QoS > 0 {
+			packet.PacketID = r.readUint16()
+		}
+		if isVersion5 {
+			packet.Properties = readProperties(r)
+		}
+		packet.Payload = r.readRest()
+		packet.PayloadSize = len(packet.Payload)
+	case packetPuback, packetPubrec, packetPubrel, packetPubcomp:
+		packet.PacketID = r.readUint16()
+		if isVersion5 && r.remaining() > 0 {
+			packet.ReasonCode = int(r.readUint8())
+			if r.remaining() > 0 {
+				packet.Properties = readProperties(r)
+			}
+		}
+		packet.Reason = reasonCodes[packet.ReasonCode]
+	case packetSubscribe:
+		packet.PacketID = r.readUint16()
+		if isVersion5 {
+			packet.Properties = readProperties(r)
+		}
+		for r.remaining() > 0 && r.err == nil {
+			topicFilter := r.readString()
+			options := r.readUint8()
+			packet.Subscriptions = append(packet.Subscriptions, MQTTSubscription{
+				TopicFilter: topicFilter,
+				QoS:         options & 0x03,
+			})
+		}
+	case packetUnsubscribe:
+		packet.PacketID = r.readUint16()
+		if isVersion5 {
+			packet.Properties = readProperties(r)
+		}
+		for r.remaining() > 0 && r.err == nil {
+			packet.Subscriptions = append(packet.Subscriptions, MQTTSubscription{
+				TopicFilter: r.readString(),
+			})
+		}
+	case packetSuback, packetUnsuback:
+		packet.PacketID = r.readUint16()
+		if isVersion5 {
+			packet.Properties = readProperties(r)
+		}
+		for _, code := range r.readRest() {
+			packet.ReasonCodes = append(packet.ReasonCodes, int(code))
+			// The first failure stands for the whole packet
+			if code >= 0x80 && packet.ReasonCode == 0 {
+				packet.ReasonCode = int(code)
+				packet.Reason = reasonCodes[packet.ReasonCode]
+			}
+		}
+	case packetDisconnect, packetAuth:
+		if r.remaining() > 0 {
+			packet.ReasonCode = int(r.readUint8())
+			if r.remaining() > 0 {
+				packet.Properties = readProperties(r)
+			}
+		}
+		packet.Reason = reasonCodes[packet.ReasonCode]
+	case packetPingreq, packetPingresp:
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return packet, nil
+}
+
+func parseConnect(r *packetReader, flags uint8) (*MQTTPacket, error) {
+	protocolName := r.readString()
+	level := r.readUint8()
+	connectFlags := r.readUint8()
+	if r.err != nil {
+		return nil, r.err
+	}
+	if _, ok := versions[level]; !ok || (protocolName != "MQTT" && protocolName != "MQIsdp") || connectFlags&0x01 != 0 {
+		return nil, fmt.Errorf("Unsupported MQTT protocol: %s %d", protocolName, level)
+	}
+
+	packet := newPacket(packetConnect, flags, level)
+	packet.ProtocolName = protocolName
+	packet.protocolLevel = level
+	packet.CleanSession = connectFlags&0x02 != 0
+	packet.KeepAlive = r.readUint16()
+	if level == version5 {
+		packet.Properties = readProperties(r)
+	}
+	packet.ClientID = r.readString()
+
+	if connectFlags&0x04 != 0 {
+		if level == version5 {
+			readProperties(r) // will properties
+		}
+		packet.WillTopic = r.readString()
+		r.readBinary() // will payload
+	}
+	if connectFlags&0x80 != 0 {
+		packet.Username = r.readString()
+	}
+	// The password is left out on purpose
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	return packet, nil
+}
+
+type propertyType int
+
+const (
+	propertyByte propertyType = iota
+	propertyUint16
+	propertyUint32
+	propertyVariableByteInteger
+	propertyString
+	propertyBinary
+	propertyStringPair
+)
+
+type property struct {
+	name         string
+	propertyType propertyType
+}
+
+var properties = map[int]property{
+	0x01: {"payloadFormatIndicator", propertyByte},
+	0x02: {"messageExpiryInterval", propertyUint32},
+	0x03: {"contentType", propertyString},
+	0x08: {"responseTopic", propertyString},
+	0x09: {"correlationData", propertyBinary},
+	0x0b: {"subscriptionIdentifier", propertyVariableByteInteger},
+	0x11: {"sessionExpiryInterval", propertyUint32},
+	0x12: {"assignedClientIdentifier", propertyString},
+	0x13: {"serverKeepAlive", propertyUint16},
+	0x15: {"authenticationMethod", propertyString},
+	0x16: {"authenticationData", propertyBinary},
+	0x17: {"requestProblemInformation", propertyByte},
+	0x18: {"willDelayInterval", propertyUint32},
+	0x19: {"requestResponseInformation", propertyByte},
+	0x1a: {"responseInformation", propertyString},
+	0x1c: {"serverReference", propertyString},
+	0x1f: {"reasonString", propertyString},
+	0x21: {"receiveMaximum", propertyUint16},
+	0x22: {"topicAliasMaximum", propertyUint16},
+	0x23: {"topicAlias", propertyUint16},
+	0x24: {"maximumQoS", propertyByte},
+	0x25: {"retainAvailable", propertyByte},
+	0x26: {"userProperties", propertyStringPair},
+	0x27: {"maximumPacketSize", propertyUint32},
+	0x28: {"wildcardSubscriptionAvailable", propertyByte},
+	0x29: {"subscriptionIdentifierAvailable", propertyByte},
+	0x2a: {"sharedSubscriptionAvailable", propertyByte},
+}
+
+// The properties of MQTT 5.0, preceded by their total length
+func readProperties(r *packetReader) map[string]interface{} {
+	result := make(map[string]interface{})
+	length := r.readVariableByteInteger()
+	data := r.readBytes(length)
+	if r.err != nil {
+		return result
+	}
+
+	p := &packetReader{data: data}
+	for p.remaining() > 0 && p.err == nil {
+		identifier := p.readVariableByteInteger()
+		definition, ok := properties[identifier]
+		if !ok {
+			r.err = fmt.Errorf("Unrecognized MQTT property: 0x%02x", identifier)
+			return result
+		}
+
+		var value interface{}
+		switch definition.propertyType {
+		case propertyByte:
+			value = p.readUint8()
+		case propertyUint16:
+			value = p.readUint16()
+		case propertyUint32:
+			value = p.readUint32()
+		case propertyVariableByteInteger:
+			value = p.readVariableByteInteger()
+		case propertyString:
+			value = p.readString()
+		case propertyBinary:
+			value = p.readBinary()
+		case propertyStringPair:
+			userProperties, _ := result[definition.name].(map[string]string)
+			if userProperties == nil {
+				userProperties = make(map[string]string)
+			}
+			key := p.readString()
+			userProperties[key] = p.readString()
+			value = userProperties
+		}
+		result[definition.name] = value
+	}
+
+	if p.err != nil {
+		r.err = p.err
+	}
+	return result
+}
+
+func describeConnectReason(code int, isVersion5 bool) string {
+	if isVersion5 {
+		return reasonCodes[code]
+	}
+	return connectReturnCodes[code]
+}
@@ -0,0 +1,149 @@
+package mqtt
+
+// Control packet types
+const (
+	packetConnect     = 1
+	packetConnack     = 2
+	packetPublish     = 3
+	packetPuback      = 4
+	packetPubrec      = 5
+	packetPubrel      = 6
+	packetPubcomp     = 7
+	packetSubscribe   = 8
+	packetSuback      = 9
+	packetUnsubscribe = 10
+	packetUnsuback    = 11
+	packetPingreq     = 12
+	packetPingresp    = 13
+	packetDisconnect  = 14
+	packetAuth        = 15
+)
+
+var packetTypes = map[uint8]string{
+	packetConnect:     "CONNECT",
+	packetConnack:     "CONNACK",
+	packetPublish:     "PUBLISH",
+	packetPuback:      "PUBACK",
+	packetPubrec:      "PUBREC",
+	packetPubrel:      "PUBREL",
+	packetPubcomp:     "PUBCOMP",
+	packetSubscribe:   "SUBSCRIBE",
+	packetSuback:      "SUBACK",
+	packetUnsubscribe: "UNSUBSCRIBE",
+	packetUnsuback:    "UNSUBACK",
+	packetPingreq:     "PINGREQ",
+	packetPingresp:    "PINGRESP",
+	packetDisconnect:  "DISCONNECT",
+	packetAuth:        "AUTH",
+}
+
+// Protocol levels of the CONNECT packet
+const (
+	version31  = 3
+	version311 = 4
+	version5   = 5
+)
+
+var versions = map[uint8]string{
+	version31:  "3.1",
+	version311: "3.1.1",
+	version5:   "5.0",
+}
+
+// CONNACK return codes of MQTT 3.1.1, the reason codes of MQTT 5.0 are at least 0x80 on failure
+var connectReturnCodes = map[int]string{
+	0: "Connection Accepted",
+	1: "Unacceptable Protocol Version",
+	2: "Identifier Rejected",
+	3: "Server Unavailable",
+	4: "Bad User Name or Password",
+	5: "Not Authorized",
+}
+
+var reasonCodes = map[int]string{
+	0x00: "Success",
+	0x01: "Granted QoS 1",
+	0x02: "Granted QoS 2",
+	0x04: "Disconnect with Will Message",
+	0x10: "No matching subscribers",
+	0x11: "No subscription existed",
+	0x18: "Continue authentication",
+	0x19: "Re-authenticate",
+	0x80: "Unspecified error",
+	0x81: "Malformed Packet",
+	0x82: "Protocol Error",
+	0x83: "Implementation specific error",
+	0x84: "Unsupported Protocol Version",
+	0x85: "Client Identifier not valid",
+	0x86: "Bad User Name or Password",
+	0x87: "Not authorized",
+	0x88: "Server unavailable",
+	0x89: "Server busy",
+	0x8a: "Banned",
+	0x8b: "Server shutting down",
+	0x8c: "Bad authentication method",
+	0x8d: "Keep Alive timeout",
+	0x8e: "Session taken over",
+	0x8f: "Topic Filter invalid",
+	0x90: "Topic Name invalid",
+	0x91: "Packet Identifier in use",
+	0x92: "Packet Identifier not found",
+	0x93: "Receive Maximum exceeded",
+	0x94: "Topic Alias invalid",
+	0x95: "Packet too large",
+	0x96: "Message rate too high",
+	0x97: "Quota exceeded",
+	0x98: "Administrative action",
+	0x99: "Payload format invalid",
+	0x9a: "Retain not supported",
+	0x9b: "QoS not supported",
+	0x9c: "Use another server",
+	0x9d: "Server moved",
+	0x9e: "Shared Subscriptions not supported",
+	0x9f: "Connection rate exceeded",
+	0xa0: "Maximum connect time",
+	0xa1: "Subscription Identifiers not supported",
+	0xa2: "Wildcard Subscriptions not supported",
+}
+
+type MQTTSubscription struct {
+	TopicFilter string `json:"topicFilter"`
+	QoS         uint8  `json:"qos"`
+}
+
+type MQTTPacket struct {
+	Type            string                 `json:"type"`
+	Flags           uint8                  `json:"flags"`
+	ProtocolVersion string                 `json:"protocolVersion"`
+	PacketID        uint16                 `json:"packetId"`
+	ProtocolName    string                 `json:"protocolName"`
+	ClientID        string                 `json:"clientId"`
+	Username        string                 `json:"username"`
+	CleanSession    bool                   `json:"cleanSession"`
+	KeepAlive       uint16                 `json:"keepAlive"`
+	WillTopic       string                 `json:"willTopic"`
+	SessionPresent  bool                   `json:"sessionPresent"`
+	ReasonCode      int                    `json:"reasonCode"`
+	Reason          string                 `json:"reason"`
+	Topic           string                 `json:"topic"`
+	QoS             uint8                  `json:"qos"`
+	Retain          bool                   `json:"retain"`
+	Dup             bool                   `json:"dup"`
+	Payload         []byte                 `json:"payload"`
+	PayloadSize     int                    `json:"payloadSize"`
+	Subscriptions   []MQTTSubscription     `json:"subscriptions"`
+	ReasonCodes     []int                  `json:"reasonCodes"`
+	Properties      map[string]interface{} `json:"properties"`
+	protocolLevel   uint8
+}
+
+func newPacket(packetType uint8, flags uint8, version uint8) *MQTTPacket {
+	return &MQTTPacket{
+		Type:            packetTypes[packetType],
+		Flags:           flags,
+		ProtocolVersion: versions[version],
+		Subscriptions:   make([]MQTTSubscription, 0),
+		ReasonCodes:     make([]int, 0),
+		Properties:      make(map[string]interface{}),
+	}
+}
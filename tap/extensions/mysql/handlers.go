@@ -0,0 +1,440 @@
+package mysql
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+var errEncrypted = errors.New("MySQL connection is encrypted")
+
+func dissectClient(b *bufio.Reader, reader api.TcpReader, reqResMatcher *requestResponseMatcher) error {
+	identified := false
+
+	for {
+		// Don't buffer a whole payload of some other protocol just to find out the sequence is wrong
+		if !identified {
+			if sequence, _, _, err := peekPacket(b); err != nil {
+				return err
+			} else if sequence > 1 {
+				return fmt.Errorf("Unexpected MySQL packet sequence: %d", sequence)
+			}
+		}
+
+		packet, err := readPacket(b)
+		if err != nil {
+			return err
+		}
+		captureTime := reader.GetCaptureTime()
+
+		switch packet.Sequence {
+		case 0:
+			request, err := readCommand(packet.Payload, reqResMatcher.statements)
+			if err != nil {
+				return err
+			}
+			reader.GetParent().SetProtocol(&protocol)
+			identified = true
+
+			switch packet.Payload[0] {
+			// These are not answered by the server
+			case comQuit, comStmtSendLongData:
+				continue
+			case comStmtClose:
+				reqResMatcher.statements.remove(request.StatementID)
+				continue
+			}
+
+			handleClientStream(reader, captureTime, request, reqResMatcher)
+		case 1:
+			if len(packet.Payload) == sslRequestLength && binary.LittleEndian.Uint32(packet.Payload)&clientSSL != 0 {
+				reader.GetParent().SetProtocol(&protocol)
+				return errEncrypted
+			}
+
+			request, capabilities, err := readHandshakeResponse(packet.Payload)
+			if err != nil {
+				return err
+			}
+			reader.GetParent().SetProtocol(&protocol)
+			identified = true
+
+			reqResMatcher.setCapabilities(capabilities)
+			handleClientStream(reader, captureTime, request, reqResMatcher)
+		default:
+			// The rest of the authentication exchange and the LOCAL INFILE contents
+		}
+	}
+}
+
+func dissectServer(b *bufio.Reader, reader api.TcpReader, reqResMatcher *requestResponseMatcher) error {
+	identified := false
+
+	for {
+		// Don't buffer a whole payload of some other protocol just to find out the sequence is wrong
+		if !identified {
+			if sequence, _, _, err := peekPacket(b); err != nil {
+				return err
+			} else if sequence > 1 {
+				return fmt.Errorf("Unexpected MySQL packet sequence: %d", sequence)
+			}
+		}
+
+		packet, err := readPacket(b)
+		if err != nil {
+			return err
+		}
+		captureTime := reader.GetCaptureTime()
+
+		var response *MySQLResponse
+		switch packet.Sequence {
+		case 0:
+			response, _, err = readHandshake(packet.Payload)
+			if err != nil {
+				return err
+			}
+			reader.GetParent().SetProtocol(&protocol)
+			identified = true
+
+			err = readAuthenticationResult(b, response)
+		case 1:
+			if len(packet.Payload) == 0 {
+				return errMalformedPacket
+			}
+			// Mid-connection, the first response is trusted only if it has one of the distinctive shapes
+			if !identified && !isResponseStart(packet.Payload) {
+				return errors.New("Unrecognized MySQL response")
+			}
+			response, err = readResponse(b, packet, reqResMatcher)
+		default:
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+		reader.GetParent().SetProtocol(&protocol)
+		identified = true
+
+		handleServerStream(reader, captureTime, response, reqResMatcher)
+	}
+}
+
+func isResponseStart(payload []byte) bool {
+	switch payload[0] {
+	case packetOK:
+		return len(payload) >= 7
+	case packetERR:
+		return len(payload) > 9 && payload[3] == '#'
+	case packetEOF:
+		return isEOFPacket(payload)
+	default:
+		// A column count
+		return len(payload) == 1 && payload[0] < 0xfb
+	}
+}
+
+// The authentication ends with either an OK or an ERR packet, optionally preceded by
+// the AuthSwitchRequest and AuthMoreData exchanges.
+func readAuthenticationResult(b *bufio.Reader, response *MySQLResponse) error {
+	for {
+		if isTLSRecord(b) {
+			return errEncrypted
+		}
+
+		packet, err := readPacket(b)
+		if err != nil {
+			return err
+		}
+		if len(packet.Payload) == 0 {
+			return errMalformedPacket
+		}
+
+		switch packet.Payload[0] {
+		case packetOK:
+			result, _ := readOK(packet.Payload)
+			response.Type = result.Type
+			return nil
+		case packetERR:
+			result := readERR(packet.Payload)
+			response.Type = result.Type
+			response.ErrorCode = result.ErrorCode
+			response.SQLState = result.SQLState
+			response.ErrorMessage = result.ErrorMessage
+			return nil
+		case packetEOF:
+			r := &payloadReader{data: packet.Payload[1:]}
+			if plugin := r.readNullTerminatedString(); r.err == nil {
+				response.AuthPlugin = plugin
+			}
+		}
+	}
+}
+
+// A response consists of several results if the server sets SERVER_MORE_RESULTS_EXISTS,
+// e.g. for multi-statements and stored procedures. Those are folded into the first result.
+func readResponse(b *bufio.Reader, packet *MySQLPacket, reqResMatcher *requestResponseMatcher) (*MySQLResponse, error) {
+	var response *MySQLResponse
+	for {
+		result, status, err := readResult(b, packet, reqResMatcher)
+		if err != nil {
+			return nil, err
+		}
+
+		if response == nil {
+			response = result
+		} else {
+			response.AffectedRows += result.AffectedRows
+			response.RowCount += result.RowCount
+			if result.Type == "ERR" {
+				response.Type = result.Type
+				response.ErrorCode = result.ErrorCode
+				response.SQLState = result.SQLState
+				response.ErrorMessage = result.ErrorMessage
+			}
+		}
+
+		if status&serverMoreResultsExists == 0 {
+			return response, nil
+		}
+
+		packet, err = readPacket(b)
+		if err != nil {
+			return nil, err
+		}
+		if len(packet.Payload) == 0 {
+			return nil, errMalformedPacket
+		}
+	}
+}
+
+func readResult(b *bufio.Reader, packet *MySQLPacket, reqResMatcher *requestResponseMatcher) (*MySQLResponse, uint16, error) {
+	payload := packet.Payload
+
+	switch {
+	case payload[0] == packetERR:
+		return readERR(payload), 0, nil
+	case payload[0] == packetOK && isPrepareOK(payload):
+		response, err := readPrepareOK(b, payload, reqResMatcher)
+		return response, 0, err
+	case payload[0] == packetOK:
+		response, status := readOK(payload)
+		return response, status, nil
+	case isEOFPacket(payload):
+		return newResponse("EOF"), readEOFStatus(payload), nil
+	case payload[0] == packetLocalInfile:
+		// The client sends the file and the server answers with an OK or an ERR
+		next, err := readPacket(b)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(next.Payload) == 0 {
+			return nil, 0, errMalformedPacket
+		}
+		response, status, err := readResult(b, next, reqResMatcher)
+		if err == nil && response.Type == "OK" {
+			response.Type = "LOCAL INFILE"
+		}
+		return response, status, err
+	}
+
+	r := &payloadReader{data: payload}
+	columnCount, _ := r.readLengthEncodedInt()
+	if r.err != nil || r.remaining() != 0 {
+		// Such as the human readable string of COM_STATISTICS
+		response := newResponse("Info")
+		response.Info = formatValue(payload)
+		return response, 0, nil
+	}
+
+	return readResultSet(b, int(columnCount), reqResMatcher)
+}
+
+func readResultSet(b *bufio.Reader, columnCount int, reqResMatcher *requestResponseMatcher) (*MySQLResponse, uint16, error) {
+	response := newResponse("Result Set")
+	columnTypes, err := readColumnDefinitions(b, columnCount, response)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err = skipIntermediateEOF(b, reqResMatcher, false); err != nil {
+		return nil, 0, err
+	}
+
+	for {
+		packet, err := readPacket(b)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		switch {
+		case len(packet.Payload) > 0 && packet.Payload[0] == packetERR:
+			result := readERR(packet.Payload)
+			response.Type = result.Type
+			response.ErrorCode = result.ErrorCode
+			response.SQLState = result.SQLState
+			response.ErrorMessage = result.ErrorMessage
+			return response, 0, nil
+		case isEOFPacket(packet.Payload):
+			return response, readEOFStatus(packet.Payload), nil
+		case isResultSetTerminator(packet.Payload):
+			result, status := readOK(packet.Payload)
+			response.Warnings = result.Warnings
+			return response, status, nil
+		}
+
+		response.RowCount++
+		if len(response.Rows) < maxSampleRows {
+			if row := readRow(packet.Payload, columnTypes); row != nil {
+				response.Rows = append(response.Rows, row)
+			}
+		}
+	}
+}
+
+func readColumnDefinitions(b *bufio.Reader, count int, response *MySQLResponse) ([]byte, error) {
+	columnTypes := make([]byte, 0, count)
+	for i := 0; i < count; i++ {
+		packet, err := readPacket(b)
+		if err != nil {
+			return nil, err
+		}
+
+		column, columnType, err := readColumnDefinition(packet.Payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if response != nil {
+			response.Columns = append(response.Columns, column)
+		}
+		columnTypes = append(columnTypes, columnType)
+	}
+	return columnTypes, nil
+}
+
+// COM_STMT_PREPARE_OK has the length of 12 and a zero filler byte, which tells it apart from an OK packet.
+func isPrepareOK(payload []byte) bool {
+	return len(payload) == 12 && payload[9] == 0
+}
+
+func readPrepareOK(b *bufio.Reader, payload []byte, reqResMatcher *requestResponseMatcher) (*MySQLResponse, error) {
+	r := &payloadReader{data: payload[1:]}
+	response := newResponse("Prepare OK")
+	response.StatementID = r.readUint32()
+	columnCount := int(r.readUint16())
+	response.ParamCount = int(r.readUint16())
+	r.readUint8() // filler
+	response.Warnings = r.readUint16()
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if response.ParamCount > 0 {
+		if _, err := readColumnDefinitions(b, response.ParamCount, nil); err != nil {
+			return nil, err
+		}
+		if err := skipIntermediateEOF(b, reqResMatcher, columnCount == 0); err != nil {
+			return nil, err
+		}
+	}
+
+	if columnCount > 0 {
+		if _, err := readColumnDefinitions(b, columnCount, response); err != nil {
+			return nil, err
+		}
+		if err := skipIntermediateEOF(b, reqResMatcher, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return response, nil
+}
+
+// The column definitions are followed by an EOF packet unless CLIENT_DEPRECATE_EOF is negotiated.
+// If the handshake wasn't captured the next packet is peeked, which for the last one in a response
+// means waiting for the next response to arrive.
+func skipIntermediateEOF(b *bufio.Reader, reqResMatcher *requestResponseMatcher, last bool) error {
+	deprecated, known := reqResMatcher.isEOFDeprecated()
+	if known && deprecated {
+		return nil
+	}
+
+	if !known {
+		sequence, first, length, err := peekPacket(b)
+		if err != nil {
+			return err
+		}
+		if first != packetEOF || length != eofPacketLength || (last && sequence == 1) {
+			return nil
+		}
+	}
+
+	_, err := readPacket(b)
+	return err
+}
+
+func handleClientStream(reader api.TcpReader, captureTime time.Time, request *MySQLRequest, reqResMatcher *requestResponseMatcher) {
+	tcpID := reader.GetTcpID()
+	counterPair := reader.GetCounterPair()
+	counterPair.Lock()
+	counterPair.Request++
+	requestCounter := counterPair.Request
+	counterPair.Unlock()
+
+	ident := fmt.Sprintf(
+		"%s_%s_%s_%s_%d",
+		tcpID.SrcIP,
+		tcpID.DstIP,
+		tcpID.SrcPort,
+		tcpID.DstPort,
+		requestCounter,
+	)
+
+	item := reqResMatcher.registerRequest(ident, request, captureTime, reader.GetReadProgress().Current())
+	if item != nil {
+		item.Capture = reader.GetParent().GetOrigin()
+		item.ConnectionInfo = &api.ConnectionInfo{
+			ClientIP:   tcpID.SrcIP,
+			ClientPort: tcpID.SrcPort,
+			ServerIP:   tcpID.DstIP,
+			ServerPort: tcpID.DstPort,
+			IsOutgoing: true,
+		}
+		reader.GetEmitter().Emit(item)
+	}
+}
+
+func handleServerStream(reader api.TcpReader, captureTime time.Time, response *MySQLResponse, reqResMatcher *requestResponseMatcher) {
+	tcpID := reader.GetTcpID()
+	counterPair := reader.GetCounterPair()
+	counterPair.Lock()
+	counterPair.Response++
+	responseCounter := counterPair.Response
+	counterPair.Unlock()
+
+	ident := fmt.Sprintf(
+		"%s_%s_%s_%s_%d",
+		tcpID.DstIP,
+		tcpID.SrcIP,
+		tcpID.DstPort,
+		tcpID.SrcPort,
+		responseCounter,
+	)
+
+	item := reqResMatcher.registerResponse(ident, response, captureTime, reader.GetReadProgress().Current())
+	if item != nil {
+		item.Capture = reader.GetParent().GetOrigin()
+		item.ConnectionInfo = &api.ConnectionInfo{
+			ClientIP:   tcpID.DstIP,
+			ClientPort: tcpID.DstPort,
+			ServerIP:   tcpID.SrcIP,
+			ServerPort: tcpID.SrcPort,
+			IsOutgoing: false,
+		}
+		reader.GetEmitter().Emit(item)
+	}
+}
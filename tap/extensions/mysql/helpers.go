@@ -0,0 +1,224 @@
+package mysql
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+type MySQLPayload struct {
+	Data interface{}
+}
+
+type MySQLPayloader interface {
+	MarshalJSON() ([]byte, error)
+}
+
+func (h MySQLPayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Data)
+}
+
+type MySQLWrapper struct {
+	Method  string      `json:"method"`
+	Url     string      `json:"url"`
+	Details interface{} `json:"details"`
+}
+
+func representRequest(request map[string]interface{}) (repRequest []interface{}) {
+	details, _ := json.Marshal([]api.TableData{
+		{
+			Name:     "Command",
+			Value:    request["command"].(string),
+			Selector: `request.command`,
+		},
+		{
+			Name:     "Verb",
+			Value:    request["verb"].(string),
+			Selector: `request.verb`,
+		},
+		{
+			Name:     "Table",
+			Value:    request["table"].(string),
+			Selector: `request.table`,
+		},
+		{
+			Name:     "Statement ID",
+			Value:    int64(request["statementId"].(float64)),
+			Selector: `request.statementId`,
+		},
+		{
+			Name:     "Schema",
+			Value:    request["schema"].(string),
+			Selector: `request.schema`,
+		},
+		{
+			Name:     "User",
+			Value:    request["user"].(string),
+			Selector: `request.user`,
+		},
+		{
+			Name:     "Auth Plugin",
+			Value:    request["authPlugin"].(string),
+			Selector: `request.authPlugin`,
+		},
+	})
+	repRequest = append(repRequest, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Details",
+		Data:  string(details),
+	})
+
+	if request["query"].(string) != "" {
+		repRequest = append(repRequest, api.SectionData{
+			Type:     api.BODY,
+			Title:    "Query",
+			Data:     request["query"].(string),
+			Selector: `request.query`,
+		})
+	}
+
+	parameters, _ := request["parameters"].([]interface{})
+	if len(parameters) > 0 {
+		var table []api.TableData
+		for i, parameter := range parameters {
+			table = append(table, api.TableData{
+				Name:     fmt.Sprintf("[%d]", i),
+				Value:    parameter.(string),
+				Selector: fmt.Sprintf(`request.parameters[%d]`, i),
+			})
+		}
+		obj, _ := json.Marshal(table)
+		repRequest = append(repRequest, api.SectionData{
+			Type:  api.TABLE,
+			Title: "Parameters",
+			Data:  string(obj),
+		})
+	}
+
+	return
+}
+
+func representResponse(response map[string]interface{}) (repResponse []interface{}) {
+	repResponse = make([]interface{}, 0)
+
+	details, _ := json.Marshal([]api.TableData{
+		{
+			Name:     "Type",
+			Value:    response["type"].(string),
+			Selector: `response.type`,
+		},
+		{
+			Name:     "Affected Rows",
+			Value:    int64(response["affectedRows"].(float64)),
+			Selector: `response.affectedRows`,
+		},
+		{
+			Name:     "Last Insert ID",
+			Value:    int64(response["lastInsertId"].(float64)),
+			Selector: `response.lastInsertId`,
+		},
+		{
+			Name:     "Row Count",
+			Value:    int64(response["rowCount"].(float64)),
+			Selector: `response.rowCount`,
+		},
+		{
+			Name:     "Warnings",
+			Value:    int64(response["warnings"].(float64)),
+			Selector: `response.warnings`,
+		},
+		{
+			Name:     "Info",
+			Value:    response["info"].(string),
+			Selector: `response.info`,
+		},
+		{
+			Name:     "Statement ID",
+			Value:    int64(response["statementId"].(float64)),
+			Selector: `response.statementId`,
+		},
+		{
+			Name:     "Server Version",
+			Value:    response["serverVersion"].(string),
+			Selector: `response.serverVersion`,
+		},
+		{
+			Name:     "Connection ID",
+			Value:    int64(response["connectionId"].(float64)),
+			Selector: `response.connectionId`,
+		},
+		{
+			Name:     "Auth Plugin",
+			Value:    response["authPlugin"].(string),
+			Selector: `response.authPlugin`,
+		},
+	})
+	repResponse = append(repResponse, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Details",
+		Data:  string(details),
+	})
+
+	if response["type"].(string) == "ERR" {
+		_error, _ := json.Marshal([]api.TableData{
+			{
+				Name:     "Error Code",
+				Value:    int64(response["errorCode"].(float64)),
+				Selector: `response.errorCode`,
+			},
+			{
+				Name:     "SQL State",
+				Value:    response["sqlState"].(string),
+				Selector: `response.sqlState`,
+			},
+			{
+				Name:     "Error Message",
+				Value:    response["errorMessage"].(string),
+				Selector: `response.errorMessage`,
+			},
+		})
+		repResponse = append(repResponse, api.SectionData{
+			Type:  api.TABLE,
+			Title: "Error",
+			Data:  string(_error),
+		})
+	}
+
+	columns, _ := response["columns"].([]interface{})
+	if len(columns) > 0 {
+		var table []api.TableData
+		for i, _column := range columns {
+			column := _column.(map[string]interface{})
+			name := column["name"].(string)
+			if column["table"].(string) != "" {
+				name = fmt.Sprintf("%s.%s", column["table"].(string), name)
+			}
+			table = append(table, api.TableData{
+				Name:     name,
+				Value:    column["type"].(string),
+				Selector: fmt.Sprintf(`response.columns[%d].name`, i),
+			})
+		}
+		obj, _ := json.Marshal(table)
+		repResponse = append(repResponse, api.SectionData{
+			Type:  api.TABLE,
+			Title: "Columns",
+			Data:  string(obj),
+		})
+	}
+
+	rows, _ := response["rows"].([]interface{})
+	if len(rows) > 0 {
+		obj, _ := json.Marshal(rows)
+		repResponse = append(repResponse, api.SectionData{
+			Type:     api.BODY,
+			Title:    "Rows",
+			Data:     string(obj),
+			MimeType: "application/json",
+			Selector: `response.rows`,
+		})
+	}
+
+	return
+}
@@ -0,0 +1,235 @@
+package mysql
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/api/apitest"
+)
+
+func TestRegister(t *testing.T) {
+	dissector := NewDissector()
+	extension := &api.Extension{}
+	dissector.Register(extension)
+	assert.Equal(t, "mysql", extension.Protocol.Name)
+}
+
+func TestMacros(t *testing.T) {
+	expectedMacros := map[string]string{
+		"mysql": `protocol.name == "mysql"`,
+	}
+	dissector := NewDissector()
+	macros := dissector.Macros()
+	assert.Equal(t, expectedMacros, macros)
+}
+
+func TestPing(t *testing.T) {
+	dissector := NewDissector()
+	dissector.Ping()
+}
+
+func TestParseStatement(t *testing.T) {
+	tests := []struct {
+		query string
+		verb  string
+		table string
+	}{
+		{"SELECT * FROM users WHERE id = 1", "SELECT", "users"},
+		{"/* app */ select name from `shop`.`orders`", "SELECT", "shop.orders"},
+		{"INSERT IGNORE INTO logs (msg) VALUES (?)", "INSERT", "logs"},
+		{"UPDATE LOW_PRIORITY accounts SET balance = 0", "UPDATE", "accounts"},
+		{"DELETE FROM sessions", "DELETE", "sessions"},
+		{"CREATE TABLE IF NOT EXISTS items (id INT)", "CREATE", "items"},
+		{"BEGIN", "BEGIN", ""},
+	}
+
+	for _, test := range tests {
+		verb, table := parseStatement(test.query)
+		assert.Equal(t, test.verb, verb, test.query)
+		assert.Equal(t, test.table, table, test.query)
+	}
+}
+
+type packetBuilder struct {
+	bytes.Buffer
+}
+
+func (p *packetBuilder) packet(sequence byte, fields ...interface{}) {
+	var payload bytes.Buffer
+	for _, field := range fields {
+		switch value := field.(type) {
+		case string:
+			// Length-encoded strings shorter than 251 bytes
+			payload.WriteByte(byte(len(value)))
+			payload.WriteString(value)
+		case []byte:
+			payload.Write(value)
+		case byte:
+			payload.WriteByte(value)
+		default:
+			_ = binary.Write(&payload, binary.LittleEndian, value)
+		}
+	}
+
+	length := payload.Len()
+	p.Write([]byte{byte(length), byte(length >> 8), byte(length >> 16), sequence})
+	p.Write(payload.Bytes())
+}
+
+func (p *packetBuilder) column(sequence byte, table string, name string, columnType byte) {
+	p.packet(sequence, "def", "shop", table, table, name, name, byte(0x0c), uint16(33), uint32(255), columnType, uint16(0), byte(0), uint16(0))
+}
+
+func (p *packetBuilder) eof(sequence byte) {
+	p.packet(sequence, byte(packetEOF), uint16(0), uint16(0x0002))
+}
+
+const capabilities = clientProtocol41 | clientSecureConnection | clientConnectWithDB | clientPluginAuth
+
+func dissect(t *testing.T, dissector api.Dissector, data []byte, isClient bool, counterPair *api.CounterPair, reqResMatcher api.RequestResponseMatcher, emitter api.Emitter) {
+	tcpID := &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "40000", DstPort: "3306"}
+	captureTime := time.Unix(0, 0)
+	if !isClient {
+		tcpID = &api.TcpID{SrcIP: "2", DstIP: "1", SrcPort: "3306", DstPort: "40000"}
+		captureTime = time.Unix(0, int64(time.Millisecond))
+	}
+
+	reader := NewTcpReader(&api.ReadProgress{}, "", tcpID, captureTime, NewTcpStream(api.Pcap), isClient, false, nil, emitter, counterPair, reqResMatcher)
+	err := dissector.Dissect(bufio.NewReader(bytes.NewReader(data)), reader, &api.TrafficFilteringOptions{})
+	assert.Equal(t, io.EOF, err)
+}
+
+func toEntry(t *testing.T, item *api.OutputChannelItem) *api.Entry {
+	// Simulate the round trip through the JSON encoding as the items do in Mizu
+	marshaled, err := json.Marshal(item)
+	assert.Nil(t, err)
+	var unmarshaled *api.OutputChannelItem
+	err = json.Unmarshal(marshaled, &unmarshaled)
+	assert.Nil(t, err)
+
+	return NewDissector().Analyze(unmarshaled, "client", "server", "default")
+}
+
+func TestDissect(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 16)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	counterPair := &api.CounterPair{}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+
+	// The prepared statement is known only after the COM_STMT_PREPARE is paired,
+	// so the streams are dissected in the order the packets are exchanged.
+	client := &packetBuilder{}
+	client.packet(1, uint32(capabilities), uint32(1<<24), byte(33), make([]byte, 23), []byte("orders\x00"), "secret", []byte("shop\x00"), []byte("mysql_native_password\x00"))
+	client.packet(0, byte(comQuery), []byte("SELECT id, name FROM customers"))
+	client.packet(0, byte(comStmtPrepare), []byte("UPDATE customers SET name = ? WHERE id = 1"))
+	dissect(t, dissector, client.Bytes(), true, counterPair, reqResMatcher, emitter)
+
+	server := &packetBuilder{}
+	server.packet(0, byte(handshakeProtocol), []byte("8.0.28\x00"), uint32(7), make([]byte, 8), byte(0), uint16(capabilities&0xffff), byte(33), uint16(2), uint16(capabilities>>16), byte(21), make([]byte, 10), make([]byte, 13), []byte("mysql_native_password\x00"))
+	server.packet(2, byte(packetOK), byte(0), byte(0), uint16(2), uint16(0))
+	server.packet(1, byte(2))
+	server.column(2, "customers", "id", typeLong)
+	server.column(3, "customers", "name", typeVarString)
+	server.eof(4)
+	server.packet(5, "1", "Alice")
+	server.packet(6, "2", []byte{0xfb})
+	server.eof(7)
+	server.packet(1, byte(packetOK), uint32(1), uint16(0), uint16(1), byte(0), uint16(0))
+	server.column(2, "", "?", typeVarString)
+	server.eof(3)
+	dissect(t, dissector, server.Bytes(), false, counterPair, reqResMatcher, emitter)
+
+	client = &packetBuilder{}
+	client.packet(0, byte(comStmtExecute), uint32(1), byte(0), uint32(1), byte(0), byte(1), uint16(typeVarString), "Bob")
+	client.packet(0, byte(comQuery), []byte("SELECT * FROM missing"))
+	dissect(t, dissector, client.Bytes(), true, counterPair, reqResMatcher, emitter)
+
+	server = &packetBuilder{}
+	server.packet(1, byte(packetOK), byte(1), byte(0), uint16(2), uint16(0), []byte("Rows matched: 1  Changed: 1  Warnings: 0"))
+	server.packet(1, byte(packetERR), uint16(1146), []byte("#42S02"), []byte("Table 'shop.missing' doesn't exist"))
+	dissect(t, dissector, server.Bytes(), false, counterPair, reqResMatcher, emitter)
+
+	close(itemChannel)
+	var items []*api.OutputChannelItem
+	for item := range itemChannel {
+		items = append(items, item)
+	}
+	assert.Len(t, items, 5)
+
+	handshake := toEntry(t, items[0])
+	assert.Equal(t, "orders", handshake.Request["user"])
+	assert.Equal(t, "shop", handshake.Request["schema"])
+	assert.Equal(t, "8.0.28", handshake.Response["serverVersion"])
+	assert.Equal(t, "OK", handshake.Response["type"])
+
+	query := toEntry(t, items[1])
+	assert.Equal(t, "Result Set", query.Response["type"])
+	assert.Equal(t, float64(2), query.Response["rowCount"])
+	assert.Equal(t, []interface{}{"2", "NULL"}, query.Response["rows"].([]interface{})[1])
+	summary := dissector.Summarize(query)
+	assert.Equal(t, "SELECT", summary.Method)
+	assert.Equal(t, "customers", summary.Summary)
+	assert.Equal(t, 0, summary.Status)
+
+	prepare := toEntry(t, items[2])
+	assert.Equal(t, "Prepare OK", prepare.Response["type"])
+	assert.Equal(t, float64(1), prepare.Response["paramCount"])
+
+	execute := toEntry(t, items[3])
+	assert.Equal(t, "UPDATE customers SET name = ? WHERE id = 1", execute.Request["query"])
+	assert.Equal(t, []interface{}{"Bob"}, execute.Request["parameters"])
+	assert.Equal(t, float64(1), execute.Response["affectedRows"])
+
+	missing := toEntry(t, items[4])
+	summary = dissector.Summarize(missing)
+	assert.Equal(t, 1146, summary.Status)
+	assert.Equal(t, `response.errorCode == 1146`, summary.StatusQuery)
+	assert.Equal(t, "missing", summary.Summary)
+
+	for _, entry := range []*api.Entry{handshake, query, prepare, execute, missing} {
+		representation, err := dissector.Represent(entry.Request, entry.Response)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, representation)
+	}
+}
+
+func TestDissectGarbage(t *testing.T) {
+	dissector := NewDissector()
+	reader := NewTcpReader(&api.ReadProgress{}, "", &api.TcpID{}, time.Time{}, NewTcpStream(api.Pcap), true, false, nil, nil, &api.CounterPair{}, dissector.NewResponseRequestMatcher())
+	err := dissector.Dissect(bufio.NewReader(bytes.NewBufferString("GET / HTTP/1.1\r\n\r\n")), reader, &api.TrafficFilteringOptions{})
+	assert.NotNil(t, err)
+	assert.NotEqual(t, io.EOF, err)
+}
+
+func TestNewUnansweredItem(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 1)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+
+	client := &packetBuilder{}
+	client.packet(0, byte(comQuery), []byte("SELECT SLEEP(3600)"))
+	dissect(t, dissector, client.Bytes(), true, &api.CounterPair{}, reqResMatcher, emitter)
+
+	items := apitest.UnansweredItems(reqResMatcher)
+	assert.Len(t, items, 1)
+
+	items[0].ConnectionInfo = &api.ConnectionInfo{ClientIP: "1", ClientPort: "40000", ServerIP: "2", ServerPort: "3306"}
+	_, summary := apitest.AnalyzeUnanswered(t, dissector, items[0])
+	assert.Equal(t, "SELECT", summary.Method)
+	assert.Equal(t, 0, summary.Status)
+}
@@ -0,0 +1,158 @@
+package mysql
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+type preparedStatement struct {
+	query      string
+	numParams  int
+	paramTypes []byte
+}
+
+// The statement IDs are assigned by the server, so the statements are known only once
+// the COM_STMT_PREPARE request is paired with its response.
+type preparedStatements struct {
+	statements map[uint32]*preparedStatement
+	sync.Mutex
+}
+
+func (s *preparedStatements) get(id uint32) *preparedStatement {
+	s.Lock()
+	defer s.Unlock()
+	return s.statements[id]
+}
+
+func (s *preparedStatements) put(id uint32, statement *preparedStatement) {
+	s.Lock()
+	defer s.Unlock()
+	s.statements[id] = statement
+}
+
+func (s *preparedStatements) remove(id uint32) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.statements, id)
+}
+
+// Key is `{src_ip}_{dst_ip}_{src_ip}_{src_port}_{incremental_counter}`
+// The matchers are created per TCP stream, so they also keep the state of the connection.
+type requestResponseMatcher struct {
+	openMessagesMap *sync.Map
+	statements      *preparedStatements
+	capabilities    uint32
+}
+
+func createResponseRequestMatcher() api.RequestResponseMatcher {
+	return &requestResponseMatcher{
+		openMessagesMap: &sync.Map{},
+		statements: &preparedStatements{
+			statements: make(map[uint32]*preparedStatement),
+		},
+	}
+}
+
+func (matcher *requestResponseMatcher) GetMap() *sync.Map {
+	return matcher.openMessagesMap
+}
+
+func (matcher *requestResponseMatcher) SetMaxTry(value int) {
+}
+
+func (matcher *requestResponseMatcher) setCapabilities(capabilities uint32) {
+	atomic.StoreUint32(&matcher.capabilities, capabilities)
+}
+
+// Reports whether the client is known to have negotiated CLIENT_DEPRECATE_EOF.
+// The second return value is false if the handshake wasn't captured.
+func (matcher *requestResponseMatcher) isEOFDeprecated() (bool, bool) {
+	capabilities := atomic.LoadUint32(&matcher.capabilities)
+	return capabilities&clientDeprecateEOF != 0, capabilities != 0
+}
+
+func (matcher *requestResponseMatcher) registerRequest(ident string, request *MySQLRequest, captureTime time.Time, captureSize int) *api.OutputChannelItem {
+	requestMySQLMessage := api.GenericMessage{
+		IsRequest:   true,
+		CaptureTime: captureTime,
+		CaptureSize: captureSize,
+		Payload: MySQLPayload{
+			Data: &MySQLWrapper{
+				Method:  request.Command,
+				Url:     request.Query,
+				Details: request,
+			},
+		},
+	}
+
+	if response, found := matcher.openMessagesMap.LoadAndDelete(ident); found {
+		// Type assertion always succeeds because all of the map's values are of api.GenericMessage type
+		responseMySQLMessage := response.(*api.GenericMessage)
+		if responseMySQLMessage.IsRequest {
+			return nil
+		}
+		return matcher.preparePair(&requestMySQLMessage, responseMySQLMessage)
+	}
+
+	matcher.openMessagesMap.Store(ident, &requestMySQLMessage)
+	return nil
+}
+
+func (matcher *requestResponseMatcher) registerResponse(ident string, response *MySQLResponse, captureTime time.Time, captureSize int) *api.OutputChannelItem {
+	responseMySQLMessage := api.GenericMessage{
+		IsRequest:   false,
+		CaptureTime: captureTime,
+		CaptureSize: captureSize,
+		Payload: MySQLPayload{
+			Data: &MySQLWrapper{
+				Method:  response.Type,
+				Url:     "",
+				Details: response,
+			},
+		},
+	}
+
+	if request, found := matcher.openMessagesMap.LoadAndDelete(ident); found {
+		// Type assertion always succeeds because all of the map's values are of api.GenericMessage type
+		requestMySQLMessage := request.(*api.GenericMessage)
+		if !requestMySQLMessage.IsRequest {
+			return nil
+		}
+		return matcher.preparePair(requestMySQLMessage, &responseMySQLMessage)
+	}
+
+	matcher.openMessagesMap.Store(ident, &responseMySQLMessage)
+	return nil
+}
+
+func (matcher *requestResponseMatcher) preparePair(requestMySQLMessage *api.GenericMessage, responseMySQLMessage *api.GenericMessage) *api.OutputChannelItem {
+	request := requestMySQLMessage.Payload.(MySQLPayload).Data.(*MySQLWrapper).Details.(*MySQLRequest)
+	response := responseMySQLMessage.Payload.(MySQLPayload).Data.(*MySQLWrapper).Details.(*MySQLResponse)
+	if request.Command == commands[comStmtPrepare] && response.Type == "Prepare OK" {
+		matcher.statements.put(response.StatementID, &preparedStatement{
+			query:     request.Query,
+			numParams: response.ParamCount,
+		})
+	}
+
+	return &api.OutputChannelItem{
+		Protocol:       protocol,
+		Timestamp:      requestMySQLMessage.CaptureTime.UnixNano() / int64(time.Millisecond),
+		ConnectionInfo: nil,
+		Pair: &api.RequestResponsePair{
+			Request:  *requestMySQLMessage,
+			Response: *responseMySQLMessage,
+		},
+	}
+}
+
+func (matcher *requestResponseMatcher) NewUnansweredItem(key interface{}, value interface{}) *api.OutputChannelItem {
+	request, ok := value.(*api.GenericMessage)
+	if !ok || !request.IsRequest {
+		return nil
+	}
+	return api.NewUnansweredItem(protocol, request)
+}
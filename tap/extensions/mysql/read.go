@@ -0,0 +1,483 @@
+package mysql
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+const (
+	maxPacketLength   = 1<<24 - 1
+	packetHeaderBytes = 4
+	sslRequestLength  = 32
+	handshakeProtocol = 0x0a
+	packetOK          = 0x00
+	packetLocalInfile = 0xfb
+	packetEOF         = 0xfe
+	packetERR         = 0xff
+	eofPacketLength   = 5
+)
+
+var errMalformedPacket = errors.New("Malformed MySQL packet")
+
+type MySQLPacket struct {
+	Sequence byte
+	Payload  []byte
+}
+
+func readPacket(b *bufio.Reader) (*MySQLPacket, error) {
+	packet := &MySQLPacket{}
+	header := make([]byte, packetHeaderBytes)
+
+	// Payloads of 16 MB and more are split into several packets, each with the maximum length
+	for {
+		if _, err := io.ReadFull(b, header); err != nil {
+			return nil, err
+		}
+
+		length := int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(b, payload); err != nil {
+			return nil, err
+		}
+
+		if packet.Payload == nil {
+			packet.Sequence = header[3]
+		}
+		packet.Payload = append(packet.Payload, payload...)
+
+		if length < maxPacketLength {
+			return packet, nil
+		}
+	}
+}
+
+// Returns the sequence ID, the first byte and the length of the next packet without consuming it.
+func peekPacket(b *bufio.Reader) (sequence byte, first byte, length int, err error) {
+	header, err := b.Peek(packetHeaderBytes + 1)
+	if err != nil {
+		return
+	}
+	length = int(header[0]) | int(header[1])<<8 | int(header[2])<<16
+	sequence = header[3]
+	first = header[4]
+	return
+}
+
+// An EOF packet is always 5 bytes long while an OK packet with the EOF header is at least 7.
+func isEOFPacket(payload []byte) bool {
+	return len(payload) == eofPacketLength && payload[0] == packetEOF
+}
+
+// The result sets are terminated either by an EOF packet or, if CLIENT_DEPRECATE_EOF is set,
+// by an OK packet with the EOF header. A row can begin with 0xfe only if it's at least 16 MB long.
+func isResultSetTerminator(payload []byte) bool {
+	return len(payload) > 0 && payload[0] == packetEOF && len(payload) < maxPacketLength
+}
+
+func isTLSRecord(b *bufio.Reader) bool {
+	header, err := b.Peek(3)
+	return err == nil && header[0] == 0x16 && header[1] == 0x03 && header[2] <= 0x04
+}
+
+type payloadReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *payloadReader) remaining() int {
+	return len(r.data) - r.offset
+}
+
+func (r *payloadReader) readBytes(n int) []byte {
+	if r.err != nil || n < 0 || r.offset+n > len(r.data) {
+		r.err = errMalformedPacket
+		return nil
+	}
+	value := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return value
+}
+
+func (r *payloadReader) readUint8() uint8 {
+	value := r.readBytes(1)
+	if value == nil {
+		return 0
+	}
+	return value[0]
+}
+
+func (r *payloadReader) readUint16() uint16 {
+	value := r.readBytes(2)
+	if value == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(value)
+}
+
+func (r *payloadReader) readUint32() uint32 {
+	value := r.readBytes(4)
+	if value == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(value)
+}
+
+func (r *payloadReader) readUint64() uint64 {
+	value := r.readBytes(8)
+	if value == nil {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(value)
+}
+
+// Length-encoded integer, the second return value is false for the NULL marker
+func (r *payloadReader) readLengthEncodedInt() (uint64, bool) {
+	first := r.readUint8()
+	switch first {
+	case 0xfb:
+		return 0, false
+	case 0xfc:
+		return uint64(r.readUint16()), true
+	case 0xfd:
+		value := r.readBytes(3)
+		if value == nil {
+			return 0, true
+		}
+		return uint64(value[0]) | uint64(value[1])<<8 | uint64(value[2])<<16, true
+	case 0xfe:
+		return r.readUint64(), true
+	case 0xff:
+		r.err = errMalformedPacket
+		return 0, true
+	default:
+		return uint64(first), true
+	}
+}
+
+func (r *payloadReader) readLengthEncodedString() ([]byte, bool) {
+	length, ok := r.readLengthEncodedInt()
+	if !ok || r.err != nil {
+		return nil, ok
+	}
+	if length > uint64(r.remaining()) {
+		r.err = errMalformedPacket
+		return nil, true
+	}
+	return r.readBytes(int(length)), true
+}
+
+func (r *payloadReader) readNullTerminatedString() string {
+	if r.err != nil {
+		return ""
+	}
+	end := bytes.IndexByte(r.data[r.offset:], 0)
+	if end < 0 {
+		r.err = errMalformedPacket
+		return ""
+	}
+	value := string(r.data[r.offset : r.offset+end])
+	r.offset += end + 1
+	return value
+}
+
+func (r *payloadReader) readRestOfPacketString() string {
+	if r.err != nil {
+		return ""
+	}
+	value := string(r.data[r.offset:])
+	r.offset = len(r.data)
+	return value
+}
+
+func readHandshake(payload []byte) (*MySQLResponse, uint32, error) {
+	r := &payloadReader{data: payload}
+	if r.readUint8() != handshakeProtocol {
+		return nil, 0, errors.New("Unsupported MySQL handshake protocol")
+	}
+
+	response := newResponse("Handshake")
+	response.ServerVersion = r.readNullTerminatedString()
+	response.ConnectionID = r.readUint32()
+	r.readBytes(8) // auth-plugin-data-part-1
+	r.readUint8()  // filler
+	capabilities := uint32(r.readUint16())
+	if r.remaining() > 0 {
+		r.readUint8()  // character set
+		r.readUint16() // status flags
+		capabilities |= uint32(r.readUint16()) << 16
+		authDataLength := int(r.readUint8())
+		r.readBytes(10) // reserved
+		if capabilities&clientSecureConnection != 0 {
+			length := authDataLength - 8
+			if length < 13 {
+				length = 13
+			}
+			r.readBytes(length)
+		}
+		if capabilities&clientPluginAuth != 0 && r.remaining() > 0 {
+			response.AuthPlugin = r.readNullTerminatedString()
+		}
+	}
+
+	return response, capabilities, r.err
+}
+
+func readHandshakeResponse(payload []byte) (*MySQLRequest, uint32, error) {
+	r := &payloadReader{data: payload}
+	capabilities := r.readUint32()
+	if capabilities&clientProtocol41 == 0 {
+		return nil, 0, errors.New("Unsupported MySQL handshake response")
+	}
+	r.readUint32() // max packet size
+	r.readUint8()  // character set
+	r.readBytes(23)
+
+	request := newRequest("Handshake Response")
+	request.User = r.readNullTerminatedString()
+	if capabilities&clientPluginAuthLenEncClientData != 0 {
+		r.readLengthEncodedString()
+	} else if capabilities&clientSecureConnection != 0 {
+		r.readBytes(int(r.readUint8()))
+	} else {
+		r.readNullTerminatedString()
+	}
+	if capabilities&clientConnectWithDB != 0 && r.remaining() > 0 {
+		request.Schema = r.readNullTerminatedString()
+	}
+	if capabilities&clientPluginAuth != 0 && r.remaining() > 0 {
+		request.AuthPlugin = r.readNullTerminatedString()
+	}
+
+	return request, capabilities, r.err
+}
+
+func readCommand(payload []byte, statements *preparedStatements) (*MySQLRequest, error) {
+	if len(payload) == 0 {
+		return nil, errMalformedPacket
+	}
+
+	name, ok := commands[payload[0]]
+	if !ok {
+		return nil, fmt.Errorf("Unrecognized MySQL command: 0x%02x", payload[0])
+	}
+
+	request := newRequest(name)
+	r := &payloadReader{data: payload[1:]}
+
+	switch payload[0] {
+	case comQuery, comStmtPrepare:
+		request.Query = r.readRestOfPacketString()
+		request.Verb, request.Table = parseStatement(request.Query)
+	case comInitDB:
+		request.Schema = r.readRestOfPacketString()
+	case comFieldList:
+		request.Table = r.readNullTerminatedString()
+	case comChangeUser:
+		request.User = r.readNullTerminatedString()
+	case comStmtExecute, comStmtReset, comStmtClose, comStmtFetch, comStmtSendLongData:
+		request.StatementID = r.readUint32()
+		if statement := statements.get(request.StatementID); statement != nil {
+			request.Query = statement.query
+			request.Verb, request.Table = parseStatement(request.Query)
+			if payload[0] == comStmtExecute {
+				request.Parameters = readExecuteParameters(r, statement)
+			}
+		}
+	}
+
+	return request, r.err
+}
+
+// COM_STMT_EXECUTE carries the parameter types only when they're bound for the first time,
+// so they're remembered on the prepared statement.
+func readExecuteParameters(r *payloadReader, statement *preparedStatement) []string {
+	parameters := make([]string, 0)
+	if statement.numParams == 0 {
+		return parameters
+	}
+
+	r.readUint8()  // flags
+	r.readUint32() // iteration count
+	nullBitmap := r.readBytes((statement.numParams + 7) / 8)
+	if r.readUint8() == 1 {
+		statement.paramTypes = make([]byte, statement.numParams)
+		for i := range statement.paramTypes {
+			statement.paramTypes[i] = r.readUint8()
+			r.readUint8() // unsigned flag
+		}
+	}
+	if r.err != nil || len(statement.paramTypes) != statement.numParams {
+		return parameters
+	}
+
+	for i := 0; i < statement.numParams; i++ {
+		if nullBitmap[i/8]&(1<<(i%8)) != 0 {
+			parameters = append(parameters, "NULL")
+			continue
+		}
+		parameters = append(parameters, readBinaryValue(r, statement.paramTypes[i]))
+	}
+	return parameters
+}
+
+func readOK(payload []byte) (*MySQLResponse, uint16) {
+	r := &payloadReader{data: payload[1:]}
+	response := newResponse("OK")
+	response.AffectedRows, _ = r.readLengthEncodedInt()
+	response.LastInsertID, _ = r.readLengthEncodedInt()
+	status := r.readUint16()
+	response.Warnings = r.readUint16()
+	if r.remaining() > 0 {
+		response.Info = r.readRestOfPacketString()
+	}
+	return response, status
+}
+
+func readEOFStatus(payload []byte) uint16 {
+	if len(payload) < 5 {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(payload[3:5])
+}
+
+func readERR(payload []byte) *MySQLResponse {
+	r := &payloadReader{data: payload[1:]}
+	response := newResponse("ERR")
+	response.ErrorCode = r.readUint16()
+	if r.remaining() > 0 && r.data[r.offset] == '#' {
+		r.readUint8()
+		response.SQLState = string(r.readBytes(5))
+	}
+	response.ErrorMessage = r.readRestOfPacketString()
+	return response
+}
+
+func readColumnDefinition(payload []byte) (MySQLColumn, byte, error) {
+	r := &payloadReader{data: payload}
+	r.readLengthEncodedString() // catalog
+	schema, _ := r.readLengthEncodedString()
+	table, _ := r.readLengthEncodedString()
+	r.readLengthEncodedString() // original table
+	name, _ := r.readLengthEncodedString()
+	r.readLengthEncodedString() // original name
+	r.readLengthEncodedInt()    // length of the fixed fields
+	r.readUint16()              // character set
+	r.readUint32()              // column length
+	columnType := r.readUint8()
+
+	return MySQLColumn{
+		Name:   string(name),
+		Table:  string(table),
+		Schema: string(schema),
+		Type:   typeName(columnType),
+	}, columnType, r.err
+}
+
+// Text rows are a sequence of length-encoded strings, binary rows begin with a 0x00 header
+// followed by a NULL bitmap offset by two bits. Text is tried first as the header alone can't tell.
+func readRow(payload []byte, columnTypes []byte) []string {
+	r := &payloadReader{data: payload}
+	row := make([]string, 0, len(columnTypes))
+	for range columnTypes {
+		value, ok := r.readLengthEncodedString()
+		if !ok {
+			row = append(row, "NULL")
+		} else {
+			row = append(row, formatValue(value))
+		}
+	}
+	if r.err == nil && r.remaining() == 0 {
+		return row
+	}
+
+	if len(payload) == 0 || payload[0] != 0x00 {
+		return nil
+	}
+	r = &payloadReader{data: payload[1:]}
+	row = row[:0]
+	nullBitmap := r.readBytes((len(columnTypes) + 7 + 2) / 8)
+	for i, columnType := range columnTypes {
+		if r.err != nil {
+			return nil
+		}
+		bit := i + 2
+		if nullBitmap[bit/8]&(1<<(bit%8)) != 0 {
+			row = append(row, "NULL")
+			continue
+		}
+		row = append(row, readBinaryValue(r, columnType))
+	}
+	if r.err != nil {
+		return nil
+	}
+	return row
+}
+
+func readBinaryValue(r *payloadReader, columnType byte) string {
+	switch columnType {
+	case typeTiny:
+		return fmt.Sprintf("%d", int8(r.readUint8()))
+	case typeShort, typeYear:
+		return fmt.Sprintf("%d", int16(r.readUint16()))
+	case typeLong, typeInt24:
+		return fmt.Sprintf("%d", int32(r.readUint32()))
+	case typeLongLong:
+		return fmt.Sprintf("%d", int64(r.readUint64()))
+	case typeFloat:
+		return fmt.Sprintf("%v", math.Float32frombits(r.readUint32()))
+	case typeDouble:
+		return fmt.Sprintf("%v", math.Float64frombits(r.readUint64()))
+	case typeDate, typeDateTime, typeTimestamp:
+		value := r.readBytes(int(r.readUint8()))
+		return formatDateTime(value)
+	case typeTime:
+		value := r.readBytes(int(r.readUint8()))
+		return formatTime(value)
+	case typeNull:
+		return "NULL"
+	default:
+		value, _ := r.readLengthEncodedString()
+		return formatValue(value)
+	}
+}
+
+func formatDateTime(value []byte) string {
+	if len(value) < 4 {
+		return "0000-00-00 00:00:00"
+	}
+	t := time.Date(int(binary.LittleEndian.Uint16(value)), time.Month(value[2]), int(value[3]), 0, 0, 0, 0, time.UTC)
+	if len(value) >= 7 {
+		t = t.Add(time.Duration(value[4])*time.Hour + time.Duration(value[5])*time.Minute + time.Duration(value[6])*time.Second)
+	}
+	if len(value) >= 11 {
+		t = t.Add(time.Duration(binary.LittleEndian.Uint32(value[7:])) * time.Microsecond)
+		return t.Format("2006-01-02 15:04:05.000000")
+	}
+	if len(value) == 4 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
+
+func formatTime(value []byte) string {
+	if len(value) < 8 {
+		return "00:00:00"
+	}
+	sign := ""
+	if value[0] == 1 {
+		sign = "-"
+	}
+	hours := binary.LittleEndian.Uint32(value[1:5])*24 + uint32(value[5])
+	result := fmt.Sprintf("%s%02d:%02d:%02d", sign, hours, value[6], value[7])
+	if len(value) >= 12 {
+		result += fmt.Sprintf(".%06d", binary.LittleEndian.Uint32(value[8:]))
+	}
+	return result
+}
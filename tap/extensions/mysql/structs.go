@@ -0,0 +1,257 @@
+package mysql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxSampleRows = 10
+
+// Capability flags
+const (
+	clientConnectWithDB              = 0x00000008
+	clientProtocol41                 = 0x00000200
+	clientSSL                        = 0x00000800
+	clientSecureConnection           = 0x00008000
+	clientPluginAuth                 = 0x00080000
+	clientPluginAuthLenEncClientData = 0x00200000
+	clientDeprecateEOF               = 0x01000000
+)
+
+// Status flags
+const (
+	serverMoreResultsExists = 0x0008
+)
+
+const (
+	comQuit             = 0x01
+	comInitDB           = 0x02
+	comQuery            = 0x03
+	comFieldList        = 0x04
+	comStatistics       = 0x09
+	comProcessKill      = 0x0c
+	comDebug            = 0x0d
+	comPing             = 0x0e
+	comChangeUser       = 0x11
+	comStmtPrepare      = 0x16
+	comStmtExecute      = 0x17
+	comStmtSendLongData = 0x18
+	comStmtClose        = 0x19
+	comStmtReset        = 0x1a
+	comSetOption        = 0x1b
+	comStmtFetch        = 0x1c
+	comResetConnection  = 0x1f
+)
+
+var commands = map[byte]string{
+	comQuit:             "COM_QUIT",
+	comInitDB:           "COM_INIT_DB",
+	comQuery:            "COM_QUERY",
+	comFieldList:        "COM_FIELD_LIST",
+	comStatistics:       "COM_STATISTICS",
+	comProcessKill:      "COM_PROCESS_KILL",
+	comDebug:            "COM_DEBUG",
+	comPing:             "COM_PING",
+	comChangeUser:       "COM_CHANGE_USER",
+	comStmtPrepare:      "COM_STMT_PREPARE",
+	comStmtExecute:      "COM_STMT_EXECUTE",
+	comStmtSendLongData: "COM_STMT_SEND_LONG_DATA",
+	comStmtClose:        "COM_STMT_CLOSE",
+	comStmtReset:        "COM_STMT_RESET",
+	comSetOption:        "COM_SET_OPTION",
+	comStmtFetch:        "COM_STMT_FETCH",
+	comResetConnection:  "COM_RESET_CONNECTION",
+}
+
+// Column types
+const (
+	typeDecimal    = 0x00
+	typeTiny       = 0x01
+	typeShort      = 0x02
+	typeLong       = 0x03
+	typeFloat      = 0x04
+	typeDouble     = 0x05
+	typeNull       = 0x06
+	typeTimestamp  = 0x07
+	typeLongLong   = 0x08
+	typeInt24      = 0x09
+	typeDate       = 0x0a
+	typeTime       = 0x0b
+	typeDateTime   = 0x0c
+	typeYear       = 0x0d
+	typeVarchar    = 0x0f
+	typeBit        = 0x10
+	typeJSON       = 0xf5
+	typeNewDecimal = 0xf6
+	typeEnum       = 0xf7
+	typeSet        = 0xf8
+	typeTinyBlob   = 0xf9
+	typeMediumBlob = 0xfa
+	typeLongBlob   = 0xfb
+	typeBlob       = 0xfc
+	typeVarString  = 0xfd
+	typeString     = 0xfe
+	typeGeometry   = 0xff
+)
+
+var typeNames = map[byte]string{
+	typeDecimal:    "DECIMAL",
+	typeTiny:       "TINY",
+	typeShort:      "SHORT",
+	typeLong:       "LONG",
+	typeFloat:      "FLOAT",
+	typeDouble:     "DOUBLE",
+	typeNull:       "NULL",
+	typeTimestamp:  "TIMESTAMP",
+	typeLongLong:   "LONGLONG",
+	typeInt24:      "INT24",
+	typeDate:       "DATE",
+	typeTime:       "TIME",
+	typeDateTime:   "DATETIME",
+	typeYear:       "YEAR",
+	typeVarchar:    "VARCHAR",
+	typeBit:        "BIT",
+	typeJSON:       "JSON",
+	typeNewDecimal: "NEWDECIMAL",
+	typeEnum:       "ENUM",
+	typeSet:        "SET",
+	typeTinyBlob:   "TINY_BLOB",
+	typeMediumBlob: "MEDIUM_BLOB",
+	typeLongBlob:   "LONG_BLOB",
+	typeBlob:       "BLOB",
+	typeVarString:  "VAR_STRING",
+	typeString:     "STRING",
+	typeGeometry:   "GEOMETRY",
+}
+
+type MySQLColumn struct {
+	Name   string `json:"name"`
+	Table  string `json:"table"`
+	Schema string `json:"schema"`
+	Type   string `json:"type"`
+}
+
+type MySQLRequest struct {
+	Command     string   `json:"command"`
+	Verb        string   `json:"verb"`
+	Table       string   `json:"table"`
+	Query       string   `json:"query"`
+	StatementID uint32   `json:"statementId"`
+	Parameters  []string `json:"parameters"`
+	Schema      string   `json:"schema"`
+	User        string   `json:"user"`
+	AuthPlugin  string   `json:"authPlugin"`
+}
+
+type MySQLResponse struct {
+	Type          string        `json:"type"`
+	AffectedRows  uint64        `json:"affectedRows"`
+	LastInsertID  uint64        `json:"lastInsertId"`
+	Warnings      uint16        `json:"warnings"`
+	Info          string        `json:"info"`
+	ErrorCode     uint16        `json:"errorCode"`
+	SQLState      string        `json:"sqlState"`
+	ErrorMessage  string        `json:"errorMessage"`
+	StatementID   uint32        `json:"statementId"`
+	ParamCount    int           `json:"paramCount"`
+	Columns       []MySQLColumn `json:"columns"`
+	RowCount      int           `json:"rowCount"`
+	Rows          [][]string    `json:"rows"`
+	ServerVersion string        `json:"serverVersion"`
+	ConnectionID  uint32        `json:"connectionId"`
+	AuthPlugin    string        `json:"authPlugin"`
+}
+
+func newRequest(command string) *MySQLRequest {
+	return &MySQLRequest{
+		Command:    command,
+		Parameters: make([]string, 0),
+	}
+}
+
+func newResponse(responseType string) *MySQLResponse {
+	return &MySQLResponse{
+		Type:    responseType,
+		Columns: make([]MySQLColumn, 0),
+		Rows:    make([][]string, 0),
+	}
+}
+
+func typeName(columnType byte) string {
+	if name, ok := typeNames[columnType]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%02x", columnType)
+}
+
+var identifier = "(?:`[^`]+`|[\\w$]+)(?:\\.(?:`[^`]+`|[\\w$]+))?"
+
+var tablePatterns = map[string]*regexp.Regexp{
+	"SELECT":   regexp.MustCompile(`(?is)\bFROM\s+(` + identifier + `)`),
+	"DELETE":   regexp.MustCompile(`(?is)\bFROM\s+(` + identifier + `)`),
+	"INSERT":   regexp.MustCompile(`(?is)^INSERT\s+(?:(?:LOW_PRIORITY|DELAYED|HIGH_PRIORITY|IGNORE)\s+)*(?:INTO\s+)?(` + identifier + `)`),
+	"REPLACE":  regexp.MustCompile(`(?is)^REPLACE\s+(?:(?:LOW_PRIORITY|DELAYED)\s+)*(?:INTO\s+)?(` + identifier + `)`),
+	"UPDATE":   regexp.MustCompile(`(?is)^UPDATE\s+(?:(?:LOW_PRIORITY|IGNORE)\s+)*(` + identifier + `)`),
+	"CREATE":   regexp.MustCompile(`(?is)\bTABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(` + identifier + `)`),
+	"ALTER":    regexp.MustCompile(`(?is)\bTABLE\s+(` + identifier + `)`),
+	"DROP":     regexp.MustCompile(`(?is)\bTABLE\s+(?:IF\s+EXISTS\s+)?(` + identifier + `)`),
+	"TRUNCATE": regexp.MustCompile(`(?is)^TRUNCATE\s+(?:TABLE\s+)?(` + identifier + `)`),
+}
+
+// Returns the statement verb, e.g. SELECT, and the first table the statement refers to.
+func parseStatement(query string) (verb string, table string) {
+	for {
+		query = strings.TrimSpace(query)
+		if strings.HasPrefix(query, "--") || strings.HasPrefix(query, "#") {
+			end := strings.IndexByte(query, '\n')
+			if end < 0 {
+				return
+			}
+			query = query[end:]
+		} else if strings.HasPrefix(query, "/*") {
+			end := strings.Index(query, "*/")
+			if end < 0 {
+				return
+			}
+			query = query[end+2:]
+		} else {
+			break
+		}
+	}
+
+	end := strings.IndexFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if end < 0 {
+		end = len(query)
+	}
+	verb = strings.ToUpper(query[:end])
+
+	if pattern, ok := tablePatterns[verb]; ok {
+		if match := pattern.FindStringSubmatch(query); match != nil {
+			table = strings.ReplaceAll(match[1], "`", "")
+		}
+	}
+	return
+}
+
+// Printable values are shown as they are, binary ones in the hex notation.
+func formatValue(value []byte) string {
+	if utf8.Valid(value) {
+		printable := true
+		for _, r := range string(value) {
+			if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+				printable = false
+				break
+			}
+		}
+		if printable {
+			return string(value)
+		}
+	}
+
+	return fmt.Sprintf("0x%x", value)
+}
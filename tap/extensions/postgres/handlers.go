@@ -0,0 +1,202 @@
+package postgres
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+func dissectFrontend(b *bufio.Reader, reader api.TcpReader, reqResMatcher *requestResponseMatcher) error {
+	var request *PostgresRequest
+	var captureTime time.Time
+
+	for {
+		peek, err := b.Peek(1)
+		if err != nil {
+			return err
+		}
+
+		// The length of a startup message always begins with a zero byte
+		if peek[0] == 0 {
+			code, payload, err := readStartupMessage(b)
+			if err != nil {
+				return err
+			}
+
+			// The encryption requests are answered with a single byte, the cancel requests aren't answered at all
+			if code != protocolVersion3 {
+				continue
+			}
+
+			parameters, err := parseStartupParameters(payload)
+			if err != nil {
+				return err
+			}
+
+			reader.GetParent().SetProtocol(&protocol)
+			handleClientStream(reader, reader.GetCaptureTime(), &PostgresRequest{
+				Type:              "Startup",
+				Command:           "STARTUP",
+				Parameters:        make([]string, 0),
+				StartupParameters: parameters,
+				Messages:          []string{"StartupMessage"},
+			}, reqResMatcher)
+			continue
+		}
+
+		message, err := readMessage(b, frontendMessages)
+		if err != nil {
+			return err
+		}
+		reader.GetParent().SetProtocol(&protocol)
+
+		switch message.Type {
+		// These are not followed by a ReadyForQuery, so they can't be paired
+		case 'H', 'X', 'p', 'd', 'c', 'f':
+			continue
+		}
+
+		if request == nil {
+			request = &PostgresRequest{
+				Parameters: make([]string, 0),
+				Messages:   make([]string, 0),
+			}
+			captureTime = reader.GetCaptureTime()
+		}
+
+		if err = request.addMessage(message); err != nil {
+			return err
+		}
+
+		switch message.Type {
+		case 'Q', 'S', 'F':
+			handleClientStream(reader, captureTime, request, reqResMatcher)
+			request = nil
+		}
+	}
+}
+
+func dissectBackend(b *bufio.Reader, reader api.TcpReader, reqResMatcher *requestResponseMatcher) error {
+	var response *PostgresResponse
+	var captureTime time.Time
+	started := false
+
+	for {
+		if !started {
+			encryption, err := isEncryptionResponse(b)
+			if err != nil {
+				return err
+			}
+
+			if encryption {
+				answer, _ := b.ReadByte()
+				if answer != 'N' {
+					return errors.New("PostgreSQL connection is encrypted")
+				}
+				reader.GetParent().SetProtocol(&protocol)
+				continue
+			}
+		}
+
+		message, err := readMessage(b, backendMessages)
+		if err != nil {
+			// The server closes the connection without a ReadyForQuery on fatal errors
+			if err == io.EOF && response != nil && response.Error != nil {
+				handleServerStream(reader, captureTime, response, reqResMatcher)
+			}
+			return err
+		}
+		reader.GetParent().SetProtocol(&protocol)
+		started = true
+
+		// Asynchronous notifications and parameter changes don't belong to any query
+		if response == nil && (message.Type == 'A' || message.Type == 'N' || message.Type == 'S') {
+			continue
+		}
+
+		if response == nil {
+			response = &PostgresResponse{
+				Columns:  make([]PostgresColumn, 0),
+				Rows:     make([][]string, 0),
+				Notices:  make([]PostgresError, 0),
+				Messages: make([]string, 0),
+			}
+			captureTime = reader.GetCaptureTime()
+		}
+
+		if err = response.addMessage(message); err != nil {
+			return err
+		}
+
+		if message.Type == 'Z' {
+			handleServerStream(reader, captureTime, response, reqResMatcher)
+			response = nil
+		}
+	}
+}
+
+func handleClientStream(reader api.TcpReader, captureTime time.Time, request *PostgresRequest, reqResMatcher *requestResponseMatcher) {
+	tcpID := reader.GetTcpID()
+	counterPair := reader.GetCounterPair()
+	counterPair.Lock()
+	counterPair.Request++
+	requestCounter := counterPair.Request
+	counterPair.Unlock()
+
+	ident := fmt.Sprintf(
+		"%s_%s_%s_%s_%d",
+		tcpID.SrcIP,
+		tcpID.DstIP,
+		tcpID.SrcPort,
+		tcpID.DstPort,
+		requestCounter,
+	)
+
+	item := reqResMatcher.registerRequest(ident, request, captureTime, reader.GetReadProgress().Current())
+	if item != nil {
+		item.Capture = reader.GetParent().GetOrigin()
+		item.ConnectionInfo = &api.ConnectionInfo{
+			ClientIP:   tcpID.SrcIP,
+			ClientPort: tcpID.SrcPort,
+			ServerIP:   tcpID.DstIP,
+			ServerPort: tcpID.DstPort,
+			IsOutgoing: true,
+		}
+		reader.GetEmitter().Emit(item)
+	}
+}
+
+func handleServerStream(reader api.TcpReader, captureTime time.Time, response *PostgresResponse, reqResMatcher *requestResponseMatcher) {
+	tcpID := reader.GetTcpID()
+	counterPair := reader.GetCounterPair()
+	counterPair.Lock()
+	counterPair.Response++
+	responseCounter := counterPair.Response
+	counterPair.Unlock()
+
+	ident := fmt.Sprintf(
+		"%s_%s_%s_%s_%d",
+		tcpID.DstIP,
+		tcpID.SrcIP,
+		tcpID.DstPort,
+		tcpID.SrcPort,
+		responseCounter,
+	)
+
+	item := reqResMatcher.registerResponse(ident, response, captureTime, reader.GetReadProgress().Current())
+	if item != nil {
+		item.Capture = reader.GetParent().GetOrigin()
+		item.ConnectionInfo = &api.ConnectionInfo{
+			ClientIP:   tcpID.DstIP,
+			ClientPort: tcpID.DstPort,
+			ServerIP:   tcpID.SrcIP,
+			ServerPort: tcpID.SrcPort,
+			IsOutgoing: false,
+		}
+		reader.GetEmitter().Emit(item)
+	}
+}
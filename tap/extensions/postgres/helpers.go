@@ -0,0 +1,262 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+type PostgresPayload struct {
+	Data interface{}
+}
+
+type PostgresPayloader interface {
+	MarshalJSON() ([]byte, error)
+}
+
+func (h PostgresPayload) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Data)
+}
+
+type PostgresWrapper struct {
+	Method  string      `json:"method"`
+	Url     string      `json:"url"`
+	Details interface{} `json:"details"`
+}
+
+func representRequest(request map[string]interface{}) (repRequest []interface{}) {
+	details, _ := json.Marshal([]api.TableData{
+		{
+			Name:     "Type",
+			Value:    request["type"].(string),
+			Selector: `request.type`,
+		},
+		{
+			Name:     "Command",
+			Value:    request["command"].(string),
+			Selector: `request.command`,
+		},
+		{
+			Name:     "Statement",
+			Value:    request["statement"].(string),
+			Selector: `request.statement`,
+		},
+		{
+			Name:     "Portal",
+			Value:    request["portal"].(string),
+			Selector: `request.portal`,
+		},
+		{
+			Name:     "Messages",
+			Value:    joinStrings(request["messages"]),
+			Selector: `request.messages`,
+		},
+	})
+	repRequest = append(repRequest, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Details",
+		Data:  string(details),
+	})
+
+	if request["query"].(string) != "" {
+		repRequest = append(repRequest, api.SectionData{
+			Type:     api.BODY,
+			Title:    "Query",
+			Data:     request["query"].(string),
+			Selector: `request.query`,
+		})
+	}
+
+	parameters, _ := request["parameters"].([]interface{})
+	if len(parameters) > 0 {
+		var table []api.TableData
+		for i, parameter := range parameters {
+			table = append(table, api.TableData{
+				Name:     fmt.Sprintf("$%d", i+1),
+				Value:    parameter.(string),
+				Selector: fmt.Sprintf(`request.parameters[%d]`, i),
+			})
+		}
+		obj, _ := json.Marshal(table)
+		repRequest = append(repRequest, api.SectionData{
+			Type:  api.TABLE,
+			Title: "Parameters",
+			Data:  string(obj),
+		})
+	}
+
+	startupParameters, _ := request["startupParameters"].(map[string]interface{})
+	if len(startupParameters) > 0 {
+		repRequest = append(repRequest, api.SectionData{
+			Type:  api.TABLE,
+			Title: "Startup Parameters",
+			Data:  representMap(startupParameters, `request.startupParameters`),
+		})
+	}
+
+	return
+}
+
+func representResponse(response map[string]interface{}) (repResponse []interface{}) {
+	repResponse = make([]interface{}, 0)
+
+	details, _ := json.Marshal([]api.TableData{
+		{
+			Name:     "Command Tag",
+			Value:    response["commandTag"].(string),
+			Selector: `response.commandTag`,
+		},
+		{
+			Name:     "Row Count",
+			Value:    int64(response["rowCount"].(float64)),
+			Selector: `response.rowCount`,
+		},
+		{
+			Name:     "Transaction Status",
+			Value:    response["transactionStatus"].(string),
+			Selector: `response.transactionStatus`,
+		},
+		{
+			Name:     "Authentication",
+			Value:    response["authentication"].(string),
+			Selector: `response.authentication`,
+		},
+		{
+			Name:     "Messages",
+			Value:    joinStrings(response["messages"]),
+			Selector: `response.messages`,
+		},
+	})
+	repResponse = append(repResponse, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Details",
+		Data:  string(details),
+	})
+
+	if _error, ok := response["error"].(map[string]interface{}); ok {
+		repResponse = append(repResponse, api.SectionData{
+			Type:  api.TABLE,
+			Title: "Error",
+			Data:  representError(_error, `response.error`),
+		})
+	}
+
+	notices, _ := response["notices"].([]interface{})
+	for i, notice := range notices {
+		repResponse = append(repResponse, api.SectionData{
+			Type:  api.TABLE,
+			Title: fmt.Sprintf("Notice [%d]", i),
+			Data:  representError(notice.(map[string]interface{}), fmt.Sprintf(`response.notices[%d]`, i)),
+		})
+	}
+
+	columns, _ := response["columns"].([]interface{})
+	if len(columns) > 0 {
+		var table []api.TableData
+		for i, _column := range columns {
+			column := _column.(map[string]interface{})
+			table = append(table, api.TableData{
+				Name:     column["name"].(string),
+				Value:    column["type"].(string),
+				Selector: fmt.Sprintf(`response.columns[%d].name`, i),
+			})
+		}
+		obj, _ := json.Marshal(table)
+		repResponse = append(repResponse, api.SectionData{
+			Type:  api.TABLE,
+			Title: "Columns",
+			Data:  string(obj),
+		})
+	}
+
+	rows, _ := response["rows"].([]interface{})
+	if len(rows) > 0 {
+		obj, _ := json.Marshal(rows)
+		repResponse = append(repResponse, api.SectionData{
+			Type:     api.BODY,
+			Title:    "Rows",
+			Data:     string(obj),
+			MimeType: "application/json",
+			Selector: `response.rows`,
+		})
+	}
+
+	parameterStatus, _ := response["parameterStatus"].(map[string]interface{})
+	if len(parameterStatus) > 0 {
+		repResponse = append(repResponse, api.SectionData{
+			Type:  api.TABLE,
+			Title: "Parameter Status",
+			Data:  representMap(parameterStatus, `response.parameterStatus`),
+		})
+	}
+
+	return
+}
+
+func representError(fields map[string]interface{}, selector string) string {
+	table, _ := json.Marshal([]api.TableData{
+		{
+			Name:     "Severity",
+			Value:    fields["severity"].(string),
+			Selector: fmt.Sprintf("%s.severity", selector),
+		},
+		{
+			Name:     "Code",
+			Value:    fields["code"].(string),
+			Selector: fmt.Sprintf("%s.code", selector),
+		},
+		{
+			Name:     "Message",
+			Value:    fields["message"].(string),
+			Selector: fmt.Sprintf("%s.message", selector),
+		},
+		{
+			Name:     "Detail",
+			Value:    fields["detail"].(string),
+			Selector: fmt.Sprintf("%s.detail", selector),
+		},
+		{
+			Name:     "Hint",
+			Value:    fields["hint"].(string),
+			Selector: fmt.Sprintf("%s.hint", selector),
+		},
+		{
+			Name:     "Position",
+			Value:    fields["position"].(string),
+			Selector: fmt.Sprintf("%s.position", selector),
+		},
+	})
+	return string(table)
+}
+
+func representMap(values map[string]interface{}, selector string) string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var table []api.TableData
+	for _, key := range keys {
+		table = append(table, api.TableData{
+			Name:     key,
+			Value:    values[key].(string),
+			Selector: fmt.Sprintf(`%s["%s"]`, selector, key),
+		})
+	}
+
+	obj, _ := json.Marshal(table)
+	return string(obj)
+}
+
+func joinStrings(values interface{}) string {
+	_values, _ := values.([]interface{})
+	result := make([]string, 0, len(_values))
+	for _, value := range _values {
+		result = append(result, value.(string))
+	}
+	return strings.Join(result, ", ")
+}
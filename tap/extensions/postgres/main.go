@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+var protocol = api.Protocol{
+	ProtocolSummary: api.ProtocolSummary{
+		Name:         "postgres",
+		Version:      "3.0",
+		Abbreviation: "PGSQL",
+	},
+	LongName:        "PostgreSQL Frontend/Backend Protocol",
+	Macro:           "pg",
+	BackgroundColor: "#336791",
+	ForegroundColor: "#ffffff",
+	FontSize:        11,
+	ReferenceLink:   "https://www.postgresql.org/docs/current/protocol.html",
+	Ports:           []string{"5432"},
+	Priority:        5,
+}
+
+var protocolsMap = map[string]*api.Protocol{
+	protocol.ToString(): &protocol,
+}
+
+type dissecting string
+
+func (d dissecting) Register(extension *api.Extension) {
+	extension.Protocol = &protocol
+}
+
+func (d dissecting) GetProtocols() map[string]*api.Protocol {
+	return protocolsMap
+}
+
+func (d dissecting) Ping() {
+	log.Printf("pong %s", protocol.Name)
+}
+
+func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.TrafficFilteringOptions) error {
+	reqResMatcher := reader.GetReqResMatcher().(*requestResponseMatcher)
+	if reader.GetIsClient() {
+		return dissectFrontend(b, reader, reqResMatcher)
+	}
+	return dissectBackend(b, reader, reqResMatcher)
+}
+
+func (d dissecting) Analyze(item *api.OutputChannelItem, resolvedSource string, resolvedDestination string, namespace string) *api.Entry {
+	request := item.Pair.Request.Payload.(map[string]interface{})
+	response := item.Pair.Response.Payload.(map[string]interface{})
+	reqDetails := request["details"].(map[string]interface{})
+	resDetails := response["details"].(map[string]interface{})
+
+	elapsedTime := item.Pair.Response.CaptureTime.Sub(item.Pair.Request.CaptureTime).Round(time.Millisecond).Milliseconds()
+	if elapsedTime < 0 {
+		elapsedTime = 0
+	}
+	return &api.Entry{
+		Protocol: protocol.ProtocolSummary,
+		Capture:  item.Capture,
+		Source: &api.TCP{
+			Name: resolvedSource,
+			IP:   item.ConnectionInfo.ClientIP,
+			Port: item.ConnectionInfo.ClientPort,
+		},
+		Destination: &api.TCP{
+			Name: resolvedDestination,
+			IP:   item.ConnectionInfo.ServerIP,
+			Port: item.ConnectionInfo.ServerPort,
+		},
+		Namespace:    namespace,
+		Outgoing:     item.ConnectionInfo.IsOutgoing,
+		Request:      reqDetails,
+		Response:     resDetails,
+		RequestSize:  item.Pair.Request.CaptureSize,
+		ResponseSize: item.Pair.Response.CaptureSize,
+		Timestamp:    item.Timestamp,
+		StartTime:    item.Pair.Request.CaptureTime,
+		ElapsedTime:  elapsedTime,
+	}
+}
+
+func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
+	status := 0
+	statusQuery := ""
+	if api.IsUnanswered(entry.Response) {
+		statusQuery = api.UnansweredStatusQuery
+	}
+
+	method := ""
+	methodQuery := ""
+	if entry.Request["command"] != nil {
+		method = entry.Request["command"].(string)
+		methodQuery = fmt.Sprintf(`request.command == "%s"`, method)
+	}
+
+	summary := ""
+	summaryQuery := ""
+	if query, _ := entry.Request["query"].(string); query != "" {
+		summary = query
+		summaryQuery = fmt.Sprintf(`request.query == %s`, strconv.Quote(query))
+	} else if statement, _ := entry.Request["statement"].(string); statement != "" {
+		summary = statement
+		summaryQuery = fmt.Sprintf(`request.statement == "%s"`, statement)
+	} else if parameters, ok := entry.Request["startupParameters"].(map[string]interface{}); ok {
+		user, _ := parameters["user"].(string)
+		database, _ := parameters["database"].(string)
+		if database == "" {
+			database = user
+		}
+		summary = fmt.Sprintf("%s@%s", user, database)
+		summaryQuery = fmt.Sprintf(`request.startupParameters["user"] == "%s"`, user)
+	}
+
+	return &api.BaseEntry{
+		Id:           entry.Id,
+		Protocol:     *protocolsMap[entry.Protocol.ToString()],
+		Capture:      entry.Capture,
+		Summary:      summary,
+		SummaryQuery: summaryQuery,
+		Status:       status,
+		StatusQuery:  statusQuery,
+		Method:       method,
+		MethodQuery:  methodQuery,
+		Timestamp:    entry.Timestamp,
+		Source:       entry.Source,
+		Destination:  entry.Destination,
+		IsOutgoing:   entry.Outgoing,
+		Latency:      entry.ElapsedTime,
+	}
+}
+
+func (d dissecting) Represent(request map[string]interface{}, response map[string]interface{}) (object []byte, err error) {
+	representation := make(map[string]interface{})
+	repRequest := representRequest(request)
+	repResponse := api.RepresentUnanswered()
+	if !api.IsUnanswered(response) {
+		repResponse = representResponse(response)
+	}
+	representation["request"] = repRequest
+	representation["response"] = repResponse
+	object, err = json.Marshal(representation)
+	return
+}
+
+func (d dissecting) Macros() map[string]string {
+	return map[string]string{
+		`pg`: fmt.Sprintf(`protocol.name == "%s"`, protocol.Name),
+	}
+}
+
+func (d dissecting) NewResponseRequestMatcher() api.RequestResponseMatcher {
+	return createResponseRequestMatcher()
+}
+
+var Dissector dissecting
+
+func NewDissector() api.Dissector {
+	return Dissector
+}
@@ -0,0 +1,251 @@
+package postgres
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/api/apitest"
+)
+
+func TestRegister(t *testing.T) {
+	dissector := NewDissector()
+	extension := &api.Extension{}
+	dissector.Register(extension)
+	assert.Equal(t, "postgres", extension.Protocol.Name)
+}
+
+func TestMacros(t *testing.T) {
+	expectedMacros := map[string]string{
+		"pg": `protocol.name == "postgres"`,
+	}
+	dissector := NewDissector()
+	macros := dissector.Macros()
+	assert.Equal(t, expectedMacros, macros)
+}
+
+func TestPing(t *testing.T) {
+	dissector := NewDissector()
+	dissector.Ping()
+}
+
+type messageBuilder struct {
+	bytes.Buffer
+}
+
+func (m *messageBuilder) message(messageType byte, fields ...interface{}) {
+	var payload bytes.Buffer
+	for _, field := range fields {
+		switch value := field.(type) {
+		case string:
+			payload.WriteString(value)
+			payload.WriteByte(0)
+		case []byte:
+			_ = binary.Write(&payload, binary.BigEndian, int32(len(value)))
+			payload.Write(value)
+		case byte:
+			payload.WriteByte(value)
+		default:
+			_ = binary.Write(&payload, binary.BigEndian, value)
+		}
+	}
+
+	// Zero stands for the startup messages, they don't have a type byte
+	if messageType != 0 {
+		m.WriteByte(messageType)
+	}
+	_ = binary.Write(m, binary.BigEndian, int32(payload.Len()+4))
+	m.Write(payload.Bytes())
+}
+
+func frontendStream() []byte {
+	m := &messageBuilder{}
+	m.message(0, int32(sslRequestCode))
+	m.message(0, int32(protocolVersion3), "user", "orders", "database", "shop", byte(0))
+	m.message('p', "secret")
+	m.message('Q', "SELECT id, name FROM customers")
+	m.message('P', "", "INSERT INTO orders (customer_id) VALUES ($1)", int16(0))
+	m.message('B', "", "", int16(0), int16(1), []byte("42"), int16(0))
+	m.message('D', byte('P'), "")
+	m.message('E', "", int32(0))
+	m.message('S')
+	m.message('X')
+	return m.Bytes()
+}
+
+func backendStream() []byte {
+	m := &messageBuilder{}
+	m.WriteByte('N')
+	m.message('R', int32(5), [4]byte{1, 2, 3, 4})
+	m.message('R', int32(0))
+	m.message('S', "server_version", "14.2")
+	m.message('K', int32(1234), int32(5678))
+	m.message('Z', byte('I'))
+	m.message('T', int16(2),
+		"id", int32(16384), int16(1), int32(23), int16(4), int32(-1), int16(0),
+		"name", int32(16384), int16(2), int32(25), int16(-1), int32(-1), int16(0),
+	)
+	m.message('D', int16(2), []byte("1"), []byte("Alice"))
+	m.message('D', int16(2), []byte("2"), []byte("Bob"))
+	m.message('C', "SELECT 2")
+	m.message('Z', byte('I'))
+	m.message('1')
+	m.message('2')
+	m.message('n')
+	m.message('E', byte('S'), "ERROR", byte('C'), "23503", byte('M'), "insert or update on table \"orders\" violates foreign key constraint", byte(0))
+	m.message('Z', byte('I'))
+	return m.Bytes()
+}
+
+func dissectStreams(t *testing.T, client []byte, server []byte) []*api.OutputChannelItem {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 16)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	counterPair := &api.CounterPair{}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+	stream := NewTcpStream(api.Pcap)
+	options := &api.TrafficFilteringOptions{}
+
+	tcpIDClient := &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "40000", DstPort: "5432"}
+	reader := NewTcpReader(&api.ReadProgress{}, "", tcpIDClient, time.Unix(0, 0), stream, true, false, nil, emitter, counterPair, reqResMatcher)
+	err := dissector.Dissect(bufio.NewReader(bytes.NewReader(client)), reader, options)
+	assert.Equal(t, io.EOF, err)
+
+	tcpIDServer := &api.TcpID{SrcIP: "2", DstIP: "1", SrcPort: "5432", DstPort: "40000"}
+	reader = NewTcpReader(&api.ReadProgress{}, "", tcpIDServer, time.Unix(0, int64(2*time.Millisecond)), stream, false, false, nil, emitter, counterPair, reqResMatcher)
+	err = dissector.Dissect(bufio.NewReader(bytes.NewReader(server)), reader, options)
+	if server[0] == 'S' {
+		assert.NotNil(t, err)
+	} else {
+		assert.Equal(t, io.EOF, err)
+	}
+
+	close(itemChannel)
+	var items []*api.OutputChannelItem
+	for item := range itemChannel {
+		items = append(items, item)
+	}
+	return items
+}
+
+func toEntry(t *testing.T, item *api.OutputChannelItem) *api.Entry {
+	// Simulate the round trip through the JSON encoding as the items do in Mizu
+	marshaled, err := json.Marshal(item)
+	assert.Nil(t, err)
+	var unmarshaled *api.OutputChannelItem
+	err = json.Unmarshal(marshaled, &unmarshaled)
+	assert.Nil(t, err)
+
+	return NewDissector().Analyze(unmarshaled, "client", "server", "default")
+}
+
+func TestDissect(t *testing.T) {
+	dissector := NewDissector()
+	items := dissectStreams(t, frontendStream(), backendStream())
+	assert.Len(t, items, 3)
+
+	startup := toEntry(t, items[0])
+	assert.Equal(t, "STARTUP", startup.Request["command"])
+	assert.Equal(t, "MD5Password", startup.Response["authentication"])
+	assert.Equal(t, int64(2), startup.ElapsedTime)
+	summary := dissector.Summarize(startup)
+	assert.Equal(t, "orders@shop", summary.Summary)
+
+	query := toEntry(t, items[1])
+	assert.Equal(t, "Simple Query", query.Request["type"])
+	assert.Equal(t, "SELECT 2", query.Response["commandTag"])
+	assert.Equal(t, float64(2), query.Response["rowCount"])
+	assert.Equal(t, []interface{}{"1", "Alice"}, query.Response["rows"].([]interface{})[0])
+	summary = dissector.Summarize(query)
+	assert.Equal(t, "SELECT", summary.Method)
+	assert.Equal(t, `request.command == "SELECT"`, summary.MethodQuery)
+	assert.Equal(t, "SELECT id, name FROM customers", summary.Summary)
+
+	insert := toEntry(t, items[2])
+	assert.Equal(t, "Extended Query", insert.Request["type"])
+	assert.Equal(t, "INSERT", insert.Request["command"])
+	assert.Equal(t, []interface{}{"42"}, insert.Request["parameters"])
+	assert.Equal(t, "23503", insert.Response["error"].(map[string]interface{})["code"])
+	summary = dissector.Summarize(insert)
+	assert.Equal(t, `request.query == "INSERT INTO orders (customer_id) VALUES ($1)"`, summary.SummaryQuery)
+
+	for _, entry := range []*api.Entry{startup, query, insert} {
+		representation, err := dissector.Represent(entry.Request, entry.Response)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, representation)
+	}
+}
+
+func TestDissectEncrypted(t *testing.T) {
+	m := &messageBuilder{}
+	m.message(0, int32(sslRequestCode))
+
+	// The server accepts the SSLRequest and continues with the TLS ServerHello
+	items := dissectStreams(t, m.Bytes(), []byte{'S', 0x16, 0x03, 0x03, 0x00, 0x7a, 0x02})
+	assert.Len(t, items, 0)
+}
+
+func TestDissectGarbage(t *testing.T) {
+	dissector := NewDissector()
+	reader := NewTcpReader(&api.ReadProgress{}, "", &api.TcpID{}, time.Time{}, NewTcpStream(api.Pcap), true, false, nil, nil, &api.CounterPair{}, dissector.NewResponseRequestMatcher())
+	err := dissector.Dissect(bufio.NewReader(bytes.NewBufferString("GET / HTTP/1.1\r\n\r\n")), reader, &api.TrafficFilteringOptions{})
+	assert.NotNil(t, err)
+	assert.NotEqual(t, io.EOF, err)
+}
+
+func TestMalformedBind(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []interface{}
+	}{
+		{"negative format count", []interface{}{"", "", int16(-1)}},
+		{"format count past the payload", []interface{}{"", "", int16(100), int16(0)}},
+		{"negative parameter count", []interface{}{"", "", int16(0), int16(-1)}},
+		{"parameter count past the payload", []interface{}{"", "", int16(0), int16(1000), []byte("1")}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := &messageBuilder{}
+			m.message('B', test.fields...)
+			message, err := readMessage(bufio.NewReader(bytes.NewReader(m.Bytes())), frontendMessages)
+			assert.Nil(t, err)
+
+			request := &PostgresRequest{}
+			assert.Equal(t, errMalformedMessage, request.addMessage(message))
+		})
+	}
+}
+
+func TestNewUnansweredItem(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 1)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+
+	m := &messageBuilder{}
+	m.message('Q', "SELECT pg_sleep(3600)")
+	tcpID := &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "40000", DstPort: "5432"}
+	reader := NewTcpReader(&api.ReadProgress{}, "", tcpID, time.Unix(0, 0), NewTcpStream(api.Pcap), true, false, nil, emitter, &api.CounterPair{}, reqResMatcher)
+	err := dissector.Dissect(bufio.NewReader(bytes.NewReader(m.Bytes())), reader, &api.TrafficFilteringOptions{})
+	assert.Equal(t, io.EOF, err)
+
+	items := apitest.UnansweredItems(reqResMatcher)
+	assert.Len(t, items, 1)
+
+	items[0].ConnectionInfo = &api.ConnectionInfo{ClientIP: "1", ClientPort: "40000", ServerIP: "2", ServerPort: "5432"}
+	_, summary := apitest.AnalyzeUnanswered(t, dissector, items[0])
+	assert.Equal(t, "SELECT pg_sleep(3600)", summary.Summary)
+}
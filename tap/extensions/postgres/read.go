@@ -0,0 +1,336 @@
+package postgres
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	protocolVersion3   = 196608
+	cancelRequestCode  = 80877102
+	sslRequestCode     = 80877103
+	gssEncRequestCode  = 80877104
+	maxStartupLength   = 10000
+	maxMessageLength   = 1 << 24
+	messageHeaderBytes = 5
+)
+
+var errMalformedMessage = errors.New("Malformed PostgreSQL message")
+
+type PostgresMessage struct {
+	Type    byte
+	Payload []byte
+}
+
+// The startup, SSL, GSSENC and cancel requests are the only messages without a type byte.
+func readStartupMessage(b *bufio.Reader) (code uint32, payload []byte, err error) {
+	header := make([]byte, 8)
+	if _, err = io.ReadFull(b, header); err != nil {
+		return
+	}
+
+	length := binary.BigEndian.Uint32(header[0:4])
+	code = binary.BigEndian.Uint32(header[4:8])
+	if length < 8 || length > maxStartupLength {
+		err = fmt.Errorf("Invalid PostgreSQL startup message length: %d", length)
+		return
+	}
+
+	switch code {
+	case protocolVersion3, cancelRequestCode, sslRequestCode, gssEncRequestCode:
+	default:
+		err = fmt.Errorf("Unrecognized PostgreSQL startup code: %d", code)
+		return
+	}
+
+	payload = make([]byte, length-8)
+	_, err = io.ReadFull(b, payload)
+	return
+}
+
+func readMessage(b *bufio.Reader, knownTypes map[byte]string) (*PostgresMessage, error) {
+	header := make([]byte, messageHeaderBytes)
+	if _, err := io.ReadFull(b, header); err != nil {
+		return nil, err
+	}
+
+	if _, ok := knownTypes[header[0]]; !ok {
+		return nil, fmt.Errorf("Unrecognized PostgreSQL message type: %q", header[0])
+	}
+
+	length := binary.BigEndian.Uint32(header[1:])
+	if length < 4 || length > maxMessageLength {
+		return nil, fmt.Errorf("Invalid PostgreSQL message length: %d", length)
+	}
+
+	payload := make([]byte, length-4)
+	if _, err := io.ReadFull(b, payload); err != nil {
+		return nil, err
+	}
+
+	return &PostgresMessage{
+		Type:    header[0],
+		Payload: payload,
+	}, nil
+}
+
+// The server answers the SSL and GSSENC requests with a single byte instead of a message.
+// Such a byte is told apart from a regular message by the implausible length that follows it.
+func isEncryptionResponse(b *bufio.Reader) (bool, error) {
+	header, err := b.Peek(messageHeaderBytes)
+	if err != nil {
+		return false, err
+	}
+
+	switch header[0] {
+	case 'S', 'N', 'G':
+		length := binary.BigEndian.Uint32(header[1:])
+		return length < 4 || length > maxMessageLength, nil
+	default:
+		return false, nil
+	}
+}
+
+type payloadReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *payloadReader) readByte() byte {
+	if r.err != nil || r.offset+1 > len(r.data) {
+		r.err = errMalformedMessage
+		return 0
+	}
+	value := r.data[r.offset]
+	r.offset++
+	return value
+}
+
+func (r *payloadReader) readInt16() int16 {
+	if r.err != nil || r.offset+2 > len(r.data) {
+		r.err = errMalformedMessage
+		return 0
+	}
+	value := int16(binary.BigEndian.Uint16(r.data[r.offset:]))
+	r.offset += 2
+	return value
+}
+
+func (r *payloadReader) readInt32() int32 {
+	if r.err != nil || r.offset+4 > len(r.data) {
+		r.err = errMalformedMessage
+		return 0
+	}
+	value := int32(binary.BigEndian.Uint32(r.data[r.offset:]))
+	r.offset += 4
+	return value
+}
+
+func (r *payloadReader) readString() string {
+	if r.err != nil {
+		return ""
+	}
+	end := bytes.IndexByte(r.data[r.offset:], 0)
+	if end < 0 {
+		r.err = errMalformedMessage
+		return ""
+	}
+	value := string(r.data[r.offset : r.offset+end])
+	r.offset += end + 1
+	return value
+}
+
+// A length of -1 stands for NULL
+func (r *payloadReader) readValue() []byte {
+	length := r.readInt32()
+	if r.err != nil || length < 0 {
+		return nil
+	}
+	if r.offset+int(length) > len(r.data) {
+		r.err = errMalformedMessage
+		return nil
+	}
+	value := r.data[r.offset : r.offset+int(length)]
+	r.offset += int(length)
+	return value
+}
+
+func (r *payloadReader) remaining() int {
+	return len(r.data) - r.offset
+}
+
+func parseStartupParameters(payload []byte) (map[string]string, error) {
+	parameters := make(map[string]string)
+	r := &payloadReader{data: payload}
+	for r.remaining() > 1 {
+		name := r.readString()
+		value := r.readString()
+		if r.err != nil {
+			return nil, r.err
+		}
+		parameters[name] = value
+	}
+	return parameters, nil
+}
+
+// Frontend messages are folded into the request until Query or Sync completes it.
+func (request *PostgresRequest) addMessage(message *PostgresMessage) error {
+	request.Messages = append(request.Messages, frontendMessages[message.Type])
+	r := &payloadReader{data: message.Payload}
+
+	switch message.Type {
+	case 'Q':
+		request.Type = "Simple Query"
+		request.Query = r.readString()
+		request.Command = queryCommand(request.Query)
+	case 'P':
+		request.Type = "Extended Query"
+		request.Statement = r.readString()
+		query := r.readString()
+		if request.Query != "" {
+			request.Query += ";\n"
+		}
+		request.Query += query
+		if request.Command == "" {
+			request.Command = queryCommand(query)
+		}
+	case 'B':
+		request.Type = "Extended Query"
+		request.Portal = r.readString()
+		request.Statement = r.readString()
+
+		// The counts are checked against the payload before allocating, any stream is parsed while it's identified
+		formatCount := int(r.readInt16())
+		if formatCount < 0 || formatCount > r.remaining()/2 {
+			return errMalformedMessage
+		}
+		formats := make([]int16, formatCount)
+		for i := range formats {
+			formats[i] = r.readInt16()
+		}
+
+		count := int(r.readInt16())
+		if count < 0 || count > r.remaining()/2 {
+			return errMalformedMessage
+		}
+		for i := 0; i < count && r.err == nil; i++ {
+			value := r.readValue()
+			// A single format code applies to all the parameters
+			if (len(formats) == 1 && formats[0] == 1) || (len(formats) > i && formats[i] == 1) {
+				request.Parameters = append(request.Parameters, fmt.Sprintf("\\x%x", value))
+			} else {
+				request.Parameters = append(request.Parameters, formatValue(value))
+			}
+		}
+	case 'E':
+		request.Type = "Extended Query"
+		request.Portal = r.readString()
+		if request.Command == "" {
+			request.Command = "EXECUTE"
+		}
+	case 'F':
+		request.Type = "Function Call"
+		request.Command = "FUNCTION CALL"
+		request.Statement = fmt.Sprintf("%d", r.readInt32())
+	}
+
+	return r.err
+}
+
+func (response *PostgresResponse) addMessage(message *PostgresMessage) error {
+	response.Messages = append(response.Messages, backendMessages[message.Type])
+	r := &payloadReader{data: message.Payload}
+
+	switch message.Type {
+	case 'R':
+		// The first request tells the method, the rest are its continuations and the final Ok
+		method := uint32(r.readInt32())
+		if response.Authentication != "" {
+			break
+		}
+		if name, ok := authenticationMethods[method]; ok {
+			response.Authentication = name
+		} else {
+			response.Authentication = fmt.Sprintf("%d", method)
+		}
+	case 'S':
+		name := r.readString()
+		value := r.readString()
+		if response.ParameterStatus == nil {
+			response.ParameterStatus = make(map[string]string)
+		}
+		response.ParameterStatus[name] = value
+	case 'T':
+		response.Columns = make([]PostgresColumn, 0)
+		count := int(r.readInt16())
+		for i := 0; i < count && r.err == nil; i++ {
+			name := r.readString()
+			r.readInt32() // table OID
+			r.readInt16() // column attribute number
+			oid := uint32(r.readInt32())
+			r.readInt16() // type size
+			r.readInt32() // type modifier
+			r.readInt16() // format code
+			response.Columns = append(response.Columns, PostgresColumn{
+				Name: name,
+				Type: typeName(oid),
+			})
+		}
+	case 'D':
+		response.RowCount++
+		if len(response.Rows) >= maxSampleRows {
+			break
+		}
+		count := int(r.readInt16())
+		row := make([]string, 0, count)
+		for i := 0; i < count && r.err == nil; i++ {
+			row = append(row, formatValue(r.readValue()))
+		}
+		response.Rows = append(response.Rows, row)
+	case 'C':
+		response.CommandTag = r.readString()
+	case 'E':
+		response.Error = readErrorFields(r)
+	case 'N':
+		response.Notices = append(response.Notices, *readErrorFields(r))
+	case 'I':
+		response.CommandTag = "EMPTY"
+	case 'Z':
+		status := r.readByte()
+		response.TransactionStatus = transactionStatuses[status]
+	}
+
+	return r.err
+}
+
+// The fields of ErrorResponse and NoticeResponse are identified by a single byte
+func readErrorFields(r *payloadReader) *PostgresError {
+	fields := &PostgresError{}
+	for r.err == nil {
+		code := r.readByte()
+		if code == 0 {
+			break
+		}
+		value := r.readString()
+		switch code {
+		case 'S':
+			fields.Severity = value
+		case 'C':
+			fields.Code = value
+		case 'M':
+			fields.Message = value
+		case 'D':
+			fields.Detail = value
+		case 'H':
+			fields.Hint = value
+		case 'P':
+			fields.Position = value
+		}
+	}
+	return fields
+}
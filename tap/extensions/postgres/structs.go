@@ -0,0 +1,195 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxSampleRows = 10
+
+var frontendMessages = map[byte]string{
+	'B': "Bind",
+	'C': "Close",
+	'D': "Describe",
+	'E': "Execute",
+	'F': "FunctionCall",
+	'H': "Flush",
+	'P': "Parse",
+	'Q': "Query",
+	'S': "Sync",
+	'X': "Terminate",
+	'c': "CopyDone",
+	'd': "CopyData",
+	'f': "CopyFail",
+	'p': "PasswordMessage",
+}
+
+var backendMessages = map[byte]string{
+	'1': "ParseComplete",
+	'2': "BindComplete",
+	'3': "CloseComplete",
+	'A': "NotificationResponse",
+	'C': "CommandComplete",
+	'D': "DataRow",
+	'E': "ErrorResponse",
+	'G': "CopyInResponse",
+	'H': "CopyOutResponse",
+	'I': "EmptyQueryResponse",
+	'K': "BackendKeyData",
+	'N': "NoticeResponse",
+	'R': "Authentication",
+	'S': "ParameterStatus",
+	'T': "RowDescription",
+	'V': "FunctionCallResponse",
+	'W': "CopyBothResponse",
+	'Z': "ReadyForQuery",
+	'c': "CopyDone",
+	'd': "CopyData",
+	'n': "NoData",
+	's': "PortalSuspended",
+	't': "ParameterDescription",
+	'v': "NegotiateProtocolVersion",
+}
+
+var authenticationMethods = map[uint32]string{
+	0:  "Ok",
+	2:  "KerberosV5",
+	3:  "CleartextPassword",
+	5:  "MD5Password",
+	7:  "GSS",
+	8:  "GSSContinue",
+	9:  "SSPI",
+	10: "SASL",
+	11: "SASLContinue",
+	12: "SASLFinal",
+}
+
+var transactionStatuses = map[byte]string{
+	'I': "Idle",
+	'T': "In Transaction",
+	'E': "Failed Transaction",
+}
+
+var typeNames = map[uint32]string{
+	16:   "bool",
+	17:   "bytea",
+	18:   "char",
+	19:   "name",
+	20:   "int8",
+	21:   "int2",
+	23:   "int4",
+	25:   "text",
+	26:   "oid",
+	114:  "json",
+	700:  "float4",
+	701:  "float8",
+	1042: "bpchar",
+	1043: "varchar",
+	1082: "date",
+	1083: "time",
+	1114: "timestamp",
+	1184: "timestamptz",
+	1186: "interval",
+	1700: "numeric",
+	2950: "uuid",
+	3802: "jsonb",
+}
+
+type PostgresColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type PostgresError struct {
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Detail   string `json:"detail"`
+	Hint     string `json:"hint"`
+	Position string `json:"position"`
+}
+
+type PostgresRequest struct {
+	Type              string            `json:"type"`
+	Command           string            `json:"command"`
+	Query             string            `json:"query"`
+	Statement         string            `json:"statement"`
+	Portal            string            `json:"portal"`
+	Parameters        []string          `json:"parameters"`
+	StartupParameters map[string]string `json:"startupParameters"`
+	Messages          []string          `json:"messages"`
+}
+
+type PostgresResponse struct {
+	CommandTag        string            `json:"commandTag"`
+	Columns           []PostgresColumn  `json:"columns"`
+	RowCount          int               `json:"rowCount"`
+	Rows              [][]string        `json:"rows"`
+	Error             *PostgresError    `json:"error"`
+	Notices           []PostgresError   `json:"notices"`
+	Authentication    string            `json:"authentication"`
+	ParameterStatus   map[string]string `json:"parameterStatus"`
+	TransactionStatus string            `json:"transactionStatus"`
+	Messages          []string          `json:"messages"`
+}
+
+func typeName(oid uint32) string {
+	if name, ok := typeNames[oid]; ok {
+		return name
+	}
+	return fmt.Sprintf("oid %d", oid)
+}
+
+// The first keyword of the query, e.g. SELECT, INSERT or BEGIN, skipping the leading comments.
+func queryCommand(query string) string {
+	for {
+		query = strings.TrimSpace(query)
+		if strings.HasPrefix(query, "--") {
+			end := strings.IndexByte(query, '\n')
+			if end < 0 {
+				return ""
+			}
+			query = query[end:]
+		} else if strings.HasPrefix(query, "/*") {
+			end := strings.Index(query, "*/")
+			if end < 0 {
+				return ""
+			}
+			query = query[end+2:]
+		} else {
+			break
+		}
+	}
+
+	end := strings.IndexFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	if end < 0 {
+		end = len(query)
+	}
+	return strings.ToUpper(query[:end])
+}
+
+// Text format values are shown as they are, binary ones in the bytea hex notation.
+func formatValue(value []byte) string {
+	if value == nil {
+		return "NULL"
+	}
+
+	if utf8.Valid(value) {
+		printable := true
+		for _, r := range string(value) {
+			if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+				printable = false
+				break
+			}
+		}
+		if printable {
+			return string(value)
+		}
+	}
+
+	return fmt.Sprintf("\\x%x", value)
+}
@@ -8,9 +8,12 @@ import (
 )
 
 func handleClientStream(progress *api.ReadProgress, capture api.Capture, tcpID *api.TcpID, counterPair *api.CounterPair, captureTime time.Time, emitter api.Emitter, request *RedisPacket, reqResMatcher *requestResponseMatcher) error {
+	// The request takes the first of the replies it expects, the rest are paired with no request
+	replies := reqResMatcher.countReplies(request)
 	counterPair.Lock()
 	counterPair.Request++
 	requestCounter := counterPair.Request
+	counterPair.Request += uint(replies - 1)
 	counterPair.Unlock()
 
 	ident := fmt.Sprintf(
@@ -34,10 +37,29 @@ func handleClientStream(progress *api.ReadProgress, capture api.Capture, tcpID *
 		}
 		emitter.Emit(item)
 	}
+
+	for i := 1; i < replies; i++ {
+		ident := fmt.Sprintf(
+			"%s_%s_%s_%s_%d",
+			tcpID.SrcIP,
+			tcpID.DstIP,
+			tcpID.SrcPort,
+			tcpID.DstPort,
+			requestCounter+uint(i),
+		)
+		if response := reqResMatcher.registerExtraReply(ident); response != nil {
+			emitPush(capture, tcpID.SrcIP, tcpID.SrcPort, tcpID.DstIP, tcpID.DstPort, captureTime, progress.Current(), emitter, response, reqResMatcher)
+		}
+	}
 	return nil
 }
 
 func handleServerStream(progress *api.ReadProgress, capture api.Capture, tcpID *api.TcpID, counterPair *api.CounterPair, captureTime time.Time, emitter api.Emitter, response *RedisPacket, reqResMatcher *requestResponseMatcher) error {
+	if reqResMatcher.isPush(response) {
+		emitPush(capture, tcpID.DstIP, tcpID.DstPort, tcpID.SrcIP, tcpID.SrcPort, captureTime, progress.Current(), emitter, response, reqResMatcher)
+		return nil
+	}
+
 	counterPair.Lock()
 	counterPair.Response++
 	responseCounter := counterPair.Response
@@ -66,3 +88,16 @@ func handleServerStream(progress *api.ReadProgress, capture api.Capture, tcpID *
 	}
 	return nil
 }
+
+func emitPush(capture api.Capture, clientIP string, clientPort string, serverIP string, serverPort string, captureTime time.Time, captureSize int, emitter api.Emitter, push *RedisPacket, reqResMatcher *requestResponseMatcher) {
+	item := reqResMatcher.registerPush(push, captureTime, captureSize)
+	item.Capture = capture
+	item.ConnectionInfo = &api.ConnectionInfo{
+		ClientIP:   clientIP,
+		ClientPort: clientPort,
+		ServerIP:   serverIP,
+		ServerPort: serverPort,
+		IsOutgoing: false,
+	}
+	emitter.Emit(item)
+}
@@ -26,7 +26,7 @@ type RedisWrapper struct {
 }
 
 func representGeneric(generic map[string]interface{}, selectorPrefix string) (representation []interface{}) {
-	details, _ := json.Marshal([]api.TableData{
+	table := []api.TableData{
 		{
 			Name:     "Type",
 			Value:    generic["type"].(string),
@@ -47,7 +47,15 @@ func representGeneric(generic map[string]interface{}, selectorPrefix string) (re
 			Value:    generic["keyword"].(string),
 			Selector: fmt.Sprintf("%skeyword", selectorPrefix),
 		},
-	})
+	}
+	if attributes, ok := generic["attributes"].(string); ok && attributes != "" {
+		table = append(table, api.TableData{
+			Name:     "Attributes",
+			Value:    attributes,
+			Selector: fmt.Sprintf("%sattributes", selectorPrefix),
+		})
+	}
+	details, _ := json.Marshal(table)
 	representation = append(representation, api.SectionData{
 		Type:  api.TABLE,
 		Title: "Details",
@@ -44,13 +44,17 @@ func (d dissecting) Ping() {
 	log.Printf("pong %s", protocol.Name)
 }
 
+func (d dissecting) Probe(peek []byte, isClient bool) api.Confidence {
+	return probe(peek, isClient)
+}
+
 func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.TrafficFilteringOptions) error {
 	reqResMatcher := reader.GetReqResMatcher().(*requestResponseMatcher)
 	is := &RedisInputStream{
 		Reader: b,
 		Buf:    make([]byte, 8192),
 	}
-	proto := NewProtocol(is)
+	proto := NewProtocol(is, reader.GetIsClient())
 	for {
 		redisPacket, err := proto.Read()
 		if err != nil {
@@ -108,6 +112,9 @@ func (d dissecting) Analyze(item *api.OutputChannelItem, resolvedSource string,
 func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
 	status := 0
 	statusQuery := ""
+	if api.IsUnanswered(entry.Response) {
+		statusQuery = api.UnansweredStatusQuery
+	}
 
 	method := ""
 	methodQuery := ""
@@ -143,10 +150,15 @@ func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
 
 func (d dissecting) Represent(request map[string]interface{}, response map[string]interface{}) (object []byte, err error) {
 	representation := make(map[string]interface{})
-	repRequest := representGeneric(request, `request.`)
-	repResponse := representGeneric(response, `response.`)
-	representation["request"] = repRequest
-	representation["response"] = repResponse
+	representation["request"] = representGeneric(request, `request.`)
+	// The pushes of the server have no response
+	if api.IsUnanswered(response) {
+		representation["response"] = api.RepresentUnanswered()
+	} else if len(response) > 0 {
+		representation["response"] = representGeneric(response, `response.`)
+	} else {
+		representation["response"] = []interface{}{}
+	}
 	object, err = json.Marshal(representation)
 	return
 }
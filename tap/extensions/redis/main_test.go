@@ -2,6 +2,7 @@ package redis
 
 import (
 	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,11 +13,13 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/api/apitest"
 )
 
 const (
@@ -57,6 +60,28 @@ func TestPing(t *testing.T) {
 	dissector.Ping()
 }
 
+func TestProbe(t *testing.T) {
+	dissector := NewDissector().(api.Prober)
+
+	tests := []struct {
+		peek       string
+		isClient   bool
+		confidence api.Confidence
+	}{
+		{"*2\r\n$3\r\nGET\r\n", true, api.ConfidenceHigh},
+		{"*", true, api.ConfidenceLow},
+		{"PING\r\n", true, api.ConfidenceUnknown},
+		{"\x00\x00\x00\x08\x04\xd2\x16\x2f", true, api.ConfidenceNone},
+		{"+OK\r\n", false, api.ConfidenceLow},
+		{"%1\r\n+key\r\n:1\r\n", false, api.ConfidenceLow},
+		{"\x01\x00\x00", false, api.ConfidenceNone},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.confidence, dissector.Probe([]byte(test.peek), test.isClient), test.peek)
+	}
+}
+
 func TestDissect(t *testing.T) {
 	_, testUpdateEnabled := os.LookupEnv(testUpdate)
 
@@ -373,3 +398,185 @@ func TestRepresent(t *testing.T) {
 		}
 	}
 }
+
+// Encodes a command the way the clients send them, as an array of bulk strings
+func encodeCommand(args ...string) string {
+	command := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		command += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+	return command
+}
+
+func TestDissectResp3(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 16)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	options := &api.TrafficFilteringOptions{}
+	counterPair := &api.CounterPair{}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+	stream := NewTcpStream(api.Pcap)
+
+	client := strings.Join([]string{
+		encodeCommand("HELLO", "3"),
+		// Pipelined
+		encodeCommand("SET", "greeting", "hello\r\nworld"),
+		encodeCommand("GET", "greeting"),
+		encodeCommand("GET", "missing"),
+		encodeCommand("INCRBYFLOAT", "price", "0.5"),
+		encodeCommand("SUBSCRIBE", "news", "sports"),
+		encodeCommand("PING"),
+	}, "")
+
+	server := strings.Join([]string{
+		"%2\r\n+server\r\n$5\r\nredis\r\n+proto\r\n:3\r\n",
+		"+OK\r\n",
+		"$12\r\nhello\r\nworld\r\n",
+		"_\r\n",
+		",10.5\r\n",
+		">3\r\n$9\r\nsubscribe\r\n$4\r\nnews\r\n:1\r\n",
+		">3\r\n$7\r\nmessage\r\n$4\r\nnews\r\n$8\r\nheadline\r\n",
+		">3\r\n$9\r\nsubscribe\r\n$6\r\nsports\r\n:2\r\n",
+		"|1\r\n+ttl\r\n:10\r\n+PONG\r\n",
+	}, "")
+
+	for _, side := range []struct {
+		data     string
+		isClient bool
+		tcpID    *api.TcpID
+	}{
+		{client, true, &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "1", DstPort: "6379"}},
+		{server, false, &api.TcpID{SrcIP: "2", DstIP: "1", SrcPort: "6379", DstPort: "1"}},
+	} {
+		reader := NewTcpReader(&api.ReadProgress{}, "", side.tcpID, time.Time{}, stream, side.isClient, false, nil, emitter, counterPair, reqResMatcher)
+		err := dissector.Dissect(bufio.NewReader(bytes.NewReader([]byte(side.data))), reader, options)
+		assert.EqualError(t, err, io.EOF.Error())
+	}
+
+	close(itemChannel)
+	entries := make(map[string]*api.Entry)
+	for item := range itemChannel {
+		// Simulate the round trip through the JSON encoding as the items do in Mizu
+		marshaled, err := json.Marshal(item)
+		assert.Nil(t, err)
+		var unmarshaled *api.OutputChannelItem
+		err = json.Unmarshal(marshaled, &unmarshaled)
+		assert.Nil(t, err)
+		entry := dissector.Analyze(unmarshaled, "", "", "")
+
+		representation, err := dissector.Represent(entry.Request, entry.Response)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, representation)
+		summary := dissector.Summarize(entry)
+		entries[summary.Method+" "+summary.Summary] = entry
+	}
+	assert.Len(t, entries, 9)
+
+	hello := entries["HELLO 3"]
+	assert.Equal(t, "Map", hello.Response["type"])
+	assert.Equal(t, "{server: redis, proto: 3}", hello.Response["value"])
+
+	assert.Equal(t, "OK", entries["SET greeting"].Response["keyword"])
+	assert.Equal(t, "hello\r\nworld", entries["GET greeting"].Response["value"])
+	assert.Equal(t, "Null", entries["GET missing"].Response["type"])
+	assert.Equal(t, "10.5", entries["INCRBYFLOAT price"].Response["value"])
+
+	subscribe := entries["SUBSCRIBE news"]
+	assert.Equal(t, "[subscribe, news, 1]", subscribe.Response["value"])
+
+	// The confirmation of the second channel has no request of its own
+	subscribe = entries["SUBSCRIBE sports"]
+	assert.Equal(t, "Push", subscribe.Request["type"])
+	assert.Empty(t, subscribe.Response)
+
+	message := entries["MESSAGE news"]
+	assert.Equal(t, "headline", message.Request["value"])
+	assert.Equal(t, "6379", message.Destination.Port)
+	assert.False(t, message.Outgoing)
+	assert.Empty(t, message.Response)
+
+	ping := entries["PING "]
+	assert.Equal(t, "PONG", ping.Response["keyword"])
+	assert.Equal(t, "{ttl: 10}", ping.Response["attributes"])
+}
+
+func TestDissectResp2PubSub(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 16)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	options := &api.TrafficFilteringOptions{}
+	counterPair := &api.CounterPair{}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+	stream := NewTcpStream(api.Pcap)
+
+	// The server replies before the client stream is read
+	server := strings.Join([]string{
+		"*3\r\n$10\r\npsubscribe\r\n$7\r\nnews.*\r\n:1\r\n",
+		"*4\r\n$8\r\npmessage\r\n$7\r\nnews.*\r\n$9\r\nnews.tech\r\n$3\r\nai!\r\n",
+		"*3\r\n$12\r\npunsubscribe\r\n$7\r\nnews.*\r\n:0\r\n",
+	}, "")
+	client := strings.Join([]string{
+		encodeCommand("PSUBSCRIBE", "news.*"),
+		encodeCommand("PUNSUBSCRIBE"),
+	}, "")
+
+	for _, side := range []struct {
+		data     string
+		isClient bool
+		tcpID    *api.TcpID
+	}{
+		{server, false, &api.TcpID{SrcIP: "2", DstIP: "1", SrcPort: "6379", DstPort: "1"}},
+		{client, true, &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "1", DstPort: "6379"}},
+	} {
+		reader := NewTcpReader(&api.ReadProgress{}, "", side.tcpID, time.Time{}, stream, side.isClient, false, nil, emitter, counterPair, reqResMatcher)
+		err := dissector.Dissect(bufio.NewReader(bytes.NewReader([]byte(side.data))), reader, options)
+		assert.EqualError(t, err, io.EOF.Error())
+	}
+
+	close(itemChannel)
+	var summaries []*api.BaseEntry
+	for item := range itemChannel {
+		marshaled, err := json.Marshal(item)
+		assert.Nil(t, err)
+		var unmarshaled *api.OutputChannelItem
+		err = json.Unmarshal(marshaled, &unmarshaled)
+		assert.Nil(t, err)
+		summaries = append(summaries, dissector.Summarize(dissector.Analyze(unmarshaled, "", "", "")))
+	}
+	assert.Len(t, summaries, 3)
+
+	assert.Equal(t, "PMESSAGE", summaries[0].Method)
+	assert.Equal(t, "news.tech", summaries[0].Summary)
+	assert.Equal(t, "PSUBSCRIBE", summaries[1].Method)
+	assert.Equal(t, "PUNSUBSCRIBE", summaries[2].Method)
+}
+
+func TestNewUnansweredItem(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 1)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+
+	// A blocking pop waits for a push that never comes
+	tcpID := &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "1", DstPort: "6379"}
+	reader := NewTcpReader(&api.ReadProgress{}, "", tcpID, time.Time{}, NewTcpStream(api.Pcap), true, false, nil, emitter, &api.CounterPair{}, reqResMatcher)
+	err := dissector.Dissect(bufio.NewReader(bytes.NewReader([]byte(encodeCommand("BLPOP", "jobs", "0")))), reader, &api.TrafficFilteringOptions{})
+	assert.EqualError(t, err, io.EOF.Error())
+
+	items := apitest.UnansweredItems(reqResMatcher)
+	assert.Len(t, items, 1)
+
+	items[0].ConnectionInfo = &api.ConnectionInfo{ClientIP: "1", ClientPort: "1", ServerIP: "2", ServerPort: "6379"}
+	_, summary := apitest.AnalyzeUnanswered(t, dissector, items[0])
+	assert.Equal(t, "BLPOP", summary.Method)
+	assert.Equal(t, "jobs", summary.Summary)
+}
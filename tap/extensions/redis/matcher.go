@@ -1,19 +1,39 @@
 package redis
 
 import (
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/up9inc/mizu/tap/api"
 )
 
+type emptyResponse struct {
+}
+
+// Stands for a reply that is expected without a request of its own, like the confirmations
+// of all but the first channel of a SUBSCRIBE
+type extraReply struct {
+}
+
 // Key is `{src_ip}_{dst_ip}_{src_ip}_{src_port}_{incremental_counter}`
+// The replies are in the order of the commands, so the pipelined commands are paired by the counters.
+// The matchers are created per TCP stream, so they also keep the subscriptions of the connection.
 type requestResponseMatcher struct {
 	openMessagesMap *sync.Map
+	subscriptions   map[string]map[string]bool
+	sync.Mutex
 }
 
 func createResponseRequestMatcher() api.RequestResponseMatcher {
-	return &requestResponseMatcher{openMessagesMap: &sync.Map{}}
+	return &requestResponseMatcher{
+		openMessagesMap: &sync.Map{},
+		subscriptions: map[string]map[string]bool{
+			"subscribe":  {},
+			"psubscribe": {},
+			"ssubscribe": {},
+		},
+	}
 }
 
 func (matcher *requestResponseMatcher) GetMap() *sync.Map {
@@ -37,9 +57,8 @@ func (matcher *requestResponseMatcher) registerRequest(ident string, request *Re
 	}
 
 	if response, found := matcher.openMessagesMap.LoadAndDelete(ident); found {
-		// Type assertion always succeeds because all of the map's values are of api.GenericMessage type
-		responseRedisMessage := response.(*api.GenericMessage)
-		if responseRedisMessage.IsRequest {
+		responseRedisMessage, ok := response.(*api.GenericMessage)
+		if !ok || responseRedisMessage.IsRequest {
 			return nil
 		}
 		return matcher.preparePair(&requestRedisMessage, responseRedisMessage)
@@ -49,6 +68,166 @@ func (matcher *requestResponseMatcher) registerRequest(ident string, request *Re
 	return nil
 }
 
+// Returns the reply if it has arrived before the request that expects it
+func (matcher *requestResponseMatcher) registerExtraReply(ident string) *RedisPacket {
+	if response, found := matcher.openMessagesMap.LoadAndDelete(ident); found {
+		if responseRedisMessage, ok := response.(*api.GenericMessage); ok && !responseRedisMessage.IsRequest {
+			return responseRedisMessage.Payload.(RedisPayload).Data.(*RedisWrapper).Details.(*RedisPacket)
+		}
+		return nil
+	}
+
+	matcher.openMessagesMap.Store(ident, &extraReply{})
+	return nil
+}
+
+// The pushes of the server, like the pub/sub messages, are not replies, so they are paired with an empty response
+func (matcher *requestResponseMatcher) registerPush(push *RedisPacket, captureTime time.Time, captureSize int) *api.OutputChannelItem {
+	pushRedisMessage := api.GenericMessage{
+		IsRequest:   true,
+		CaptureTime: captureTime,
+		CaptureSize: captureSize,
+		Payload: RedisPayload{
+			Data: &RedisWrapper{
+				Method:  string(push.Command),
+				Url:     "",
+				Details: push,
+			},
+		},
+	}
+
+	responseRedisMessage := api.GenericMessage{
+		IsRequest:   false,
+		CaptureTime: captureTime,
+		Payload: RedisPayload{
+			Data: &RedisWrapper{
+				Method:  "",
+				Url:     "",
+				Details: &emptyResponse{},
+			},
+		},
+	}
+
+	return matcher.preparePair(&pushRedisMessage, &responseRedisMessage)
+}
+
+// Every channel of a SUBSCRIBE is confirmed by a reply of its own. The UNSUBSCRIBE
+// without arguments is confirmed once for every channel it unsubscribes from.
+func (matcher *requestResponseMatcher) countReplies(request *RedisPacket) int {
+	command := strings.ToLower(string(request.Command))
+	kind := subscriptionKind(command)
+	if kind == "" {
+		return 1
+	}
+
+	replies := len(request.elements) - 1
+	if replies < 1 && strings.Contains(command, "unsubscribe") {
+		matcher.Lock()
+		replies = len(matcher.subscriptions[kind])
+		matcher.Unlock()
+	}
+	if replies < 1 {
+		return 1
+	}
+	return replies
+}
+
+// Classifies the arrays and the pushes of the server. The confirmations of the subscriptions are replies,
+// the rest of the pushes, like the messages of the channels, are standalone.
+func (matcher *requestResponseMatcher) isPush(response *RedisPacket) bool {
+	if len(response.elements) < 2 {
+		return false
+	}
+	name, ok := response.elements[0].([]uint8)
+	if !ok {
+		return false
+	}
+	kind := strings.ToLower(string(name))
+
+	if len(response.elements) == 3 {
+		if count, ok := response.elements[2].(int64); ok && subscriptionKind(kind) != "" {
+			response.Command = RedisCommand(strings.ToUpper(kind))
+			response.Key = formatValue(response.elements[1])
+			matcher.trackSubscription(kind, response.Key, count)
+			return false
+		}
+	}
+
+	// In RESP2 only a subscribed connection receives the messages
+	if response.Type != types[pushByte] && !matcher.isSubscribed() {
+		return false
+	}
+
+	switch kind {
+	case "message", "smessage":
+		if len(response.elements) != 3 {
+			return false
+		}
+		response.Key = formatValue(response.elements[1])
+		response.Value = formatValue(response.elements[2])
+	case "pmessage":
+		if len(response.elements) != 4 {
+			return false
+		}
+		response.Key = formatValue(response.elements[2])
+		response.Value = formatValue(response.elements[3])
+	default:
+		// Like the invalidations of the client-side caching, which only RESP3 pushes
+		if response.Type != types[pushByte] {
+			return false
+		}
+		response.Value = formatValue(response.elements[1:])
+	}
+
+	response.Command = RedisCommand(strings.ToUpper(kind))
+	return true
+}
+
+func (matcher *requestResponseMatcher) trackSubscription(kind string, channel string, count int64) {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	subscriptions := matcher.subscriptions[subscriptionKind(kind)]
+	if strings.Contains(kind, "unsubscribe") {
+		delete(subscriptions, channel)
+	} else {
+		subscriptions[channel] = true
+	}
+	// The server counts all kinds of subscriptions together
+	if count == 0 {
+		for _, subscriptions := range matcher.subscriptions {
+			for channel := range subscriptions {
+				delete(subscriptions, channel)
+			}
+		}
+	}
+}
+
+func (matcher *requestResponseMatcher) isSubscribed() bool {
+	matcher.Lock()
+	defer matcher.Unlock()
+
+	for _, subscriptions := range matcher.subscriptions {
+		if len(subscriptions) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func subscriptionKind(command string) string {
+	switch command {
+	case "subscribe", "unsubscribe":
+		return "subscribe"
+	case "psubscribe", "punsubscribe":
+		return "psubscribe"
+	case "ssubscribe", "sunsubscribe":
+		return "ssubscribe"
+	default:
+		return ""
+	}
+}
+
 func (matcher *requestResponseMatcher) registerResponse(ident string, response *RedisPacket, captureTime time.Time, captureSize int) *api.OutputChannelItem {
 	responseRedisMessage := api.GenericMessage{
 		IsRequest:   false,
@@ -64,7 +243,9 @@ func (matcher *requestResponseMatcher) registerResponse(ident string, response *
 	}
 
 	if request, found := matcher.openMessagesMap.LoadAndDelete(ident); found {
-		// Type assertion always succeeds because all of the map's values are of api.GenericMessage type
+		if _, ok := request.(*extraReply); ok {
+			return matcher.registerPush(response, captureTime, captureSize)
+		}
 		requestRedisMessage := request.(*api.GenericMessage)
 		if !requestRedisMessage.IsRequest {
 			return nil
@@ -87,3 +268,11 @@ func (matcher *requestResponseMatcher) preparePair(requestRedisMessage *api.Gene
 		},
 	}
 }
+
+func (matcher *requestResponseMatcher) NewUnansweredItem(key interface{}, value interface{}) *api.OutputChannelItem {
+	request, ok := value.(*api.GenericMessage)
+	if !ok || !request.IsRequest {
+		return nil
+	}
+	return api.NewUnansweredItem(protocol, request)
+}
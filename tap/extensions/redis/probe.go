@@ -0,0 +1,48 @@
+package redis
+
+import (
+	"bytes"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+var respTypes = []byte{
+	dollarByte, asteriskByte, plusByte, minusByte, colonByte,
+	nullByte, booleanByte, doubleByte, bigNumberByte, blobErrorByte, verbatimByte, mapByte, setByte, pushByte,
+	attributeByte,
+}
+
+// The commands are arrays of bulk strings, except for the inline commands. The replies are of any type.
+func probe(peek []byte, isClient bool) api.Confidence {
+	if len(peek) == 0 {
+		return api.ConfidenceUnknown
+	}
+
+	if isClient && peek[0] == asteriskByte {
+		if isRespLength(peek[1:]) {
+			return api.ConfidenceHigh
+		}
+		return api.ConfidenceLow
+	}
+
+	if !isClient && bytes.IndexByte(respTypes, peek[0]) >= 0 {
+		return api.ConfidenceLow
+	}
+
+	// The inline commands, or the middle of a reply
+	for _, c := range peek {
+		if c < 0x20 && c != '\r' && c != '\n' && c != '\t' {
+			return api.ConfidenceNone
+		}
+	}
+	return api.ConfidenceUnknown
+}
+
+// Whether the data starts with the digits of a length and CRLF
+func isRespLength(data []byte) bool {
+	i := 0
+	for i < len(data) && data[i] >= '0' && data[i] <= '9' {
+		i++
+	}
+	return i > 0 && bytes.HasPrefix(data[i:], []byte("\r\n"))
+}
@@ -22,8 +22,28 @@ const (
 	minusByte         = '-'
 	colonByte         = ':'
 	notApplicableByte = '0'
+
+	// RESP3
+	nullByte      = '_'
+	booleanByte   = '#'
+	doubleByte    = ','
+	bigNumberByte = '('
+	blobErrorByte = '!'
+	verbatimByte  = '='
+	mapByte       = '%'
+	setByte       = '~'
+	pushByte      = '>'
+	attributeByte = '|'
 )
 
+// The key-value pairs of a RESP3 map or attribute, in the order they are sent
+type redisMap []redisMapEntry
+
+type redisMapEntry struct {
+	key   interface{}
+	value interface{}
+}
+
 // receive message from redis
 type RedisInputStream struct {
 	*bufio.Reader
@@ -115,11 +135,7 @@ func (r *RedisInputStream) readLineBytes() ([]byte, error) {
 	}
 	N := pos - r.count - 2
 	line := make([]byte, N)
-	j := 0
-	for i := r.count; i <= N; i++ {
-		line[j] = buf[i]
-		j++
-	}
+	copy(line, buf[r.count:r.count+N])
 	r.count = pos
 	return line, nil
 }
@@ -133,7 +149,7 @@ func (r *RedisInputStream) readLineBytesSlowly() ([]byte, error) {
 		}
 		b := r.Buf[r.count]
 		r.count++
-		if b == 'r' {
+		if b == '\r' {
 			err := r.ensureFill()
 			if err != nil {
 				return nil, err
@@ -194,27 +210,71 @@ func (r *RedisInputStream) readIntCrLf() (int64, error) {
 	return value, nil
 }
 
+func (r *RedisInputStream) readBytes(n int64) ([]byte, error) {
+	data := make([]byte, 0)
+	for n > 0 {
+		err := r.ensureFill()
+		if err != nil {
+			return nil, err
+		}
+		available := int64(r.limit - r.count)
+		if available > n {
+			available = n
+		}
+		data = append(data, r.Buf[r.count:r.count+int(available)]...)
+		r.count += int(available)
+		n -= available
+	}
+	return data, nil
+}
+
+func (r *RedisInputStream) readCrLf() error {
+	for _, expected := range []byte{'\r', '\n'} {
+		b, err := r.readByte()
+		if err != nil {
+			return err
+		}
+		if b != expected {
+			return newConnectError("Unexpected character!")
+		}
+	}
+	return nil
+}
+
 type RedisProtocol struct {
-	is *RedisInputStream
+	is         *RedisInputStream
+	isClient   bool
+	attributes redisMap
 }
 
-func NewProtocol(is *RedisInputStream) *RedisProtocol {
+func NewProtocol(is *RedisInputStream, isClient bool) *RedisProtocol {
 	return &RedisProtocol{
-		is: is,
+		is:       is,
+		isClient: isClient,
 	}
 }
 
 func (p *RedisProtocol) Read() (packet *RedisPacket, err error) {
+	p.attributes = nil
 	x, r, err := p.process()
 	if err != nil {
 		return
 	}
 	packet = &RedisPacket{}
 	packet.Type = r
+	if p.attributes != nil {
+		packet.Attributes = formatValue(p.attributes)
+	}
 
 	switch v := x.(type) {
 	case []interface{}:
 		array := v
+		packet.elements = array
+		// Only the requests are commands, the arrays of the server are the values of the replies
+		if !p.isClient {
+			packet.Value = formatValue(array)
+			break
+		}
 		if len(array) > 0 {
 			switch array[0].(type) {
 			case []uint8:
@@ -269,6 +329,8 @@ func (p *RedisProtocol) Read() (packet *RedisPacket, err error) {
 		packet.Value = v
 	case int64:
 		packet.Value = fmt.Sprintf("%d", v)
+	case nil, bool, float64, redisMap:
+		packet.Value = formatValue(v)
 	default:
 		msg := fmt.Sprintf("Unrecognized Redis data type: %v", reflect.TypeOf(x))
 		err = errors.New(msg)
@@ -311,6 +373,51 @@ func (p *RedisProtocol) process() (v interface{}, r RedisType, err error) {
 		v, err = p.processError()
 		r = types[minusByte]
 		return
+	case nullByte:
+		err = p.is.readCrLf()
+		r = types[nullByte]
+		return
+	case booleanByte:
+		v, err = p.processBoolean()
+		r = types[booleanByte]
+		return
+	case doubleByte:
+		v, err = p.processDouble()
+		r = types[doubleByte]
+		return
+	case bigNumberByte:
+		v, err = p.processBigNumber()
+		r = types[bigNumberByte]
+		return
+	case blobErrorByte:
+		v, err = p.processBlobError()
+		r = types[blobErrorByte]
+		return
+	case verbatimByte:
+		v, err = p.processVerbatimString()
+		r = types[verbatimByte]
+		return
+	case mapByte:
+		v, err = p.processMap()
+		r = types[mapByte]
+		return
+	case setByte:
+		v, err = p.processArray()
+		r = types[setByte]
+		return
+	case pushByte:
+		v, err = p.processArray()
+		r = types[pushByte]
+		return
+	case attributeByte:
+		// The attributes are auxiliary data of the reply that follows them
+		var attributes redisMap
+		attributes, err = p.processMap()
+		if err != nil {
+			return
+		}
+		p.attributes = append(p.attributes, attributes...)
+		return p.process()
 	default:
 		return nil, types[notApplicableByte], newConnectError(fmt.Sprintf("Unknown reply: %b", b))
 	}
@@ -320,36 +427,21 @@ func (p *RedisProtocol) processSimpleString() ([]byte, error) {
 	return p.is.readLineBytes()
 }
 
+// The bulk strings are binary safe, so they are read by their length rather than up to a CRLF
 func (p *RedisProtocol) processBulkString() ([]byte, error) {
 	l, err := p.is.readIntCrLf()
 	if err != nil {
 		return nil, newConnectError(err.Error())
 	}
-	if l == -1 {
+	if l < 0 {
 		return nil, nil
 	}
-	line := make([]byte, 0)
-	for {
-		err := p.is.ensureFill()
-		if err != nil {
-			return nil, err
-		}
-		b := p.is.Buf[p.is.count]
-		p.is.count++
-		if b == '\r' {
-			err := p.is.ensureFill()
-			if err != nil {
-				return nil, err
-			}
-			c := p.is.Buf[p.is.count]
-			p.is.count++
-			if c != '\n' {
-				return nil, newConnectError("Unexpected character!")
-			}
-			break
-		} else {
-			line = append(line, b)
-		}
+	line, err := p.is.readBytes(l)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.is.readCrLf(); err != nil {
+		return nil, err
 	}
 	return line, nil
 }
@@ -377,11 +469,87 @@ func (p *RedisProtocol) processInteger() (int64, error) {
 	return p.is.readIntCrLf()
 }
 
+func (p *RedisProtocol) processBoolean() (bool, error) {
+	line, err := p.is.readLineBytes()
+	if err != nil {
+		return false, err
+	}
+	switch string(line) {
+	case "t":
+		return true, nil
+	case "f":
+		return false, nil
+	default:
+		return false, newConnectError(fmt.Sprintf("Invalid Redis boolean: %s", line))
+	}
+}
+
+// The doubles may also be `inf`, `-inf` and `nan`, which ParseFloat accepts
+func (p *RedisProtocol) processDouble() (float64, error) {
+	line, err := p.is.readLineBytes()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(string(line), 64)
+}
+
+func (p *RedisProtocol) processBigNumber() (string, error) {
+	line, err := p.is.readLineBytes()
+	if err != nil {
+		return "", err
+	}
+	return string(line), nil
+}
+
+func (p *RedisProtocol) processBlobError() (interface{}, error) {
+	msg, err := p.processBulkString()
+	if err != nil {
+		return nil, err
+	}
+	return p.describeError(string(msg))
+}
+
+// A verbatim string is prefixed by its format, e.g. `txt:` or `mkd:`
+func (p *RedisProtocol) processVerbatimString() ([]byte, error) {
+	line, err := p.processBulkString()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) >= 4 && line[3] == ':' {
+		line = line[4:]
+	}
+	return line, nil
+}
+
+func (p *RedisProtocol) processMap() (redisMap, error) {
+	l, err := p.is.readIntCrLf()
+	if err != nil {
+		return nil, newConnectError(err.Error())
+	}
+	ret := make(redisMap, 0)
+	for i := 0; i < int(l); i++ {
+		key, _, err := p.process()
+		if err != nil {
+			return nil, err
+		}
+		value, _, err := p.process()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, redisMapEntry{key: key, value: value})
+	}
+	return ret, nil
+}
+
 func (p *RedisProtocol) processError() (interface{}, error) {
 	msg, err := p.is.readLine()
 	if err != nil {
 		return nil, newConnectError(err.Error())
 	}
+	return p.describeError(msg)
+}
+
+func (p *RedisProtocol) describeError(msg string) (interface{}, error) {
 	if strings.HasPrefix(msg, movedPrefix) {
 		host, port, slot, err := p.parseTargetHostAndSlot(msg)
 		if err != nil {
@@ -424,3 +592,37 @@ func (p *RedisProtocol) extractParts(from string) (string, string) {
 	}
 	return host, port
 }
+
+// Formats the values of the replies the way redis-cli shows them
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case []uint8:
+		return string(v)
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case error:
+		return v.Error()
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, formatValue(item))
+		}
+		return fmt.Sprintf("[%s]", strings.Join(items, ", "))
+	case redisMap:
+		items := make([]string, 0, len(v))
+		for _, entry := range v {
+			items = append(items, fmt.Sprintf("%s: %s", formatValue(entry.key), formatValue(entry.value)))
+		}
+		return fmt.Sprintf("{%s}", strings.Join(items, ", "))
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -11,6 +11,15 @@ var types = map[rune]RedisType{
 	colonByte:         "Integer",
 	minusByte:         "Error",
 	notApplicableByte: "N/A",
+	nullByte:          "Null",
+	booleanByte:       "Boolean",
+	doubleByte:        "Double",
+	bigNumberByte:     "Big Number",
+	blobErrorByte:     "Blob Error",
+	verbatimByte:      "Verbatim String",
+	mapByte:           "Map",
+	setByte:           "Set",
+	pushByte:          "Push",
 }
 
 var commands = []RedisCommand{
@@ -107,7 +116,10 @@ var commands = []RedisCommand{
 	"UNSUBSCRIBE",
 	"PSUBSCRIBE",
 	"PUNSUBSCRIBE",
+	"SSUBSCRIBE",
+	"SUNSUBSCRIBE",
 	"PUBSUB",
+	"HELLO",
 	"ZCOUNT",
 	"ZRANGEBYSCORE",
 	"ZREVRANGEBYSCORE",
@@ -198,6 +210,7 @@ var commands = []RedisCommand{
 	"XREADGROUP",
 	"XPENDING",
 	"XCLAIM",
+	"RESET",
 }
 
 var keywords = []RedisKeyword{
@@ -264,11 +277,13 @@ var keywords = []RedisKeyword{
 }
 
 type RedisPacket struct {
-	Type    RedisType    `json:"type"`
-	Command RedisCommand `json:"command"`
-	Key     string       `json:"key"`
-	Value   string       `json:"value"`
-	Keyword RedisKeyword `json:"keyword"`
+	Type       RedisType    `json:"type"`
+	Command    RedisCommand `json:"command"`
+	Key        string       `json:"key"`
+	Value      string       `json:"value"`
+	Keyword    RedisKeyword `json:"keyword"`
+	Attributes string       `json:"attributes"`
+	elements   []interface{}
 }
 
 func isValidRedisCommand(s []RedisCommand, c RedisCommand) bool {
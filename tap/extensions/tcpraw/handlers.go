@@ -0,0 +1,99 @@
+package tcpraw
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// A connection is one entry, the client to server direction is the request and the other one is the response.
+func handlePayload(reader api.TcpReader, payload *TcpRawPayload, reqResMatcher *requestResponseMatcher) {
+	tcpID := reader.GetTcpID()
+
+	var item *api.OutputChannelItem
+	var connectionInfo *api.ConnectionInfo
+
+	if reader.GetIsClient() {
+		ident := fmt.Sprintf(
+			"%s_%s_%s_%s",
+			tcpID.SrcIP,
+			tcpID.DstIP,
+			tcpID.SrcPort,
+			tcpID.DstPort,
+		)
+		item = reqResMatcher.registerRequest(ident, payload)
+		connectionInfo = &api.ConnectionInfo{
+			ClientIP:   tcpID.SrcIP,
+			ClientPort: tcpID.SrcPort,
+			ServerIP:   tcpID.DstIP,
+			ServerPort: tcpID.DstPort,
+			IsOutgoing: true,
+		}
+	} else {
+		ident := fmt.Sprintf(
+			"%s_%s_%s_%s",
+			tcpID.DstIP,
+			tcpID.SrcIP,
+			tcpID.DstPort,
+			tcpID.SrcPort,
+		)
+		item = reqResMatcher.registerResponse(ident, payload)
+		connectionInfo = &api.ConnectionInfo{
+			ClientIP:   tcpID.DstIP,
+			ClientPort: tcpID.DstPort,
+			ServerIP:   tcpID.SrcIP,
+			ServerPort: tcpID.SrcPort,
+			IsOutgoing: false,
+		}
+	}
+
+	if item != nil {
+		item.Capture = reader.GetParent().GetOrigin()
+		item.ConnectionInfo = connectionInfo
+		reader.GetEmitter().Emit(item)
+	}
+}
+
+// The connections are summarized by the tapper, so there is nothing to match
+func handleConnection(connection *api.TcpConnection, emitter api.Emitter) {
+	duration := connection.EndTime.Sub(connection.StartTime).Round(time.Millisecond).Milliseconds()
+	handshakeRtt := connection.HandshakeRtt.Round(time.Millisecond).Milliseconds()
+
+	sides := make([]api.GenericMessage, 2)
+	for i, direction := range []api.TcpConnectionDirection{connection.Client, connection.Server} {
+		captureTime := connection.StartTime
+		if i == 1 {
+			captureTime = connection.EndTime
+		}
+		sides[i] = api.GenericMessage{
+			IsRequest:   i == 0,
+			CaptureTime: captureTime,
+			CaptureSize: direction.Bytes,
+			Payload: TcpRawPayloadWrapper{
+				Data: &TcpRawWrapper{
+					Method: string(connection.Termination),
+					Url:    "",
+					Details: &TcpConnectionSide{
+						TcpConnectionDirection: direction,
+						HandshakeRtt:           handshakeRtt,
+						Duration:               duration,
+						Termination:            string(connection.Termination),
+						TerminatedBy:           connection.TerminatedBy,
+					},
+				},
+			},
+		}
+	}
+
+	emitter.Emit(&api.OutputChannelItem{
+		Protocol:       connectionProtocol,
+		Capture:        connection.Capture,
+		Timestamp:      connection.StartTime.UnixNano() / int64(time.Millisecond),
+		ConnectionInfo: connection.ConnectionInfo,
+		Pair: &api.RequestResponsePair{
+			Request:  sides[0],
+			Response: sides[1],
+		},
+	})
+}
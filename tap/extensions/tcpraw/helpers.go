@@ -0,0 +1,168 @@
+package tcpraw
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+type TcpRawPayloadWrapper struct {
+	Data interface{}
+}
+
+type TcpRawPayloader interface {
+	MarshalJSON() ([]byte, error)
+}
+
+func (h TcpRawPayloadWrapper) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Data)
+}
+
+type TcpRawWrapper struct {
+	Method  string      `json:"method"`
+	Url     string      `json:"url"`
+	Details interface{} `json:"details"`
+}
+
+func representPayload(payload map[string]interface{}, side string) (repPayload []interface{}) {
+	repPayload = make([]interface{}, 0)
+
+	details, _ := json.Marshal([]api.TableData{
+		{
+			Name:     "Bytes",
+			Value:    int64(payload["bytes"].(float64)),
+			Selector: fmt.Sprintf(`%s.bytes`, side),
+		},
+		{
+			Name:     "First Seen",
+			Value:    payload["firstSeen"].(string),
+			Selector: fmt.Sprintf(`%s.firstSeen`, side),
+		},
+		{
+			Name:     "Last Seen",
+			Value:    payload["lastSeen"].(string),
+			Selector: fmt.Sprintf(`%s.lastSeen`, side),
+		},
+		{
+			Name:     "Truncated",
+			Value:    payload["truncated"].(bool),
+			Selector: fmt.Sprintf(`%s.truncated`, side),
+		},
+	})
+	repPayload = append(repPayload, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Details",
+		Data:  string(details),
+	})
+
+	if dump, _ := payload["payload"].(string); dump != "" {
+		repPayload = append(repPayload, api.SectionData{
+			Type:     api.BODY,
+			Title:    "Payload",
+			MimeType: "text/plain",
+			Data:     dump,
+			Selector: fmt.Sprintf(`%s.payload`, side),
+		})
+	}
+
+	return
+}
+
+func summarizeConnection(entry *api.Entry) *api.BaseEntry {
+	termination := entry.Request["termination"].(string)
+	terminatedBy := entry.Request["terminatedBy"].(string)
+
+	summary := "timed out"
+	summaryQuery := fmt.Sprintf(`request.termination == "%s"`, termination)
+	switch termination {
+	case string(api.TcpTerminationRst):
+		summary = fmt.Sprintf("reset by %s", terminatedBy)
+		summaryQuery = fmt.Sprintf(`request.termination == "%s" and request.terminatedBy == "%s"`, termination, terminatedBy)
+	case string(api.TcpTerminationFin):
+		summary = fmt.Sprintf("closed by %s", terminatedBy)
+		summaryQuery = fmt.Sprintf(`request.termination == "%s" and request.terminatedBy == "%s"`, termination, terminatedBy)
+	}
+
+	method := strings.ToUpper(termination)
+	methodQuery := fmt.Sprintf(`request.termination == "%s"`, termination)
+
+	return &api.BaseEntry{
+		Id:           entry.Id,
+		Protocol:     *protocolsMap[entry.Protocol.ToString()],
+		Capture:      entry.Capture,
+		Summary:      summary,
+		SummaryQuery: summaryQuery,
+		Status:       0,
+		StatusQuery:  "",
+		Method:       method,
+		MethodQuery:  methodQuery,
+		Timestamp:    entry.Timestamp,
+		Source:       entry.Source,
+		Destination:  entry.Destination,
+		IsOutgoing:   entry.Outgoing,
+		Latency:      entry.ElapsedTime,
+	}
+}
+
+func representConnection(side map[string]interface{}, selectorPrefix string) (repSide []interface{}) {
+	repSide = make([]interface{}, 0)
+
+	table := []api.TableData{
+		{
+			Name:     "Bytes",
+			Value:    int64(side["bytes"].(float64)),
+			Selector: fmt.Sprintf(`%s.bytes`, selectorPrefix),
+		},
+		{
+			Name:     "Packets",
+			Value:    int64(side["packets"].(float64)),
+			Selector: fmt.Sprintf(`%s.packets`, selectorPrefix),
+		},
+		{
+			Name:     "Retransmissions",
+			Value:    int64(side["retransmissions"].(float64)),
+			Selector: fmt.Sprintf(`%s.retransmissions`, selectorPrefix),
+		},
+		{
+			Name:     "Out of Order",
+			Value:    int64(side["outOfOrder"].(float64)),
+			Selector: fmt.Sprintf(`%s.outOfOrder`, selectorPrefix),
+		},
+	}
+	// The connection wide values are the same on both sides
+	if selectorPrefix == "request" {
+		table = append(table, []api.TableData{
+			{
+				Name:     "Handshake RTT (ms)",
+				Value:    int64(side["handshakeRtt"].(float64)),
+				Selector: `request.handshakeRtt`,
+			},
+			{
+				Name:     "Duration (ms)",
+				Value:    int64(side["duration"].(float64)),
+				Selector: `request.duration`,
+			},
+			{
+				Name:     "Termination",
+				Value:    side["termination"].(string),
+				Selector: `request.termination`,
+			},
+			{
+				Name:     "Terminated By",
+				Value:    side["terminatedBy"].(string),
+				Selector: `request.terminatedBy`,
+			},
+		}...)
+	}
+
+	details, _ := json.Marshal(table)
+	repSide = append(repSide, api.SectionData{
+		Type:  api.TABLE,
+		Title: "Details",
+		Data:  string(details),
+	})
+
+	return
+}
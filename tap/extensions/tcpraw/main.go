@@ -0,0 +1,195 @@
+package tcpraw
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+var protocol = api.Protocol{
+	ProtocolSummary: api.ProtocolSummary{
+		Name:         "tcp",
+		Version:      "raw",
+		Abbreviation: "TCP",
+	},
+	LongName:        "Unidentified Transmission Control Protocol Payload",
+	Macro:           "tcpraw",
+	BackgroundColor: "#7a7a7a",
+	ForegroundColor: "#ffffff",
+	FontSize:        12,
+	ReferenceLink:   "https://datatracker.ietf.org/doc/html/rfc9293",
+	Ports:           []string{},
+	Priority:        9,
+}
+
+var connectionProtocol = api.Protocol{
+	ProtocolSummary: api.ProtocolSummary{
+		Name:         "tcp",
+		Version:      "connection",
+		Abbreviation: "TCP",
+	},
+	LongName:        "Transmission Control Protocol Connection",
+	Macro:           "tcpconn",
+	BackgroundColor: "#4a4a4a",
+	ForegroundColor: "#ffffff",
+	FontSize:        12,
+	ReferenceLink:   "https://datatracker.ietf.org/doc/html/rfc9293",
+	Ports:           []string{},
+	Priority:        9,
+}
+
+var protocolsMap = map[string]*api.Protocol{
+	protocol.ToString():           &protocol,
+	connectionProtocol.ToString(): &connectionProtocol,
+}
+
+type dissecting string
+
+func (d dissecting) Register(extension *api.Extension) {
+	extension.Protocol = &protocol
+}
+
+func (d dissecting) GetProtocols() map[string]*api.Protocol {
+	return protocolsMap
+}
+
+func (d dissecting) Ping() {
+	log.Printf("pong %s", protocol.Name)
+}
+
+func (d dissecting) Dissect(b *bufio.Reader, reader api.TcpReader, options *api.TrafficFilteringOptions) error {
+	// Anything is a raw TCP payload, so it's only given the streams that the other dissectors didn't identify.
+	return errors.New("Raw TCP payloads are only dissected as a fallback")
+}
+
+func (d dissecting) DissectFallback(b *bufio.Reader, reader api.TcpReader, options *api.TrafficFilteringOptions) error {
+	if !options.EnableTcpRaw {
+		return nil
+	}
+
+	reqResMatcher := reader.GetReqResMatcher().(*requestResponseMatcher)
+
+	payload, err := readPayload(b, reader)
+	if err != nil {
+		return err
+	}
+
+	handlePayload(reader, payload, reqResMatcher)
+	return nil
+}
+
+func (d dissecting) DissectConnection(connection *api.TcpConnection, emitter api.Emitter, options *api.TrafficFilteringOptions) {
+	handleConnection(connection, emitter)
+}
+
+func (d dissecting) Analyze(item *api.OutputChannelItem, resolvedSource string, resolvedDestination string, namespace string) *api.Entry {
+	request := item.Pair.Request.Payload.(map[string]interface{})
+	response := item.Pair.Response.Payload.(map[string]interface{})
+	reqDetails := request["details"].(map[string]interface{})
+	resDetails := response["details"].(map[string]interface{})
+
+	elapsedTime := item.Pair.Response.CaptureTime.Sub(item.Pair.Request.CaptureTime).Round(time.Millisecond).Milliseconds()
+	if elapsedTime < 0 {
+		elapsedTime = 0
+	}
+	return &api.Entry{
+		Protocol: item.Protocol.ProtocolSummary,
+		Capture:  item.Capture,
+		Source: &api.TCP{
+			Name: resolvedSource,
+			IP:   item.ConnectionInfo.ClientIP,
+			Port: item.ConnectionInfo.ClientPort,
+		},
+		Destination: &api.TCP{
+			Name: resolvedDestination,
+			IP:   item.ConnectionInfo.ServerIP,
+			Port: item.ConnectionInfo.ServerPort,
+		},
+		Namespace:    namespace,
+		Outgoing:     item.ConnectionInfo.IsOutgoing,
+		Request:      reqDetails,
+		Response:     resDetails,
+		RequestSize:  item.Pair.Request.CaptureSize,
+		ResponseSize: item.Pair.Response.CaptureSize,
+		Timestamp:    item.Timestamp,
+		StartTime:    item.Pair.Request.CaptureTime,
+		ElapsedTime:  elapsedTime,
+	}
+}
+
+func (d dissecting) Summarize(entry *api.Entry) *api.BaseEntry {
+	if entry.Protocol.Version == connectionProtocol.Version {
+		return summarizeConnection(entry)
+	}
+
+	sent := int64(entry.Request["bytes"].(float64))
+	summary := fmt.Sprintf("%d bytes sent", sent)
+	summaryQuery := fmt.Sprintf(`request.bytes == %d`, sent)
+	statusQuery := api.UnansweredStatusQuery
+	if !api.IsUnanswered(entry.Response) {
+		received := int64(entry.Response["bytes"].(float64))
+		summary = fmt.Sprintf("%d bytes sent, %d bytes received", sent, received)
+		summaryQuery = fmt.Sprintf(`request.bytes == %d and response.bytes == %d`, sent, received)
+		statusQuery = ""
+	}
+
+	return &api.BaseEntry{
+		Id:           entry.Id,
+		Protocol:     *protocolsMap[entry.Protocol.ToString()],
+		Capture:      entry.Capture,
+		Summary:      summary,
+		SummaryQuery: summaryQuery,
+		Status:       0,
+		StatusQuery:  statusQuery,
+		Method:       entry.Destination.Port,
+		MethodQuery:  fmt.Sprintf(`dst.port == "%s"`, entry.Destination.Port),
+		Timestamp:    entry.Timestamp,
+		Source:       entry.Source,
+		Destination:  entry.Destination,
+		IsOutgoing:   entry.Outgoing,
+		Latency:      entry.ElapsedTime,
+	}
+}
+
+func (d dissecting) Represent(request map[string]interface{}, response map[string]interface{}) (object []byte, err error) {
+	representation := make(map[string]interface{})
+	if _, ok := request["termination"]; ok {
+		representation["request"] = representConnection(request, "request")
+		representation["response"] = representConnection(response, "response")
+		object, err = json.Marshal(representation)
+		return
+	}
+
+	repRequest := representPayload(request, "request")
+	repResponse := api.RepresentUnanswered()
+	if !api.IsUnanswered(response) {
+		repResponse = representPayload(response, "response")
+	}
+	representation["request"] = repRequest
+	representation["response"] = repResponse
+	object, err = json.Marshal(representation)
+	return
+}
+
+func (d dissecting) Macros() map[string]string {
+	return map[string]string{
+		`tcp`:     fmt.Sprintf(`protocol.name == "%s"`, protocol.Name),
+		`tcpraw`:  fmt.Sprintf(`protocol.name == "%s" and protocol.version == "%s"`, protocol.Name, protocol.Version),
+		`tcpconn`: fmt.Sprintf(`protocol.name == "%s" and protocol.version == "%s"`, connectionProtocol.Name, connectionProtocol.Version),
+	}
+}
+
+func (d dissecting) NewResponseRequestMatcher() api.RequestResponseMatcher {
+	return createResponseRequestMatcher()
+}
+
+var Dissector dissecting
+
+func NewDissector() api.Dissector {
+	return Dissector
+}
@@ -0,0 +1,200 @@
+package tcpraw
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/api/apitest"
+)
+
+func TestRegister(t *testing.T) {
+	dissector := NewDissector()
+	extension := &api.Extension{}
+	dissector.Register(extension)
+	assert.Equal(t, "tcp", extension.Protocol.Name)
+}
+
+func TestMacros(t *testing.T) {
+	expectedMacros := map[string]string{
+		"tcp":     `protocol.name == "tcp"`,
+		"tcpraw":  `protocol.name == "tcp" and protocol.version == "raw"`,
+		"tcpconn": `protocol.name == "tcp" and protocol.version == "connection"`,
+	}
+	dissector := NewDissector()
+	macros := dissector.Macros()
+	assert.Equal(t, expectedMacros, macros)
+}
+
+func TestPing(t *testing.T) {
+	dissector := NewDissector()
+	dissector.Ping()
+}
+
+func TestDissect(t *testing.T) {
+	dissector := NewDissector()
+	b := bufio.NewReader(bytes.NewReader([]byte("anything")))
+	assert.NotNil(t, dissector.Dissect(b, nil, &api.TrafficFilteringOptions{}))
+}
+
+func dissectConnection(t *testing.T, clientData []byte, serverData []byte, options *api.TrafficFilteringOptions) []*api.OutputChannelItem {
+	dissector := NewDissector().(api.FallbackDissector)
+	itemChannel := make(chan *api.OutputChannelItem, 2)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	reqResMatcher := NewDissector().NewResponseRequestMatcher()
+	stream := NewTcpStream(api.Pcap)
+
+	clientID := &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "40000", DstPort: "7777"}
+	serverID := &api.TcpID{SrcIP: "2", DstIP: "1", SrcPort: "7777", DstPort: "40000"}
+
+	client := NewTcpReader(&api.ReadProgress{}, "", clientID, time.Unix(0, 0), stream, true, true, nil, emitter, &api.CounterPair{}, reqResMatcher)
+	b := bufio.NewReader(bytes.NewReader(clientData))
+	assert.Nil(t, dissector.DissectFallback(b, client, options))
+
+	server := NewTcpReader(&api.ReadProgress{}, "", serverID, time.Unix(0, int64(5*time.Millisecond)), stream, false, false, nil, emitter, &api.CounterPair{}, reqResMatcher)
+	b = bufio.NewReader(bytes.NewReader(serverData))
+	assert.Nil(t, dissector.DissectFallback(b, server, options))
+
+	close(itemChannel)
+	var items []*api.OutputChannelItem
+	for item := range itemChannel {
+		items = append(items, item)
+	}
+	return items
+}
+
+func TestDissectFallback(t *testing.T) {
+	clientData := bytes.Repeat([]byte{0xca, 0xfe}, maxPayloadDumpSize)
+	serverData := []byte("PONG\r\n")
+
+	items := dissectConnection(t, clientData, serverData, &api.TrafficFilteringOptions{EnableTcpRaw: true})
+	assert.Len(t, items, 1)
+
+	item := items[0]
+	assert.Equal(t, "1", item.ConnectionInfo.ClientIP)
+	assert.Equal(t, "7777", item.ConnectionInfo.ServerPort)
+
+	// Round-trip through JSON the same way the items travel to the API server
+	marshaled, err := json.Marshal(item)
+	assert.Nil(t, err)
+	var unmarshaled api.OutputChannelItem
+	assert.Nil(t, json.Unmarshal(marshaled, &unmarshaled))
+
+	dissector := NewDissector()
+	entry := dissector.Analyze(&unmarshaled, "", "", "")
+	assert.Equal(t, int64(5), entry.ElapsedTime)
+	assert.Equal(t, len(clientData), entry.RequestSize)
+	assert.Equal(t, float64(len(clientData)), entry.Request["bytes"])
+	assert.Equal(t, true, entry.Request["truncated"])
+	assert.Equal(t, false, entry.Response["truncated"])
+	assert.Contains(t, entry.Response["payload"], "|PONG..|")
+
+	baseEntry := dissector.Summarize(entry)
+	assert.Equal(t, "2048 bytes sent, 6 bytes received", baseEntry.Summary)
+	assert.Equal(t, "7777", baseEntry.Method)
+
+	_, err = dissector.Represent(entry.Request, entry.Response)
+	assert.Nil(t, err)
+}
+
+func TestDissectFallbackEmptyDirection(t *testing.T) {
+	items := dissectConnection(t, []byte("HELLO"), nil, &api.TrafficFilteringOptions{EnableTcpRaw: true})
+	assert.Len(t, items, 1)
+
+	marshaled, err := json.Marshal(items[0])
+	assert.Nil(t, err)
+	var unmarshaled api.OutputChannelItem
+	assert.Nil(t, json.Unmarshal(marshaled, &unmarshaled))
+
+	dissector := NewDissector()
+	entry := dissector.Analyze(&unmarshaled, "", "", "")
+	assert.Equal(t, float64(0), entry.Response["bytes"])
+
+	_, err = dissector.Represent(entry.Request, entry.Response)
+	assert.Nil(t, err)
+}
+
+func TestDissectFallbackDisabled(t *testing.T) {
+	items := dissectConnection(t, []byte("HELLO"), []byte("WORLD"), &api.TrafficFilteringOptions{})
+	assert.Len(t, items, 0)
+}
+
+func TestDissectConnection(t *testing.T) {
+	dissector := NewDissector().(api.ConnectionDissector)
+	itemChannel := make(chan *api.OutputChannelItem, 1)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+
+	connection := &api.TcpConnection{
+		ConnectionInfo: &api.ConnectionInfo{
+			ClientIP:   "1",
+			ClientPort: "40000",
+			ServerIP:   "2",
+			ServerPort: "5432",
+		},
+		Capture:      api.Pcap,
+		StartTime:    time.Unix(0, 0),
+		EndTime:      time.Unix(2, 0),
+		HandshakeRtt: 3 * time.Millisecond,
+		Client:       api.TcpConnectionDirection{Bytes: 120, Packets: 5, Retransmissions: 1},
+		Server:       api.TcpConnectionDirection{Bytes: 0, Packets: 2},
+		Termination:  api.TcpTerminationRst,
+		TerminatedBy: api.TcpServer,
+	}
+	dissector.DissectConnection(connection, emitter, &api.TrafficFilteringOptions{})
+	close(itemChannel)
+
+	item := <-itemChannel
+	assert.NotNil(t, item)
+
+	marshaled, err := json.Marshal(item)
+	assert.Nil(t, err)
+	var unmarshaled api.OutputChannelItem
+	assert.Nil(t, json.Unmarshal(marshaled, &unmarshaled))
+
+	entry := NewDissector().Analyze(&unmarshaled, "", "", "")
+	assert.Equal(t, "connection", entry.Protocol.Version)
+	assert.Equal(t, int64(2000), entry.ElapsedTime)
+	assert.Equal(t, float64(3), entry.Request["handshakeRtt"])
+	assert.Equal(t, float64(1), entry.Request["retransmissions"])
+	assert.Equal(t, 120, entry.RequestSize)
+
+	baseEntry := NewDissector().Summarize(entry)
+	assert.Equal(t, "reset by server", baseEntry.Summary)
+	assert.Equal(t, "RST", baseEntry.Method)
+	assert.Equal(t, `request.termination == "rst" and request.terminatedBy == "server"`, baseEntry.SummaryQuery)
+
+	_, err = NewDissector().Represent(entry.Request, entry.Response)
+	assert.Nil(t, err)
+}
+
+func TestNewUnansweredItem(t *testing.T) {
+	dissector := NewDissector()
+	itemChannel := make(chan *api.OutputChannelItem, 1)
+	emitter := &api.Emitting{
+		AppStats:      &api.AppStats{},
+		OutputChannel: itemChannel,
+	}
+	reqResMatcher := dissector.NewResponseRequestMatcher()
+
+	clientID := &api.TcpID{SrcIP: "1", DstIP: "2", SrcPort: "40000", DstPort: "7777"}
+	client := NewTcpReader(&api.ReadProgress{}, "", clientID, time.Unix(0, 0), NewTcpStream(api.Pcap), true, true, nil, emitter, &api.CounterPair{}, reqResMatcher)
+	b := bufio.NewReader(bytes.NewReader([]byte("HELLO")))
+	assert.Nil(t, dissector.(api.FallbackDissector).DissectFallback(b, client, &api.TrafficFilteringOptions{EnableTcpRaw: true}))
+
+	items := apitest.UnansweredItems(reqResMatcher)
+	assert.Len(t, items, 1)
+
+	items[0].ConnectionInfo = &api.ConnectionInfo{ClientIP: "1", ClientPort: "40000", ServerIP: "2", ServerPort: "7777"}
+	_, summary := apitest.AnalyzeUnanswered(t, dissector, items[0])
+	assert.Equal(t, "5 bytes sent", summary.Summary)
+}
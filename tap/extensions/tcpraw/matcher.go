@@ -0,0 +1,98 @@
+package tcpraw
+
+import (
+	"sync"
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// Key is {client_addr}_{dest_addr}_{client_port}_{dest_port}
+type requestResponseMatcher struct {
+	openMessagesMap *sync.Map
+}
+
+func createResponseRequestMatcher() api.RequestResponseMatcher {
+	return &requestResponseMatcher{openMessagesMap: &sync.Map{}}
+}
+
+func (matcher *requestResponseMatcher) GetMap() *sync.Map {
+	return matcher.openMessagesMap
+}
+
+func (matcher *requestResponseMatcher) SetMaxTry(value int) {
+}
+
+func (matcher *requestResponseMatcher) registerRequest(ident string, request *TcpRawPayload) *api.OutputChannelItem {
+	requestTcpRawMessage := api.GenericMessage{
+		IsRequest:   true,
+		CaptureTime: request.FirstSeen,
+		CaptureSize: request.Bytes,
+		Payload: TcpRawPayloadWrapper{
+			Data: &TcpRawWrapper{
+				Method:  "",
+				Url:     "",
+				Details: request,
+			},
+		},
+	}
+
+	if response, found := matcher.openMessagesMap.LoadAndDelete(ident); found {
+		// Type assertion always succeeds because all of the map's values are of api.GenericMessage type
+		responseTcpRawMessage := response.(*api.GenericMessage)
+		if responseTcpRawMessage.IsRequest {
+			return nil
+		}
+		return matcher.preparePair(&requestTcpRawMessage, responseTcpRawMessage)
+	}
+
+	matcher.openMessagesMap.Store(ident, &requestTcpRawMessage)
+	return nil
+}
+
+func (matcher *requestResponseMatcher) registerResponse(ident string, response *TcpRawPayload) *api.OutputChannelItem {
+	responseTcpRawMessage := api.GenericMessage{
+		IsRequest:   false,
+		CaptureTime: response.FirstSeen,
+		CaptureSize: response.Bytes,
+		Payload: TcpRawPayloadWrapper{
+			Data: &TcpRawWrapper{
+				Method:  "",
+				Url:     "",
+				Details: response,
+			},
+		},
+	}
+
+	if request, found := matcher.openMessagesMap.LoadAndDelete(ident); found {
+		// Type assertion always succeeds because all of the map's values are of api.GenericMessage type
+		requestTcpRawMessage := request.(*api.GenericMessage)
+		if !requestTcpRawMessage.IsRequest {
+			return nil
+		}
+		return matcher.preparePair(requestTcpRawMessage, &responseTcpRawMessage)
+	}
+
+	matcher.openMessagesMap.Store(ident, &responseTcpRawMessage)
+	return nil
+}
+
+func (matcher *requestResponseMatcher) preparePair(requestTcpRawMessage *api.GenericMessage, responseTcpRawMessage *api.GenericMessage) *api.OutputChannelItem {
+	return &api.OutputChannelItem{
+		Protocol:       protocol,
+		Timestamp:      requestTcpRawMessage.CaptureTime.UnixNano() / int64(time.Millisecond),
+		ConnectionInfo: nil,
+		Pair: &api.RequestResponsePair{
+			Request:  *requestTcpRawMessage,
+			Response: *responseTcpRawMessage,
+		},
+	}
+}
+
+func (matcher *requestResponseMatcher) NewUnansweredItem(key interface{}, value interface{}) *api.OutputChannelItem {
+	request, ok := value.(*api.GenericMessage)
+	if !ok || !request.IsRequest {
+		return nil
+	}
+	return api.NewUnansweredItem(protocol, request)
+}
@@ -0,0 +1,54 @@
+package tcpraw
+
+import (
+	"bufio"
+	"encoding/hex"
+	"io"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// Reads a direction until the connection is closed. The payload is a hexdump with an ASCII column,
+// since nothing is known about its encoding.
+func readPayload(b *bufio.Reader, reader api.TcpReader) (*TcpRawPayload, error) {
+	payload := &TcpRawPayload{}
+	head := make([]byte, 0, maxPayloadDumpSize)
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := b.Read(buf)
+		if n > 0 {
+			if payload.Bytes == 0 {
+				payload.FirstSeen = reader.GetCaptureTime()
+			}
+			payload.LastSeen = reader.GetCaptureTime()
+			payload.Bytes += n
+
+			if len(head) < maxPayloadDumpSize {
+				head = append(head, buf[:min(n, maxPayloadDumpSize-len(head))]...)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if payload.Bytes == 0 {
+		payload.FirstSeen = reader.GetCaptureTime()
+		payload.LastSeen = payload.FirstSeen
+	}
+	payload.Truncated = payload.Bytes > len(head)
+	payload.Payload = hex.Dump(head)
+
+	return payload, nil
+}
+
+func min(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,27 @@
+package tcpraw
+
+import (
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// Only the beginning of a direction is kept, the rest of it is counted
+const maxPayloadDumpSize = 1024
+
+type TcpRawPayload struct {
+	Bytes     int       `json:"bytes"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Truncated bool      `json:"truncated"`
+	Payload   string    `json:"payload"`
+}
+
+// A side of a connection, the client is the request and the server is the response
+type TcpConnectionSide struct {
+	api.TcpConnectionDirection
+	HandshakeRtt int64  `json:"handshakeRtt"`
+	Duration     int64  `json:"duration"`
+	Termination  string `json:"termination"`
+	TerminatedBy string `json:"terminatedBy"`
+}
@@ -0,0 +1,99 @@
+package tap
+
+import (
+	"runtime"
+
+	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/diagnose"
+)
+
+// GetMetrics returns the stats the tapper prints periodically as metrics, the counters are since the start
+func GetMetrics() []*api.Metric {
+	appStats := diagnose.AppStats.Totals()
+
+	metrics := []*api.Metric{
+		counter("mizu_tapper_packets_total", "Packets read from the packet sources", appStats.PacketsCount),
+		counter("mizu_tapper_processed_bytes_total", "Bytes of the packets read from the packet sources", appStats.ProcessedBytes),
+		counter("mizu_tapper_tcp_packets_total", "TCP packets read from the packet sources", appStats.TcpPacketsCount),
+		counter("mizu_tapper_udp_packets_total", "UDP packets read from the packet sources", appStats.UdpPacketsCount),
+		counter("mizu_tapper_ignored_packets_total", "Packets ignored by the filtering options", appStats.IgnoredPacketsCount),
+		counter("mizu_tapper_ignored_last_ack_packets_total", "Last ACK packets that aren't reassembled", appStats.IgnoredLastAckCount),
+		counter("mizu_tapper_throttled_packets_total", "Packets dropped while the live streams are over the limit", appStats.ThrottledPackets),
+		counter("mizu_tapper_reassembled_tcp_payloads_total", "TCP payloads handed to the dissectors", appStats.ReassembledTcpPayloadsCount),
+		counter("mizu_tapper_matched_pairs_total", "Requests and responses matched by the dissectors", appStats.MatchedPairs),
+		counter("mizu_tapper_dropped_tcp_streams_total", "TCP streams dropped since their dissectors didn't keep up", appStats.DroppedTcpStreams),
+		gauge("mizu_tapper_live_tcp_streams", "TCP streams that are being dissected", float64(appStats.LiveTcpStreams)),
+		gauge("mizu_tapper_matcher_pending_messages", "Requests and responses waiting for their pair in the matchers", float64(countPendingMessages())),
+		api.NewMetric("mizu_tapper_emit_dropped_items_total", api.CounterMetric, "Items dropped while the emit queue was full").
+			AddSample(float64(appStats.DroppedNewestItems), map[string]string{"dropped": "newest"}).
+			AddSample(float64(appStats.DroppedOldestItems), map[string]string{"dropped": "oldest"}),
+		counter("mizu_tapper_tls_connections_total", "TLS connections seen by the packet sources", appStats.TlsConnectionsCount),
+		counter("mizu_tapper_tls_chunks_total", "Chunks read from the TLS tapper", appStats.TlsChunksCount),
+		counter("mizu_tapper_tls_lost_chunks_total", "Chunks lost while the buffer of the TLS tapper was full", appStats.TlsLostChunksCount),
+		gauge("mizu_tapper_goroutines", "Goroutines of the tapper", float64(runtime.NumGoroutine())),
+	}
+
+	if emitQueue != nil {
+		metrics = append(metrics,
+			gauge("mizu_tapper_emit_queue_items", "Items waiting in the emit queue", float64(emitQueue.Len())),
+			gauge("mizu_tapper_emit_queue_capacity", "Capacity of the emit queue", float64(emitQueue.Cap())),
+		)
+	}
+
+	if diagnose.TapErrors != nil {
+		metrics = append(metrics, counter("mizu_tapper_errors_total", "Errors counted by the tapper", uint64(diagnose.TapErrors.ErrorsCount)))
+	}
+
+	if stats := diagnose.InternalStats; stats != nil {
+		metrics = append(metrics,
+			counter("mizu_tapper_ipdefrag_packets_total", "Packets that were IPv4 fragments", uint64(stats.Ipdefrag)),
+			counter("mizu_tapper_reassembly_packets_total", "Packets reassembled into TCP streams", uint64(stats.Pkt)),
+			counter("mizu_tapper_reassembly_tcp_bytes_total", "TCP payload bytes handed to the reassembly", uint64(stats.Totalsz)),
+			counter("mizu_tapper_reassembly_reassembled_bytes_total", "Bytes reassembled into TCP streams", uint64(stats.Sz)),
+			counter("mizu_tapper_reassembly_missed_bytes_total", "Bytes skipped by the reassembly", uint64(stats.MissedBytes)),
+			counter("mizu_tapper_reassembly_chunks_total", "Chunks reassembled into TCP streams", uint64(stats.Reassembled)),
+			counter("mizu_tapper_reassembly_out_of_order_packets_total", "Packets that came out of order", uint64(stats.OutOfOrderPackets)),
+			counter("mizu_tapper_reassembly_out_of_order_bytes_total", "Bytes that came out of order", uint64(stats.OutOfOrderBytes)),
+			counter("mizu_tapper_reassembly_overlap_packets_total", "Packets that overlapped the reassembled data", uint64(stats.OverlapPackets)),
+			counter("mizu_tapper_reassembly_overlap_bytes_total", "Bytes that overlapped the reassembled data", uint64(stats.OverlapBytes)),
+			gauge("mizu_tapper_reassembly_biggest_chunk_packets", "Packets of the biggest reassembled chunk", float64(stats.BiggestChunkPackets)),
+			gauge("mizu_tapper_reassembly_biggest_chunk_bytes", "Bytes of the biggest reassembled chunk", float64(stats.BiggestChunkBytes)),
+			api.NewMetric("mizu_tapper_reassembly_rejected_packets_total", api.CounterMetric, "Packets rejected by the reassembly").
+				AddSample(float64(stats.RejectFsm), map[string]string{"reason": "fsm"}).
+				AddSample(float64(stats.RejectConnFsm), map[string]string{"reason": "connection-fsm"}).
+				AddSample(float64(stats.RejectOpt), map[string]string{"reason": "options"}),
+		)
+	}
+
+	return metrics
+}
+
+func countPendingMessages() int {
+	if tcpStreams == nil {
+		return 0
+	}
+
+	pending := 0
+	tcpStreams.Range(func(_, value interface{}) bool {
+		for _, matcher := range value.(api.TcpStream).GetReqResMatchers() {
+			if matcher == nil || matcher.GetMap() == nil {
+				continue
+			}
+			matcher.GetMap().Range(func(_, _ interface{}) bool {
+				pending++
+				return true
+			})
+		}
+		return true
+	})
+
+	return pending
+}
+
+func counter(name string, help string, value uint64) *api.Metric {
+	return api.NewMetric(name, api.CounterMetric, help).AddSample(float64(value), nil)
+}
+
+func gauge(name string, help string, value float64) *api.Metric {
+	return api.NewMetric(name, api.GaugeMetric, help).AddSample(value, nil)
+}
@@ -24,7 +24,6 @@ import (
 	"github.com/up9inc/mizu/tap/api"
 	"github.com/up9inc/mizu/tap/diagnose"
 	"github.com/up9inc/mizu/tap/source"
-	"github.com/up9inc/mizu/tap/tlstapper"
 	v1 "k8s.io/api/core/v1"
 )
 
@@ -34,7 +33,7 @@ var maxcount = flag.Int64("c", -1, "Only grab this many packets, then exit")
 var decoder = flag.String("decoder", "", "Name of the decoder to use (default: guess from capture)")
 var statsevery = flag.Int("stats", 60, "Output statistics every N seconds")
 var lazy = flag.Bool("lazy", false, "If true, do lazy decoding")
-var nodefrag = flag.Bool("nodefrag", false, "If true, do not do IPv4 defrag")
+var nodefrag = flag.Bool("nodefrag", false, "If true, do not do IPv4 and IPv6 defrag")
 var checksum = flag.Bool("checksum", false, "Check TCP checksum")                                                      // global
 var nooptcheck = flag.Bool("nooptcheck", true, "Do not check TCP options (useful to ignore MSS on captures with TSO)") // global
 var ignorefsmerr = flag.Bool("ignorefsmerr", true, "Ignore TCP FSM errors")                                            // global
@@ -46,6 +45,9 @@ var hexdumppkt = flag.Bool("dumppkt", false, "Dump packet as hex")
 var procfs = flag.String("procfs", "/proc", "The procfs directory, used when mapping host volumes into a container")
 var ignoredPorts = flag.String("ignore-ports", "", "A comma separated list of ports to ignore")
 var maxLiveStreams = flag.Int("max-live-streams", 500, "Maximum live streams to handle concurrently")
+var emitQueueSize = flag.Int("emit-queue-size", 1000, "Maximum items waiting to be sent to the API server")
+var emitPolicy = flag.String("emit-policy", string(api.EmitPolicyBlock), "What to do with the items emitted while the emit queue is full: block, drop-newest or drop-oldest")
+var emitSampleRate = flag.Int("emit-sample-rate", 1, "Queue only one in every N items that drop the oldest one, the others are dropped")
 
 // capture
 var iface = flag.String("i", "en0", "Interface to read packets from")
@@ -53,9 +55,15 @@ var fname = flag.String("r", "", "Filename to read from, overrides -i")
 var snaplen = flag.Int("s", 65536, "Snap length (number of bytes max to read per packet")
 var tstype = flag.String("timestamp_type", "", "Type of timestamps to use")
 var promisc = flag.Bool("promisc", true, "Set promiscuous mode")
+var captureBackend = flag.String("capture-backend", source.DefaultCaptureBackend, "How to capture the live packets: pcap (libpcap) or af_packet (AF_PACKET ring buffers)")
+var afPacketFanout = flag.Int("af-packet-fanout", 4, "Number of the AF_PACKET sockets the packets of the host are spread on")
+var afPacketRingSize = flag.Int("af-packet-ring-size", 32*1024*1024, "Size in bytes of the ring buffer of every AF_PACKET socket")
 var staleTimeoutSeconds = flag.Int("staletimout", 120, "Max time in seconds to keep connections which don't transmit data")
 var servicemesh = flag.Bool("servicemesh", false, "Record decrypted traffic if the cluster is configured with a service mesh and with mtls")
 var tls = flag.Bool("tls", false, "Enable TLS tapper")
+var pcapDir = flag.String("pcap-dir", "", "Directory to write the packets of the tap targets to as rotating pcapng files (empty disables it)")
+var pcapMaxFileSize = flag.Int64("pcap-max-file-size", 50*1024*1024, "Size in bytes to rotate the pcap files at")
+var pcapMaxFiles = flag.Int("pcap-max-files", 10, "Number of the pcap files to keep, the oldest ones are removed")
 
 var memprofile = flag.String("memprofile", "", "Write memory profile")
 
@@ -70,22 +78,22 @@ var extensions []*api.Extension                     // global
 var filteringOptions *api.TrafficFilteringOptions   // global
 var tapTargets []v1.Pod                             // global
 var packetSourceManager *source.PacketSourceManager // global
+var pcapWriter *source.PcapWriter                   // global
 var mainPacketInputChan chan source.TcpPacketInfo   // global
-var tlsTapperInstance *tlstapper.TlsTapper          // global
+var emitQueue *api.EmitQueue                        // global
+var tcpStreams api.TcpStreamMap                     // global
 
 func StartPassiveTapper(opts *TapOpts, outputItems chan *api.OutputChannelItem, extensionsRef []*api.Extension, options *api.TrafficFilteringOptions) {
 	extensions = extensionsRef
 	filteringOptions = options
 
 	streamsMap := NewTcpStreamMap()
+	tcpStreams = streamsMap
+
+	emitQueue = newEmitQueue(outputItems)
 
 	if *tls {
-		for _, e := range extensions {
-			if e.Protocol.Name == "http" {
-				tlsTapperInstance = startTlsTapper(e, outputItems, options, streamsMap)
-				break
-			}
-		}
+		startTlsTapper(extensions, outputItems, options, streamsMap)
 	}
 
 	if GetMemoryProfilingEnabled() {
@@ -102,6 +110,32 @@ func StartPassiveTapper(opts *TapOpts, outputItems chan *api.OutputChannelItem,
 	go startPassiveTapper(streamsMap, assembler)
 }
 
+// GetInputFilename returns the pcap file the packets are read from, it's empty when they're captured live
+func GetInputFilename() string {
+	return *fname
+}
+
+// SetInputFilename sets the pcap file to read the packets from, when the tapper is embedded and doesn't parse its flags
+func SetInputFilename(filename string) {
+	*fname = filename
+}
+
+// GetPcapDir returns the directory the pcap files are written to, it's empty when they aren't written
+func GetPcapDir() string {
+	return *pcapDir
+}
+
+// ClosePcapWriter writes the buffered packets to the current pcap file and closes it, it's called when the tapper exits
+func ClosePcapWriter() {
+	if pcapWriter == nil {
+		return
+	}
+
+	if err := pcapWriter.Close(); err != nil {
+		logger.Log.Errorf("Error closing the pcap writer: %v", err)
+	}
+}
+
 func UpdateTapTargets(newTapTargets []v1.Pod) {
 	success := true
 
@@ -109,11 +143,8 @@ func UpdateTapTargets(newTapTargets []v1.Pod) {
 
 	packetSourceManager.UpdatePods(tapTargets, !*nodefrag, mainPacketInputChan)
 
-	if tlsTapperInstance != nil && os.Getenv("MIZU_GLOBAL_GOLANG_PID") == "" {
-		if err := tlstapper.UpdateTapTargets(tlsTapperInstance, &tapTargets, *procfs); err != nil {
-			tlstapper.LogError(err)
-			success = false
-		}
+	if !updateTlsTapTargets() {
+		success = false
 	}
 
 	printNewTapTargets(success)
@@ -133,6 +164,17 @@ func printNewTapTargets(success bool) {
 	}
 }
 
+func newEmitQueue(outputItems chan *api.OutputChannelItem) *api.EmitQueue {
+	policy, err := api.ParseEmitPolicy(*emitPolicy)
+	if err != nil {
+		logger.Log.Warningf("%v, falling back to %s", err, api.EmitPolicyBlock)
+		policy = api.EmitPolicyBlock
+	}
+
+	logger.Log.Infof("Emit queue options: size=%d, policy=%s, sampleRate=%d", *emitQueueSize, policy, *emitSampleRate)
+	return api.NewEmitQueue(outputItems, *emitQueueSize, policy, *emitSampleRate, &diagnose.AppStats)
+}
+
 func printPeriodicStats(cleaner *Cleaner, assembler *tcpAssembler) {
 	statsPeriod := time.Second * time.Duration(*statsevery)
 	ticker := time.NewTicker(statsPeriod)
@@ -193,6 +235,15 @@ func printPeriodicStats(cleaner *Cleaner, assembler *tcpAssembler) {
 		currentAppStats := diagnose.AppStats.DumpStats()
 		appStatsJSON, _ := json.Marshal(currentAppStats)
 		logger.Log.Infof("app stats - %v", string(appStatsJSON))
+		if currentAppStats.DroppedNewestItems > 0 || currentAppStats.DroppedOldestItems > 0 {
+			logger.Log.Warningf(
+				"emit queue - dropped newest items: %d, dropped oldest items: %d, queued items: %d/%d",
+				currentAppStats.DroppedNewestItems,
+				currentAppStats.DroppedOldestItems,
+				emitQueue.Len(),
+				emitQueue.Cap(),
+			)
+		}
 
 		// At the moment
 		logger.Log.Infof("assembler-stats: %s, packet-source-stats: %s", assembler.Dump(), packetSourceManager.Stats())
@@ -210,16 +261,26 @@ func initializePacketSources() error {
 	}
 
 	behaviour := source.TcpPacketSourceBehaviour{
-		SnapLength:  *snaplen,
-		Promisc:     *promisc,
-		Tstype:      *tstype,
-		DecoderName: *decoder,
-		Lazy:        *lazy,
-		BpfFilter:   bpffilter,
+		SnapLength:       *snaplen,
+		Promisc:          *promisc,
+		Tstype:           *tstype,
+		DecoderName:      *decoder,
+		Lazy:             *lazy,
+		BpfFilter:        bpffilter,
+		Backend:          *captureBackend,
+		AfPacketFanout:   *afPacketFanout,
+		AfPacketRingSize: *afPacketRingSize,
+	}
+
+	if *pcapDir != "" && pcapWriter == nil {
+		var err error
+		if pcapWriter, err = source.NewPcapWriter(*pcapDir, *pcapMaxFileSize, *pcapMaxFiles); err != nil {
+			logger.Log.Errorf("Error initializing the pcap writer, the packets won't be written to pcap files: %v", err)
+		}
 	}
 
 	var err error
-	packetSourceManager, err = source.NewPacketSourceManager(*procfs, *fname, *iface, *servicemesh, tapTargets, behaviour, !*nodefrag, mainPacketInputChan)
+	packetSourceManager, err = source.NewPacketSourceManager(*procfs, *fname, *iface, *servicemesh, tapTargets, behaviour, !*nodefrag, mainPacketInputChan, pcapWriter)
 	return err
 }
 
@@ -251,6 +312,7 @@ func startPassiveTapper(streamsMap api.TcpStreamMap, assembler *tcpAssembler) {
 		cleanPeriod:       cleanPeriod,
 		connectionTimeout: staleConnectionTimeout,
 		streamsMap:        streamsMap,
+		emitter:           assembler.streamFactory.emitter,
 	}
 	cleaner.start()
 
@@ -258,6 +320,9 @@ func startPassiveTapper(streamsMap api.TcpStreamMap, assembler *tcpAssembler) {
 
 	assembler.processPackets(*hexdumppkt, mainPacketInputChan)
 
+	// The packets stopped, at the end of the file or on SIGINT
+	ClosePcapWriter()
+
 	if diagnose.TapErrors.OutputLevel >= 2 {
 		assembler.dumpStreamPool()
 	}
@@ -268,56 +333,19 @@ func startPassiveTapper(streamsMap api.TcpStreamMap, assembler *tcpAssembler) {
 
 	assembler.waitAndDump()
 
+	// All the packets of the file were processed, so the requests that are left unanswered are emitted
+	// and the output channel is closed to tell the consumers there are no more items
+	if *fname != "" && !isTlsTapperStarted() {
+		cleaner.stop()
+		cleaner.clean()
+		emitQueue.Close()
+	}
+
 	diagnose.InternalStats.PrintStatsSummary()
 	diagnose.TapErrors.PrintSummary()
 	logger.Log.Infof("AppStats: %v", diagnose.AppStats)
 }
 
-func startTlsTapper(extension *api.Extension, outputItems chan *api.OutputChannelItem,
-	options *api.TrafficFilteringOptions, streamsMap api.TcpStreamMap) *tlstapper.TlsTapper {
-	tls := tlstapper.TlsTapper{}
-	chunksBufferSize := os.Getpagesize() * 100
-	logBufferSize := os.Getpagesize()
-
-	if err := tls.Init(chunksBufferSize, logBufferSize, *procfs, extension); err != nil {
-		tlstapper.LogError(err)
-		return nil
-	}
-
-	if err := tlstapper.UpdateTapTargets(&tls, &tapTargets, *procfs); err != nil {
-		tlstapper.LogError(err)
-		return nil
-	}
-
-	// A quick way to instrument libssl.so without PID filtering - used for debuging and troubleshooting
-	//
-	if os.Getenv("MIZU_GLOBAL_SSL_LIBRARY") != "" {
-		if err := tls.GlobalSSLLibTap(os.Getenv("MIZU_GLOBAL_SSL_LIBRARY")); err != nil {
-			tlstapper.LogError(err)
-			return nil
-		}
-	}
-
-	// A quick way to instrument Go `crypto/tls` without PID filtering - used for debuging and troubleshooting
-	//
-	if os.Getenv("MIZU_GLOBAL_GOLANG_PID") != "" {
-		if err := tls.GlobalGoTap(*procfs, os.Getenv("MIZU_GLOBAL_GOLANG_PID")); err != nil {
-			tlstapper.LogError(err)
-			return nil
-		}
-	}
-
-	var emitter api.Emitter = &api.Emitting{
-		AppStats:      &diagnose.AppStats,
-		OutputChannel: outputItems,
-	}
-
-	go tls.PollForLogging()
-	go tls.Poll(emitter, options, streamsMap)
-
-	return &tls
-}
-
 func buildIgnoredPortsList(ignoredPorts string) []uint16 {
 	tmp := strings.Split(ignoredPorts, ",")
 	result := make([]uint16, len(tmp))
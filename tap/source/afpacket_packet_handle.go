@@ -0,0 +1,336 @@
+//go:build linux
+
+package source
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/up9inc/mizu/logger"
+	"golang.org/x/net/bpf"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	afPacketBlockSize      = 1 << 20
+	afPacketFrameSize      = 1 << 11
+	afPacketBlockTimeout   = 50 * time.Millisecond
+	afPacketPollTimeout    = 100 * time.Millisecond
+	afPacketLinuxSLLLength = 16
+	// The link layer address of a packet follows its header, aligned to TPACKET_ALIGNMENT
+	afPacketSockaddrOffset = (int(unsafe.Sizeof(unix.Tpacket3Hdr{})) + unix.TPACKET_ALIGNMENT - 1) &^ (unix.TPACKET_ALIGNMENT - 1)
+	afPacketAnyInterface   = "any"
+)
+
+/* afPacketPacketHandle captures the packets with a TPACKET_V3 ring buffer, which is mapped to the memory of the tapper,
+ * so the packets are read from the blocks the kernel fills without a system call for every packet.
+ * The socket is a datagram socket, so the packets are captured on every kind of interface the same way,
+ * and they're read as Linux cooked captures, like the packets libpcap captures on the any interface.
+ * The sockets of the tapper on the same interface join a fanout group, which spreads the flows between them.
+ * The kernel allocates the id of the group for the first socket, so the groups of the tappers on the same node never mix.
+ */
+type afPacketPacketHandle struct {
+	sync.Mutex
+	fd         int
+	ring       []byte
+	blocks     int
+	block      int
+	packets    uint32
+	offset     uint32
+	snapLength int
+	stats      PacketSourceStats
+	closed     bool
+	// fanoutGroup is the id of the fanout group the socket joined, the other sockets of the tapper join it too
+	fanoutGroup int
+}
+
+func newAfPacketHandle(interfaceName string, behaviour TcpPacketSourceBehaviour) (packetHandle, error) {
+	if behaviour.Tstype != "" {
+		logger.Log.Warningf("The timestamp type %q is ignored by the %s capture backend", behaviour.Tstype, AfPacketBackend)
+	}
+
+	// The socket receives nothing until it's bound, after its ring buffer and its filter are set up
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		return nil, fmt.Errorf("could not create an AF_PACKET socket: %v", err)
+	}
+
+	handle := &afPacketPacketHandle{fd: fd, snapLength: behaviour.SnapLength}
+	if err := handle.setup(interfaceName, behaviour); err != nil {
+		handle.Close()
+		return nil, err
+	}
+
+	return handle, nil
+}
+
+func (h *afPacketPacketHandle) setup(interfaceName string, behaviour TcpPacketSourceBehaviour) error {
+	if err := unix.SetsockoptInt(h.fd, unix.SOL_PACKET, unix.PACKET_VERSION, unix.TPACKET_V3); err != nil {
+		return fmt.Errorf("could not set TPACKET_V3: %v", err)
+	}
+
+	// The snap length is applied by the filter, which captures every packet until SetBPFFilter is called
+	if err := h.SetBPFFilter(""); err != nil {
+		return err
+	}
+
+	h.blocks = behaviour.AfPacketRingSize / afPacketBlockSize
+	if h.blocks < 1 {
+		h.blocks = 1
+	}
+	request := unix.TpacketReq3{
+		Block_size:     afPacketBlockSize,
+		Block_nr:       uint32(h.blocks),
+		Frame_size:     afPacketFrameSize,
+		Frame_nr:       uint32(h.blocks * afPacketBlockSize / afPacketFrameSize),
+		Retire_blk_tov: uint32(afPacketBlockTimeout / time.Millisecond),
+	}
+	if err := unix.SetsockoptTpacketReq3(h.fd, unix.SOL_PACKET, unix.PACKET_RX_RING, &request); err != nil {
+		return fmt.Errorf("could not set up the ring buffer: %v", err)
+	}
+
+	ring, err := unix.Mmap(h.fd, 0, h.blocks*afPacketBlockSize, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	if err != nil {
+		return fmt.Errorf("could not map the ring buffer: %v", err)
+	}
+	h.ring = ring
+
+	interfaceIndex := 0
+	if interfaceName != afPacketAnyInterface {
+		networkInterface, err := net.InterfaceByName(interfaceName)
+		if err != nil {
+			return fmt.Errorf("could not find interface %s: %v", interfaceName, err)
+		}
+		interfaceIndex = networkInterface.Index
+	}
+
+	if err := unix.Bind(h.fd, &unix.SockaddrLinklayer{Protocol: htons(unix.ETH_P_ALL), Ifindex: interfaceIndex}); err != nil {
+		return fmt.Errorf("could not bind to interface %s: %v", interfaceName, err)
+	}
+
+	// Like libpcap, the promiscuous mode can't be set on the any interface
+	if behaviour.Promisc && interfaceIndex != 0 {
+		membership := unix.PacketMreq{Ifindex: int32(interfaceIndex), Type: unix.PACKET_MR_PROMISC}
+		if err := unix.SetsockoptPacketMreq(h.fd, unix.SOL_PACKET, unix.PACKET_ADD_MEMBERSHIP, &membership); err != nil {
+			return fmt.Errorf("could not set promisc mode: %v", err)
+		}
+	}
+
+	if behaviour.AfPacketFanout > 1 {
+		if err := h.joinFanoutGroup(behaviour.afPacketFanoutGroup, behaviour.afPacketFanoutCreated); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// joinFanoutGroup joins an existing fanout group, or a new one with an id the kernel allocates
+func (h *afPacketPacketHandle) joinFanoutGroup(group int, created bool) error {
+	// The flows are hashed symmetrically, and the fragments are defragmented before they're hashed
+	typeFlags := unix.PACKET_FANOUT_HASH | unix.PACKET_FANOUT_FLAG_DEFRAG
+	if !created {
+		// The id must be 0 for the kernel to allocate one
+		group = 0
+		typeFlags |= unix.PACKET_FANOUT_FLAG_UNIQUEID
+	}
+
+	if err := unix.SetsockoptInt(h.fd, unix.SOL_PACKET, unix.PACKET_FANOUT, group|typeFlags<<16); err != nil {
+		if !created {
+			return fmt.Errorf("could not create a fanout group with a unique id: %v", err)
+		}
+		return fmt.Errorf("could not join fanout group %d: %v", group, err)
+	}
+
+	fanout, err := unix.GetsockoptInt(h.fd, unix.SOL_PACKET, unix.PACKET_FANOUT)
+	if err != nil {
+		return fmt.Errorf("could not read the fanout group: %v", err)
+	}
+	h.fanoutGroup = fanout & 0xffff
+
+	return nil
+}
+
+func (h *afPacketPacketHandle) FanoutGroup() int {
+	return h.fanoutGroup
+}
+
+func htons(value uint16) uint16 {
+	var network [2]byte
+	binary.BigEndian.PutUint16(network[:], value)
+	return *(*uint16)(unsafe.Pointer(&network[0]))
+}
+
+func toSockFilters(instructions []bpf.Instruction) ([]unix.SockFilter, error) {
+	raw, err := bpf.Assemble(instructions)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := make([]unix.SockFilter, 0, len(raw))
+	for _, instruction := range raw {
+		filters = append(filters, unix.SockFilter{Code: instruction.Op, Jt: instruction.Jt, Jf: instruction.Jf, K: instruction.K})
+	}
+	return filters, nil
+}
+
+func (h *afPacketPacketHandle) blockHeader(block int) *unix.TpacketHdrV1 {
+	// The header of a block follows the version and the private data offset of its description
+	return (*unix.TpacketHdrV1)(unsafe.Pointer(&h.ring[block*afPacketBlockSize+8]))
+}
+
+func (h *afPacketPacketHandle) ReadPacketData() (data []byte, ci gopacket.CaptureInfo, err error) {
+	for {
+		var ok bool
+		if data, ci, ok, err = h.nextPacket(); ok || err != nil {
+			return
+		}
+	}
+}
+
+// nextPacket reads the next packet, or waits for the kernel to fill a block, the lock is released between the waits
+func (h *afPacketPacketHandle) nextPacket() (data []byte, ci gopacket.CaptureInfo, ok bool, err error) {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.closed {
+		return nil, ci, false, io.EOF
+	}
+
+	header := h.blockHeader(h.block)
+	if h.packets == 0 {
+		if atomic.LoadUint32(&header.Block_status)&unix.TP_STATUS_USER == 0 {
+			return nil, ci, false, h.poll()
+		}
+
+		h.packets = header.Num_pkts
+		h.offset = header.Offset_to_first_pkt
+		if h.packets == 0 {
+			h.releaseBlock(header)
+			return nil, ci, false, nil
+		}
+	}
+
+	data, ci = h.readPacket(h.block*afPacketBlockSize + int(h.offset))
+	h.packets--
+	if h.packets == 0 {
+		h.releaseBlock(header)
+	} else {
+		packetHeader := (*unix.Tpacket3Hdr)(unsafe.Pointer(&h.ring[h.block*afPacketBlockSize+int(h.offset)]))
+		h.offset += packetHeader.Next_offset
+	}
+
+	return data, ci, true, nil
+}
+
+// readPacket copies a packet out of the ring buffer, after a Linux cooked capture header
+func (h *afPacketPacketHandle) readPacket(position int) ([]byte, gopacket.CaptureInfo) {
+	packetHeader := (*unix.Tpacket3Hdr)(unsafe.Pointer(&h.ring[position]))
+	address := (*unix.RawSockaddrLinklayer)(unsafe.Pointer(&h.ring[position+afPacketSockaddrOffset]))
+
+	data := make([]byte, afPacketLinuxSLLLength+int(packetHeader.Snaplen))
+	binary.BigEndian.PutUint16(data[0:], uint16(address.Pkttype))
+	binary.BigEndian.PutUint16(data[2:], address.Hatype)
+	binary.BigEndian.PutUint16(data[4:], uint16(address.Halen))
+	copy(data[6:14], address.Addr[:])
+	// The protocol is in network byte order already
+	*(*uint16)(unsafe.Pointer(&data[14])) = address.Protocol
+	start := position + int(packetHeader.Mac)
+	copy(data[afPacketLinuxSLLLength:], h.ring[start:start+int(packetHeader.Snaplen)])
+
+	return data, gopacket.CaptureInfo{
+		Timestamp:      time.Unix(int64(packetHeader.Sec), int64(packetHeader.Nsec)),
+		CaptureLength:  len(data),
+		Length:         afPacketLinuxSLLLength + int(packetHeader.Len),
+		InterfaceIndex: int(address.Ifindex),
+	}
+}
+
+func (h *afPacketPacketHandle) releaseBlock(header *unix.TpacketHdrV1) {
+	atomic.StoreUint32(&header.Block_status, unix.TP_STATUS_KERNEL)
+	h.packets = 0
+	h.block = (h.block + 1) % h.blocks
+}
+
+// poll waits for the kernel to fill a block, the lock is held meanwhile so the ring buffer isn't unmapped
+func (h *afPacketPacketHandle) poll() error {
+	fds := []unix.PollFd{{Fd: int32(h.fd), Events: unix.POLLIN | unix.POLLERR}}
+	if _, err := unix.Poll(fds, int(afPacketPollTimeout/time.Millisecond)); err != nil && err != unix.EINTR {
+		return err
+	}
+	return nil
+}
+
+func (h *afPacketPacketHandle) LinkType() layers.LinkType {
+	return layers.LinkTypeLinuxSLL
+}
+
+func (h *afPacketPacketHandle) SetBPFFilter(expr string) error {
+	instructions, err := compileBPFFilter(expr, bpfCookedLayout, h.snapLength)
+	if err != nil {
+		return err
+	}
+
+	program, err := toSockFilters(instructions)
+	if err != nil {
+		return err
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	if h.closed {
+		return fmt.Errorf("the handle is closed")
+	}
+
+	return unix.SetsockoptSockFprog(h.fd, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, &unix.SockFprog{
+		Len:    uint16(len(program)),
+		Filter: &program[0],
+	})
+}
+
+func (h *afPacketPacketHandle) Stats() (*PacketSourceStats, error) {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.closed {
+		return nil, fmt.Errorf("the handle is closed")
+	}
+
+	// The kernel resets its counters whenever they're read, so they're accumulated
+	stats, err := unix.GetsockoptTpacketStatsV3(h.fd, unix.SOL_PACKET, unix.PACKET_STATISTICS)
+	if err != nil {
+		return nil, err
+	}
+	h.stats.PacketsReceived += int(stats.Packets)
+	h.stats.PacketsDropped += int(stats.Drops)
+
+	result := h.stats
+	return &result, nil
+}
+
+func (h *afPacketPacketHandle) Close() {
+	h.Lock()
+	defer h.Unlock()
+
+	if h.closed {
+		return
+	}
+	h.closed = true
+
+	if h.ring != nil {
+		if err := unix.Munmap(h.ring); err != nil {
+			logger.Log.Warningf("Error unmapping the ring buffer: %v", err)
+		}
+		h.ring = nil
+	}
+	unix.Close(h.fd)
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package source
+
+import "fmt"
+
+func newAfPacketHandle(interfaceName string, behaviour TcpPacketSourceBehaviour) (packetHandle, error) {
+	return nil, fmt.Errorf("the %s capture backend is only supported on Linux", AfPacketBackend)
+}
@@ -0,0 +1,57 @@
+//go:build linux
+
+package source
+
+import (
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+func newTestFanoutGroup(t *testing.T, fanout int) []int {
+	behaviour := TcpPacketSourceBehaviour{
+		SnapLength:       65536,
+		Backend:          AfPacketBackend,
+		AfPacketFanout:   fanout,
+		AfPacketRingSize: afPacketBlockSize,
+	}
+
+	sources, err := newHostPacketSources("", "lo", behaviour)
+	if err != nil {
+		t.Fatalf("error opening the AF_PACKET sockets: %v", err)
+	}
+
+	groups := make([]int, 0, len(sources))
+	for _, source := range sources {
+		t.Cleanup(source.close)
+		groups = append(groups, source.handle.(fanoutPacketHandle).FanoutGroup())
+	}
+	return groups
+}
+
+// Two tappers on the same node, during a rolling update or in two sessions, must not share a fanout group
+func TestAfPacketFanoutGroups(t *testing.T) {
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		t.Skipf("the AF_PACKET sockets require CAP_NET_RAW: %v", err)
+	}
+	unix.Close(fd)
+
+	first := newTestFanoutGroup(t, 3)
+	second := newTestFanoutGroup(t, 3)
+
+	for _, groups := range [][]int{first, second} {
+		if len(groups) != 3 {
+			t.Fatalf("expected 3 sockets, got %d", len(groups))
+		}
+		for _, group := range groups {
+			if group != groups[0] {
+				t.Errorf("expected the sockets of a tapper in a single fanout group, got %v", groups)
+			}
+		}
+	}
+
+	if first[0] == second[0] {
+		t.Errorf("expected the tappers in different fanout groups, both got %d", first[0])
+	}
+}
@@ -0,0 +1,488 @@
+package source
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/bpf"
+)
+
+/* The kernel filters of the AF_PACKET sockets can't be compiled by libpcap without cgo,
+ * so the filter expressions are compiled here to classic BPF programs.
+ * Only the part of the pcap-filter syntax that the tapper uses is supported:
+ * the and, or and not operators (and and or have the same precedence, like in libpcap), parentheses,
+ * the ip, ip6, tcp and udp protocols and the host and port primitives with an optional src or dst direction.
+ */
+
+const (
+	bpfMaxInstructions = 4096
+	bpfMaxSkip         = 255
+
+	etherTypeIPv4 = uint32(layers.EthernetTypeIPv4)
+	etherTypeIPv6 = uint32(layers.EthernetTypeIPv6)
+)
+
+// bpfLayout tells where the headers of a packet are, the programs are compiled for a layout
+type bpfLayout struct {
+	loadEtherType bpf.Instruction
+	networkOffset uint32
+}
+
+var (
+	// The packets of an AF_PACKET datagram socket start at their network header and the kernel tells their protocol
+	bpfCookedLayout   = bpfLayout{loadEtherType: bpf.LoadExtension{Num: bpf.ExtProto}, networkOffset: 0}
+	bpfEthernetLayout = bpfLayout{loadEtherType: bpf.LoadAbsolute{Off: 12, Size: 2}, networkOffset: 14}
+	bpfLinuxSLLLayout = bpfLayout{loadEtherType: bpf.LoadAbsolute{Off: 14, Size: 2}, networkOffset: 16}
+)
+
+func bpfLayoutOf(linkType layers.LinkType) (bpfLayout, error) {
+	switch linkType {
+	case layers.LinkTypeEthernet:
+		return bpfEthernetLayout, nil
+	case layers.LinkTypeLinuxSLL:
+		return bpfLinuxSLLLayout, nil
+	default:
+		return bpfLayout{}, fmt.Errorf("BPF filters aren't supported on link type %v", linkType)
+	}
+}
+
+// compileBPFFilter compiles a filter expression to a program that keeps snapLength bytes of the matching packets
+func compileBPFFilter(expr string, layout bpfLayout, snapLength int) ([]bpf.Instruction, error) {
+	compiler := &bpfCompiler{layout: layout}
+	match, noMatch := compiler.newLabel(), compiler.newLabel()
+
+	if strings.TrimSpace(expr) != "" {
+		parser := &bpfParser{tokens: tokenizeBPFFilter(expr)}
+		node, err := parser.parseExpression()
+		if err != nil {
+			return nil, fmt.Errorf("unsupported BPF filter %q: %v", expr, err)
+		}
+		if token := parser.peek(); token != "" {
+			return nil, fmt.Errorf("unsupported BPF filter %q: unexpected %q", expr, token)
+		}
+		node(compiler, match, noMatch)
+	}
+
+	compiler.placeLabel(match)
+	compiler.emit(bpf.RetConstant{Val: uint32(snapLength)})
+	compiler.placeLabel(noMatch)
+	compiler.emit(bpf.RetConstant{Val: 0})
+
+	instructions := compiler.assemble()
+	if len(instructions) > bpfMaxInstructions {
+		return nil, fmt.Errorf("the BPF filter %q compiles to %d instructions, the limit is %d", expr, len(instructions), bpfMaxInstructions)
+	}
+
+	return instructions, nil
+}
+
+func tokenizeBPFFilter(expr string) []string {
+	expr = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(expr)
+	return strings.Fields(expr)
+}
+
+// bpfNode generates the code of an expression, which jumps to the match label or to the noMatch label
+type bpfNode func(c *bpfCompiler, match int, noMatch int)
+
+type bpfParser struct {
+	tokens   []string
+	position int
+}
+
+func (p *bpfParser) peek() string {
+	if p.position == len(p.tokens) {
+		return ""
+	}
+	return p.tokens[p.position]
+}
+
+func (p *bpfParser) next() string {
+	token := p.peek()
+	if token != "" {
+		p.position++
+	}
+	return token
+}
+
+func (p *bpfParser) parseExpression() (bpfNode, error) {
+	node, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		switch p.peek() {
+		case "and", "&&":
+			p.next()
+			right, err := p.parseTerm()
+			if err != nil {
+				return nil, err
+			}
+			node = bpfAnd(node, right)
+		case "or", "||":
+			p.next()
+			right, err := p.parseTerm()
+			if err != nil {
+				return nil, err
+			}
+			node = bpfOr(node, right)
+		default:
+			return node, nil
+		}
+	}
+}
+
+func (p *bpfParser) parseTerm() (bpfNode, error) {
+	switch token := p.next(); token {
+	case "not", "!":
+		node, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		return bpfNot(node), nil
+	case "(":
+		node, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing != ")" {
+			return nil, fmt.Errorf("expected ) instead of %q", closing)
+		}
+		return node, nil
+	case "":
+		return nil, fmt.Errorf("unexpected end of the expression")
+	default:
+		p.position--
+		return p.parsePrimitive()
+	}
+}
+
+func (p *bpfParser) parsePrimitive() (bpfNode, error) {
+	var protocol bpfNode
+	switch p.peek() {
+	case "ip":
+		protocol = bpfEtherType(etherTypeIPv4)
+	case "ip6":
+		protocol = bpfEtherType(etherTypeIPv6)
+	case "tcp":
+		protocol = bpfIPProtocol(uint32(layers.IPProtocolTCP))
+	case "udp":
+		protocol = bpfIPProtocol(uint32(layers.IPProtocolUDP))
+	}
+	if protocol != nil {
+		p.next()
+		switch p.peek() {
+		case "src", "dst", "host", "port":
+		default:
+			return protocol, nil
+		}
+	}
+
+	direction := ""
+	if token := p.peek(); token == "src" || token == "dst" {
+		direction = p.next()
+	}
+
+	qualifier := p.next()
+	if qualifier != "host" && qualifier != "port" {
+		return nil, fmt.Errorf("unexpected %q", qualifier)
+	}
+
+	// The value can be negated as well, "port not 443" is "not port 443"
+	negated := false
+	for p.peek() == "not" || p.peek() == "!" {
+		p.next()
+		negated = !negated
+	}
+
+	var node bpfNode
+	value := p.next()
+	switch qualifier {
+	case "host":
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return nil, fmt.Errorf("%q isn't an IP address", value)
+		}
+		node = bpfHost(ip, direction)
+	case "port":
+		port, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("%q isn't a port", value)
+		}
+		node = bpfPort(uint32(port), direction)
+	}
+
+	if negated {
+		node = bpfNot(node)
+	}
+	if protocol != nil {
+		node = bpfAnd(protocol, node)
+	}
+	return node, nil
+}
+
+func bpfAnd(left bpfNode, right bpfNode) bpfNode {
+	return func(c *bpfCompiler, match int, noMatch int) {
+		rightLabel := c.newLabel()
+		left(c, rightLabel, noMatch)
+		c.placeLabel(rightLabel)
+		right(c, match, noMatch)
+	}
+}
+
+func bpfOr(left bpfNode, right bpfNode) bpfNode {
+	return func(c *bpfCompiler, match int, noMatch int) {
+		rightLabel := c.newLabel()
+		left(c, match, rightLabel)
+		c.placeLabel(rightLabel)
+		right(c, match, noMatch)
+	}
+}
+
+func bpfNot(node bpfNode) bpfNode {
+	return func(c *bpfCompiler, match int, noMatch int) {
+		node(c, noMatch, match)
+	}
+}
+
+func bpfEtherType(etherType uint32) bpfNode {
+	return func(c *bpfCompiler, match int, noMatch int) {
+		c.emit(c.layout.loadEtherType)
+		c.jumpIf(bpf.JumpEqual, etherType, match, noMatch)
+	}
+}
+
+// bpfLoadNetwork loads from an offset in the network header
+func bpfLoadNetwork(c *bpfCompiler, offset uint32, size int) {
+	c.emit(bpf.LoadAbsolute{Off: c.layout.networkOffset + offset, Size: size})
+}
+
+// bpfJumpIfAny jumps to match if the loaded value is one of the values
+func bpfJumpIfAny(c *bpfCompiler, values []uint32, match int, noMatch int) {
+	for i, value := range values {
+		next := noMatch
+		if i < len(values)-1 {
+			next = c.newLabel()
+		}
+		c.jumpIf(bpf.JumpEqual, value, match, next)
+		if next != noMatch {
+			c.placeLabel(next)
+		}
+	}
+}
+
+// bpfIPProtocol matches the transport protocol of IPv4 and IPv6 packets
+func bpfIPProtocol(protocol uint32) bpfNode {
+	return bpfOr(
+		bpfAnd(bpfEtherType(etherTypeIPv4), func(c *bpfCompiler, match int, noMatch int) {
+			bpfLoadNetwork(c, 9, 1)
+			c.jumpIf(bpf.JumpEqual, protocol, match, noMatch)
+		}),
+		bpfAnd(bpfEtherType(etherTypeIPv6), func(c *bpfCompiler, match int, noMatch int) {
+			bpfLoadNetwork(c, 6, 1)
+			c.jumpIf(bpf.JumpEqual, protocol, match, noMatch)
+		}),
+	)
+}
+
+func bpfHost(ip net.IP, direction string) bpfNode {
+	etherType, srcOffset, dstOffset := etherTypeIPv6, uint32(8), uint32(24)
+	address := ip.To16()
+	if ip4 := ip.To4(); ip4 != nil {
+		etherType, srcOffset, dstOffset = etherTypeIPv4, 12, 16
+		address = ip4
+	}
+
+	words := make([]uint32, 0, len(address)/4)
+	for i := 0; i < len(address); i += 4 {
+		words = append(words, uint32(address[i])<<24|uint32(address[i+1])<<16|uint32(address[i+2])<<8|uint32(address[i+3]))
+	}
+
+	addressAt := func(offset uint32) bpfNode {
+		return func(c *bpfCompiler, match int, noMatch int) {
+			for i, word := range words {
+				next := match
+				if i < len(words)-1 {
+					next = c.newLabel()
+				}
+				bpfLoadNetwork(c, offset+uint32(i*4), 4)
+				c.jumpIf(bpf.JumpEqual, word, next, noMatch)
+				if next != match {
+					c.placeLabel(next)
+				}
+			}
+		}
+	}
+
+	var node bpfNode
+	switch direction {
+	case "src":
+		node = addressAt(srcOffset)
+	case "dst":
+		node = addressAt(dstOffset)
+	default:
+		node = bpfOr(addressAt(srcOffset), addressAt(dstOffset))
+	}
+
+	return bpfAnd(bpfEtherType(etherType), node)
+}
+
+// bpfPort matches the TCP, UDP and SCTP packets from or to a port, like libpcap the IPv4 fragments after the first one don't match
+func bpfPort(port uint32, direction string) bpfNode {
+	protocols := []uint32{uint32(layers.IPProtocolTCP), uint32(layers.IPProtocolUDP), uint32(layers.IPProtocolSCTP)}
+
+	portAt := func(load func(c *bpfCompiler, offset uint32)) bpfNode {
+		srcPort := func(c *bpfCompiler, match int, noMatch int) {
+			load(c, 0)
+			c.jumpIf(bpf.JumpEqual, port, match, noMatch)
+		}
+		dstPort := func(c *bpfCompiler, match int, noMatch int) {
+			load(c, 2)
+			c.jumpIf(bpf.JumpEqual, port, match, noMatch)
+		}
+
+		switch direction {
+		case "src":
+			return srcPort
+		case "dst":
+			return dstPort
+		default:
+			return bpfOr(srcPort, dstPort)
+		}
+	}
+
+	ip4 := bpfAnd(bpfEtherType(etherTypeIPv4), func(c *bpfCompiler, match int, noMatch int) {
+		isTransport, isFirstFragment := c.newLabel(), c.newLabel()
+		bpfLoadNetwork(c, 9, 1)
+		bpfJumpIfAny(c, protocols, isTransport, noMatch)
+		c.placeLabel(isTransport)
+		bpfLoadNetwork(c, 6, 2)
+		c.jumpIf(bpf.JumpBitsSet, 0x1fff, noMatch, isFirstFragment)
+		c.placeLabel(isFirstFragment)
+		// The transport header follows the options of the IPv4 header
+		c.emit(bpf.LoadMemShift{Off: c.layout.networkOffset})
+		portAt(func(c *bpfCompiler, offset uint32) {
+			c.emit(bpf.LoadIndirect{Off: c.layout.networkOffset + offset, Size: 2})
+		})(c, match, noMatch)
+	})
+
+	ip6 := bpfAnd(bpfEtherType(etherTypeIPv6), func(c *bpfCompiler, match int, noMatch int) {
+		isTransport := c.newLabel()
+		bpfLoadNetwork(c, 6, 1)
+		bpfJumpIfAny(c, protocols, isTransport, noMatch)
+		c.placeLabel(isTransport)
+		portAt(func(c *bpfCompiler, offset uint32) {
+			bpfLoadNetwork(c, 40+offset, 2)
+		})(c, match, noMatch)
+	})
+
+	return bpfOr(ip4, ip6)
+}
+
+/* bpfCompiler collects the code with symbolic labels, the labels are resolved to skips by assemble.
+ * Every label is placed after the jumps to it, so all the jumps are forward like BPF requires.
+ */
+type bpfCompiler struct {
+	layout bpfLayout
+	items  []bpfItem
+	labels int
+}
+
+// bpfItem is either an instruction, a jump to labels or the place of a label
+type bpfItem struct {
+	instruction bpf.Instruction
+	jump        *bpfJump
+	label       int
+}
+
+type bpfJump struct {
+	condition bpf.JumpTest
+	value     uint32
+	match     int
+	noMatch   int
+	// far jumps are assembled to unconditional jumps, since the skips of a conditional jump are at most 255
+	far bool
+}
+
+func (c *bpfCompiler) newLabel() int {
+	c.labels++
+	return c.labels
+}
+
+func (c *bpfCompiler) placeLabel(label int) {
+	c.items = append(c.items, bpfItem{label: label})
+}
+
+func (c *bpfCompiler) emit(instruction bpf.Instruction) {
+	c.items = append(c.items, bpfItem{instruction: instruction})
+}
+
+func (c *bpfCompiler) jumpIf(condition bpf.JumpTest, value uint32, match int, noMatch int) {
+	c.items = append(c.items, bpfItem{jump: &bpfJump{condition: condition, value: value, match: match, noMatch: noMatch}})
+}
+
+func (c *bpfCompiler) assemble() []bpf.Instruction {
+	positions := make([]uint32, len(c.items))
+	labelPositions := make(map[int]uint32)
+
+	// A far jump makes the code longer, which may make other jumps far, so the positions are computed until they're stable
+	for {
+		position := uint32(0)
+		for i, item := range c.items {
+			positions[i] = position
+			switch {
+			case item.jump != nil && item.jump.far:
+				position += 3
+			case item.jump != nil:
+				position++
+			case item.instruction != nil:
+				position++
+			default:
+				labelPositions[item.label] = position
+			}
+		}
+
+		stable := true
+		for i, item := range c.items {
+			if item.jump == nil || item.jump.far {
+				continue
+			}
+			next := positions[i] + 1
+			if labelPositions[item.jump.match]-next > bpfMaxSkip || labelPositions[item.jump.noMatch]-next > bpfMaxSkip {
+				item.jump.far = true
+				stable = false
+			}
+		}
+
+		if stable {
+			break
+		}
+	}
+
+	instructions := make([]bpf.Instruction, 0, len(c.items))
+	for i, item := range c.items {
+		switch {
+		case item.jump != nil && item.jump.far:
+			instructions = append(instructions,
+				bpf.JumpIf{Cond: item.jump.condition, Val: item.jump.value, SkipTrue: 0, SkipFalse: 1},
+				bpf.Jump{Skip: labelPositions[item.jump.match] - (positions[i] + 2)},
+				bpf.Jump{Skip: labelPositions[item.jump.noMatch] - (positions[i] + 3)},
+			)
+		case item.jump != nil:
+			next := positions[i] + 1
+			instructions = append(instructions, bpf.JumpIf{
+				Cond:      item.jump.condition,
+				Val:       item.jump.value,
+				SkipTrue:  uint8(labelPositions[item.jump.match] - next),
+				SkipFalse: uint8(labelPositions[item.jump.noMatch] - next),
+			})
+		case item.instruction != nil:
+			instructions = append(instructions, item.instruction)
+		}
+	}
+
+	return instructions
+}
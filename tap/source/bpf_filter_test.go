@@ -0,0 +1,314 @@
+package source
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"golang.org/x/net/bpf"
+	v1 "k8s.io/api/core/v1"
+)
+
+const testSnapLength = 65536
+
+type testPacket struct {
+	src      string
+	dst      string
+	protocol layers.IPProtocol
+	srcPort  uint16
+	dstPort  uint16
+	// fragOffset is the offset of an IPv4 fragment in 8 bytes units
+	fragOffset uint16
+	options    bool
+}
+
+func (p testPacket) String() string {
+	return fmt.Sprintf("%v %s:%d > %s:%d (fragment offset %d, options %v)", p.protocol, p.src, p.srcPort, p.dst, p.dstPort, p.fragOffset, p.options)
+}
+
+func (p testPacket) isIPv4() bool {
+	return net.ParseIP(p.src).To4() != nil
+}
+
+// network serializes the packet from its network header
+func (p testPacket) network(t *testing.T) []byte {
+	var transport gopacket.SerializableLayer
+	var network gopacket.NetworkLayer
+	if p.isIPv4() {
+		ip := &layers.IPv4{
+			Version:    4,
+			TTL:        64,
+			Protocol:   p.protocol,
+			SrcIP:      net.ParseIP(p.src),
+			DstIP:      net.ParseIP(p.dst),
+			FragOffset: p.fragOffset,
+		}
+		if p.options {
+			ip.Options = []layers.IPv4Option{{OptionType: 7, OptionLength: 7, OptionData: []byte{4, 0, 0, 0, 0}}}
+		}
+		network = ip
+	} else {
+		network = &layers.IPv6{
+			Version:    6,
+			HopLimit:   64,
+			NextHeader: p.protocol,
+			SrcIP:      net.ParseIP(p.src),
+			DstIP:      net.ParseIP(p.dst),
+		}
+	}
+
+	switch p.protocol {
+	case layers.IPProtocolTCP:
+		tcp := &layers.TCP{SrcPort: layers.TCPPort(p.srcPort), DstPort: layers.TCPPort(p.dstPort), SYN: true, Window: 1024}
+		if err := tcp.SetNetworkLayerForChecksum(network); err != nil {
+			t.Fatal(err)
+		}
+		transport = tcp
+	case layers.IPProtocolUDP:
+		udp := &layers.UDP{SrcPort: layers.UDPPort(p.srcPort), DstPort: layers.UDPPort(p.dstPort)}
+		if err := udp.SetNetworkLayerForChecksum(network); err != nil {
+			t.Fatal(err)
+		}
+		transport = udp
+	default:
+		t.Fatalf("unsupported protocol %v", p.protocol)
+	}
+
+	buffer := gopacket.NewSerializeBuffer()
+	options := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buffer, options, network.(gopacket.SerializableLayer), transport, gopacket.Payload("payload")); err != nil {
+		t.Fatal(err)
+	}
+	return buffer.Bytes()
+}
+
+func (p testPacket) etherType() layers.EthernetType {
+	if p.isIPv4() {
+		return layers.EthernetTypeIPv4
+	}
+	return layers.EthernetTypeIPv6
+}
+
+type testLinkLayer struct {
+	name   string
+	layout func(p testPacket) bpfLayout
+	frame  func(p testPacket, network []byte) []byte
+}
+
+var testLinkLayers = []testLinkLayer{
+	{
+		name:   "ethernet",
+		layout: func(p testPacket) bpfLayout { return bpfEthernetLayout },
+		frame: func(p testPacket, network []byte) []byte {
+			header := make([]byte, 14)
+			copy(header[0:6], []byte{0x02, 0, 0, 0, 0, 0x02})
+			copy(header[6:12], []byte{0x02, 0, 0, 0, 0, 0x01})
+			binary.BigEndian.PutUint16(header[12:14], uint16(p.etherType()))
+			return append(header, network...)
+		},
+	},
+	{
+		name:   "linux sll",
+		layout: func(p testPacket) bpfLayout { return bpfLinuxSLLLayout },
+		frame: func(p testPacket, network []byte) []byte {
+			header := make([]byte, 16)
+			binary.BigEndian.PutUint16(header[0:2], uint16(layers.LinuxSLLPacketTypeHost))
+			binary.BigEndian.PutUint16(header[2:4], 1)
+			binary.BigEndian.PutUint16(header[4:6], 6)
+			copy(header[6:12], []byte{0x02, 0, 0, 0, 0, 0x01})
+			binary.BigEndian.PutUint16(header[14:16], uint16(p.etherType()))
+			return append(header, network...)
+		},
+	},
+	{
+		// The VM has no protocol extension, the protocol the kernel tells is loaded as a constant instead
+		name: "cooked",
+		layout: func(p testPacket) bpfLayout {
+			layout := bpfCookedLayout
+			layout.loadEtherType = bpf.LoadConstant{Dst: bpf.RegA, Val: uint32(p.etherType())}
+			return layout
+		},
+		frame: func(p testPacket, network []byte) []byte {
+			return network
+		},
+	},
+}
+
+func runBPFFilter(t *testing.T, expr string, link testLinkLayer, packet testPacket) bool {
+	instructions, err := compileBPFFilter(expr, link.layout(packet), testSnapLength)
+	if err != nil {
+		t.Fatalf("error compiling %q: %v", expr, err)
+	}
+
+	vm, err := bpf.NewVM(instructions)
+	if err != nil {
+		t.Fatalf("error loading the program of %q: %v", expr, err)
+	}
+
+	accepted, err := vm.Run(link.frame(packet, packet.network(t)))
+	if err != nil {
+		t.Fatalf("error running the program of %q: %v", expr, err)
+	}
+	return accepted > 0
+}
+
+func TestCompileBPFFilter(t *testing.T) {
+	tcpPacket := func(src string, srcPort uint16, dst string, dstPort uint16) testPacket {
+		return testPacket{src: src, dst: dst, protocol: layers.IPProtocolTCP, srcPort: srcPort, dstPort: dstPort}
+	}
+	udpPacket := func(src string, srcPort uint16, dst string, dstPort uint16) testPacket {
+		return testPacket{src: src, dst: dst, protocol: layers.IPProtocolUDP, srcPort: srcPort, dstPort: dstPort}
+	}
+	withOptions := func(packet testPacket) testPacket {
+		packet.options = true
+		return packet
+	}
+	fragment := func(packet testPacket, offset uint16) testPacket {
+		packet.fragOffset = offset
+		return packet
+	}
+
+	tests := []struct {
+		expr     string
+		packet   testPacket
+		expected bool
+	}{
+		{"", tcpPacket("10.0.0.1", 1234, "10.0.0.2", 80), true},
+		{"ip", tcpPacket("10.0.0.1", 1234, "10.0.0.2", 80), true},
+		{"ip", tcpPacket("fd00::1", 1234, "fd00::2", 80), false},
+		{"ip6", tcpPacket("fd00::1", 1234, "fd00::2", 80), true},
+		{"tcp", tcpPacket("10.0.0.1", 1234, "10.0.0.2", 80), true},
+		{"tcp", udpPacket("10.0.0.1", 1234, "10.0.0.2", 53), false},
+		{"udp", udpPacket("fd00::1", 1234, "fd00::2", 53), true},
+
+		{"host 10.0.0.1", tcpPacket("10.0.0.1", 1234, "10.0.0.2", 80), true},
+		{"host 10.0.0.1", tcpPacket("10.0.0.2", 80, "10.0.0.1", 1234), true},
+		{"host 10.0.0.1", tcpPacket("10.0.0.2", 1234, "10.0.0.3", 80), false},
+		// The IPv6 address ends with the IPv4 one
+		{"host 10.0.0.1", tcpPacket("fd00::a00:1", 1234, "fd00::2", 80), false},
+		{"src host 10.0.0.1", tcpPacket("10.0.0.2", 80, "10.0.0.1", 1234), false},
+		{"dst host 10.0.0.1", tcpPacket("10.0.0.2", 80, "10.0.0.1", 1234), true},
+		{"host fd00::1", tcpPacket("fd00::1", 1234, "fd00::2", 80), true},
+		{"host fd00::1", tcpPacket("fd00::2", 80, "fd00::1", 1234), true},
+		// Only the last word of the address differs
+		{"host fd00::1", tcpPacket("fd00::3", 1234, "fd00::2", 80), false},
+		{"host fd00::1", tcpPacket("fe00::1", 1234, "fd00::2", 80), false},
+		{"host fd00::1", tcpPacket("10.0.0.1", 1234, "10.0.0.2", 80), false},
+		{"src host fd00::1", tcpPacket("fd00::2", 80, "fd00::1", 1234), false},
+
+		{"port 80", tcpPacket("10.0.0.1", 1234, "10.0.0.2", 80), true},
+		{"port 80", udpPacket("10.0.0.1", 80, "10.0.0.2", 1234), true},
+		{"port 80", tcpPacket("fd00::1", 1234, "fd00::2", 80), true},
+		{"port 80", tcpPacket("10.0.0.1", 1234, "10.0.0.2", 8080), false},
+		{"dst port 80", tcpPacket("10.0.0.1", 80, "10.0.0.2", 1234), false},
+		{"src port 80", tcpPacket("10.0.0.1", 80, "10.0.0.2", 1234), true},
+		// The transport header follows the options
+		{"port 80", withOptions(tcpPacket("10.0.0.1", 1234, "10.0.0.2", 80)), true},
+		{"port 1234", withOptions(tcpPacket("10.0.0.1", 1234, "10.0.0.2", 80)), true},
+		{"port 8080", withOptions(tcpPacket("10.0.0.1", 1234, "10.0.0.2", 80)), false},
+
+		{"port not 443", tcpPacket("10.0.0.1", 1234, "10.0.0.2", 80), true},
+		{"port not 443", tcpPacket("10.0.0.1", 1234, "10.0.0.2", 443), false},
+		{"port not 443", tcpPacket("10.0.0.2", 443, "10.0.0.1", 1234), false},
+		{"port not 443", udpPacket("10.0.0.1", 1234, "10.0.0.2", 443), false},
+		{"port not 443", tcpPacket("fd00::1", 1234, "fd00::2", 443), false},
+		{"port not 443", tcpPacket("fd00::1", 1234, "fd00::2", 80), true},
+		{"port not 443", withOptions(tcpPacket("10.0.0.1", 1234, "10.0.0.2", 443)), false},
+
+		// Like libpcap, the fragments after the first one have no port
+		{"port 443", fragment(tcpPacket("10.0.0.1", 1234, "10.0.0.2", 443), 0), true},
+		{"port 443", fragment(tcpPacket("10.0.0.1", 1234, "10.0.0.2", 443), 185), false},
+		{"port not 443", fragment(tcpPacket("10.0.0.1", 1234, "10.0.0.2", 443), 185), true},
+		{"host 10.0.0.1", fragment(tcpPacket("10.0.0.1", 1234, "10.0.0.2", 443), 185), true},
+
+		{"host 10.0.0.1 and port not 443", tcpPacket("10.0.0.1", 1234, "10.0.0.2", 80), true},
+		{"host 10.0.0.1 and port not 443", tcpPacket("10.0.0.1", 1234, "10.0.0.2", 443), false},
+		{"host 10.0.0.1 or host 10.0.0.3 and port not 443", tcpPacket("10.0.0.3", 1234, "10.0.0.2", 80), true},
+		{"host 10.0.0.1 or host 10.0.0.3 and port not 443", tcpPacket("10.0.0.3", 1234, "10.0.0.2", 443), false},
+		{"(host 10.0.0.1 or host fd00::1) and tcp", udpPacket("fd00::1", 1234, "fd00::2", 53), false},
+		{"not (host 10.0.0.1 || port 80)", tcpPacket("10.0.0.2", 1234, "10.0.0.3", 8080), true},
+		{"! host 10.0.0.1 && ! port 80", tcpPacket("10.0.0.2", 1234, "10.0.0.3", 80), false},
+	}
+
+	for _, link := range testLinkLayers {
+		for _, test := range tests {
+			if matched := runBPFFilter(t, test.expr, link, test.packet); matched != test.expected {
+				t.Errorf("%s: expected %q to match %v: %v, got %v", link.name, test.expr, test.packet, test.expected, matched)
+			}
+		}
+	}
+}
+
+func TestCompileBPFFilterOfPods(t *testing.T) {
+	pods := make([]v1.Pod, 0, bpfFilterMaxPods)
+	for i := 0; i < bpfFilterMaxPods; i++ {
+		pod := v1.Pod{Status: v1.PodStatus{PodIP: fmt.Sprintf("10.1.%d.%d", i/100, i%100)}}
+		// Some pods are dual stack
+		if i%10 == 0 {
+			pod.Status.PodIPs = []v1.PodIP{{IP: pod.Status.PodIP}, {IP: fmt.Sprintf("fd00::%x", i)}}
+		}
+		pods = append(pods, pod)
+	}
+	expr := buildBPFExpr(pods)
+
+	for _, link := range testLinkLayers {
+		instructions, err := compileBPFFilter(expr, link.layout(testPacket{src: "10.0.0.1"}), testSnapLength)
+		if err != nil {
+			t.Fatalf("%s: error compiling the filter of %d pods: %v", link.name, len(pods), err)
+		}
+
+		// The first hosts are further than a conditional jump reaches from the port check
+		farJumps := 0
+		for _, instruction := range instructions {
+			if _, ok := instruction.(bpf.Jump); ok {
+				farJumps++
+			}
+		}
+		if farJumps == 0 {
+			t.Errorf("%s: expected far jumps in a program of %d instructions", link.name, len(instructions))
+		}
+		if _, err := bpf.Assemble(instructions); err != nil {
+			t.Errorf("%s: error assembling the program: %v", link.name, err)
+		}
+
+		tests := []struct {
+			packet   testPacket
+			expected bool
+		}{
+			{testPacket{src: "10.1.0.0", dst: "10.2.0.1", protocol: layers.IPProtocolTCP, srcPort: 1234, dstPort: 80}, true},
+			{testPacket{src: "10.2.0.1", dst: "10.1.0.75", protocol: layers.IPProtocolTCP, srcPort: 1234, dstPort: 80}, true},
+			{testPacket{src: "10.1.1.49", dst: "10.2.0.1", protocol: layers.IPProtocolUDP, srcPort: 1234, dstPort: 53}, true},
+			{testPacket{src: "10.1.1.49", dst: "10.2.0.1", protocol: layers.IPProtocolTCP, srcPort: 1234, dstPort: 443}, false},
+			{testPacket{src: "10.2.0.1", dst: "10.1.0.0", protocol: layers.IPProtocolTCP, srcPort: 443, dstPort: 1234}, false},
+			{testPacket{src: "10.1.1.50", dst: "10.2.0.1", protocol: layers.IPProtocolTCP, srcPort: 1234, dstPort: 80}, false},
+			{testPacket{src: "fd00::8c", dst: "fd01::1", protocol: layers.IPProtocolTCP, srcPort: 1234, dstPort: 80}, true},
+			{testPacket{src: "fd00::8c", dst: "fd01::1", protocol: layers.IPProtocolTCP, srcPort: 1234, dstPort: 443}, false},
+			{testPacket{src: "fd00::8d", dst: "fd01::1", protocol: layers.IPProtocolTCP, srcPort: 1234, dstPort: 80}, false},
+		}
+		for _, test := range tests {
+			if matched := runBPFFilter(t, expr, link, test.packet); matched != test.expected {
+				t.Errorf("%s: expected the filter of %d pods to match %v: %v, got %v", link.name, len(pods), test.packet, test.expected, matched)
+			}
+		}
+	}
+}
+
+func TestCompileBPFFilterErrors(t *testing.T) {
+	for _, expr := range []string{"host", "host 10.0.0", "port 80000", "port http", "(tcp", "tcp)", "tcp and", "icmp", "ether host 02:00:00:00:00:01"} {
+		if _, err := compileBPFFilter(expr, bpfEthernetLayout, testSnapLength); err == nil {
+			t.Errorf("expected an error compiling %q", expr)
+		}
+	}
+
+	hosts := make([]string, 0, 600)
+	for i := 0; i < cap(hosts); i++ {
+		hosts = append(hosts, fmt.Sprintf("host fd00::%x", i))
+	}
+	if _, err := compileBPFFilter(strings.Join(hosts, " or "), bpfEthernetLayout, testSnapLength); err == nil {
+		t.Errorf("expected an error compiling a filter of more than %d instructions", bpfMaxInstructions)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/up9inc/mizu/logger"
+	v1 "k8s.io/api/core/v1"
 )
 
 var numberRegex = regexp.MustCompile("[0-9]+")
@@ -36,3 +37,17 @@ func getSingleValueFromEnvironmentVariableFile(filePath string, variableName str
 
 	return "", nil
 }
+
+// The addresses of a pod, a dual-stack pod has an address of each family
+func getPodIPs(pod v1.Pod) []string {
+	ips := make([]string, 0)
+	if pod.Status.PodIP != "" {
+		ips = append(ips, pod.Status.PodIP)
+	}
+	for _, podIP := range pod.Status.PodIPs {
+		if podIP.IP != pod.Status.PodIP {
+			ips = append(ips, podIP.IP)
+		}
+	}
+	return ips
+}
@@ -74,8 +74,10 @@ func checkEnvoyPid(procfs string, pid string, pods []v1.Pod) bool {
 	logger.Log.Infof("Found envoy pid %v with cluster ip %v", pid, podIp)
 
 	for _, pod := range pods {
-		if pod.Status.PodIP == podIp {
-			return true
+		for _, ip := range getPodIPs(pod) {
+			if ip == podIp {
+				return true
+			}
 		}
 	}
 
@@ -0,0 +1,185 @@
+package source
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+// Reference RFC 8200, section 4.5
+const (
+	ipv6MaximumSize            = 65535
+	ipv6MaximumFragmentListLen = 8192
+	ipv6FragmentTimeout        = 60 * time.Second
+)
+
+var ErrIPv6FragmentOverlap = errors.New("Overlapping IPv6 fragments")
+var ErrIPv6DatagramTooLarge = errors.New("IPv6 datagram is too large")
+var ErrIPv6FragmentPastTheEnd = errors.New("IPv6 fragment is past the end of the datagram")
+var ErrIPv6FragmentGap = errors.New("Missing IPv6 fragment")
+
+type ipv6FragmentKey struct {
+	src            [16]byte
+	dst            [16]byte
+	identification uint32
+}
+
+type ipv6Fragment struct {
+	offset  int
+	payload []byte
+}
+
+type ipv6FragmentList struct {
+	fragments  []ipv6Fragment
+	nextHeader layers.IPProtocol
+	received   int
+	total      int // Known after the last fragment, -1 until then
+	lastSeen   time.Time
+}
+
+// IPv6Defragmenter reassembles the IPv6 datagrams, which gopacket only does for IPv4.
+// Unlike IPv4 the fragmentation is an extension header, so the fragments are told apart
+// by the source, the destination and the identification of the fragment header.
+type IPv6Defragmenter struct {
+	sync.Mutex
+	flows     map[ipv6FragmentKey]*ipv6FragmentList
+	lastSweep time.Time
+}
+
+func NewIPv6Defragmenter() *IPv6Defragmenter {
+	return &IPv6Defragmenter{
+		flows: make(map[ipv6FragmentKey]*ipv6FragmentList),
+	}
+}
+
+// DefragIPv6 takes in an IPv6 packet and its fragment header. It returns nil until all the fragments
+// are received, then a new IPv6 layer whose payload is the reassembled datagram. The fragments
+// are copied, so the packet data can be reused by the caller.
+func (d *IPv6Defragmenter) DefragIPv6(in *layers.IPv6, frag *layers.IPv6Fragment, t time.Time) (*layers.IPv6, error) {
+	var key ipv6FragmentKey
+	copy(key.src[:], in.SrcIP.To16())
+	copy(key.dst[:], in.DstIP.To16())
+	key.identification = frag.Identification
+
+	offset := int(frag.FragmentOffset) * 8
+	end := offset + len(frag.Payload)
+	if end > ipv6MaximumSize {
+		return nil, ErrIPv6DatagramTooLarge
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	d.discardOlderThan(t.Add(-ipv6FragmentTimeout))
+
+	list, ok := d.flows[key]
+	if !ok {
+		list = &ipv6FragmentList{total: -1}
+		d.flows[key] = list
+	}
+	list.lastSeen = t
+
+	// The fragments must end where the last fragment does, with the same last fragment when it's retransmitted
+	if list.total >= 0 && (end > list.total || (!frag.MoreFragments && end != list.total)) {
+		delete(d.flows, key)
+		return nil, ErrIPv6FragmentPastTheEnd
+	}
+	if !frag.MoreFragments {
+		for _, f := range list.fragments {
+			if f.offset+len(f.payload) > end {
+				delete(d.flows, key)
+				return nil, ErrIPv6FragmentPastTheEnd
+			}
+		}
+	}
+
+	for _, f := range list.fragments {
+		fEnd := f.offset + len(f.payload)
+		if f.offset == offset && fEnd == end {
+			// Retransmitted fragment
+			return nil, nil
+		}
+		// The datagrams with overlapping fragments are silently discarded (RFC 5722)
+		if offset < fEnd && f.offset < end {
+			delete(d.flows, key)
+			return nil, ErrIPv6FragmentOverlap
+		}
+	}
+
+	if len(list.fragments) >= ipv6MaximumFragmentListLen {
+		delete(d.flows, key)
+		return nil, ErrIPv6DatagramTooLarge
+	}
+
+	if offset == 0 {
+		list.nextHeader = frag.NextHeader
+	}
+	if !frag.MoreFragments {
+		list.total = end
+	}
+	list.fragments = append(list.fragments, ipv6Fragment{
+		offset:  offset,
+		payload: append([]byte{}, frag.Payload...),
+	})
+	list.received += len(frag.Payload)
+
+	if list.total < 0 || list.received < list.total {
+		return nil, nil
+	}
+	delete(d.flows, key)
+
+	sort.Slice(list.fragments, func(i, j int) bool {
+		return list.fragments[i].offset < list.fragments[j].offset
+	})
+	payload := make([]byte, 0, list.total)
+	for _, f := range list.fragments {
+		if f.offset != len(payload) {
+			return nil, ErrIPv6FragmentGap
+		}
+		payload = append(payload, f.payload...)
+	}
+
+	out := &layers.IPv6{
+		Version:      in.Version,
+		TrafficClass: in.TrafficClass,
+		FlowLabel:    in.FlowLabel,
+		Length:       uint16(len(payload)),
+		NextHeader:   list.nextHeader,
+		HopLimit:     in.HopLimit,
+		SrcIP:        in.SrcIP,
+		DstIP:        in.DstIP,
+	}
+	out.Contents = in.Contents
+	out.Payload = payload
+
+	return out, nil
+}
+
+// DiscardOlderThan forgets the datagrams that were not completed since the given time
+func (d *IPv6Defragmenter) DiscardOlderThan(t time.Time) int {
+	d.Lock()
+	defer d.Unlock()
+
+	d.lastSweep = time.Time{}
+	return d.discardOlderThan(t)
+}
+
+// The flows are swept at most once per timeout
+func (d *IPv6Defragmenter) discardOlderThan(t time.Time) int {
+	if t.Before(d.lastSweep) {
+		return 0
+	}
+	d.lastSweep = t.Add(ipv6FragmentTimeout)
+
+	count := 0
+	for key, list := range d.flows {
+		if list.lastSeen.Before(t) {
+			delete(d.flows, key)
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,145 @@
+package source
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+type testIPv6Fragment struct {
+	offset        int
+	length        int
+	moreFragments bool
+	after         time.Duration
+}
+
+func TestDefragIPv6(t *testing.T) {
+	tests := []struct {
+		name      string
+		fragments []testIPv6Fragment
+		// The length of the reassembled datagram, after the last fragment, 0 when it's not reassembled
+		expected int
+		err      error
+	}{
+		{
+			name:      "single fragment",
+			fragments: []testIPv6Fragment{{0, 40, false, 0}},
+			expected:  40,
+		},
+		{
+			name:      "in order",
+			fragments: []testIPv6Fragment{{0, 16, true, 0}, {16, 16, true, 0}, {32, 8, false, 0}},
+			expected:  40,
+		},
+		{
+			name:      "out of order",
+			fragments: []testIPv6Fragment{{32, 8, false, 0}, {0, 16, true, 0}, {16, 16, true, 0}},
+			expected:  40,
+		},
+		{
+			name:      "duplicate",
+			fragments: []testIPv6Fragment{{0, 16, true, 0}, {0, 16, true, 0}, {32, 8, false, 0}, {32, 8, false, 0}, {16, 16, true, 0}},
+			expected:  40,
+		},
+		{
+			name:      "overlapping",
+			fragments: []testIPv6Fragment{{0, 24, true, 0}, {16, 16, true, 0}},
+			err:       ErrIPv6FragmentOverlap,
+		},
+		{
+			name:      "past the end",
+			fragments: []testIPv6Fragment{{16, 8, false, 0}, {16, 16, true, 0}},
+			err:       ErrIPv6FragmentPastTheEnd,
+		},
+		{
+			name:      "last fragment before the others",
+			fragments: []testIPv6Fragment{{0, 16, true, 0}, {16, 16, true, 0}, {8, 8, false, 0}},
+			err:       ErrIPv6FragmentPastTheEnd,
+		},
+		{
+			name:      "another last fragment",
+			fragments: []testIPv6Fragment{{32, 8, false, 0}, {40, 8, false, 0}},
+			err:       ErrIPv6FragmentPastTheEnd,
+		},
+		{
+			name:      "too large",
+			fragments: []testIPv6Fragment{{65528, 16, false, 0}},
+			err:       ErrIPv6DatagramTooLarge,
+		},
+		{
+			name:      "gap",
+			fragments: []testIPv6Fragment{{0, 16, true, 0}, {32, 8, false, 0}},
+		},
+		{
+			name:      "timed out",
+			fragments: []testIPv6Fragment{{0, 16, true, 0}, {16, 16, true, 0}, {32, 8, false, ipv6FragmentTimeout + time.Second}},
+		},
+		{
+			name:      "within the timeout",
+			fragments: []testIPv6Fragment{{0, 16, true, 0}, {16, 16, true, 0}, {32, 8, false, ipv6FragmentTimeout - time.Second}},
+			expected:  40,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defragmenter := NewIPv6Defragmenter()
+			in := &layers.IPv6{
+				Version:    6,
+				HopLimit:   64,
+				NextHeader: layers.IPProtocolIPv6Fragment,
+				SrcIP:      net.ParseIP("fd00::1"),
+				DstIP:      net.ParseIP("fd00::2"),
+			}
+			datagram := make([]byte, ipv6MaximumSize+16)
+			for i := range datagram {
+				datagram[i] = byte(i)
+			}
+
+			now := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+			var out *layers.IPv6
+			var err error
+			for i, fragment := range test.fragments {
+				now = now.Add(fragment.after)
+				frag := &layers.IPv6Fragment{
+					NextHeader:     layers.IPProtocolUDP,
+					FragmentOffset: uint16(fragment.offset / 8),
+					MoreFragments:  fragment.moreFragments,
+					Identification: 42,
+				}
+				frag.Payload = datagram[fragment.offset : fragment.offset+fragment.length]
+
+				out, err = defragmenter.DefragIPv6(in, frag, now)
+				if err != nil {
+					break
+				}
+				if out != nil && i != len(test.fragments)-1 {
+					t.Fatalf("the datagram was reassembled after fragment %d of %d", i+1, len(test.fragments))
+				}
+			}
+
+			if err != test.err {
+				t.Fatalf("expected error %v, got %v", test.err, err)
+			}
+			if test.expected == 0 {
+				if out != nil {
+					t.Fatalf("expected no datagram, got %d bytes", len(out.Payload))
+				}
+				return
+			}
+
+			if out == nil {
+				t.Fatalf("expected a datagram of %d bytes, got none", test.expected)
+			}
+			if !bytes.Equal(out.Payload, datagram[:test.expected]) || int(out.Length) != test.expected {
+				t.Errorf("expected the first %d bytes of the datagram, got %d bytes (length %d)", test.expected, len(out.Payload), out.Length)
+			}
+			if out.NextHeader != layers.IPProtocolUDP {
+				t.Errorf("expected the next header of the first fragment, got %v", out.NextHeader)
+			}
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package source
+
+import (
+	"fmt"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+func newNetnsPacketSource(procfs string, pid string, interfaceName string,
+	behaviour TcpPacketSourceBehaviour, origin api.Capture) (*tcpPacketSource, error) {
+	return nil, fmt.Errorf("the network namespaces are not supported on Windows")
+}
@@ -13,6 +13,7 @@ const bpfFilterMaxPods = 150
 const hostSourcePid = "0"
 
 type PacketSourceManagerConfig struct {
+	filename      string
 	mtls          bool
 	procfs        string
 	interfaceName string
@@ -21,33 +22,93 @@ type PacketSourceManagerConfig struct {
 
 type PacketSourceManager struct {
 	sources map[string]*tcpPacketSource
-	config  PacketSourceManagerConfig
+	// hostSourcePids are the keys of the sources of the host, there's one per socket of the AF_PACKET fanout group
+	hostSourcePids []string
+	config         PacketSourceManagerConfig
+	pcapWriter     *PcapWriter
 }
 
+// pcapWriter is optional, when it's set the packets of the tap targets are written to pcap files
 func NewPacketSourceManager(procfs string, filename string, interfaceName string,
-	mtls bool, pods []v1.Pod, behaviour TcpPacketSourceBehaviour, ipdefrag bool, packets chan<- TcpPacketInfo) (*PacketSourceManager, error) {
-	hostSource, err := newHostPacketSource(filename, interfaceName, behaviour)
+	mtls bool, pods []v1.Pod, behaviour TcpPacketSourceBehaviour, ipdefrag bool, packets chan<- TcpPacketInfo, pcapWriter *PcapWriter) (*PacketSourceManager, error) {
+	hostSources, err := newHostPacketSources(filename, interfaceName, behaviour)
 	if err != nil {
 		return nil, err
 	}
 
 	sourceManager := &PacketSourceManager{
-		sources: map[string]*tcpPacketSource{
-			hostSourcePid: hostSource,
-		},
+		sources:    make(map[string]*tcpPacketSource),
+		pcapWriter: pcapWriter,
+	}
+
+	for i, hostSource := range hostSources {
+		hostSource.pcapWriter = pcapWriter
+		pid := hostSourcePid
+		if i > 0 {
+			pid = fmt.Sprintf("%s-%d", hostSourcePid, i)
+		}
+		sourceManager.sources[pid] = hostSource
+		sourceManager.hostSourcePids = append(sourceManager.hostSourcePids, pid)
+	}
+
+	if pcapWriter != nil {
+		pcapWriter.SetTargets(pods)
 	}
 
 	sourceManager.config = PacketSourceManagerConfig{
+		filename:      filename,
 		mtls:          mtls,
 		procfs:        procfs,
 		interfaceName: interfaceName,
 		behaviour:     behaviour,
 	}
 
-	go hostSource.readPackets(ipdefrag, packets)
+	for _, hostSource := range hostSources {
+		go func(hostSource *tcpPacketSource) {
+			hostSource.readPackets(ipdefrag, packets)
+
+			// A file ends once it's read, so the packets channel is closed to tell there are no more packets
+			if filename != "" {
+				close(packets)
+			}
+		}(hostSource)
+	}
 	return sourceManager, nil
 }
 
+// newHostPacketSources opens a source for every socket of the AF_PACKET fanout group, or a single source otherwise
+func newHostPacketSources(filename string, interfaceName string,
+	behaviour TcpPacketSourceBehaviour) ([]*tcpPacketSource, error) {
+	if filename != "" || behaviour.Backend != AfPacketBackend || behaviour.AfPacketFanout <= 1 {
+		source, err := newHostPacketSource(filename, interfaceName, behaviour)
+		if err != nil {
+			return nil, err
+		}
+		return []*tcpPacketSource{source}, nil
+	}
+
+	sources := make([]*tcpPacketSource, 0, behaviour.AfPacketFanout)
+	for i := 0; i < behaviour.AfPacketFanout; i++ {
+		name := fmt.Sprintf("host-%s-%d", interfaceName, i)
+		source, err := newTcpPacketSource(name, "", interfaceName, behaviour, api.Pcap)
+		if err != nil {
+			for _, source := range sources {
+				source.close()
+			}
+			return nil, err
+		}
+		sources = append(sources, source)
+
+		// The other sockets join the group of the first one
+		if handle, ok := source.handle.(fanoutPacketHandle); ok {
+			behaviour.afPacketFanoutGroup = handle.FanoutGroup()
+			behaviour.afPacketFanoutCreated = true
+		}
+	}
+
+	return sources, nil
+}
+
 func newHostPacketSource(filename string, interfaceName string,
 	behaviour TcpPacketSourceBehaviour) (*tcpPacketSource, error) {
 	var name string
@@ -66,11 +127,16 @@ func newHostPacketSource(filename string, interfaceName string,
 }
 
 func (m *PacketSourceManager) UpdatePods(pods []v1.Pod, ipdefrag bool, packets chan<- TcpPacketInfo) {
-	if m.config.mtls {
+	// The mtls sources capture live traffic, which can't be mixed with the packets of a file
+	if m.config.mtls && m.config.filename == "" {
 		m.updateMtlsPods(m.config.procfs, pods, m.config.interfaceName, m.config.behaviour, ipdefrag, packets)
 	}
 
 	m.setBPFFilter(pods)
+
+	if m.pcapWriter != nil {
+		m.pcapWriter.SetTargets(pods)
+	}
 }
 
 func (m *PacketSourceManager) updateMtlsPods(procfs string, pods []v1.Pod,
@@ -91,6 +157,7 @@ func (m *PacketSourceManager) updateMtlsPods(procfs string, pods []v1.Pod,
 			source, err := newNetnsPacketSource(procfs, pid, interfaceName, behaviour, origin)
 
 			if err == nil {
+				source.pcapWriter = m.pcapWriter
 				go source.readPackets(ipdefrag, packets)
 				m.sources[pid] = source
 			}
@@ -100,7 +167,9 @@ func (m *PacketSourceManager) updateMtlsPods(procfs string, pods []v1.Pod,
 
 func (m *PacketSourceManager) getRelevantPids(procfs string, pods []v1.Pod) map[string]api.Capture {
 	relevantPids := make(map[string]api.Capture)
-	relevantPids[hostSourcePid] = api.Pcap
+	for _, pid := range m.hostSourcePids {
+		relevantPids[pid] = api.Pcap
+	}
 
 	if envoyPids, err := discoverRelevantEnvoyPids(procfs, pods); err != nil {
 		logger.Log.Warningf("Unable to discover envoy pids - %w", err)
@@ -125,7 +194,9 @@ func buildBPFExpr(pods []v1.Pod) string {
 	hostsFilter := make([]string, 0)
 
 	for _, pod := range pods {
-		hostsFilter = append(hostsFilter, fmt.Sprintf("host %s", pod.Status.PodIP))
+		for _, ip := range getPodIPs(pod) {
+			hostsFilter = append(hostsFilter, fmt.Sprintf("host %s", ip))
+		}
 	}
 
 	return fmt.Sprintf("%s and port not 443", strings.Join(hostsFilter, " or "))
@@ -0,0 +1,67 @@
+//go:build cgo
+
+package source
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/gopacket/pcap"
+)
+
+// DefaultCaptureBackend is libpcap, unless the tapper is built without cgo
+const DefaultCaptureBackend = PcapBackend
+
+type pcapPacketHandle struct {
+	*pcap.Handle
+}
+
+func newPcapFileHandle(filename string) (packetHandle, error) {
+	handle, err := pcap.OpenOffline(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pcapPacketHandle{Handle: handle}, nil
+}
+
+func newPcapLiveHandle(interfaceName string, behaviour TcpPacketSourceBehaviour) (packetHandle, error) {
+	// This is a little complicated because we want to allow all possible options
+	// for creating the packet capture handle... instead of all this you can
+	// just call pcap.OpenLive if you want a simple handle.
+	inactive, err := pcap.NewInactiveHandle(interfaceName)
+	if err != nil {
+		return nil, fmt.Errorf("could not create: %v", err)
+	}
+	defer inactive.CleanUp()
+	if err = inactive.SetSnapLen(behaviour.SnapLength); err != nil {
+		return nil, fmt.Errorf("could not set snap length: %v", err)
+	} else if err = inactive.SetPromisc(behaviour.Promisc); err != nil {
+		return nil, fmt.Errorf("could not set promisc mode: %v", err)
+	} else if err = inactive.SetTimeout(time.Second); err != nil {
+		return nil, fmt.Errorf("could not set timeout: %v", err)
+	}
+	if behaviour.Tstype != "" {
+		if t, err := pcap.TimestampSourceFromString(behaviour.Tstype); err != nil {
+			return nil, fmt.Errorf("supported timestamp types: %v", inactive.SupportedTimestamps())
+		} else if err := inactive.SetTimestampSource(t); err != nil {
+			return nil, fmt.Errorf("supported timestamp types: %v", inactive.SupportedTimestamps())
+		}
+	}
+
+	handle, err := inactive.Activate()
+	if err != nil {
+		return nil, fmt.Errorf("PCAP Activate error: %v", err)
+	}
+
+	return &pcapPacketHandle{Handle: handle}, nil
+}
+
+func (h *pcapPacketHandle) Stats() (*PacketSourceStats, error) {
+	stats, err := h.Handle.Stats()
+	if err != nil {
+		return nil, err
+	}
+
+	return &PacketSourceStats{PacketsReceived: stats.PacketsReceived, PacketsDropped: stats.PacketsDropped}, nil
+}
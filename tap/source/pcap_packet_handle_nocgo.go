@@ -0,0 +1,128 @@
+//go:build !cgo
+
+package source
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"golang.org/x/net/bpf"
+)
+
+// DefaultCaptureBackend is AF_PACKET, since libpcap can't be used without cgo
+const DefaultCaptureBackend = AfPacketBackend
+
+func newPcapLiveHandle(interfaceName string, behaviour TcpPacketSourceBehaviour) (packetHandle, error) {
+	return nil, fmt.Errorf("the %s capture backend requires a build with cgo, use the %s backend", PcapBackend, AfPacketBackend)
+}
+
+type packetDataReader interface {
+	gopacket.PacketDataSource
+	LinkType() layers.LinkType
+}
+
+// filePacketHandle reads pcap and pcapng files without libpcap, its BPF filter runs in a BPF virtual machine
+type filePacketHandle struct {
+	sync.Mutex
+	file   *os.File
+	reader packetDataReader
+	filter *bpf.VM
+	closed bool
+}
+
+func newPcapFileHandle(filename string) (packetHandle, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	buffered := bufio.NewReader(file)
+	magic, err := buffered.Peek(4)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	var reader packetDataReader
+	if binary.LittleEndian.Uint32(magic) == pcapngSectionHeaderBlock {
+		reader, err = pcapgo.NewNgReader(buffered, pcapgo.DefaultNgReaderOptions)
+	} else {
+		reader, err = pcapgo.NewReader(buffered)
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &filePacketHandle{file: file, reader: reader}, nil
+}
+
+func (h *filePacketHandle) ReadPacketData() (data []byte, ci gopacket.CaptureInfo, err error) {
+	h.Lock()
+	defer h.Unlock()
+
+	for {
+		if h.closed {
+			return nil, ci, io.EOF
+		}
+
+		if data, ci, err = h.reader.ReadPacketData(); err != nil {
+			return
+		}
+
+		if h.filter == nil {
+			return
+		}
+
+		if keep, err := h.filter.Run(data); err == nil && keep > 0 {
+			return data, ci, nil
+		}
+	}
+}
+
+func (h *filePacketHandle) LinkType() layers.LinkType {
+	return h.reader.LinkType()
+}
+
+func (h *filePacketHandle) SetBPFFilter(expr string) error {
+	layout, err := bpfLayoutOf(h.reader.LinkType())
+	if err != nil {
+		return err
+	}
+
+	instructions, err := compileBPFFilter(expr, layout, 1)
+	if err != nil {
+		return err
+	}
+
+	filter, err := bpf.NewVM(instructions)
+	if err != nil {
+		return err
+	}
+
+	h.Lock()
+	h.filter = filter
+	h.Unlock()
+	return nil
+}
+
+func (h *filePacketHandle) Stats() (*PacketSourceStats, error) {
+	return nil, fmt.Errorf("statistics aren't available for files")
+}
+
+func (h *filePacketHandle) Close() {
+	h.Lock()
+	defer h.Unlock()
+
+	if !h.closed {
+		h.closed = true
+		h.file.Close()
+	}
+}
@@ -0,0 +1,247 @@
+package source
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/up9inc/mizu/logger"
+	"github.com/up9inc/mizu/tap/dbgctl"
+	v1 "k8s.io/api/core/v1"
+)
+
+const pcapFlushInterval = time.Second
+
+/* PcapWriter writes the packets of the tap targets to pcapng files in a directory.
+ * The current file is rotated once it reaches maxFileSize and only the last maxFiles files are kept.
+ * Every packet is commented with the pods it's from and to, so they can be filtered in Wireshark with pkt_comment.
+ * The current file is flushed periodically rather than on every packet, so it can be fetched while it's written,
+ * even when no packets arrive.
+ */
+type PcapWriter struct {
+	sync.Mutex
+	dir         string
+	maxFileSize int64
+	maxFiles    int
+	file        *os.File
+	writer      *bufio.Writer
+	size        int64
+	// interfaces are the ids of the packet sources in the current file
+	interfaces map[string]uint32
+	// targets are the names of the tap targets by their IPs
+	targets        map[string]string
+	stopChannel    chan struct{}
+	stoppedChannel chan struct{}
+	stopOnce       sync.Once
+	// closed drops the packets the sources still read while the tapper stops
+	closed bool
+}
+
+func NewPcapWriter(dir string, maxFileSize int64, maxFiles int) (*PcapWriter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("error creating the pcap directory %s: %v", dir, err)
+	}
+
+	writer := &PcapWriter{
+		dir:            dir,
+		maxFileSize:    maxFileSize,
+		maxFiles:       maxFiles,
+		targets:        make(map[string]string),
+		stopChannel:    make(chan struct{}),
+		stoppedChannel: make(chan struct{}),
+	}
+
+	go writer.flushPeriodically()
+	return writer, nil
+}
+
+func (w *PcapWriter) flushPeriodically() {
+	ticker := time.NewTicker(pcapFlushInterval)
+	defer ticker.Stop()
+	defer close(w.stoppedChannel)
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := w.Flush(); err != nil {
+				logger.Log.Warningf("Error flushing the pcap file: %v", err)
+			}
+		case <-w.stopChannel:
+			return
+		}
+	}
+}
+
+// Flush writes the buffered packets to the current file
+func (w *PcapWriter) Flush() error {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.file == nil {
+		return nil
+	}
+	return w.writer.Flush()
+}
+
+func (w *PcapWriter) SetTargets(pods []v1.Pod) {
+	targets := make(map[string]string)
+	for _, pod := range pods {
+		for _, ip := range getPodIPs(pod) {
+			targets[ip] = fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)
+		}
+	}
+
+	w.Lock()
+	w.targets = targets
+	w.Unlock()
+}
+
+// WritePacket writes a packet that was read by a source, unless it's neither from nor to a tap target
+func (w *PcapWriter) WritePacket(source *tcpPacketSource, packet gopacket.Packet) error {
+	networkLayer := packet.NetworkLayer()
+	if networkLayer == nil {
+		return nil
+	}
+
+	w.Lock()
+	defer w.Unlock()
+
+	if w.closed {
+		return nil
+	}
+
+	srcIP := networkLayer.NetworkFlow().Src().String()
+	dstIP := networkLayer.NetworkFlow().Dst().String()
+	srcName, isSrcTarget := w.targets[srcIP]
+	dstName, isDstTarget := w.targets[dstIP]
+	if !isSrcTarget && !isDstTarget {
+		return nil
+	}
+	if !isSrcTarget {
+		srcName = srcIP
+	}
+	if !isDstTarget {
+		dstName = dstIP
+	}
+
+	if w.file == nil || w.size >= w.maxFileSize {
+		if err := w.rotate(); err != nil {
+			return err
+		}
+	}
+
+	interfaceId, ok := w.interfaces[source.name]
+	if !ok {
+		interfaceId = uint32(len(w.interfaces))
+		if err := w.write(newPcapngInterfaceDescription(source.name, source.handle.LinkType(), source.Behaviour.SnapLength)); err != nil {
+			return err
+		}
+		w.interfaces[source.name] = interfaceId
+	}
+
+	captureInfo := packet.Metadata().CaptureInfo
+	comment := fmt.Sprintf("%s -> %s", srcName, dstName)
+	return w.write(newPcapngEnhancedPacket(interfaceId, captureInfo.Timestamp, packet.Data(), captureInfo.Length, comment))
+}
+
+func (w *PcapWriter) write(block []byte) error {
+	n, err := w.writer.Write(block)
+	w.size += int64(n)
+	return err
+}
+
+func (w *PcapWriter) rotate() error {
+	if err := w.closeFile(); err != nil {
+		logger.Log.Warningf("Error closing the pcap file: %v", err)
+	}
+
+	path := filepath.Join(w.dir, fmt.Sprintf("%020d%s", time.Now().UnixNano(), dbgctl.PcapFileSuffix))
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating the pcap file %s: %v", path, err)
+	}
+
+	w.file = file
+	w.writer = bufio.NewWriter(file)
+	w.size = 0
+	w.interfaces = make(map[string]uint32)
+
+	hostname, _ := os.Hostname()
+	if err := w.write(newPcapngSectionHeader(fmt.Sprintf("Captured by %s", hostname))); err != nil {
+		return err
+	}
+
+	w.removeOldFiles()
+	return nil
+}
+
+func (w *PcapWriter) removeOldFiles() {
+	files, err := listPcapFiles(w.dir)
+	if err != nil {
+		logger.Log.Warningf("Error listing the pcap files in %s: %v", w.dir, err)
+		return
+	}
+
+	for len(files) > w.maxFiles {
+		if err := os.Remove(filepath.Join(w.dir, files[0].Name())); err != nil {
+			logger.Log.Warningf("Error removing the pcap file %s: %v", files[0].Name(), err)
+		}
+		files = files[1:]
+	}
+}
+
+func (w *PcapWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+
+	file := w.file
+	w.file = nil
+	if err := w.writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Close stops the periodic flush and closes the current file, the packets written after it are dropped
+func (w *PcapWriter) Close() error {
+	w.stopOnce.Do(func() {
+		close(w.stopChannel)
+	})
+	<-w.stoppedChannel
+
+	w.Lock()
+	defer w.Unlock()
+
+	w.closed = true
+	return w.closeFile()
+}
+
+// listPcapFiles lists the pcap files in a directory from the oldest to the newest
+func listPcapFiles(dir string) ([]os.FileInfo, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []os.FileInfo
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), dbgctl.PcapFileSuffix) {
+			files = append(files, entry)
+		}
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name() < files[j].Name()
+	})
+
+	return files, nil
+}
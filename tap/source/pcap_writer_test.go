@@ -0,0 +1,250 @@
+package source
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type testPacketHandle struct {
+	linkType layers.LinkType
+}
+
+func (h *testPacketHandle) ReadPacketData() ([]byte, gopacket.CaptureInfo, error) {
+	return nil, gopacket.CaptureInfo{}, io.EOF
+}
+
+func (h *testPacketHandle) LinkType() layers.LinkType {
+	return h.linkType
+}
+
+func (h *testPacketHandle) SetBPFFilter(expr string) error {
+	return nil
+}
+
+func (h *testPacketHandle) Stats() (*PacketSourceStats, error) {
+	return &PacketSourceStats{}, nil
+}
+
+func (h *testPacketHandle) Close() {
+}
+
+func newTestPacketSource(name string) *tcpPacketSource {
+	return &tcpPacketSource{
+		name:      name,
+		handle:    &testPacketHandle{linkType: layers.LinkTypeEthernet},
+		Behaviour: &TcpPacketSourceBehaviour{SnapLength: 65536},
+	}
+}
+
+func newTestEthernetPacket(t *testing.T, src string, dst string, timestamp time.Time) gopacket.Packet {
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolTCP, SrcIP: net.ParseIP(src), DstIP: net.ParseIP(dst)}
+	tcp := &layers.TCP{SrcPort: 1234, DstPort: 80, ACK: true, Window: 1024}
+	if err := tcp.SetNetworkLayerForChecksum(ip); err != nil {
+		t.Fatal(err)
+	}
+	ethernet := &layers.Ethernet{
+		SrcMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 0x01},
+		DstMAC:       net.HardwareAddr{0x02, 0, 0, 0, 0, 0x02},
+		EthernetType: layers.EthernetTypeIPv4,
+	}
+
+	buffer := gopacket.NewSerializeBuffer()
+	options := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	if err := gopacket.SerializeLayers(buffer, options, ethernet, ip, tcp, gopacket.Payload("GET / HTTP/1.1\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	packet := gopacket.NewPacket(buffer.Bytes(), layers.LayerTypeEthernet, gopacket.Default)
+	packet.Metadata().CaptureInfo = gopacket.CaptureInfo{
+		Timestamp:     timestamp,
+		CaptureLength: len(buffer.Bytes()),
+		Length:        len(buffer.Bytes()),
+	}
+	return packet
+}
+
+type readPcapPacket struct {
+	data      []byte
+	timestamp time.Time
+	iface     string
+}
+
+// readPcapFile reads the packets that were flushed to a file so far
+func readPcapFile(t *testing.T, path string) []readPcapPacket {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("error opening %s: %v", path, err)
+	}
+	defer file.Close()
+
+	reader, err := pcapgo.NewNgReader(file, pcapgo.NgReaderOptions{WantMixedLinkType: true})
+	if err != nil {
+		if err == io.EOF {
+			return nil
+		}
+		t.Fatalf("error reading %s: %v", path, err)
+	}
+
+	var packets []readPcapPacket
+	for {
+		data, ci, err := reader.ReadPacketData()
+		if err == io.EOF {
+			return packets
+		}
+		if err != nil {
+			t.Fatalf("error reading a packet of %s: %v", path, err)
+		}
+		iface, err := reader.Interface(ci.InterfaceIndex)
+		if err != nil {
+			t.Fatalf("error reading interface %d of %s: %v", ci.InterfaceIndex, path, err)
+		}
+		packets = append(packets, readPcapPacket{data: data, timestamp: ci.Timestamp, iface: iface.Name})
+	}
+}
+
+func listTestPcapFiles(t *testing.T, dir string) []string {
+	files, err := listPcapFiles(dir)
+	if err != nil {
+		t.Fatalf("error listing the pcap files: %v", err)
+	}
+
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		paths = append(paths, filepath.Join(dir, file.Name()))
+	}
+	return paths
+}
+
+func TestPcapWriterRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewPcapWriter(dir, 1024*1024, 2)
+	if err != nil {
+		t.Fatalf("error creating the pcap writer: %v", err)
+	}
+	defer writer.Close()
+
+	writer.SetTargets([]v1.Pod{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "target"},
+		Status:     v1.PodStatus{PodIP: "10.0.0.1"},
+	}})
+
+	start := time.Date(2022, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	sources := []*tcpPacketSource{newTestPacketSource("eth0"), newTestPacketSource("eth1")}
+	written := []gopacket.Packet{
+		newTestEthernetPacket(t, "10.0.0.1", "10.0.0.2", start),
+		newTestEthernetPacket(t, "10.0.0.2", "10.0.0.1", start.Add(time.Millisecond)),
+		newTestEthernetPacket(t, "10.0.0.1", "10.0.0.3", start.Add(2*time.Millisecond)),
+	}
+	for i, packet := range written {
+		if err := writer.WritePacket(sources[i%2], packet); err != nil {
+			t.Fatalf("error writing packet %d: %v", i, err)
+		}
+	}
+	// Neither from nor to a tap target
+	if err := writer.WritePacket(sources[0], newTestEthernetPacket(t, "10.0.0.2", "10.0.0.3", start)); err != nil {
+		t.Fatalf("error writing a packet of another pod: %v", err)
+	}
+
+	paths := listTestPcapFiles(t, dir)
+	if len(paths) != 1 {
+		t.Fatalf("expected a single pcap file, got %v", paths)
+	}
+
+	// The packets are flushed while no other packets arrive
+	var packets []readPcapPacket
+	deadline := time.Now().Add(3 * pcapFlushInterval)
+	for len(packets) < len(written) && time.Now().Before(deadline) {
+		time.Sleep(pcapFlushInterval / 10)
+		packets = readPcapFile(t, paths[0])
+	}
+	if len(packets) != len(written) {
+		t.Fatalf("expected %d flushed packets, got %d", len(written), len(packets))
+	}
+
+	for i, packet := range written {
+		if !bytes.Equal(packet.Data(), packets[i].data) {
+			t.Errorf("packet %d differs", i)
+		}
+		if !packet.Metadata().Timestamp.Equal(packets[i].timestamp) {
+			t.Errorf("expected packet %d at %v, got %v", i, packet.Metadata().Timestamp, packets[i].timestamp)
+		}
+		if packets[i].iface != sources[i%2].name {
+			t.Errorf("expected packet %d on %s, got %s", i, sources[i%2].name, packets[i].iface)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatalf("error closing the pcap writer: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("error closing the pcap writer again: %v", err)
+	}
+
+	// The sources may still read packets while the tapper stops, they don't open a new file
+	if err := writer.WritePacket(sources[0], newTestEthernetPacket(t, "10.0.0.1", "10.0.0.2", start)); err != nil {
+		t.Fatalf("error writing a packet after closing: %v", err)
+	}
+	if paths := listTestPcapFiles(t, dir); len(paths) != 1 {
+		t.Errorf("expected no new pcap file after closing, got %v", paths)
+	}
+	if packets = readPcapFile(t, paths[0]); len(packets) != len(written) {
+		t.Errorf("expected the %d packets written before closing, got %d", len(written), len(packets))
+	}
+}
+
+func TestPcapWriterRotates(t *testing.T) {
+	dir := t.TempDir()
+	writer, err := NewPcapWriter(dir, 512, 3)
+	if err != nil {
+		t.Fatalf("error creating the pcap writer: %v", err)
+	}
+
+	writer.SetTargets([]v1.Pod{{Status: v1.PodStatus{PodIP: "10.0.0.1"}}})
+	source := newTestPacketSource("eth0")
+	start := time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 40; i++ {
+		// The file names are the creation times in nanoseconds
+		time.Sleep(time.Microsecond)
+		if err := writer.WritePacket(source, newTestEthernetPacket(t, "10.0.0.1", "10.0.0.2", start.Add(time.Duration(i)*time.Second))); err != nil {
+			t.Fatalf("error writing packet %d: %v", i, err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("error closing the pcap writer: %v", err)
+	}
+
+	paths := listTestPcapFiles(t, dir)
+	if len(paths) != 3 {
+		t.Fatalf("expected the last 3 pcap files, got %v", paths)
+	}
+
+	// The files hold the newest packets in order, each file is readable on its own
+	var last time.Time
+	count := 0
+	for _, path := range paths {
+		for _, packet := range readPcapFile(t, path) {
+			if !packet.timestamp.After(last) {
+				t.Errorf("expected the packets in order, got %v after %v", packet.timestamp, last)
+			}
+			last = packet.timestamp
+			count++
+		}
+	}
+	if count == 0 || count >= 40 {
+		t.Errorf("expected only the newest packets, got %d", count)
+	}
+	if !last.Equal(start.Add(39 * time.Second)) {
+		t.Errorf("expected the last packet at %v, got %v", start.Add(39*time.Second), last)
+	}
+}
@@ -0,0 +1,121 @@
+package source
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/google/gopacket/layers"
+)
+
+// The blocks of the pcapng format, see https://www.ietf.org/archive/id/draft-tuexen-opsawg-pcapng-03.html
+// gopacket's pcapgo.NgWriter can't write the comments of the packets, so the blocks are built here
+const (
+	pcapngSectionHeaderBlock        = 0x0A0D0D0A
+	pcapngInterfaceDescriptionBlock = 0x00000001
+	pcapngEnhancedPacketBlock       = 0x00000006
+	pcapngByteOrderMagic            = 0x1A2B3C4D
+
+	pcapngOptionEndOfOptions = 0
+	pcapngOptionComment      = 1
+	pcapngOptionShbUserAppl  = 4
+	pcapngOptionIfName       = 2
+	pcapngOptionIfTsresol    = 9
+
+	// The timestamps are written in nanoseconds
+	pcapngTsresolNanoseconds = 9
+)
+
+type pcapngOption struct {
+	code  uint16
+	value []byte
+}
+
+func pcapngPad(length int) int {
+	return (4 - length%4) % 4
+}
+
+func pcapngOptionsLength(options []pcapngOption) int {
+	if len(options) == 0 {
+		return 0
+	}
+
+	length := 4 // The end of the options
+	for _, option := range options {
+		length += 4 + len(option.value) + pcapngPad(len(option.value))
+	}
+	return length
+}
+
+func putPcapngOptions(buffer []byte, options []pcapngOption) int {
+	if len(options) == 0 {
+		return 0
+	}
+
+	offset := 0
+	for _, option := range options {
+		binary.LittleEndian.PutUint16(buffer[offset:], option.code)
+		binary.LittleEndian.PutUint16(buffer[offset+2:], uint16(len(option.value)))
+		offset += 4 + copy(buffer[offset+4:], option.value) + pcapngPad(len(option.value))
+	}
+	binary.LittleEndian.PutUint16(buffer[offset:], pcapngOptionEndOfOptions)
+	binary.LittleEndian.PutUint16(buffer[offset+2:], 0)
+	return offset + 4
+}
+
+// newPcapngBlock frames the body of a block with its type and its total length, before and after it
+func newPcapngBlock(blockType uint32, bodyLength int, putBody func(body []byte)) []byte {
+	block := make([]byte, 12+bodyLength)
+	binary.LittleEndian.PutUint32(block[0:], blockType)
+	binary.LittleEndian.PutUint32(block[4:], uint32(len(block)))
+	putBody(block[8 : 8+bodyLength])
+	binary.LittleEndian.PutUint32(block[8+bodyLength:], uint32(len(block)))
+	return block
+}
+
+func newPcapngSectionHeader(comment string) []byte {
+	options := []pcapngOption{{code: pcapngOptionShbUserAppl, value: []byte("mizu")}}
+	if comment != "" {
+		options = append(options, pcapngOption{code: pcapngOptionComment, value: []byte(comment)})
+	}
+
+	return newPcapngBlock(pcapngSectionHeaderBlock, 16+pcapngOptionsLength(options), func(body []byte) {
+		binary.LittleEndian.PutUint32(body[0:], pcapngByteOrderMagic)
+		binary.LittleEndian.PutUint16(body[4:], 1) // Major version
+		binary.LittleEndian.PutUint16(body[6:], 0) // Minor version
+		// The length of the section isn't known in advance
+		binary.LittleEndian.PutUint64(body[8:], 0xFFFFFFFFFFFFFFFF)
+		putPcapngOptions(body[16:], options)
+	})
+}
+
+func newPcapngInterfaceDescription(name string, linkType layers.LinkType, snapLength int) []byte {
+	options := []pcapngOption{
+		{code: pcapngOptionIfName, value: []byte(name)},
+		{code: pcapngOptionIfTsresol, value: []byte{pcapngTsresolNanoseconds}},
+	}
+
+	return newPcapngBlock(pcapngInterfaceDescriptionBlock, 8+pcapngOptionsLength(options), func(body []byte) {
+		binary.LittleEndian.PutUint16(body[0:], uint16(linkType))
+		binary.LittleEndian.PutUint32(body[4:], uint32(snapLength))
+		putPcapngOptions(body[8:], options)
+	})
+}
+
+func newPcapngEnhancedPacket(interfaceId uint32, timestamp time.Time, data []byte, length int, comment string) []byte {
+	var options []pcapngOption
+	if comment != "" {
+		options = append(options, pcapngOption{code: pcapngOptionComment, value: []byte(comment)})
+	}
+
+	dataLength := len(data) + pcapngPad(len(data))
+	return newPcapngBlock(pcapngEnhancedPacketBlock, 20+dataLength+pcapngOptionsLength(options), func(body []byte) {
+		ts := uint64(timestamp.UnixNano())
+		binary.LittleEndian.PutUint32(body[0:], interfaceId)
+		binary.LittleEndian.PutUint32(body[4:], uint32(ts>>32))
+		binary.LittleEndian.PutUint32(body[8:], uint32(ts))
+		binary.LittleEndian.PutUint32(body[12:], uint32(len(data)))
+		binary.LittleEndian.PutUint32(body[16:], uint32(length))
+		copy(body[20:], data)
+		putPcapngOptions(body[20+dataLength:], options)
+	})
+}
@@ -3,12 +3,10 @@ package source
 import (
 	"fmt"
 	"io"
-	"time"
 
 	"github.com/google/gopacket"
 	"github.com/google/gopacket/ip4defrag"
 	"github.com/google/gopacket/layers"
-	"github.com/google/gopacket/pcap"
 	"github.com/up9inc/mizu/logger"
 	"github.com/up9inc/mizu/tap/api"
 	"github.com/up9inc/mizu/tap/dbgctl"
@@ -16,14 +14,21 @@ import (
 )
 
 type tcpPacketSource struct {
-	source    *gopacket.PacketSource
-	handle    *pcap.Handle
-	defragger *ip4defrag.IPv4Defragmenter
-	Behaviour *TcpPacketSourceBehaviour
-	name      string
-	Origin    api.Capture
+	source     *gopacket.PacketSource
+	handle     packetHandle
+	defragger  *ip4defrag.IPv4Defragmenter
+	defragger6 *IPv6Defragmenter
+	Behaviour  *TcpPacketSourceBehaviour
+	name       string
+	Origin     api.Capture
+	pcapWriter *PcapWriter
 }
 
+const (
+	PcapBackend     = "pcap"
+	AfPacketBackend = "af_packet"
+)
+
 type TcpPacketSourceBehaviour struct {
 	SnapLength  int
 	Promisc     bool
@@ -31,6 +36,34 @@ type TcpPacketSourceBehaviour struct {
 	DecoderName string
 	Lazy        bool
 	BpfFilter   string
+	// Backend captures the live packets, either with libpcap or with AF_PACKET ring buffers
+	Backend string
+	// AfPacketFanout is the number of the AF_PACKET sockets the packets of the host are spread on
+	AfPacketFanout int
+	// AfPacketRingSize is the size in bytes of the ring buffer of every AF_PACKET socket
+	AfPacketRingSize int
+	// afPacketFanoutGroup is the fanout group the kernel allocated for the first socket, once afPacketFanoutCreated
+	afPacketFanoutGroup   int
+	afPacketFanoutCreated bool
+}
+
+// fanoutPacketHandle is a handle which spreads the packets of an interface with the other handles of its fanout group
+type fanoutPacketHandle interface {
+	FanoutGroup() int
+}
+
+// packetHandle reads the packets of a source, either from a live capture or from a file
+type packetHandle interface {
+	gopacket.PacketDataSource
+	LinkType() layers.LinkType
+	SetBPFFilter(expr string) error
+	Stats() (*PacketSourceStats, error)
+	Close()
+}
+
+type PacketSourceStats struct {
+	PacketsReceived int
+	PacketsDropped  int
 }
 
 type TcpPacketInfo struct {
@@ -43,41 +76,28 @@ func newTcpPacketSource(name, filename string, interfaceName string,
 	var err error
 
 	result := &tcpPacketSource{
-		name:      name,
-		defragger: ip4defrag.NewIPv4Defragmenter(),
-		Behaviour: &behaviour,
-		Origin:    origin,
+		name:       name,
+		defragger:  ip4defrag.NewIPv4Defragmenter(),
+		defragger6: NewIPv6Defragmenter(),
+		Behaviour:  &behaviour,
+		Origin:     origin,
 	}
 
 	if filename != "" {
-		if result.handle, err = pcap.OpenOffline(filename); err != nil {
+		if result.handle, err = newPcapFileHandle(filename); err != nil {
 			return result, fmt.Errorf("PCAP OpenOffline error: %v", err)
 		}
 	} else {
-		// This is a little complicated because we want to allow all possible options
-		// for creating the packet capture handle... instead of all this you can
-		// just call pcap.OpenLive if you want a simple handle.
-		inactive, err := pcap.NewInactiveHandle(interfaceName)
-		if err != nil {
-			return result, fmt.Errorf("could not create: %v", err)
+		switch behaviour.Backend {
+		case PcapBackend, "":
+			result.handle, err = newPcapLiveHandle(interfaceName, behaviour)
+		case AfPacketBackend:
+			result.handle, err = newAfPacketHandle(interfaceName, behaviour)
+		default:
+			err = fmt.Errorf("unknown capture backend %q", behaviour.Backend)
 		}
-		defer inactive.CleanUp()
-		if err = inactive.SetSnapLen(behaviour.SnapLength); err != nil {
-			return result, fmt.Errorf("could not set snap length: %v", err)
-		} else if err = inactive.SetPromisc(behaviour.Promisc); err != nil {
-			return result, fmt.Errorf("could not set promisc mode: %v", err)
-		} else if err = inactive.SetTimeout(time.Second); err != nil {
-			return result, fmt.Errorf("could not set timeout: %v", err)
-		}
-		if behaviour.Tstype != "" {
-			if t, err := pcap.TimestampSourceFromString(behaviour.Tstype); err != nil {
-				return result, fmt.Errorf("supported timestamp types: %v", inactive.SupportedTimestamps())
-			} else if err := inactive.SetTimestampSource(t); err != nil {
-				return result, fmt.Errorf("supported timestamp types: %v", inactive.SupportedTimestamps())
-			}
-		}
-		if result.handle, err = inactive.Activate(); err != nil {
-			return result, fmt.Errorf("PCAP Activate error: %v", err)
+		if err != nil {
+			return result, err
 		}
 	}
 	if behaviour.BpfFilter != "" {
@@ -116,14 +136,11 @@ func (source *tcpPacketSource) close() {
 	}
 }
 
-func (source *tcpPacketSource) Stats() (stat *pcap.Stats, err error) {
+func (source *tcpPacketSource) Stats() (stat *PacketSourceStats, err error) {
 	return source.handle.Stats()
 }
 
 func (source *tcpPacketSource) readPackets(ipdefrag bool, packets chan<- TcpPacketInfo) {
-	if dbgctl.MizuTapperDisablePcap {
-		return
-	}
 	logger.Log.Infof("Start reading packets from %v", source.name)
 
 	for {
@@ -139,7 +156,19 @@ func (source *tcpPacketSource) readPackets(ipdefrag bool, packets chan<- TcpPack
 			continue
 		}
 
-		// defrag the IPv4 packet if required
+		// The packets are still read while the stage is disabled, so it can be enabled again at runtime
+		if dbgctl.MizuTapperDisablePcap.Enabled() {
+			continue
+		}
+
+		// The packets are written as they were captured, before they're defragmented
+		if source.pcapWriter != nil {
+			if err := source.pcapWriter.WritePacket(source, packet); err != nil {
+				logger.Log.Debugf("Error writing a packet from %v to the pcap file - %v", source.name, err)
+			}
+		}
+
+		// defrag the IP packet if required
 		if ipdefrag {
 			if ip4Layer := packet.Layer(layers.LayerTypeIPv4); ip4Layer != nil {
 				ip4 := ip4Layer.(*layers.IPv4)
@@ -162,6 +191,31 @@ func (source *tcpPacketSource) readPackets(ipdefrag bool, packets chan<- TcpPack
 					_ = nextDecoder.Decode(newip4.Payload, pb)
 				}
 			}
+
+			// defrag the IPv6 packet, the fragmentation is an extension header
+			if fragLayer := packet.Layer(layers.LayerTypeIPv6Fragment); fragLayer != nil {
+				ip6Layer := packet.Layer(layers.LayerTypeIPv6)
+				if ip6Layer == nil {
+					continue
+				}
+				newip6, err := source.defragger6.DefragIPv6(ip6Layer.(*layers.IPv6), fragLayer.(*layers.IPv6Fragment),
+					packet.Metadata().Timestamp)
+				if err != nil {
+					logger.Log.Debugf("Error while de-fragmenting IPv6: %v", err)
+					continue
+				} else if newip6 == nil {
+					logger.Log.Debugf("Fragment...")
+					continue // packet fragment, we don't have whole packet yet.
+				}
+				diagnose.InternalStats.Ipdefrag++
+				logger.Log.Debugf("Decoding re-assembled packet: %s", newip6.NextHeader.LayerType())
+				pb, ok := packet.(gopacket.PacketBuilder)
+				if !ok {
+					logger.Log.Panic("Not a PacketBuilder")
+				}
+				nextDecoder := newip6.NextHeader.LayerType()
+				_ = nextDecoder.Decode(newip6.Payload, pb)
+			}
 		}
 
 		packets <- TcpPacketInfo{
@@ -39,6 +39,7 @@ type tcpAssembler struct {
 	*reassembly.Assembler
 	streamPool             *reassembly.StreamPool
 	streamFactory          *tcpStreamFactory
+	udpStream              *udpStream
 	ignoredPorts           []uint16
 	lastClosedConnections  *simplelru.LRU // Actual type is map[string]int64 which is "connId -> lastSeen"
 	liveConnections        map[connectionId]bool
@@ -62,6 +63,7 @@ func NewTcpAssembler(outputItems chan *api.OutputChannelItem, streamsMap api.Tcp
 	var emitter api.Emitter = &api.Emitting{
 		AppStats:      &diagnose.AppStats,
 		OutputChannel: outputItems,
+		Queue:         emitQueue,
 	}
 
 	lastClosedConnections, err := simplelru.NewLRU(lastClosedConnectionsMaxItems, func(key interface{}, value interface{}) {})
@@ -80,6 +82,7 @@ func NewTcpAssembler(outputItems chan *api.OutputChannelItem, streamsMap api.Tcp
 	}
 
 	a.streamFactory = NewTcpStreamFactory(emitter, streamsMap, opts, a)
+	a.udpStream = NewUdpStream(streamsMap)
 	a.streamPool = reassembly.NewStreamPool(a.streamFactory)
 	a.Assembler = reassembly.NewAssembler(a.streamPool)
 
@@ -137,6 +140,8 @@ func (a *tcpAssembler) processPacket(packetInfo source.TcpPacketInfo, dumpPacket
 	tcp := packet.Layer(layers.LayerTypeTCP)
 	if tcp != nil {
 		a.processTcpPacket(packetInfo.Source.Origin, packet, tcp.(*layers.TCP))
+	} else if udp := packet.Layer(layers.LayerTypeUDP); udp != nil {
+		a.processUdpPacket(packetInfo.Source.Origin, packet, udp.(*layers.UDP))
 	}
 
 	done := *maxcount > 0 && int64(diagnose.AppStats.PacketsCount) >= *maxcount
@@ -179,11 +184,49 @@ func (a *tcpAssembler) processTcpPacket(origin api.Capture, packet gopacket.Pack
 		Origin:      origin,
 	}
 	diagnose.InternalStats.Totalsz += len(tcp.Payload)
-	if !dbgctl.MizuTapperDisableTcpReassembly {
+	if !dbgctl.MizuTapperDisableTcpReassembly.Enabled() {
 		a.AssembleWithContext(packet.NetworkLayer().NetworkFlow(), tcp, &c)
 	}
 }
 
+func (a *tcpAssembler) processUdpPacket(origin api.Capture, packet gopacket.Packet, udp *layers.UDP) {
+	diagnose.AppStats.IncUdpPacketsCount()
+	if a.shouldIgnorePort(uint16(udp.DstPort)) || a.shouldIgnorePort(uint16(udp.SrcPort)) {
+		diagnose.AppStats.IncIgnoredPacketsCount()
+		return
+	}
+
+	if len(udp.Payload) == 0 || len(a.udpStream.dissectors) == 0 || dbgctl.MizuTapperDisableDissectors.Enabled() {
+		return
+	}
+
+	srcIp := packet.NetworkLayer().NetworkFlow().Src().String()
+	dstIp := packet.NetworkLayer().NetworkFlow().Dst().String()
+	srcPort := udp.TransportFlow().Src().String()
+	dstPort := udp.TransportFlow().Dst().String()
+
+	props := a.streamFactory.getStreamProps(srcIp, srcPort, dstIp, dstPort)
+	if !props.isTapTarget {
+		return
+	}
+
+	datagram := &udpDatagram{
+		payload: udp.Payload,
+		udpID: &api.TcpID{
+			SrcIP:   srcIp,
+			DstIP:   dstIp,
+			SrcPort: srcPort,
+			DstPort: dstPort,
+		},
+		captureTime: packet.Metadata().CaptureInfo.Timestamp,
+		origin:      origin,
+		isOutgoing:  props.isOutgoing,
+		emitter:     a.streamFactory.emitter,
+	}
+
+	a.udpStream.dissect(datagram, filteringOptions)
+}
+
 func (a *tcpAssembler) tcpStreamCreated(stream *tcpStream) {
 	a.liveConnections[stream.connectionId] = true
 }
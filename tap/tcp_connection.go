@@ -0,0 +1,107 @@
+package tap
+
+import (
+	"time"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/reassembly"
+	"github.com/up9inc/mizu/tap/api"
+)
+
+/* Keeps the lifecycle of a connection as the packets are seen by the assembler
+ * The packets are counted before the reassembly, so the retransmissions are known from the expected sequence numbers.
+ * The bytes are counted after the reassembly, so they are the actual payloads without the retransmitted ones.
+ */
+type tcpConnection struct {
+	summary  api.TcpConnection
+	synAt    time.Time
+	synAckAt time.Time
+	emitter  api.Emitter
+}
+
+func newTcpConnection(connectionInfo *api.ConnectionInfo, origin api.Capture, emitter api.Emitter) *tcpConnection {
+	return &tcpConnection{
+		emitter: emitter,
+		summary: api.TcpConnection{
+			ConnectionInfo: connectionInfo,
+			Capture:        origin,
+			Termination:    api.TcpTerminationTimeout,
+		},
+	}
+}
+
+func (c *tcpConnection) direction(dir reassembly.TCPFlowDirection) *api.TcpConnectionDirection {
+	if dir == reassembly.TCPDirClientToServer {
+		return &c.summary.Client
+	}
+	return &c.summary.Server
+}
+
+func (c *tcpConnection) accept(tcp *layers.TCP, ci gopacket.CaptureInfo, dir reassembly.TCPFlowDirection, nextSeq reassembly.Sequence) {
+	if c.summary.StartTime.IsZero() {
+		c.summary.StartTime = ci.Timestamp
+	}
+	if ci.Timestamp.After(c.summary.EndTime) {
+		c.summary.EndTime = ci.Timestamp
+	}
+
+	direction := c.direction(dir)
+	direction.Packets++
+
+	// The sequence numbers are expected only once the direction has started
+	if len(tcp.Payload) > 0 && nextSeq >= 0 && nextSeq.Difference(reassembly.Sequence(tcp.Seq)) < 0 {
+		direction.Retransmissions++
+	}
+
+	c.handshake(tcp, ci, dir)
+	c.terminate(tcp, dir)
+}
+
+func (c *tcpConnection) handshake(tcp *layers.TCP, ci gopacket.CaptureInfo, dir reassembly.TCPFlowDirection) {
+	switch {
+	case tcp.SYN && !tcp.ACK && dir == reassembly.TCPDirClientToServer:
+		c.synAt = ci.Timestamp
+	case tcp.SYN && tcp.ACK && dir == reassembly.TCPDirServerToClient && !c.synAt.IsZero():
+		c.synAckAt = ci.Timestamp
+	case !tcp.SYN && tcp.ACK && dir == reassembly.TCPDirClientToServer && !c.synAckAt.IsZero() && c.summary.HandshakeRtt == 0:
+		c.summary.HandshakeRtt = ci.Timestamp.Sub(c.synAt)
+	}
+}
+
+// The first RST ends the connection, even after a FIN. Otherwise the first FIN tells which side closed it.
+func (c *tcpConnection) terminate(tcp *layers.TCP, dir reassembly.TCPFlowDirection) {
+	side := api.TcpServer
+	if dir == reassembly.TCPDirClientToServer {
+		side = api.TcpClient
+	}
+
+	switch {
+	case tcp.RST && c.summary.Termination != api.TcpTerminationRst:
+		c.summary.Termination = api.TcpTerminationRst
+		c.summary.TerminatedBy = side
+	case tcp.FIN && c.summary.Termination == api.TcpTerminationTimeout:
+		c.summary.Termination = api.TcpTerminationFin
+		c.summary.TerminatedBy = side
+	}
+}
+
+func (c *tcpConnection) reassembled(dir reassembly.TCPFlowDirection, length int, stats reassembly.TCPAssemblyStats) {
+	direction := c.direction(dir)
+	direction.Bytes += length
+	direction.OutOfOrder += stats.QueuedPackets
+}
+
+func (c *tcpConnection) emit(options *api.TrafficFilteringOptions) {
+	if options == nil || !options.EnableTcpConnections {
+		return
+	}
+
+	i := api.FindConnectionExtension(extensions)
+	if i < 0 {
+		return
+	}
+
+	summary := c.summary
+	extensions[i].Dissector.(api.ConnectionDissector).DissectConnection(&summary, c.emitter, options)
+}
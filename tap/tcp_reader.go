@@ -10,6 +10,13 @@ import (
 	"github.com/up9inc/mizu/tap/dbgctl"
 )
 
+const (
+	// The dissectors are ranked by the first bytes of the stream, up to this size
+	maxProbeSize = 1024
+	// The stream is kept for the identification of the protocol up to this size, then it's no longer rewound
+	maxIdentificationBufferSize = 1 << 18
+)
+
 /* TcpReader gets reads from a channel of bytes of tcp payload, and parses it into requests and responses.
  * The payload is written to the channel by a tcpStream object that is dedicated to one tcp connection.
  * An TcpReader object is unidirectional: it parses either a client stream or a server stream.
@@ -24,6 +31,8 @@ type tcpReader struct {
 	msgQueue        chan api.TcpReaderDataMsg // Channel of captured reassembled tcp payload
 	msgBuffer       []api.TcpReaderDataMsg
 	msgBufferMaster []api.TcpReaderDataMsg
+	masterSize      int
+	isReleased      bool
 	data            []byte
 	progress        *api.ReadProgress
 	captureTime     time.Time
@@ -50,21 +59,71 @@ func NewTcpReader(ident string, tcpId *api.TcpID, parent *tcpStream, isClient bo
 func (reader *tcpReader) run(options *api.TrafficFilteringOptions, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	if dbgctl.MizuTapperDisableDissectors {
+	if dbgctl.MizuTapperDisableDissectors.Enabled() {
 		b := bufio.NewReader(reader)
 		_, _ = io.ReadAll(b)
 		return
 	}
 
-	for i, extension := range extensions {
+	peek := reader.peek()
+	if len(peek) > 0 {
+		for _, i := range api.RankExtensions(extensions, peek, reader.isClient, reader.getServerPort()) {
+			reader.reqResMatcher = reader.parent.reqResMatchers[i]
+			reader.counterPair = reader.parent.counterPairs[i]
+			b := bufio.NewReader(reader)
+			extension := extensions[i]
+			extension.Dissector.Dissect(b, reader, options) //nolint
+			if reader.isProtocolIdentified() {
+				return
+			}
+			if reader.isReleased {
+				break
+			}
+			reader.rewind()
+		}
+	}
+
+	reader.release(options)
+}
+
+// Blocks until the stream has data, the data is replayed to the dissectors
+func (reader *tcpReader) peek() []byte {
+	b := bufio.NewReaderSize(reader, maxProbeSize)
+	if _, err := b.Peek(1); err != nil {
+		return nil
+	}
+	peek, _ := b.Peek(b.Buffered())
+	peek = append([]byte{}, peek...)
+	reader.rewind()
+	return peek
+}
+
+func (reader *tcpReader) getServerPort() string {
+	if reader.isClient {
+		return reader.tcpID.DstPort
+	}
+	return reader.tcpID.SrcPort
+}
+
+// None of the dissectors identified the stream, the rest of it is given to the fallback dissector without keeping it
+func (reader *tcpReader) release(options *api.TrafficFilteringOptions) {
+	reader.rewind()
+	reader.parent.Lock()
+	reader.msgBufferMaster = nil
+	reader.masterSize = 0
+	reader.isReleased = true
+	reader.parent.Unlock()
+
+	if i := api.FindFallbackExtension(extensions); i >= 0 && !reader.isProtocolIdentified() {
 		reader.reqResMatcher = reader.parent.reqResMatchers[i]
 		reader.counterPair = reader.parent.counterPairs[i]
 		b := bufio.NewReader(reader)
-		extension.Dissector.Dissect(b, reader, options) //nolint
-		if reader.isProtocolIdentified() {
-			break
-		}
-		reader.rewind()
+		extensions[i].Dissector.(api.FallbackDissector).DissectFallback(b, reader, options) //nolint
+	}
+
+	reader.msgBuffer = nil
+	reader.data = nil
+	for range reader.msgQueue {
 	}
 }
 
@@ -103,6 +162,20 @@ func (reader *tcpReader) rewind() {
 	reader.progress.Reset()
 }
 
+func (reader *tcpReader) appendToMaster(msg api.TcpReaderDataMsg) {
+	reader.parent.Lock()
+	defer reader.parent.Unlock()
+
+	reader.masterSize += len(msg.GetBytes())
+	if reader.masterSize > maxIdentificationBufferSize {
+		// The current dissector is the last one to try
+		reader.isReleased = true
+		reader.msgBufferMaster = nil
+		return
+	}
+	reader.msgBufferMaster = append(reader.msgBufferMaster, msg)
+}
+
 func (reader *tcpReader) populateData(msg api.TcpReaderDataMsg) {
 	reader.data = msg.GetBytes()
 	reader.captureTime = msg.GetTimestamp()
@@ -130,11 +203,8 @@ func (reader *tcpReader) Read(p []byte) (int, error) {
 		if msg != nil {
 			reader.populateData(msg)
 
-			if !reader.isProtocolIdentified() {
-				reader.msgBufferMaster = append(
-					reader.msgBufferMaster,
-					msg,
-				)
+			if !reader.isProtocolIdentified() && !reader.isReleased {
+				reader.appendToMaster(msg)
 			}
 		}
 	}
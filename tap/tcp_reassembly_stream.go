@@ -64,6 +64,8 @@ func (t *tcpReassemblyStream) Accept(tcp *layers.TCP, ci gopacket.CaptureInfo, d
 	}
 	if !accept {
 		diagnose.InternalStats.RejectOpt++
+	} else if t.tcpStream.connection != nil {
+		t.tcpStream.connection.accept(tcp, ci, dir, nextSeq)
 	}
 
 	*start = true
@@ -100,6 +102,10 @@ func (t *tcpReassemblyStream) ReassembledSG(sg reassembly.ScatterGather, ac reas
 	diagnose.InternalStats.OverlapBytes += sgStats.OverlapBytes
 	diagnose.InternalStats.OverlapPackets += sgStats.OverlapPackets
 
+	if t.tcpStream.connection != nil {
+		t.tcpStream.connection.reassembled(dir, length, sgStats)
+	}
+
 	if skip != -1 && skip != 0 {
 		// Missing bytes in stream: do not even try to parse it
 		return
@@ -150,6 +156,7 @@ func (t *tcpReassemblyStream) ReassembledSG(sg reassembly.ScatterGather, ac reas
 func (t *tcpReassemblyStream) ReassemblyComplete(ac reassembly.AssemblerContext) bool {
 	if t.tcpStream.GetIsTapTarget() && !t.tcpStream.GetIsClosed() {
 		t.tcpStream.close()
+		t.tcpStream.connection.emit(filteringOptions)
 	}
 
 	return true
@@ -5,7 +5,6 @@ import (
 	"time"
 
 	"github.com/up9inc/mizu/tap/api"
-	"github.com/up9inc/mizu/tap/dbgctl"
 )
 
 type tcpStreamCallbacks interface {
@@ -25,6 +24,7 @@ type tcpStream struct {
 	isTapTarget    bool
 	client         *tcpReader
 	server         *tcpReader
+	connection     *tcpConnection
 	origin         api.Capture
 	counterPairs   []*api.CounterPair
 	reqResMatchers []api.RequestResponseMatcher
@@ -89,7 +89,9 @@ func (t *tcpStream) SetProtocol(protocol *api.Protocol) {
 	// Clean the buffers
 	t.Lock()
 	t.client.msgBufferMaster = make([]api.TcpReaderDataMsg, 0)
+	t.client.masterSize = 0
 	t.server.msgBufferMaster = make([]api.TcpReaderDataMsg, 0)
+	t.server.masterSize = 0
 	t.Unlock()
 }
 
@@ -102,12 +104,23 @@ func (t *tcpStream) GetReqResMatchers() []api.RequestResponseMatcher {
 }
 
 func (t *tcpStream) GetIsTapTarget() bool {
-	if dbgctl.MizuTapperDisableTcpStream {
-		return false
-	}
 	return t.isTapTarget
 }
 
 func (t *tcpStream) GetIsClosed() bool {
 	return t.isClosed
 }
+
+func (t *tcpStream) GetConnectionInfo() *api.ConnectionInfo {
+	if t.connection == nil {
+		return nil
+	}
+	return t.connection.summary.ConnectionInfo
+}
+
+func (t *tcpStream) GetEmitter() api.Emitter {
+	if t.connection == nil {
+		return nil
+	}
+	return t.connection.emitter
+}
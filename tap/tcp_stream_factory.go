@@ -2,10 +2,12 @@ package tap
 
 import (
 	"fmt"
+	"net"
 	"sync"
 
 	"github.com/up9inc/mizu/logger"
 	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/dbgctl"
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/google/gopacket"
@@ -58,7 +60,8 @@ func (factory *tcpStreamFactory) New(net, transport gopacket.Flow, tcpLayer *lay
 	dstPort := transport.Dst().String()
 
 	props := factory.getStreamProps(srcIp, srcPort, dstIp, dstPort)
-	isTapTarget := props.isTapTarget
+	// The switch is checked once per stream, a stream keeps its readers until it's closed whatever the switch becomes
+	isTapTarget := props.isTapTarget && !dbgctl.MizuTapperDisableTcpStream.Enabled()
 	connectionId := getConnectionId(srcIp, srcPort, dstIp, dstPort)
 	stream := NewTcpStream(isTapTarget, factory.streamsMap, getPacketOrigin(ac), connectionId, factory.streamsCallbacks)
 	reassemblyStream := NewTcpReassemblyStream(fmt.Sprintf("%s:%s", net, transport), tcpLayer, fsmOptions, stream)
@@ -103,6 +106,18 @@ func (factory *tcpStreamFactory) New(net, transport gopacket.Flow, tcpLayer *lay
 			factory.emitter,
 		)
 
+		stream.connection = newTcpConnection(
+			&api.ConnectionInfo{
+				ClientIP:   srcIp,
+				ClientPort: srcPort,
+				ServerIP:   dstIp,
+				ServerPort: dstPort,
+				IsOutgoing: props.isOutgoing,
+			},
+			stream.GetOrigin(),
+			factory.emitter,
+		)
+
 		factory.streamsMap.Store(stream.getId(), stream)
 
 		factory.wg.Add(2)
@@ -118,20 +133,35 @@ func (factory *tcpStreamFactory) WaitGoRoutines() {
 
 func inArrayPod(pods []v1.Pod, address string) bool {
 	for _, pod := range pods {
-		if pod.Status.PodIP == address {
+		if isPodAddress(pod.Status.PodIP, address) {
 			return true
 		}
+		// The dual-stack pods have an address of each family
+		for _, podIP := range pod.Status.PodIPs {
+			if isPodAddress(podIP.IP, address) {
+				return true
+			}
+		}
 	}
 	return false
 }
 
+// The IPv6 addresses have more than one textual form
+func isPodAddress(podIP string, address string) bool {
+	if podIP == address {
+		return true
+	}
+	ip := net.ParseIP(address)
+	return ip != nil && ip.Equal(net.ParseIP(podIP))
+}
+
 func (factory *tcpStreamFactory) getStreamProps(srcIP string, srcPort string, dstIP string, dstPort string) *streamProps {
 	if factory.opts.HostMode {
-		if inArrayPod(tapTargets, fmt.Sprintf("%s:%s", dstIP, dstPort)) {
+		if inArrayPod(tapTargets, net.JoinHostPort(dstIP, dstPort)) {
 			return &streamProps{isTapTarget: true, isOutgoing: false}
 		} else if inArrayPod(tapTargets, dstIP) {
 			return &streamProps{isTapTarget: true, isOutgoing: false}
-		} else if inArrayPod(tapTargets, fmt.Sprintf("%s:%s", srcIP, srcPort)) {
+		} else if inArrayPod(tapTargets, net.JoinHostPort(srcIP, srcPort)) {
 			return &streamProps{isTapTarget: true, isOutgoing: true}
 		} else if inArrayPod(tapTargets, srcIP) {
 			return &streamProps{isTapTarget: true, isOutgoing: true}
@@ -0,0 +1,82 @@
+//go:build linux && !notls
+
+package tap
+
+import (
+	"os"
+
+	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/diagnose"
+	"github.com/up9inc/mizu/tap/tlstapper"
+)
+
+var tlsTapperInstance *tlstapper.TlsTapper // global
+
+func startTlsTapper(extensions []*api.Extension, outputItems chan *api.OutputChannelItem,
+	options *api.TrafficFilteringOptions, streamsMap api.TcpStreamMap) {
+	tlsTapperInstance = newTlsTapper(extensions, outputItems, options, streamsMap)
+}
+
+func newTlsTapper(extensions []*api.Extension, outputItems chan *api.OutputChannelItem,
+	options *api.TrafficFilteringOptions, streamsMap api.TcpStreamMap) *tlstapper.TlsTapper {
+	tls := tlstapper.TlsTapper{}
+	chunksBufferSize := os.Getpagesize() * 100
+	logBufferSize := os.Getpagesize()
+
+	if err := tls.Init(chunksBufferSize, logBufferSize, *procfs, extensions); err != nil {
+		tlstapper.LogError(err)
+		return nil
+	}
+
+	if err := tlstapper.UpdateTapTargets(&tls, &tapTargets, *procfs); err != nil {
+		tlstapper.LogError(err)
+		return nil
+	}
+
+	// A quick way to instrument libssl.so without PID filtering - used for debuging and troubleshooting
+	//
+	if os.Getenv("MIZU_GLOBAL_SSL_LIBRARY") != "" {
+		if err := tls.GlobalSSLLibTap(os.Getenv("MIZU_GLOBAL_SSL_LIBRARY")); err != nil {
+			tlstapper.LogError(err)
+			return nil
+		}
+	}
+
+	// A quick way to instrument Go `crypto/tls` without PID filtering - used for debuging and troubleshooting
+	//
+	if os.Getenv("MIZU_GLOBAL_GOLANG_PID") != "" {
+		if err := tls.GlobalGoTap(*procfs, os.Getenv("MIZU_GLOBAL_GOLANG_PID")); err != nil {
+			tlstapper.LogError(err)
+			return nil
+		}
+	}
+
+	var emitter api.Emitter = &api.Emitting{
+		AppStats:      &diagnose.AppStats,
+		OutputChannel: outputItems,
+		Queue:         emitQueue,
+	}
+
+	go tls.PollForLogging()
+	go tls.Poll(emitter, options, streamsMap)
+
+	return &tls
+}
+
+// updateTlsTapTargets returns false when the TLS tapper failed to hook the new tap targets
+func updateTlsTapTargets() bool {
+	if tlsTapperInstance == nil || os.Getenv("MIZU_GLOBAL_GOLANG_PID") != "" {
+		return true
+	}
+
+	if err := tlstapper.UpdateTapTargets(tlsTapperInstance, &tapTargets, *procfs); err != nil {
+		tlstapper.LogError(err)
+		return false
+	}
+
+	return true
+}
+
+func isTlsTapperStarted() bool {
+	return tlsTapperInstance != nil
+}
@@ -0,0 +1,23 @@
+//go:build !linux || notls
+
+package tap
+
+import (
+	"github.com/up9inc/mizu/logger"
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// The TLS tapper hooks the processes with eBPF, it's left out of the builds that don't run on a node,
+// like the CLI, which would otherwise need the eBPF objects of every platform it's built for
+func startTlsTapper(extensions []*api.Extension, outputItems chan *api.OutputChannelItem,
+	options *api.TrafficFilteringOptions, streamsMap api.TcpStreamMap) {
+	logger.Log.Errorf("The TLS tapper is not supported in this build")
+}
+
+func updateTlsTapTargets() bool {
+	return true
+}
+
+func isTlsTapperStarted() bool {
+	return false
+}
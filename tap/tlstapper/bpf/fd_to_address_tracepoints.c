@@ -12,6 +12,7 @@ Copyright (C) UP9 Inc.
 #include "include/pids.h"
 
 #define IPV4_ADDR_LEN (16)
+#define IPV6_ADDR_LEN (28)
 
 struct accept_info {
 	__u32* addrlen;
@@ -87,8 +88,8 @@ void sys_exit_accept4(struct sys_exit_accept4_ctx *ctx) {
 	__u32 addrlen;
 	bpf_probe_read(&addrlen, sizeof(__u32), info.addrlen);
 	
-	if (addrlen != IPV4_ADDR_LEN) {
-		// Currently only ipv4 is supported linux-src/include/linux/inet.h
+	if (addrlen != IPV4_ADDR_LEN && addrlen != IPV6_ADDR_LEN) {
+		// Only sockaddr_in and sockaddr_in6 are supported linux-src/include/linux/inet.h
 		return;
 	}
 	
@@ -180,8 +181,8 @@ void sys_exit_connect(struct sys_exit_connect_ctx *ctx) {
 		return;
 	}
 	
-	if (info.addrlen != IPV4_ADDR_LEN) {
-		// Currently only ipv4 is supported linux-src/include/linux/inet.h
+	if (info.addrlen != IPV4_ADDR_LEN && info.addrlen != IPV6_ADDR_LEN) {
+		// Only sockaddr_in and sockaddr_in6 are supported linux-src/include/linux/inet.h
 		return;
 	}
 	
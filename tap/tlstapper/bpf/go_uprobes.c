@@ -295,10 +295,7 @@ static __always_inline void go_crypto_tls_ex_uprobe(struct pt_regs *ctx, struct
         return;
     }
 
-    info.address_info.daddr = address_info->daddr;
-    info.address_info.dport = address_info->dport;
-    info.address_info.saddr = address_info->saddr;
-    info.address_info.sport = address_info->sport;
+    __builtin_memcpy(&info.address_info, address_info, sizeof(struct address_info));
 
     output_ssl_chunk(ctx, &info, info.buffer_len, pid_tgid, flags);
 
@@ -8,6 +8,7 @@ Copyright (C) UP9 Inc.
 #define __COMMON__
 
 #define AF_INET	2	/* Internet IP Protocol */
+#define AF_INET6	10	/* IP version 6 */
 
 const __s32 invalid_fd = -1;
 
@@ -25,9 +25,11 @@ Copyright (C) UP9 Inc.
 //  Be careful when editing, alignment and padding should be exactly the same in go/c.
 //
 
+// The addresses are IPv6, IPv4 addresses are stored IPv4-mapped (::ffff:a.b.c.d)
+//
 struct address_info {
-    __be32 saddr;
-    __be32 daddr;
+    __u8 saddr[16];
+    __u8 daddr[16];
     __be16 sport;
     __be16 dport;
 };
@@ -16,27 +16,52 @@ static __always_inline int tcp_kprobes_get_address_pair_from_ctx(struct pt_regs
 		log_error(ctx, LOG_ERROR_READING_SOCKET_FAMILY, id, err, 0l);
 		return -1;
 	}
-	if (family != AF_INET) {
+	if (family != AF_INET && family != AF_INET6) {
 		return -1;
 	}
 
 	// daddr, saddr and dport are in network byte order (big endian)
 	// sport is in host byte order
-	__be32 saddr;
-	__be32 daddr;
 	__be16 dport;
 	__u16 sport;
 
-	err = bpf_probe_read(&saddr, sizeof(saddr), (void *)&sk->__sk_common.skc_rcv_saddr);
-	if (err != 0) {
-		log_error(ctx, LOG_ERROR_READING_SOCKET_SADDR, id, err, 0l);
-		return -1;
-	}
-	err = bpf_probe_read(&daddr, sizeof(daddr), (void *)&sk->__sk_common.skc_daddr);
-	if (err != 0) {
-		log_error(ctx, LOG_ERROR_READING_SOCKET_DADDR, id, err, 0l);
-		return -1;
+	if (family == AF_INET) {
+		__be32 saddr;
+		__be32 daddr;
+
+		err = bpf_probe_read(&saddr, sizeof(saddr), (void *)&sk->__sk_common.skc_rcv_saddr);
+		if (err != 0) {
+			log_error(ctx, LOG_ERROR_READING_SOCKET_SADDR, id, err, 0l);
+			return -1;
+		}
+		err = bpf_probe_read(&daddr, sizeof(daddr), (void *)&sk->__sk_common.skc_daddr);
+		if (err != 0) {
+			log_error(ctx, LOG_ERROR_READING_SOCKET_DADDR, id, err, 0l);
+			return -1;
+		}
+
+		// IPv4-mapped IPv6 address ::ffff:a.b.c.d
+		__builtin_memset(address_info_ptr->saddr, 0, 10);
+		__builtin_memset(address_info_ptr->daddr, 0, 10);
+		address_info_ptr->saddr[10] = 0xff;
+		address_info_ptr->saddr[11] = 0xff;
+		address_info_ptr->daddr[10] = 0xff;
+		address_info_ptr->daddr[11] = 0xff;
+		__builtin_memcpy(&address_info_ptr->saddr[12], &saddr, sizeof(saddr));
+		__builtin_memcpy(&address_info_ptr->daddr[12], &daddr, sizeof(daddr));
+	} else {
+		err = bpf_probe_read(address_info_ptr->saddr, sizeof(address_info_ptr->saddr), (void *)&sk->__sk_common.skc_v6_rcv_saddr);
+		if (err != 0) {
+			log_error(ctx, LOG_ERROR_READING_SOCKET_SADDR, id, err, 0l);
+			return -1;
+		}
+		err = bpf_probe_read(address_info_ptr->daddr, sizeof(address_info_ptr->daddr), (void *)&sk->__sk_common.skc_v6_daddr);
+		if (err != 0) {
+			log_error(ctx, LOG_ERROR_READING_SOCKET_DADDR, id, err, 0l);
+			return -1;
+		}
 	}
+
 	err = bpf_probe_read(&dport, sizeof(dport), (void *)&sk->__sk_common.skc_dport);
 	if (err != 0) {
 		log_error(ctx, LOG_ERROR_READING_SOCKET_DPORT, id, err, 0l);
@@ -48,8 +73,6 @@ static __always_inline int tcp_kprobes_get_address_pair_from_ctx(struct pt_regs
 		return -1;
 	}
 
-	address_info_ptr->daddr = daddr;
-	address_info_ptr->saddr = saddr;
 	address_info_ptr->dport = dport;
 	address_info_ptr->sport = bpf_htons(sport);
 
@@ -68,10 +91,7 @@ static __always_inline void tcp_kprobes_forward_go(struct pt_regs *ctx, __u64 id
 }
 
 static void __always_inline tcp_kprobes_forward_openssl(struct ssl_info *info_ptr, struct address_info address_info) {
-		info_ptr->address_info.daddr = address_info.daddr;
-		info_ptr->address_info.saddr = address_info.saddr;
-		info_ptr->address_info.dport = address_info.dport;
-		info_ptr->address_info.sport = address_info.sport;
+		__builtin_memcpy(&info_ptr->address_info, &address_info, sizeof(struct address_info));
 }
 
 static __always_inline void tcp_kprobe(struct pt_regs *ctx, struct bpf_map_def *map_fd_openssl, struct bpf_map_def *map_fd_go_kernel, struct bpf_map_def *map_fd_go_user_kernel) {
@@ -17,14 +17,14 @@ type addressPair struct {
 }
 
 func (c *tlsTapperTlsChunk) getSrcAddress() (net.IP, uint16) {
-	ip := intToIP(c.AddressInfo.Saddr)
+	ip := bytesToIP(c.AddressInfo.Saddr)
 	port := ntohs(c.AddressInfo.Sport)
 
 	return ip, port
 }
 
 func (c *tlsTapperTlsChunk) getDstAddress() (net.IP, uint16) {
-	ip := intToIP(c.AddressInfo.Daddr)
+	ip := bytesToIP(c.AddressInfo.Daddr)
 	port := ntohs(c.AddressInfo.Dport)
 
 	return ip, port
@@ -76,9 +76,9 @@ func (c *tlsTapperTlsChunk) getAddressPair() addressPair {
 	}
 }
 
-// intToIP converts IPv4 number to net.IP
-func intToIP(ip32be uint32) net.IP {
-	return net.IPv4(uint8(ip32be), uint8(ip32be>>8), uint8(ip32be>>16), uint8(ip32be>>24))
+// bytesToIP converts IPv6 address to net.IP, IPv4-mapped addresses are printed as IPv4
+func bytesToIP(ip [16]uint8) net.IP {
+	return append(net.IP{}, ip[:]...)
 }
 
 // ntohs converts big endian (network byte order) to little endian (assuming that's the host byte order)
@@ -7,12 +7,9 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"runtime"
 
 	"github.com/Masterminds/semver"
 	"github.com/cilium/ebpf/link"
-	"github.com/knightsc/gapstone"
-	"github.com/up9inc/mizu/logger"
 )
 
 type goAbi int
@@ -213,134 +210,6 @@ func getGoidOffset(elfFile *elf.File) (goidOffset uint64, gStructOffset uint64,
 	return
 }
 
-func getOffsets(filePath string) (offsets map[string]*goExtendedOffset, goidOffset uint64, gStructOffset uint64, err error) {
-	var engine gapstone.Engine
-	switch runtime.GOARCH {
-	case "amd64":
-		engine, err = gapstone.New(
-			gapstone.CS_ARCH_X86,
-			gapstone.CS_MODE_64,
-		)
-	case "arm64":
-		engine, err = gapstone.New(
-			gapstone.CS_ARCH_ARM64,
-			gapstone.CS_MODE_LITTLE_ENDIAN,
-		)
-	default:
-		err = fmt.Errorf("Unsupported architecture: %v", runtime.GOARCH)
-	}
-	if err != nil {
-		return
-	}
-
-	engineMajor, engineMinor := engine.Version()
-	logger.Log.Infof(
-		"Disassembling %s with Capstone %d.%d (arch: %d, mode: %d)",
-		filePath,
-		engineMajor,
-		engineMinor,
-		engine.Arch(),
-		engine.Mode(),
-	)
-
-	offsets = make(map[string]*goExtendedOffset)
-	var fd *os.File
-	fd, err = os.Open(filePath)
-	if err != nil {
-		return
-	}
-	defer fd.Close()
-
-	var elfFile *elf.File
-	elfFile, err = elf.NewFile(fd)
-	if err != nil {
-		return
-	}
-
-	textSection := elfFile.Section(".text")
-	if textSection == nil {
-		err = fmt.Errorf("No text section")
-		return
-	}
-
-	// extract the raw bytes from the .text section
-	var textSectionData []byte
-	textSectionData, err = textSection.Data()
-	if err != nil {
-		return
-	}
-
-	var syms []elf.Symbol
-	syms, err = elfFile.Symbols()
-	if err != nil {
-		return
-	}
-	for _, sym := range syms {
-		offset := sym.Value
-
-		var lastProg *elf.Prog
-		for _, prog := range elfFile.Progs {
-			if prog.Vaddr <= sym.Value && sym.Value < (prog.Vaddr+prog.Memsz) {
-				offset = sym.Value - prog.Vaddr + prog.Off
-				lastProg = prog
-				break
-			}
-		}
-
-		extendedOffset := &goExtendedOffset{enter: offset}
-
-		// source: https://gist.github.com/grantseltzer/3efa8ecc5de1fb566e8091533050d608
-		// skip over any symbols that aren't functions/methods
-		if sym.Info != byte(2) && sym.Info != byte(18) {
-			offsets[sym.Name] = extendedOffset
-			continue
-		}
-
-		// skip over empty symbols
-		if sym.Size == 0 {
-			offsets[sym.Name] = extendedOffset
-			continue
-		}
-
-		// calculate starting and ending index of the symbol within the text section
-		symStartingIndex := sym.Value - textSection.Addr
-		symEndingIndex := symStartingIndex + sym.Size
-
-		// collect the bytes of the symbol
-		textSectionDataLen := uint64(len(textSectionData) - 1)
-		if symEndingIndex > textSectionDataLen {
-			logger.Log.Warningf(
-				"Skipping symbol %v, ending index %v is bigger than text section data length %v",
-				sym.Name,
-				symEndingIndex,
-				textSectionDataLen,
-			)
-			continue
-		}
-		symBytes := textSectionData[symStartingIndex:symEndingIndex]
-
-		// disassemble the symbol
-		var instructions []gapstone.Instruction
-		instructions, err = engine.Disasm(symBytes, sym.Value, 0)
-		if err != nil {
-			return
-		}
-
-		// iterate over each instruction and if the mnemonic is `ret` then that's an exit offset
-		for _, ins := range instructions {
-			if ins.Mnemonic == "ret" {
-				extendedOffset.exits = append(extendedOffset.exits, uint64(ins.Address)-lastProg.Vaddr+lastProg.Off)
-			}
-		}
-
-		offsets[sym.Name] = extendedOffset
-	}
-
-	goidOffset, gStructOffset, err = getGoidOffset(elfFile)
-
-	return
-}
-
 func getOffset(offsets map[string]*goExtendedOffset, symbol string) (*goExtendedOffset, error) {
 	if offset, ok := offsets[symbol]; ok {
 		return offset, nil
@@ -0,0 +1,11 @@
+//go:build !cgo
+
+package tlstapper
+
+import "fmt"
+
+// The exits of the Go functions are found by disassembling them with Capstone, which is a C library
+func getOffsets(filePath string) (offsets map[string]*goExtendedOffset, goidOffset uint64, gStructOffset uint64, err error) {
+	err = fmt.Errorf("Disassembling %s requires a build with cgo", filePath)
+	return
+}
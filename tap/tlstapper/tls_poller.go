@@ -1,7 +1,6 @@
 package tlstapper
 
 import (
-	"bufio"
 	"bytes"
 	"fmt"
 	"sync"
@@ -9,6 +8,7 @@ import (
 
 	"encoding/binary"
 	"encoding/hex"
+	"net"
 	"os"
 	"strconv"
 	"strings"
@@ -18,6 +18,7 @@ import (
 	"github.com/hashicorp/golang-lru/simplelru"
 	"github.com/up9inc/mizu/logger"
 	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/diagnose"
 )
 
 const (
@@ -29,22 +30,20 @@ type tlsPoller struct {
 	tls            *TlsTapper
 	readers        map[string]*tlsReader
 	closedReaders  chan string
-	reqResMatcher  api.RequestResponseMatcher
 	chunksReader   *perf.Reader
-	extension      *api.Extension
+	extensions     []*api.Extension
 	procfs         string
 	pidToNamespace sync.Map
 	fdCache        *simplelru.LRU // Actual type is map[string]addressPair
 	evictedCounter int
 }
 
-func newTlsPoller(tls *TlsTapper, extension *api.Extension, procfs string) (*tlsPoller, error) {
+func newTlsPoller(tls *TlsTapper, extensions []*api.Extension, procfs string) (*tlsPoller, error) {
 	poller := &tlsPoller{
 		tls:           tls,
 		readers:       make(map[string]*tlsReader),
 		closedReaders: make(chan string, 100),
-		reqResMatcher: extension.Dissector.NewResponseRequestMatcher(),
-		extension:     extension,
+		extensions:    extensions,
 		chunksReader:  nil,
 		procfs:        procfs,
 	}
@@ -88,7 +87,7 @@ func (p *tlsPoller) poll(emitter api.Emitter, options *api.TrafficFilteringOptio
 				return
 			}
 
-			if err := p.handleTlsChunk(chunk, p.extension, emitter, options, streamsMap); err != nil {
+			if err := p.handleTlsChunk(chunk, emitter, options, streamsMap); err != nil {
 				LogError(err)
 			}
 		case key := <-p.closedReaders:
@@ -116,6 +115,7 @@ func (p *tlsPoller) pollChunksPerfBuffer(chunks chan<- *tlsTapperTlsChunk) {
 
 		if record.LostSamples != 0 {
 			logger.Log.Infof("Buffer is full, dropped %d chunks", record.LostSamples)
+			diagnose.AppStats.UpdateTlsLostChunksCount(record.LostSamples)
 			continue
 		}
 
@@ -128,11 +128,13 @@ func (p *tlsPoller) pollChunksPerfBuffer(chunks chan<- *tlsTapperTlsChunk) {
 			continue
 		}
 
+		diagnose.AppStats.IncTlsChunksCount()
+
 		chunks <- &chunk
 	}
 }
 
-func (p *tlsPoller) handleTlsChunk(chunk *tlsTapperTlsChunk, extension *api.Extension, emitter api.Emitter,
+func (p *tlsPoller) handleTlsChunk(chunk *tlsTapperTlsChunk, emitter api.Emitter,
 	options *api.TrafficFilteringOptions, streamsMap api.TcpStreamMap) error {
 	address := chunk.getAddressPair()
 
@@ -140,7 +142,7 @@ func (p *tlsPoller) handleTlsChunk(chunk *tlsTapperTlsChunk, extension *api.Exte
 	reader, exists := p.readers[key]
 
 	if !exists {
-		reader = p.startNewTlsReader(chunk, &address, key, emitter, extension, options, streamsMap)
+		reader = p.startNewTlsReader(chunk, &address, key, emitter, options, streamsMap)
 		p.readers[key] = reader
 	}
 
@@ -154,8 +156,7 @@ func (p *tlsPoller) handleTlsChunk(chunk *tlsTapperTlsChunk, extension *api.Exte
 }
 
 func (p *tlsPoller) startNewTlsReader(chunk *tlsTapperTlsChunk, address *addressPair, key string,
-	emitter api.Emitter, extension *api.Extension, options *api.TrafficFilteringOptions,
-	streamsMap api.TcpStreamMap) *tlsReader {
+	emitter api.Emitter, options *api.TrafficFilteringOptions, streamsMap api.TcpStreamMap) *tlsReader {
 
 	tcpid := p.buildTcpId(address)
 
@@ -169,47 +170,58 @@ func (p *tlsPoller) startNewTlsReader(chunk *tlsTapperTlsChunk, address *address
 	}
 
 	reader := &tlsReader{
-		key:           key,
-		chunks:        make(chan *tlsTapperTlsChunk, 1),
-		doneHandler:   doneHandler,
-		progress:      &api.ReadProgress{},
-		tcpID:         &tcpid,
-		isClient:      chunk.isRequest(),
-		captureTime:   time.Now(),
-		extension:     extension,
-		emitter:       tlsEmitter,
-		counterPair:   &api.CounterPair{},
-		reqResMatcher: p.reqResMatcher,
+		key:         key,
+		chunks:      make(chan *tlsTapperTlsChunk, 1),
+		doneHandler: doneHandler,
+		progress:    &api.ReadProgress{},
+		tcpID:       &tcpid,
+		isClient:    chunk.isRequest(),
+		// The connection is outgoing when the tapped process is the TLS client
+		isOutgoing:  chunk.isClient(),
+		captureTime: time.Now(),
+		emitter:     tlsEmitter,
 	}
 
-	stream := &tlsStream{
-		reader: reader,
+	// Both directions of a connection share a stream, as the matchers pair their messages
+	var stream *tlsStream
+	if opposite, exists := p.readers[buildTlsKey(reverseAddressPair(address))]; exists {
+		stream = opposite.parent
+	} else {
+		stream = newTlsStream(p.extensions)
+		streamsMap.Store(streamsMap.NextId(), stream)
 	}
-	streamsMap.Store(streamsMap.NextId(), stream)
+
+	stream.Lock()
+	if reader.isClient {
+		stream.client = reader
+	} else {
+		stream.server = reader
+	}
+	stream.Unlock()
 
 	reader.parent = stream
 
-	go dissect(extension, reader, options)
+	go reader.run(p.extensions, options)
 	return reader
 }
 
-func dissect(extension *api.Extension, reader api.TcpReader, options *api.TrafficFilteringOptions) {
-	b := bufio.NewReader(reader)
-
-	err := extension.Dissector.Dissect(b, reader, options)
-
-	if err != nil {
-		logger.Log.Warningf("Error dissecting TLS %v - %v", reader.GetTcpID(), err)
-	}
-}
-
 func (p *tlsPoller) closeReader(key string, r *tlsReader) {
 	close(r.chunks)
 	p.closedReaders <- key
 }
 
+func reverseAddressPair(address *addressPair) addressPair {
+	return addressPair{
+		srcIp:   address.dstIp,
+		srcPort: address.dstPort,
+		dstIp:   address.srcIp,
+		dstPort: address.srcPort,
+	}
+}
+
 func buildTlsKey(address addressPair) string {
-	return fmt.Sprintf("%s:%d>%s:%d", address.srcIp, address.srcPort, address.dstIp, address.dstPort)
+	return fmt.Sprintf("%s>%s", net.JoinHostPort(address.srcIp.String(), strconv.Itoa(int(address.srcPort))),
+		net.JoinHostPort(address.dstIp.String(), strconv.Itoa(int(address.dstPort))))
 }
 
 func (p *tlsPoller) buildTcpId(address *addressPair) api.TcpID {
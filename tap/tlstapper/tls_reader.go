@@ -1,27 +1,136 @@
 package tlstapper
 
 import (
+	"bufio"
 	"io"
+	"io/ioutil"
 	"time"
 
+	"github.com/up9inc/mizu/logger"
 	"github.com/up9inc/mizu/tap/api"
+	"github.com/up9inc/mizu/tap/dbgctl"
 )
 
+// The dissectors are ranked by the first bytes of the stream, up to this size
+const maxProbeSize = 1024
+
 type tlsReader struct {
-	key           string
-	chunks        chan *tlsTapperTlsChunk
-	seenChunks    int
-	data          []byte
-	doneHandler   func(r *tlsReader)
-	progress      *api.ReadProgress
-	tcpID         *api.TcpID
-	isClient      bool
-	captureTime   time.Time
-	extension     *api.Extension
-	emitter       api.Emitter
-	counterPair   *api.CounterPair
-	parent        *tlsStream
-	reqResMatcher api.RequestResponseMatcher
+	key             string
+	chunks          chan *tlsTapperTlsChunk
+	seenChunks      int
+	data            []byte
+	msgBuffer       [][]byte
+	msgBufferMaster [][]byte
+	isReleased      bool
+	doneHandler     func(r *tlsReader)
+	progress        *api.ReadProgress
+	tcpID           *api.TcpID
+	isClient        bool
+	isOutgoing      bool
+	captureTime     time.Time
+	emitter         api.Emitter
+	counterPair     *api.CounterPair
+	parent          *tlsStream
+	reqResMatcher   api.RequestResponseMatcher
+}
+
+// Runs the same protocol identification as the tcpReader of the pcap sources
+func (r *tlsReader) run(extensions []*api.Extension, options *api.TrafficFilteringOptions) {
+	if dbgctl.MizuTapperDisableDissectors.Enabled() {
+		b := bufio.NewReader(r)
+		_, _ = io.ReadAll(b)
+		return
+	}
+
+	peek := r.peek()
+	if len(peek) > 0 {
+		for _, i := range api.RankExtensions(extensions, peek, r.isClient, r.getServerPort()) {
+			extension := extensions[i]
+			// A direction may start after the other one has identified the protocol
+			if r.isProtocolIdentified() && !r.isProtocolIdentifiedBy(extension) {
+				continue
+			}
+			r.reqResMatcher = r.parent.reqResMatchers[i]
+			r.counterPair = r.parent.counterPairs[i]
+			b := bufio.NewReader(r)
+			err := extension.Dissector.Dissect(b, r, options)
+			if r.isProtocolIdentifiedBy(extension) {
+				if err != nil && err != io.EOF {
+					logger.Log.Warningf("Error dissecting TLS %v - %v", r.GetTcpID(), err)
+				}
+				return
+			}
+			r.rewind()
+		}
+	}
+
+	r.release(extensions, options)
+}
+
+// Blocks until the stream has data, the data is replayed to the dissectors
+func (r *tlsReader) peek() []byte {
+	b := bufio.NewReaderSize(r, maxProbeSize)
+	if _, err := b.Peek(1); err != nil {
+		return nil
+	}
+	peek, _ := b.Peek(b.Buffered())
+	peek = append([]byte{}, peek...)
+	r.rewind()
+	return peek
+}
+
+func (r *tlsReader) getServerPort() string {
+	if r.isClient {
+		return r.tcpID.DstPort
+	}
+	return r.tcpID.SrcPort
+}
+
+// None of the dissectors identified the stream, the rest of it is given to the fallback dissector without keeping it
+func (r *tlsReader) release(extensions []*api.Extension, options *api.TrafficFilteringOptions) {
+	r.rewind()
+	r.parent.Lock()
+	r.msgBufferMaster = nil
+	r.isReleased = true
+	r.parent.Unlock()
+
+	if i := api.FindFallbackExtension(extensions); i >= 0 && !r.isProtocolIdentified() {
+		r.reqResMatcher = r.parent.reqResMatchers[i]
+		r.counterPair = r.parent.counterPairs[i]
+		b := bufio.NewReader(r)
+		extensions[i].Dissector.(api.FallbackDissector).DissectFallback(b, r, options) //nolint
+	}
+
+	r.msgBuffer = nil
+	r.data = nil
+	_, _ = io.Copy(ioutil.Discard, r)
+}
+
+func (r *tlsReader) isProtocolIdentified() bool {
+	return r.parent.protocol != nil
+}
+
+func (r *tlsReader) isProtocolIdentifiedBy(extension *api.Extension) bool {
+	protocol := r.parent.protocol
+	if protocol == nil {
+		return false
+	}
+	_, ok := extension.Dissector.GetProtocols()[protocol.ToString()]
+	return ok
+}
+
+func (r *tlsReader) rewind() {
+	// Reset the data
+	r.data = make([]byte, 0)
+
+	// Reset msgBuffer from the master record
+	r.parent.Lock()
+	r.msgBuffer = make([][]byte, len(r.msgBufferMaster))
+	copy(r.msgBuffer, r.msgBufferMaster)
+	r.parent.Unlock()
+
+	// Reset the read progress
+	r.progress.Reset()
 }
 
 func (r *tlsReader) newChunk(chunk *tlsTapperTlsChunk) {
@@ -33,6 +142,10 @@ func (r *tlsReader) newChunk(chunk *tlsTapperTlsChunk) {
 func (r *tlsReader) Read(p []byte) (int, error) {
 	var chunk *tlsTapperTlsChunk
 
+	for len(r.msgBuffer) > 0 && len(r.data) == 0 {
+		r.data, r.msgBuffer = r.msgBuffer[0], r.msgBuffer[1:]
+	}
+
 	for len(r.data) == 0 {
 		var ok bool
 		select {
@@ -42,6 +155,12 @@ func (r *tlsReader) Read(p []byte) (int, error) {
 			}
 
 			r.data = chunk.getRecordedData()
+
+			if !r.isProtocolIdentified() && !r.isReleased {
+				r.parent.Lock()
+				r.msgBufferMaster = append(r.msgBufferMaster, r.data)
+				r.parent.Unlock()
+			}
 		case <-time.After(time.Second * 120):
 			r.doneHandler(r)
 			return 0, io.EOF
@@ -1,10 +1,29 @@
 package tlstapper
 
-import "github.com/up9inc/mizu/tap/api"
+import (
+	"sync"
 
+	"github.com/up9inc/mizu/tap/api"
+)
+
+// It's a connection (bidirectional), the way tcpStream is one for the pcap sources.
+// The plaintext of both directions is dissected by the extensions in turn, until one identifies the protocol.
 type tlsStream struct {
-	reader   *tlsReader
-	protocol *api.Protocol
+	client         *tlsReader
+	server         *tlsReader
+	protocol       *api.Protocol
+	counterPairs   []*api.CounterPair
+	reqResMatchers []api.RequestResponseMatcher
+	sync.Mutex
+}
+
+func newTlsStream(extensions []*api.Extension) *tlsStream {
+	stream := &tlsStream{}
+	for _, extension := range extensions {
+		stream.counterPairs = append(stream.counterPairs, &api.CounterPair{})
+		stream.reqResMatchers = append(stream.reqResMatchers, extension.Dissector.NewResponseRequestMatcher())
+	}
+	return stream
 }
 
 func (t *tlsStream) GetOrigin() api.Capture {
@@ -13,10 +32,19 @@ func (t *tlsStream) GetOrigin() api.Capture {
 
 func (t *tlsStream) SetProtocol(protocol *api.Protocol) {
 	t.protocol = protocol
+
+	// Clean the buffers
+	t.Lock()
+	for _, reader := range []*tlsReader{t.client, t.server} {
+		if reader != nil {
+			reader.msgBufferMaster = make([][]byte, 0)
+		}
+	}
+	t.Unlock()
 }
 
 func (t *tlsStream) GetReqResMatchers() []api.RequestResponseMatcher {
-	return []api.RequestResponseMatcher{t.reader.reqResMatcher}
+	return t.reqResMatchers
 }
 
 func (t *tlsStream) GetIsTapTarget() bool {
@@ -26,3 +54,42 @@ func (t *tlsStream) GetIsTapTarget() bool {
 func (t *tlsStream) GetIsClosed() bool {
 	return false
 }
+
+// The connection is told by either reader, the plaintext of one direction may have not been seen
+func (t *tlsStream) GetConnectionInfo() *api.ConnectionInfo {
+	t.Lock()
+	defer t.Unlock()
+
+	switch {
+	case t.client != nil:
+		return &api.ConnectionInfo{
+			ClientIP:   t.client.tcpID.SrcIP,
+			ClientPort: t.client.tcpID.SrcPort,
+			ServerIP:   t.client.tcpID.DstIP,
+			ServerPort: t.client.tcpID.DstPort,
+			IsOutgoing: t.client.isOutgoing,
+		}
+	case t.server != nil:
+		return &api.ConnectionInfo{
+			ClientIP:   t.server.tcpID.DstIP,
+			ClientPort: t.server.tcpID.DstPort,
+			ServerIP:   t.server.tcpID.SrcIP,
+			ServerPort: t.server.tcpID.SrcPort,
+			IsOutgoing: t.server.isOutgoing,
+		}
+	}
+	return nil
+}
+
+func (t *tlsStream) GetEmitter() api.Emitter {
+	t.Lock()
+	defer t.Unlock()
+
+	switch {
+	case t.client != nil:
+		return t.client.emitter
+	case t.server != nil:
+		return t.server.emitter
+	}
+	return nil
+}
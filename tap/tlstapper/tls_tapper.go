@@ -30,7 +30,7 @@ type TlsTapper struct {
 	registeredPids  sync.Map
 }
 
-func (t *TlsTapper) Init(chunksBufferSize int, logBufferSize int, procfs string, extension *api.Extension) error {
+func (t *TlsTapper) Init(chunksBufferSize int, logBufferSize int, procfs string, extensions []*api.Extension) error {
 	logger.Log.Infof("Initializing tls tapper (chunksSize: %d) (logSize: %d)", chunksBufferSize, logBufferSize)
 
 	var err error
@@ -76,7 +76,7 @@ func (t *TlsTapper) Init(chunksBufferSize int, logBufferSize int, procfs string,
 		return err
 	}
 
-	t.poller, err = newTlsPoller(t, extension, procfs)
+	t.poller, err = newTlsPoller(t, extensions, procfs)
 
 	if err != nil {
 		return err
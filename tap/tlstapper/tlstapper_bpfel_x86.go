@@ -27,8 +27,8 @@ type tlsTapperTlsChunk struct {
 	Fd          uint32
 	Flags       uint32
 	AddressInfo struct {
-		Saddr uint32
-		Daddr uint32
+		Saddr [16]uint8
+		Daddr [16]uint8
 		Sport uint16
 		Dport uint16
 	}
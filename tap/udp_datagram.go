@@ -0,0 +1,49 @@
+package tap
+
+import (
+	"time"
+
+	"github.com/up9inc/mizu/tap/api"
+)
+
+/* udpDatagram is a single UDP payload handed over to the datagram dissectors.
+ * There is no reassembly or buffering on this path, every datagram is dissected on its own.
+ * Implements api.UdpDatagram interface
+ */
+type udpDatagram struct {
+	payload       []byte
+	udpID         *api.TcpID
+	captureTime   time.Time
+	origin        api.Capture
+	isOutgoing    bool
+	reqResMatcher api.RequestResponseMatcher
+	emitter       api.Emitter
+}
+
+func (datagram *udpDatagram) GetPayload() []byte {
+	return datagram.payload
+}
+
+func (datagram *udpDatagram) GetUdpID() *api.TcpID {
+	return datagram.udpID
+}
+
+func (datagram *udpDatagram) GetCaptureTime() time.Time {
+	return datagram.captureTime
+}
+
+func (datagram *udpDatagram) GetOrigin() api.Capture {
+	return datagram.origin
+}
+
+func (datagram *udpDatagram) GetIsOutgoing() bool {
+	return datagram.isOutgoing
+}
+
+func (datagram *udpDatagram) GetReqResMatcher() api.RequestResponseMatcher {
+	return datagram.reqResMatcher
+}
+
+func (datagram *udpDatagram) GetEmitter() api.Emitter {
+	return datagram.emitter
+}
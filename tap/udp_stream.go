@@ -0,0 +1,64 @@
+package tap
+
+import (
+	"github.com/up9inc/mizu/tap/api"
+)
+
+/* UDP has no connections, so a single udpStream holds the request-response matchers
+ * of all the datagram dissectors. It's stored in the streams map to let the cleaner
+ * evict the requests that never got a response.
+ * Implements api.TcpStream interface
+ */
+type udpStream struct {
+	id             int64
+	dissectors     []api.DatagramDissector
+	reqResMatchers []api.RequestResponseMatcher
+}
+
+func NewUdpStream(streamsMap api.TcpStreamMap) *udpStream {
+	stream := &udpStream{}
+
+	for _, extension := range extensions {
+		dissector, ok := extension.Dissector.(api.DatagramDissector)
+		if !ok {
+			continue
+		}
+
+		stream.dissectors = append(stream.dissectors, dissector)
+		stream.reqResMatchers = append(stream.reqResMatchers, extension.Dissector.NewResponseRequestMatcher())
+	}
+
+	if len(stream.dissectors) > 0 {
+		stream.id = streamsMap.NextId()
+		streamsMap.Store(stream.id, stream)
+	}
+
+	return stream
+}
+
+func (t *udpStream) dissect(datagram *udpDatagram, options *api.TrafficFilteringOptions) {
+	for i, dissector := range t.dissectors {
+		datagram.reqResMatcher = t.reqResMatchers[i]
+		if err := dissector.DissectDatagram(datagram, options); err == nil {
+			return
+		}
+	}
+}
+
+func (t *udpStream) SetProtocol(protocol *api.Protocol) {}
+
+func (t *udpStream) GetOrigin() api.Capture {
+	return api.Pcap
+}
+
+func (t *udpStream) GetReqResMatchers() []api.RequestResponseMatcher {
+	return t.reqResMatchers
+}
+
+func (t *udpStream) GetIsTapTarget() bool {
+	return true
+}
+
+func (t *udpStream) GetIsClosed() bool {
+	return false
+}